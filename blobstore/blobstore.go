@@ -2,16 +2,31 @@ package blobstore
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
+	"time"
 
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+const (
+	defaultMaxTries   = 3
+	defaultRetryDelay = 1 * time.Second
+)
+
+// retryDelay doubles the delay after every failed attempt
+func retryDelay(attempt int) time.Duration {
+	return defaultRetryDelay << uint(attempt-1)
+}
+
 type Blobstore interface {
-	Get(blobID string) (LocalBlob, error)
+	// Get downloads the blob and verifies it against digest, unless digest is nil
+	Get(blobID string, digest boshcrypto.Digest) (LocalBlob, error)
 	Add(sourcePath string) (blobID string, err error)
 }
 
@@ -30,51 +45,111 @@ type blobstore struct {
 	davClient     DavCLIClient
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
+	timeService   clock.Clock
+	maxTries      int
 	logger        boshlog.Logger
 	logTag        string
 }
 
-func NewBlobstore(davClient DavCLIClient, uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+func NewBlobstore(davClient DavCLIClient, uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) Blobstore {
 	return &blobstore{
 		davClient:     davClient,
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
+		timeService:   timeService,
+		maxTries:      defaultMaxTries,
 		logger:        logger,
 		logTag:        "blobstore",
 	}
 }
 
-func (b *blobstore) Get(blobID string) (LocalBlob, error) {
+func (b *blobstore) Get(blobID string, digest boshcrypto.Digest) (LocalBlob, error) {
 	file, err := b.fs.TempFile("bosh-init-local-blob")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating temp file for blob")
+	}
+
 	destinationPath := file.Name()
 	err = file.Close()
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Closing new temp file '%s'", destinationPath)
 	}
 
+	localBlob := NewLocalBlob(destinationPath, b.fs, b.logger)
+
 	b.logger.Debug(b.logTag, "Downloading blob %s to %s", blobID, destinationPath)
 
+	var written int64
+
+	for i := 1; ; i++ {
+		written, err = b.download(blobID, destinationPath, written)
+		if err == nil {
+			break
+		}
+
+		if i >= b.maxTries {
+			localBlob.DeleteSilently()
+			return nil, bosherr.WrapErrorf(err, "Getting blob %s from blobstore", blobID)
+		}
+
+		b.logger.Info(b.logTag, "Failed to get blob %s after %d bytes with error '%s', attempt %d out of %d", blobID, written, err.Error(), i, b.maxTries)
+		b.timeService.Sleep(retryDelay(i))
+	}
+
+	if digest != nil {
+		err = digest.VerifyFilePath(destinationPath, b.fs)
+		if err != nil {
+			localBlob.DeleteSilently()
+			return nil, bosherr.WrapErrorf(err, "Verifying digest of blob %s", blobID)
+		}
+	}
+
+	return localBlob, nil
+}
+
+// download writes the blob to destinationPath, resuming after the first offset bytes
+// that are already on disk. The agent's DAV blobstore does not serve byte ranges,
+// so these bytes are read again and skipped instead of being written a second time.
+// It returns the number of bytes of the blob that are on disk, even when the transfer fails.
+func (b *blobstore) download(blobID, destinationPath string, offset int64) (int64, error) {
 	readCloser, err := b.davClient.Get(blobID)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Getting blob %s from blobstore", blobID)
+		return offset, err
 	}
 	defer func() {
-		if err = readCloser.Close(); err != nil {
+		if err := readCloser.Close(); err != nil {
 			b.logger.Warn(b.logTag, "Couldn't close davClient.Get reader: %s", err.Error())
 		}
 	}()
 
-	targetFile, err := b.fs.OpenFile(destinationPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		b.logger.Debug(b.logTag, "Resuming download of blob %s at %d bytes", blobID, offset)
+
+		_, err := io.CopyN(ioutil.Discard, readCloser, offset)
+		if err != nil {
+			return offset, bosherr.WrapErrorf(err, "Skipping %d downloaded bytes of blob %s", offset, blobID)
+		}
+
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+
+	targetFile, err := b.fs.OpenFile(destinationPath, flags, 0666)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Opening file for blob at %s", destinationPath)
+		return offset, bosherr.WrapErrorf(err, "Opening file for blob at %s", destinationPath)
 	}
+	defer func() {
+		if err := targetFile.Close(); err != nil {
+			b.logger.Warn(b.logTag, "Couldn't close blob file: %s", err.Error())
+		}
+	}()
 
-	_, err = io.Copy(targetFile, readCloser)
+	copied, err := io.Copy(targetFile, readCloser)
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
+		return offset + copied, bosherr.WrapErrorf(err, "Saving blob to %s", destinationPath)
 	}
 
-	return NewLocalBlob(destinationPath, b.fs, b.logger), nil
+	return offset + copied, nil
 }
 
 func (b *blobstore) Add(sourcePath string) (string, error) {
@@ -85,25 +160,40 @@ func (b *blobstore) Add(sourcePath string) (string, error) {
 
 	b.logger.Debug(b.logTag, "Uploading blob %s from %s", blobID, sourcePath)
 
+	for i := 1; ; i++ {
+		err = b.upload(blobID, sourcePath)
+		if err == nil {
+			return blobID, nil
+		}
+
+		if i >= b.maxTries {
+			return "", err
+		}
+
+		b.logger.Info(b.logTag, "Failed to put blob %s with error '%s', attempt %d out of %d", blobID, err.Error(), i, b.maxTries)
+		b.timeService.Sleep(retryDelay(i))
+	}
+}
+
+func (b *blobstore) upload(blobID, sourcePath string) error {
 	file, err := b.fs.OpenFile(sourcePath, os.O_RDONLY, 0)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Opening file for reading %s", sourcePath)
+		return bosherr.WrapErrorf(err, "Opening file for reading %s", sourcePath)
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			b.logger.Warn(b.logTag, "Couldn't close source file: %s", err.Error())
-		}
-	}()
 
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Getting fileInfo from %s", sourcePath)
+		if closeErr := file.Close(); closeErr != nil {
+			b.logger.Warn(b.logTag, "Couldn't close source file: %s", closeErr.Error())
+		}
+		return bosherr.WrapErrorf(err, "Getting fileInfo from %s", sourcePath)
 	}
 
+	// Put closes the file once the request has been sent
 	err = b.davClient.Put(blobID, file, fileInfo.Size())
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Putting file '%s' into blobstore (via DAVClient) as blobID '%s'", sourcePath, blobID)
+		return bosherr.WrapErrorf(err, "Putting file '%s' into blobstore (via DAVClient) as blobID '%s'", sourcePath, blobID)
 	}
 
-	return blobID, nil
+	return nil
 }
@@ -5,28 +5,35 @@ import (
 	"net/http"
 	"net/url"
 
+	"code.cloudfoundry.org/clock"
 	boshdavcli "github.com/cloudfoundry/bosh-davcli/client"
 	boshdavcliconf "github.com/cloudfoundry/bosh-davcli/config"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bireldir "github.com/cloudfoundry/bosh-cli/releasedir"
 )
 
 type Factory interface {
 	Create(string, *http.Client) (Blobstore, error)
+	CreateFromProvider(string, map[string]interface{}) (Blobstore, error)
 }
 
 type blobstoreFactory struct {
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
+	timeService   clock.Clock
 	logger        boshlog.Logger
 }
 
-func NewBlobstoreFactory(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, logger boshlog.Logger) Factory {
+func NewBlobstoreFactory(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) Factory {
 	return blobstoreFactory{
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
+		timeService:   timeService,
 		logger:        logger,
 	}
 }
@@ -43,7 +50,29 @@ func (f blobstoreFactory) Create(blobstoreURL string, httpClient *http.Client) (
 		Password: blobstoreConfig.Password,
 	}, httpClient, f.logger)
 
-	return NewBlobstore(davClient, f.uuidGenerator, f.fs, f.logger), nil
+	return NewBlobstore(davClient, f.uuidGenerator, f.fs, f.timeService, f.logger), nil
+}
+
+func (f blobstoreFactory) CreateFromProvider(provider string, options map[string]interface{}) (Blobstore, error) {
+	var blobstore boshblob.Blobstore
+
+	switch provider {
+	case "local":
+		blobstore = boshblob.NewLocalBlobstore(f.fs, f.uuidGenerator, options)
+	case "s3":
+		blobstore = bireldir.NewS3Blobstore(f.fs, f.uuidGenerator, options)
+	case "gcs":
+		blobstore = bireldir.NewGCSBlobstore(f.fs, f.uuidGenerator, options)
+	default:
+		return nil, bosherr.Errorf("Unsupported blobstore provider '%s'", provider)
+	}
+
+	err := blobstore.Validate()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Validating %s blobstore config", provider)
+	}
+
+	return NewExternalBlobstore(blobstore, f.fs, f.timeService, f.logger), nil
 }
 
 func (f blobstoreFactory) parseBlobstoreURL(blobstoreURL string) (Config, error) {
@@ -4,6 +4,9 @@ import (
 	. "github.com/cloudfoundry/bosh-cli/blobstore"
 
 	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,6 +24,7 @@ var _ = Describe("BlobstoreFactory", func() {
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
 		httpClient        *http.Client
 		fs                *fakesys.FakeFileSystem
+		timeService       *fakeclock.FakeClock
 		logger            boshlog.Logger
 		blobstoreFactory  Factory
 	)
@@ -29,8 +33,9 @@ var _ = Describe("BlobstoreFactory", func() {
 		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
 		fs = fakesys.NewFakeFileSystem()
 		logger = boshlog.NewLogger(boshlog.LevelNone)
+		timeService = fakeclock.NewFakeClock(time.Now())
 		httpClient = bihttpclient.DefaultClient
-		blobstoreFactory = NewBlobstoreFactory(fakeUUIDGenerator, fs, logger)
+		blobstoreFactory = NewBlobstoreFactory(fakeUUIDGenerator, fs, timeService, logger)
 	})
 
 	Describe("Create", func() {
@@ -43,7 +48,7 @@ var _ = Describe("BlobstoreFactory", func() {
 					User:     "fake-user",
 					Password: "fake-password",
 				}, httpClient, logger)
-				expectedBlobstore := NewBlobstore(davClient, fakeUUIDGenerator, fs, logger)
+				expectedBlobstore := NewBlobstore(davClient, fakeUUIDGenerator, fs, timeService, logger)
 				Expect(blobstore).To(Equal(expectedBlobstore))
 			})
 		})
@@ -56,7 +61,7 @@ var _ = Describe("BlobstoreFactory", func() {
 					User:     "",
 					Password: "",
 				}, httpClient, logger)
-				expectedBlobstore := NewBlobstore(davClient, fakeUUIDGenerator, fs, logger)
+				expectedBlobstore := NewBlobstore(davClient, fakeUUIDGenerator, fs, timeService, logger)
 
 				blobstore, err := blobstoreFactory.Create("https://fake-host:1234", httpClient)
 				Expect(err).ToNot(HaveOccurred())
@@ -64,4 +69,26 @@ var _ = Describe("BlobstoreFactory", func() {
 			})
 		})
 	})
+
+	Describe("CreateFromProvider", func() {
+		It("returns a local blobstore", func() {
+			blobstore, err := blobstoreFactory.CreateFromProvider("local", map[string]interface{}{
+				"blobstore_path": "/fake-blobstore-path",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobstore).ToNot(BeNil())
+		})
+
+		It("returns an error when the provider config is invalid", func() {
+			_, err := blobstoreFactory.CreateFromProvider("local", map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating local blobstore config"))
+		})
+
+		It("returns an error when the provider is not supported", func() {
+			_, err := blobstoreFactory.CreateFromProvider("fake-provider", map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported blobstore provider 'fake-provider'"))
+		})
+	})
 })
@@ -2,12 +2,20 @@ package blobstore_test
 
 import (
 	"errors"
+	"io"
 	"io/ioutil"
+	"os"
 	"strings"
+	"testing/iotest"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	. "github.com/cloudfoundry/bosh-cli/blobstore"
-	fakeboshdavcli "github.com/cloudfoundry/bosh-davcli/client/fakes"
+	fakebiblobstore "github.com/cloudfoundry/bosh-cli/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	. "github.com/onsi/ginkgo"
@@ -16,21 +24,40 @@ import (
 
 var _ = Describe("Blobstore", func() {
 	var (
-		fakeDavClient     *fakeboshdavcli.FakeClient
+		fakeDavClient     *fakebiblobstore.FakeDavClient
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
 		fs                *fakesys.FakeFileSystem
+		timeService       *fakeclock.FakeClock
+		logger            boshlog.Logger
 		blobstore         Blobstore
 	)
 
 	BeforeEach(func() {
-		fakeDavClient = fakeboshdavcli.NewFakeClient()
+		fakeDavClient = fakebiblobstore.NewFakeDavClient()
 		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
 		fs = fakesys.NewFakeFileSystem()
-		logger := boshlog.NewLogger(boshlog.LevelNone)
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, logger)
+		blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, fs, timeService, logger)
 	})
 
+	// retrying runs fn while letting the given number of retry delays pass
+	retrying := func(retries int, fn func()) {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			fn()
+		}()
+
+		for i := 0; i < retries; i++ {
+			timeService.WaitForWatcherAndIncrement(time.Duration(1<<uint(i)) * time.Second)
+		}
+
+		Eventually(done).Should(BeClosed())
+	}
+
 	Describe("Get", func() {
 		BeforeEach(func() {
 			fakeFile := fakesys.NewFakeFile("fake-destination-path", fs)
@@ -40,7 +67,7 @@ var _ = Describe("Blobstore", func() {
 		It("gets the blob from the blobstore", func() {
 			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
 
-			localBlob, err := blobstore.Get("fake-blob-id")
+			localBlob, err := blobstore.Get("fake-blob-id", nil)
 			Expect(err).ToNot(HaveOccurred())
 			defer localBlob.DeleteSilently()
 
@@ -50,7 +77,7 @@ var _ = Describe("Blobstore", func() {
 		It("saves the blob to the destination path", func() {
 			fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
 
-			localBlob, err := blobstore.Get("fake-blob-id")
+			localBlob, err := blobstore.Get("fake-blob-id", nil)
 			Expect(err).ToNot(HaveOccurred())
 			defer func() {
 				err := localBlob.Delete()
@@ -65,21 +92,137 @@ var _ = Describe("Blobstore", func() {
 		})
 
 		Context("when getting from blobstore fails", func() {
-			It("returns an error", func() {
+			BeforeEach(func() {
 				fakeDavClient.GetErr = errors.New("fake-get-error")
+			})
+
+			It("returns an error after 3 attempts", func() {
+				retrying(2, func() {
+					_, err := blobstore.Get("fake-blob-id", nil)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+				})
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(3))
+			})
+
+			It("removes the partially downloaded blob", func() {
+				retrying(2, func() {
+					_, err := blobstore.Get("fake-blob-id", nil)
+					Expect(err).To(HaveOccurred())
+				})
 
-				_, err := blobstore.Get("fake-blob-id")
+				Expect(fs.FileExists("fake-destination-path")).To(BeFalse())
+			})
+		})
+
+		Context("when a digest is provided", func() {
+			BeforeEach(func() {
+				fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+			})
+
+			It("verifies the downloaded blob against the digest", func() {
+				digest := boshcrypto.MustParseMultipleDigest("sha1:50fe6e45709c690c0737343ecd613813d8dd2d53")
+
+				localBlob, err := blobstore.Get("fake-blob-id", digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(localBlob.Path()).To(Equal("fake-destination-path"))
+			})
+
+			It("returns an error and removes the blob when the digest does not match", func() {
+				digest := boshcrypto.MustParseMultipleDigest("sha256:0000000000000000000000000000000000000000000000000000000000000000")
+
+				_, err := blobstore.Get("fake-blob-id", digest)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+				Expect(err.Error()).To(ContainSubstring("Verifying digest of blob fake-blob-id"))
+				Expect(fs.FileExists("fake-destination-path")).To(BeFalse())
+			})
+		})
+
+		Context("when the download is interrupted", func() {
+			var (
+				osFs    boshsys.FileSystem
+				tempDir string
+			)
+
+			BeforeEach(func() {
+				var err error
+				osFs = boshsys.NewOsFileSystem(logger)
+				tempDir, err = ioutil.TempDir("", "blobstore-test")
+				Expect(err).ToNot(HaveOccurred())
+				osFs.ChangeTempRoot(tempDir)
+
+				blobstore = NewBlobstore(fakeDavClient, fakeUUIDGenerator, osFs, timeService, logger)
+
+				fakeDavClient.GetContentsList = []io.ReadCloser{
+					ioutil.NopCloser(io.MultiReader(
+						strings.NewReader("fake-"),
+						iotest.ErrReader(errors.New("fake-connection-reset")),
+					)),
+				}
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(tempDir)
+			})
+
+			It("resumes the download after the bytes that are already on disk", func() {
+				fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+				var localBlob LocalBlob
+				retrying(1, func() {
+					var err error
+					localBlob, err = blobstore.Get("fake-blob-id", nil)
+					Expect(err).ToNot(HaveOccurred())
+				})
+				defer localBlob.DeleteSilently()
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(2))
+
+				contents, err := osFs.ReadFileString(localBlob.Path())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("fake-content"))
+			})
+
+			It("resumes after the bytes on disk when skipping them fails partway", func() {
+				fakeDavClient.GetContentsList = append(fakeDavClient.GetContentsList, ioutil.NopCloser(io.MultiReader(
+					strings.NewReader("fa"),
+					iotest.ErrReader(errors.New("fake-connection-reset")),
+				)))
+				fakeDavClient.GetContents = ioutil.NopCloser(strings.NewReader("fake-content"))
+
+				var localBlob LocalBlob
+				retrying(2, func() {
+					var err error
+					localBlob, err = blobstore.Get("fake-blob-id", nil)
+					Expect(err).ToNot(HaveOccurred())
+				})
+				defer localBlob.DeleteSilently()
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(3))
+
+				contents, err := osFs.ReadFileString(localBlob.Path())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("fake-content"))
+			})
+
+			It("gives up after 3 attempts", func() {
+				fakeDavClient.GetContents = ioutil.NopCloser(iotest.ErrReader(errors.New("fake-connection-refused")))
+
+				retrying(2, func() {
+					_, err := blobstore.Get("fake-blob-id", nil)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-connection-refused"))
+				})
+
+				Expect(fakeDavClient.GetCallCount).To(Equal(3))
 			})
 		})
 	})
 
 	Describe("Add", func() {
 		BeforeEach(func() {
-			fs.RegisterOpenFile("fake-source-path", &fakesys.FakeFile{
-				Contents: []byte("fake-contents"),
-			})
+			fs.WriteFileString("fake-source-path", "fake-contents")
 		})
 
 		It("adds file to blobstore and returns its blob ID", func() {
@@ -91,5 +234,58 @@ var _ = Describe("Blobstore", func() {
 			Expect(fakeDavClient.PutPath).To(Equal("fake-blob-id"))
 			Expect(fakeDavClient.PutContents).To(Equal("fake-contents"))
 		})
+
+		It("retries the upload when putting fails", func() {
+			fakeDavClient.PutErrs = []error{errors.New("fake-put-error")}
+
+			retrying(1, func() {
+				_, err := blobstore.Add("fake-source-path")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Expect(fakeDavClient.PutCallCount).To(Equal(2))
+			Expect(fakeDavClient.PutPath).To(Equal("fake-uuid-0"))
+		})
+
+		It("waits twice as long before every retry", func() {
+			fakeDavClient.PutErrs = []error{
+				errors.New("fake-put-error"),
+				errors.New("fake-put-error"),
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := blobstore.Add("fake-source-path")
+				Expect(err).ToNot(HaveOccurred())
+			}()
+
+			timeService.WaitForWatcherAndIncrement(time.Second)
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+
+			timeService.Increment(time.Second)
+			Consistently(done).ShouldNot(BeClosed())
+
+			timeService.Increment(time.Second)
+			Eventually(done).Should(BeClosed())
+			Expect(fakeDavClient.PutCallCount).To(Equal(3))
+		})
+
+		It("returns an error after 3 failed attempts", func() {
+			fakeDavClient.PutErrs = []error{
+				errors.New("fake-put-error"),
+				errors.New("fake-put-error"),
+				errors.New("fake-put-error"),
+			}
+
+			retrying(2, func() {
+				_, err := blobstore.Add("fake-source-path")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-put-error"))
+			})
+
+			Expect(fakeDavClient.PutCallCount).To(Equal(3))
+		})
 	})
 })
@@ -0,0 +1,83 @@
+package blobstore
+
+import (
+	"code.cloudfoundry.org/clock"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// externalBlobstore adapts a provider-specific blobstore (s3, gcs, local)
+// so that it can be used in place of the agent's DAV blobstore.
+type externalBlobstore struct {
+	blobstore   boshblob.Blobstore
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+	maxTries    int
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewExternalBlobstore(blobstore boshblob.Blobstore, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) Blobstore {
+	return &externalBlobstore{
+		blobstore:   blobstore,
+		fs:          fs,
+		timeService: timeService,
+		maxTries:    defaultMaxTries,
+		logger:      logger,
+		logTag:      "externalBlobstore",
+	}
+}
+
+func (b *externalBlobstore) Get(blobID string, digest boshcrypto.Digest) (LocalBlob, error) {
+	b.logger.Debug(b.logTag, "Downloading blob %s", blobID)
+
+	var destinationPath string
+	var err error
+
+	for i := 1; ; i++ {
+		destinationPath, err = b.blobstore.Get(blobID)
+		if err == nil {
+			break
+		}
+
+		if i >= b.maxTries {
+			return nil, bosherr.WrapErrorf(err, "Getting blob %s from blobstore", blobID)
+		}
+
+		b.logger.Info(b.logTag, "Failed to get blob %s with error '%s', attempt %d out of %d", blobID, err.Error(), i, b.maxTries)
+		b.timeService.Sleep(retryDelay(i))
+	}
+
+	localBlob := NewLocalBlob(destinationPath, b.fs, b.logger)
+
+	if digest != nil {
+		err = digest.VerifyFilePath(destinationPath, b.fs)
+		if err != nil {
+			localBlob.DeleteSilently()
+			return nil, bosherr.WrapErrorf(err, "Verifying digest of blob %s", blobID)
+		}
+	}
+
+	return localBlob, nil
+}
+
+func (b *externalBlobstore) Add(sourcePath string) (string, error) {
+	b.logger.Debug(b.logTag, "Uploading blob from %s", sourcePath)
+
+	for i := 1; ; i++ {
+		blobID, err := b.blobstore.Create(sourcePath)
+		if err == nil {
+			return blobID, nil
+		}
+
+		if i >= b.maxTries {
+			return "", bosherr.WrapErrorf(err, "Putting file '%s' into blobstore", sourcePath)
+		}
+
+		b.logger.Info(b.logTag, "Failed to put blob from %s with error '%s', attempt %d out of %d", sourcePath, err.Error(), i, b.maxTries)
+		b.timeService.Sleep(retryDelay(i))
+	}
+}
@@ -0,0 +1,94 @@
+package blobstore_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-cli/blobstore"
+	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExternalBlobstore", func() {
+	var (
+		fakeBlobstore *fakeblob.FakeBlobstore
+		fs            *fakesys.FakeFileSystem
+		timeService   *fakeclock.FakeClock
+		blobstore     Blobstore
+	)
+
+	BeforeEach(func() {
+		fakeBlobstore = &fakeblob.FakeBlobstore{}
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		blobstore = NewExternalBlobstore(fakeBlobstore, fs, timeService, logger)
+	})
+
+	Describe("Get", func() {
+		It("returns a local blob for the downloaded file", func() {
+			fakeBlobstore.GetReturns("fake-destination-path", nil)
+
+			localBlob, err := blobstore.Get("fake-blob-id", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(localBlob.Path()).To(Equal("fake-destination-path"))
+
+			Expect(fakeBlobstore.GetCallCount()).To(Equal(1))
+			Expect(fakeBlobstore.GetArgsForCall(0)).To(Equal("fake-blob-id"))
+		})
+
+		It("returns an error when getting from blobstore fails 3 times", func() {
+			fakeBlobstore.GetReturns("", errors.New("fake-get-error"))
+
+			errs := make(chan error)
+			go func() {
+				_, err := blobstore.Get("fake-blob-id", nil)
+				errs <- err
+			}()
+
+			timeService.WaitForWatcherAndIncrement(time.Second)
+			timeService.WaitForWatcherAndIncrement(2 * time.Second)
+
+			err := <-errs
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+			Expect(fakeBlobstore.GetCallCount()).To(Equal(3))
+		})
+	})
+
+	Describe("Add", func() {
+		It("creates the blob and returns its blob ID", func() {
+			fakeBlobstore.CreateReturns("fake-blob-id", nil)
+
+			blobID, err := blobstore.Add("fake-source-path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fakeBlobstore.CreateArgsForCall(0)).To(Equal("fake-source-path"))
+		})
+
+		It("returns an error when creating the blob fails 3 times", func() {
+			fakeBlobstore.CreateReturns("", errors.New("fake-create-error"))
+
+			errs := make(chan error)
+			go func() {
+				_, err := blobstore.Add("fake-source-path")
+				errs <- err
+			}()
+
+			timeService.WaitForWatcherAndIncrement(time.Second)
+			timeService.WaitForWatcherAndIncrement(2 * time.Second)
+
+			err := <-errs
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-create-error"))
+			Expect(fakeBlobstore.CreateCallCount()).To(Equal(3))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package fakes
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+type FakeDavClient struct {
+	GetPath      string
+	GetCallCount int
+	GetContents  io.ReadCloser
+	// GetContentsList is returned by consecutive calls to Get, after it runs out GetContents is returned
+	GetContentsList []io.ReadCloser
+	GetErr          error
+
+	PutPath          string
+	PutContents      string
+	PutContentLength int64
+	PutCallCount     int
+	// PutErrs are returned by consecutive calls to Put, after they run out Put succeeds
+	PutErrs []error
+}
+
+func NewFakeDavClient() *FakeDavClient {
+	return &FakeDavClient{}
+}
+
+func (c *FakeDavClient) Get(path string) (io.ReadCloser, error) {
+	c.GetCallCount++
+	c.GetPath = path
+
+	if len(c.GetContentsList) > 0 {
+		var contents io.ReadCloser
+		contents, c.GetContentsList = c.GetContentsList[0], c.GetContentsList[1:]
+		return contents, c.GetErr
+	}
+
+	return c.GetContents, c.GetErr
+}
+
+func (c *FakeDavClient) Put(path string, content io.ReadCloser, contentLength int64) error {
+	defer content.Close()
+
+	c.PutCallCount++
+	c.PutPath = path
+	c.PutContentLength = contentLength
+
+	contentBytes, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	c.PutContents = string(contentBytes)
+
+	if len(c.PutErrs) > 0 {
+		err, c.PutErrs = c.PutErrs[0], c.PutErrs[1:]
+		return err
+	}
+
+	return nil
+}
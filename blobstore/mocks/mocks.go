@@ -6,6 +6,7 @@ package mocks
 
 import (
 	blobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	crypto "github.com/cloudfoundry/bosh-utils/crypto"
 	gomock "github.com/golang/mock/gomock"
 	http "net/http"
 	reflect "reflect"
@@ -47,6 +48,19 @@ func (mr *MockFactoryMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFactory)(nil).Create), arg0, arg1)
 }
 
+// CreateFromProvider mocks base method
+func (m *MockFactory) CreateFromProvider(arg0 string, arg1 map[string]interface{}) (blobstore.Blobstore, error) {
+	ret := m.ctrl.Call(m, "CreateFromProvider", arg0, arg1)
+	ret0, _ := ret[0].(blobstore.Blobstore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFromProvider indicates an expected call of CreateFromProvider
+func (mr *MockFactoryMockRecorder) CreateFromProvider(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFromProvider", reflect.TypeOf((*MockFactory)(nil).CreateFromProvider), arg0, arg1)
+}
+
 // MockBlobstore is a mock of Blobstore interface
 type MockBlobstore struct {
 	ctrl     *gomock.Controller
@@ -84,14 +98,14 @@ func (mr *MockBlobstoreMockRecorder) Add(arg0 interface{}) *gomock.Call {
 }
 
 // Get mocks base method
-func (m *MockBlobstore) Get(arg0 string) (blobstore.LocalBlob, error) {
-	ret := m.ctrl.Call(m, "Get", arg0)
+func (m *MockBlobstore) Get(arg0 string, arg1 crypto.Digest) (blobstore.LocalBlob, error) {
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
 	ret0, _ := ret[0].(blobstore.LocalBlob)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get
-func (mr *MockBlobstoreMockRecorder) Get(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlobstore)(nil).Get), arg0)
+func (mr *MockBlobstoreMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlobstore)(nil).Get), arg0, arg1)
 }
@@ -19,6 +19,7 @@ type Cloud interface {
 	CreateStemcell(imagePath string, cloudProperties biproperty.Map) (stemcellCID string, err error)
 	DeleteStemcell(stemcellCID string) error
 	HasVM(vmCID string) (bool, error)
+	HasDisk(diskCID string) (bool, error)
 	CreateVM(
 		agentID string,
 		stemcellCID string,
@@ -33,6 +34,15 @@ type Cloud interface {
 	AttachDisk(vmCID, diskCID string) (interface{}, error)
 	DetachDisk(vmCID, diskCID string) error
 	DeleteDisk(diskCID string) error
+	ResizeDisk(diskCID string, newSize int) error
+	UpdateDisk(diskCID string, newSize int, cloudProperties biproperty.Map) (newDiskCID string, err error)
+	SnapshotDisk(diskCID string, metadata DiskMetadata) (snapshotCID string, err error)
+	DeleteSnapshot(snapshotCID string) error
+	GetDisks(vmCID string) (diskCIDs []string, err error)
+	ListVMs(metadata VMMetadata) (vmCIDs []string, err error)
+	ListDisks(metadata DiskMetadata) (diskCIDs []string, err error)
+	RebootVM(vmCID string) error
+	CalculateVMCloudProperties(vmResources VMResources) (cloudProperties biproperty.Map, err error)
 	Info() (cpiInfo CpiInfo, err error)
 	fmt.Stringer
 }
@@ -51,6 +61,13 @@ type CpiInfo struct {
 
 type VMMetadata map[string]string
 
+// VMResources describes the desired size of a VM independently of the cloud
+type VMResources struct {
+	CPU               int `json:"cpu"`
+	RAM               int `json:"ram"`
+	EphemeralDiskSize int `json:"ephemeral_disk_size"`
+}
+
 type DiskMetadata map[string]string
 
 func NewCloud(
@@ -149,6 +166,29 @@ func (c cloud) HasVM(vmCID string) (bool, error) {
 	return found, nil
 }
 
+func (c cloud) HasDisk(diskCID string) (bool, error) {
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return false, err
+	}
+
+	method := "has_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, diskCID)
+	if err != nil {
+		return false, err
+	}
+
+	if cmdOutput.Error != nil {
+		return false, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	found, ok := cmdOutput.Result.(bool)
+	if !ok {
+		return false, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+	return found, nil
+}
+
 func (c cloud) CreateVM(
 	agentID string,
 	stemcellCID string,
@@ -396,6 +436,242 @@ func (c cloud) DeleteDisk(diskCID string) error {
 	return nil
 }
 
+func (c cloud) ResizeDisk(diskCID string, newSize int) error {
+	c.logger.Debug(c.logTag, "Resizing disk '%s' to %d MiB", diskCID, newSize)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return err
+	}
+
+	method := "resize_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, diskCID, newSize)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling CPI 'resize_disk' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return nil
+}
+
+// UpdateDisk returns the CID of the updated disk, which differs from
+// the original CID when the CPI replaced the disk
+func (c cloud) UpdateDisk(diskCID string, newSize int, cloudProperties biproperty.Map) (string, error) {
+	c.logger.Debug(c.logTag, "Updating disk '%s' to %d MiB with cloudProperties %#v", diskCID, newSize, cloudProperties)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return "", err
+	}
+
+	method := "update_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, diskCID, newSize, cloudProperties)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Calling CPI 'update_disk' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return "", NewCPIError(method, *cmdOutput.Error)
+	}
+
+	// CPIs updating the disk in place may not return a CID
+	if cmdOutput.Result == nil {
+		return diskCID, nil
+	}
+
+	cidString, ok := cmdOutput.Result.(string)
+	if !ok {
+		return "", bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	return cidString, nil
+}
+
+func (c cloud) SnapshotDisk(diskCID string, metadata DiskMetadata) (string, error) {
+	c.logger.Debug(c.logTag, "Snapshotting disk '%s'", diskCID)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return "", err
+	}
+
+	method := "snapshot_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, diskCID, metadata)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Calling CPI 'snapshot_disk' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return "", NewCPIError(method, *cmdOutput.Error)
+	}
+
+	cidString, ok := cmdOutput.Result.(string)
+	if !ok {
+		return "", bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	return cidString, nil
+}
+
+func (c cloud) DeleteSnapshot(snapshotCID string) error {
+	c.logger.Debug(c.logTag, "Deleting snapshot '%s'", snapshotCID)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return err
+	}
+
+	method := "delete_snapshot"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, snapshotCID)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling CPI 'delete_snapshot' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return nil
+}
+
+func (c cloud) GetDisks(vmCID string) ([]string, error) {
+	c.logger.Debug(c.logTag, "Getting disks of vm '%s'", vmCID)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	method := "get_disks"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, vmCID)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Calling CPI 'get_disks' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return nil, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return cidsResult(cmdOutput.Result)
+}
+
+// ListVMs returns the cids of all VMs that have the given metadata
+func (c cloud) ListVMs(metadata VMMetadata) ([]string, error) {
+	c.logger.Debug(c.logTag, "Listing vms with metadata %#v", metadata)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	method := "list_vms"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, metadata)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Calling CPI 'list_vms' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return nil, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return cidsResult(cmdOutput.Result)
+}
+
+// ListDisks returns the cids of all disks that have the given metadata
+func (c cloud) ListDisks(metadata DiskMetadata) ([]string, error) {
+	c.logger.Debug(c.logTag, "Listing disks with metadata %#v", metadata)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	method := "list_disks"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, metadata)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Calling CPI 'list_disks' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return nil, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return cidsResult(cmdOutput.Result)
+}
+
+func cidsResult(result interface{}) ([]string, error) {
+	results, ok := result.([]interface{})
+	if !ok {
+		return nil, bosherr.Errorf("Unexpected external CPI command result: '%#v'", result)
+	}
+
+	cids := []string{}
+	for _, r := range results {
+		cid, ok := r.(string)
+		if !ok {
+			return nil, bosherr.Errorf("Unexpected external CPI command result: '%#v'", result)
+		}
+		cids = append(cids, cid)
+	}
+
+	return cids, nil
+}
+
+func (c cloud) RebootVM(vmCID string) error {
+	c.logger.Debug(c.logTag, "Rebooting vm '%s'", vmCID)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return err
+	}
+
+	method := "reboot_vm"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, vmCID)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling CPI 'reboot_vm' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return nil
+}
+
+func (c cloud) CalculateVMCloudProperties(vmResources VMResources) (biproperty.Map, error) {
+	c.logger.Debug(c.logTag, "Calculating vm cloud properties for %#v", vmResources)
+
+	cpiInfo, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	method := "calculate_vm_cloud_properties"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, cpiInfo.ApiVersion, vmResources)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Calling CPI 'calculate_vm_cloud_properties' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return nil, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	result, ok := cmdOutput.Result.(map[string]interface{})
+	if !ok {
+		return nil, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	cloudProperties := biproperty.Map{}
+	for key, value := range result {
+		cloudProperties[key] = value
+	}
+
+	return cloudProperties, nil
+}
+
 func (c cloud) Info() (cpiInfo CpiInfo, err error) {
 	c.logger.Debug(c.logTag, "Info")
 
@@ -368,6 +368,53 @@ var _ = Describe("Cloud", func() {
 		})
 	})
 
+	Describe("HasDisk", func() {
+		It("return true when disk exists", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: true},
+			}
+
+			found, err := cloud.HasDisk("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "has_disk",
+				Arguments:  []interface{}{"fake-disk-cid"},
+				ApiVersion: 1,
+			}))
+		})
+
+		It("return false when disk does not exist", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: false},
+			}
+
+			found, err := cloud.HasDisk("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error when the result is not a bool", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: "fake-result"},
+			}
+
+			_, err := cloud.HasDisk("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("has_disk", func() error {
+			_, err := cloud.HasDisk("fake-disk-cid")
+			return err
+		})
+	})
+
 	Describe("CreateVM", func() {
 		var (
 			agentID           string
@@ -891,4 +938,321 @@ var _ = Describe("Cloud", func() {
 			return cloud.DeleteDisk("fake-disk-cid")
 		})
 	})
+
+	Describe("ResizeDisk", func() {
+		It("executes the resize_disk method with the disk cid and new size", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+			}
+
+			err := cloud.ResizeDisk("fake-disk-cid", 2048)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.CurrentRunInput).To(HaveLen(2))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "resize_disk",
+				Arguments:  []interface{}{"fake-disk-cid", 2048},
+				ApiVersion: 2,
+			}))
+		})
+
+		It("returns a not implemented cloud.Error when the CPI does not support resizing", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Error: &CmdError{Type: NotImplementedError, Message: "fake-not-implemented"}},
+			}
+
+			err := cloud.ResizeDisk("fake-disk-cid", 2048)
+			Expect(err).To(HaveOccurred())
+
+			cpiError, ok := err.(Error)
+			Expect(ok).To(BeTrue())
+			Expect(cpiError.Type()).To(Equal(NotImplementedError))
+		})
+
+		itHandlesCPIErrors("resize_disk", func() error {
+			return cloud.ResizeDisk("fake-disk-cid", 2048)
+		})
+	})
+
+	Describe("UpdateDisk", func() {
+		var cloudProperties biproperty.Map
+
+		BeforeEach(func() {
+			cloudProperties = biproperty.Map{"type": "fast"}
+		})
+
+		It("returns the cid of the updated disk", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+				{Result: "fake-new-disk-cid"},
+			}
+
+			diskCID, err := cloud.UpdateDisk("fake-disk-cid", 2048, cloudProperties)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal("fake-new-disk-cid"))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "update_disk",
+				Arguments:  []interface{}{"fake-disk-cid", 2048, cloudProperties},
+				ApiVersion: 2,
+			}))
+		})
+
+		It("returns the original cid when the disk is updated in place", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+				{Result: nil},
+			}
+
+			diskCID, err := cloud.UpdateDisk("fake-disk-cid", 2048, cloudProperties)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal("fake-disk-cid"))
+		})
+
+		It("returns an error when the result is of an unexpected type", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: 1},
+			}
+
+			_, err := cloud.UpdateDisk("fake-disk-cid", 2048, cloudProperties)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result: '1'"))
+		})
+
+		itHandlesCPIErrors("update_disk", func() error {
+			_, err := cloud.UpdateDisk("fake-disk-cid", 2048, cloudProperties)
+			return err
+		})
+	})
+
+	Describe("SnapshotDisk", func() {
+		var metadata DiskMetadata
+
+		BeforeEach(func() {
+			metadata = DiskMetadata{"deployment": "fake-deployment"}
+		})
+
+		It("returns the snapshot cid", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+				{Result: "fake-snapshot-cid"},
+			}
+
+			snapshotCID, err := cloud.SnapshotDisk("fake-disk-cid", metadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshotCID).To(Equal("fake-snapshot-cid"))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "snapshot_disk",
+				Arguments:  []interface{}{"fake-disk-cid", metadata},
+				ApiVersion: 2,
+			}))
+		})
+
+		It("returns an error when the result is of an unexpected type", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: nil},
+			}
+
+			_, err := cloud.SnapshotDisk("fake-disk-cid", metadata)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("snapshot_disk", func() error {
+			_, err := cloud.SnapshotDisk("fake-disk-cid", metadata)
+			return err
+		})
+	})
+
+	Describe("DeleteSnapshot", func() {
+		It("executes the delete_snapshot method with the snapshot cid", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+			}
+
+			err := cloud.DeleteSnapshot("fake-snapshot-cid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "delete_snapshot",
+				Arguments:  []interface{}{"fake-snapshot-cid"},
+				ApiVersion: 2,
+			}))
+		})
+
+		itHandlesCPIErrors("delete_snapshot", func() error {
+			return cloud.DeleteSnapshot("fake-snapshot-cid")
+		})
+	})
+
+	Describe("GetDisks", func() {
+		It("returns the cids of the disks attached to the vm", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+				{Result: []interface{}{"fake-disk-cid-1", "fake-disk-cid-2"}},
+			}
+
+			diskCIDs, err := cloud.GetDisks("fake-vm-cid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCIDs).To(Equal([]string{"fake-disk-cid-1", "fake-disk-cid-2"}))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "get_disks",
+				Arguments:  []interface{}{"fake-vm-cid"},
+				ApiVersion: 2,
+			}))
+		})
+
+		It("returns an error when the result contains something other than cids", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: []interface{}{"fake-disk-cid-1", 2}},
+			}
+
+			_, err := cloud.GetDisks("fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("get_disks", func() error {
+			_, err := cloud.GetDisks("fake-vm-cid")
+			return err
+		})
+	})
+
+	Describe("ListVMs", func() {
+		It("returns the cids of the vms with the metadata", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: []interface{}{"fake-cid-1", "fake-cid-2"}},
+			}
+
+			cids, err := cloud.ListVMs(VMMetadata{"deployment": "fake-deployment"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cids).To(Equal([]string{"fake-cid-1", "fake-cid-2"}))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "list_vms",
+				Arguments:  []interface{}{VMMetadata{"deployment": "fake-deployment"}},
+				ApiVersion: 1,
+			}))
+		})
+
+		It("returns an error when the result is not a list of cids", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: "fake-cid"},
+			}
+
+			_, err := cloud.ListVMs(VMMetadata{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("list_vms", func() error {
+			_, err := cloud.ListVMs(VMMetadata{})
+			return err
+		})
+	})
+
+	Describe("ListDisks", func() {
+		It("returns the cids of the disks with the metadata", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: []interface{}{"fake-cid-1", "fake-cid-2"}},
+			}
+
+			cids, err := cloud.ListDisks(DiskMetadata{"deployment": "fake-deployment"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cids).To(Equal([]string{"fake-cid-1", "fake-cid-2"}))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "list_disks",
+				Arguments:  []interface{}{DiskMetadata{"deployment": "fake-deployment"}},
+				ApiVersion: 1,
+			}))
+		})
+
+		It("returns an error when the result is not a list of cids", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: "fake-cid"},
+			}
+
+			_, err := cloud.ListDisks(DiskMetadata{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("list_disks", func() error {
+			_, err := cloud.ListDisks(DiskMetadata{})
+			return err
+		})
+	})
+
+	Describe("RebootVM", func() {
+		It("executes the reboot_vm method with the vm cid", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+			}
+
+			err := cloud.RebootVM("fake-vm-cid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "reboot_vm",
+				Arguments:  []interface{}{"fake-vm-cid"},
+				ApiVersion: 2,
+			}))
+		})
+
+		itHandlesCPIErrors("reboot_vm", func() error {
+			return cloud.RebootVM("fake-vm-cid")
+		})
+	})
+
+	Describe("CalculateVMCloudProperties", func() {
+		var vmResources VMResources
+
+		BeforeEach(func() {
+			vmResources = VMResources{CPU: 2, RAM: 4096, EphemeralDiskSize: 10240}
+		})
+
+		It("returns the cloud properties calculated by the CPI", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResultWithApiV2},
+				{Result: map[string]interface{}{"instance_type": "m4.large"}},
+			}
+
+			cloudProperties, err := cloud.CalculateVMCloudProperties(vmResources)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudProperties).To(Equal(biproperty.Map{"instance_type": "m4.large"}))
+			Expect(fakeCPICmdRunner.CurrentRunInput[1]).To(Equal(fakebicloud.RunInput{
+				Context:    expectedContext,
+				Method:     "calculate_vm_cloud_properties",
+				Arguments:  []interface{}{vmResources},
+				ApiVersion: 2,
+			}))
+		})
+
+		It("returns an error when the result is of an unexpected type", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{
+				{Result: infoResult},
+				{Result: "m4.large"},
+			}
+
+			_, err := cloud.CalculateVMCloudProperties(vmResources)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		itHandlesCPIErrors("calculate_vm_cloud_properties", func() error {
+			_, err := cloud.CalculateVMCloudProperties(vmResources)
+			return err
+		})
+	})
 })
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -64,24 +67,63 @@ type CPICmdRunner interface {
 	Run(context CmdContext, method string, apiVersion int, args ...interface{}) (CmdOutput, error)
 }
 
+// CPICmdRunnerOptions controls how long CPI methods may run
+// and how calls failing with errors marked as ok_to_retry are retried
+type CPICmdRunnerOptions struct {
+	// Timeout applies to methods without a method specific timeout, zero disables it
+	Timeout        time.Duration
+	MethodTimeouts map[string]time.Duration
+
+	Retries    int
+	RetryDelay time.Duration
+
+	// Closing Cancel terminates running CPI processes and fails further calls
+	Cancel <-chan struct{}
+
+	// RecordTo receives every CPI call with its response as a JSON line
+	RecordTo io.Writer
+
+	// Replaying serves Replay, previously recorded CPI calls, instead of running the CPI;
+	// it is set separately so that an empty recording never falls back to the real CPI
+	Replaying bool
+	Replay    []CPICall
+}
+
+func (o CPICmdRunnerOptions) timeout(method string) time.Duration {
+	if timeout, found := o.MethodTimeouts[method]; found {
+		return timeout
+	}
+
+	return o.Timeout
+}
+
+// cpiKillGracePeriod is how long a terminated CPI process may take to exit before it is killed
+const cpiKillGracePeriod = 10 * time.Second
+
 type cpiCmdRunner struct {
-	cmdRunner  boshsys.CmdRunner
-	cpi        CPI
-	logger     boshlog.Logger
-	apiVersion int
-	logTag     string
+	cmdRunner   boshsys.CmdRunner
+	cpi         CPI
+	options     CPICmdRunnerOptions
+	timeService clock.Clock
+	logger      boshlog.Logger
+	apiVersion  int
+	logTag      string
 }
 
 func NewCPICmdRunner(
 	cmdRunner boshsys.CmdRunner,
 	cpi CPI,
+	options CPICmdRunnerOptions,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) CPICmdRunner {
 	return &cpiCmdRunner{
-		cmdRunner: cmdRunner,
-		cpi:       cpi,
-		logger:    logger,
-		logTag:    "cpiCmdRunner",
+		cmdRunner:   cmdRunner,
+		cpi:         cpi,
+		options:     options,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "cpiCmdRunner",
 	}
 }
 
@@ -100,6 +142,28 @@ func (r *cpiCmdRunner) Run(context CmdContext, method string, apiVersion int, ar
 		return CmdOutput{}, bosherr.WrapErrorf(err, "Marshalling external CPI command input %#v", cmdInput)
 	}
 
+	for attempt := 1; ; attempt++ {
+		cmdOutput, err := r.run(method, inputBytes)
+		if err != nil {
+			return CmdOutput{}, err
+		}
+
+		if cmdOutput.Error == nil || !cmdOutput.Error.OkToRetry || attempt > r.options.Retries {
+			return cmdOutput, nil
+		}
+
+		r.logger.Warn(r.logTag, "Retrying CPI '%s' method (attempt %d of %d) after retryable error: %s",
+			method, attempt+1, r.options.Retries+1, cmdOutput.Error)
+
+		select {
+		case <-r.timeService.After(r.options.RetryDelay):
+		case <-r.options.Cancel:
+			return CmdOutput{}, bosherr.Errorf("Cancelled retrying CPI '%s' method", method)
+		}
+	}
+}
+
+func (r *cpiCmdRunner) run(method string, inputBytes []byte) (CmdOutput, error) {
 	cmdPath := r.cpi.ExecutablePath()
 	cmd := boshsys.Command{
 		Name: cmdPath,
@@ -111,12 +175,48 @@ func (r *cpiCmdRunner) Run(context CmdContext, method string, apiVersion int, ar
 		UseIsolatedEnv: true,
 		Stdin:          bytes.NewReader(inputBytes),
 	}
-	stdout, stderr, exitCode, err := r.cmdRunner.RunComplexCommand(cmd)
-	r.logger.Debug(r.logTag, "Exit Code %d when executing external CPI command '%s'\nSTDIN: '%s'\nSTDOUT: '%s'\nSTDERR: '%s'", exitCode, cmdPath, string(inputBytes), stdout, stderr)
+
+	select {
+	case <-r.options.Cancel:
+		return CmdOutput{}, bosherr.Errorf("Cancelled external CPI command '%s' for method '%s'", cmdPath, method)
+	default:
+	}
+
+	process, err := r.cmdRunner.RunComplexCommandAsync(cmd)
 	if err != nil {
 		return CmdOutput{}, bosherr.WrapErrorf(err, "Executing external CPI command: '%s'", cmdPath)
 	}
 
+	resultCh := process.Wait()
+
+	var timeoutCh <-chan time.Time
+
+	timeout := r.options.timeout(method)
+	if timeout > 0 {
+		timer := r.timeService.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutCh = timer.C()
+	}
+
+	var result boshsys.Result
+
+	select {
+	case result = <-resultCh:
+	case <-timeoutCh:
+		r.terminate(process, resultCh, cmdPath)
+		return CmdOutput{}, bosherr.Errorf("Executing external CPI command '%s' for method '%s' timed out after %s", cmdPath, method, timeout)
+	case <-r.options.Cancel:
+		r.terminate(process, resultCh, cmdPath)
+		return CmdOutput{}, bosherr.Errorf("Cancelled external CPI command '%s' for method '%s'", cmdPath, method)
+	}
+
+	stdout, stderr, exitCode := result.Stdout, result.Stderr, result.ExitStatus
+	r.logger.Debug(r.logTag, "Exit Code %d when executing external CPI command '%s'\nSTDIN: '%s'\nSTDOUT: '%s'\nSTDERR: '%s'", exitCode, cmdPath, string(inputBytes), stdout, stderr)
+	if result.Error != nil {
+		return CmdOutput{}, bosherr.WrapErrorf(result.Error, "Executing external CPI command: '%s'", cmdPath)
+	}
+
 	cmdOutput := CmdOutput{}
 	err = json.Unmarshal([]byte(stdout), &cmdOutput)
 	if err != nil {
@@ -127,3 +227,14 @@ func (r *cpiCmdRunner) Run(context CmdContext, method string, apiVersion int, ar
 
 	return cmdOutput, err
 }
+
+func (r *cpiCmdRunner) terminate(process boshsys.Process, resultCh <-chan boshsys.Result, cmdPath string) {
+	err := process.TerminateNicely(cpiKillGracePeriod)
+	if err != nil {
+		r.logger.Warn(r.logTag, "Failed to terminate external CPI command '%s': %s", cmdPath, err.Error())
+		return
+	}
+
+	result := <-resultCh
+	r.logger.Debug(r.logTag, "Terminated external CPI command '%s'\nSTDOUT: '%s'\nSTDERR: '%s'", cmdPath, result.Stdout, result.Stderr)
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	. "github.com/cloudfoundry/bosh-cli/cloud"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -19,6 +23,9 @@ var _ = Describe("CpiCmdRunner", func() {
 		cmdRunner    *fakesys.FakeCmdRunner
 		cpi          CPI
 		apiVersion   int
+		options      CPICmdRunnerOptions
+		timeService  *fakeclock.FakeClock
+		logger       boshlog.Logger
 	)
 
 	BeforeEach(func() {
@@ -33,8 +40,10 @@ var _ = Describe("CpiCmdRunner", func() {
 		}
 
 		cmdRunner = fakesys.NewFakeCmdRunner()
-		logger := boshlog.NewLogger(boshlog.LevelNone)
-		cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, logger)
+		options = CPICmdRunnerOptions{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, options, timeService, logger)
 
 		apiVersion = 1
 	})
@@ -45,11 +54,9 @@ var _ = Describe("CpiCmdRunner", func() {
 			outputBytes, err := json.Marshal(cmdOutput)
 			Expect(err).NotTo(HaveOccurred())
 
-			result := fakesys.FakeCmdResult{
-				Stdout:     string(outputBytes),
-				ExitStatus: 0,
-			}
-			cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+			cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+			})
 
 			_, err = cpiCmdRunner.Run(context, "fake-method", apiVersion, "fake-argument-1", "fake-argument-2")
 			Expect(err).NotTo(HaveOccurred())
@@ -92,11 +99,9 @@ var _ = Describe("CpiCmdRunner", func() {
 				outputBytes, err := json.Marshal(cmdOutput)
 				Expect(err).NotTo(HaveOccurred())
 
-				result := fakesys.FakeCmdResult{
-					Stdout:     string(outputBytes),
-					ExitStatus: 0,
-				}
-				cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+				})
 
 				_, err = cpiCmdRunner.Run(context, "fake-method", apiVersion, "fake-argument-1", "fake-argument-2")
 				Expect(err).NotTo(HaveOccurred())
@@ -133,11 +138,9 @@ var _ = Describe("CpiCmdRunner", func() {
 				outputBytes, err := json.Marshal(cmdOutput)
 				Expect(err).NotTo(HaveOccurred())
 
-				result := fakesys.FakeCmdResult{
-					Stdout:     string(outputBytes),
-					ExitStatus: 0,
-				}
-				cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+				})
 			})
 
 			It("returns the result", func() {
@@ -153,10 +156,9 @@ var _ = Describe("CpiCmdRunner", func() {
 
 		Context("when running the command fails", func() {
 			BeforeEach(func() {
-				result := fakesys.FakeCmdResult{
-					Error: errors.New("fake-error-trying-to-run-command"),
-				}
-				cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Error: errors.New("fake-error-trying-to-run-command")},
+				})
 			})
 
 			It("returns an error", func() {
@@ -177,11 +179,9 @@ var _ = Describe("CpiCmdRunner", func() {
 				outputBytes, err := json.Marshal(cmdOutput)
 				Expect(err).NotTo(HaveOccurred())
 
-				result := fakesys.FakeCmdResult{
-					Stdout:     string(outputBytes),
-					ExitStatus: 0,
-				}
-				cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+				})
 			})
 
 			It("returns the command output and no error", func() {
@@ -199,11 +199,9 @@ var _ = Describe("CpiCmdRunner", func() {
 				outputBytes, err := json.Marshal(cmdOutput)
 				Expect(err).NotTo(HaveOccurred())
 
-				result := fakesys.FakeCmdResult{
-					Stdout:     string(outputBytes),
-					ExitStatus: 0,
-				}
-				cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", result)
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+				})
 			})
 
 			It("it should not pass null in arguments", func() {
@@ -224,5 +222,128 @@ var _ = Describe("CpiCmdRunner", func() {
 				))
 			})
 		})
+
+		Context("when the CPI responds with a retryable error", func() {
+			addProcess := func(cmdOutput CmdOutput) {
+				outputBytes, err := json.Marshal(cmdOutput)
+				Expect(err).NotTo(HaveOccurred())
+
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{Stdout: string(outputBytes)},
+				})
+			}
+
+			BeforeEach(func() {
+				options.Retries = 2
+				cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, options, timeService, logger)
+
+				addProcess(CmdOutput{Error: &CmdError{Type: "fake-type", Message: "fake-retryable-error", OkToRetry: true}})
+			})
+
+			It("retries the call until it succeeds", func() {
+				addProcess(CmdOutput{Result: "fake-cid"})
+
+				cmdOutput, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmdOutput.Result).To(Equal("fake-cid"))
+				Expect(cmdRunner.RunComplexCommands).To(HaveLen(2))
+
+				bytes, err := ioutil.ReadAll(cmdRunner.RunComplexCommands[1].Stdin)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(bytes)).To(ContainSubstring(`"method":"fake-method"`))
+			})
+
+			It("returns the last error once all retries are used up", func() {
+				addProcess(CmdOutput{Error: &CmdError{Type: "fake-type", Message: "fake-retryable-error", OkToRetry: true}})
+				addProcess(CmdOutput{Error: &CmdError{Type: "fake-type", Message: "fake-last-error", OkToRetry: true}})
+
+				cmdOutput, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmdOutput.Error.Message).To(Equal("fake-last-error"))
+				Expect(cmdRunner.RunComplexCommands).To(HaveLen(3))
+			})
+
+			It("does not retry errors that are not ok to retry", func() {
+				cmdRunner = fakesys.NewFakeCmdRunner()
+				cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, options, timeService, logger)
+				addProcess(CmdOutput{Error: &CmdError{Type: "fake-type", Message: "fake-error", OkToRetry: false}})
+
+				cmdOutput, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmdOutput.Error.Message).To(Equal("fake-error"))
+				Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+			})
+		})
+
+		Context("when the CPI does not finish in time", func() {
+			var process *fakesys.FakeProcess
+
+			BeforeEach(func() {
+				options.Timeout = time.Minute
+				options.MethodTimeouts = map[string]time.Duration{"create_vm": time.Hour}
+				cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, options, timeService, logger)
+
+				process = &fakesys.FakeProcess{
+					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+						p.WaitCh <- boshsys.Result{Error: errors.New("fake-terminated")}
+					},
+				}
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", process)
+			})
+
+			It("terminates the CPI and returns an error", func() {
+				go timeService.WaitForWatcherAndIncrement(time.Minute)
+
+				_, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Executing external CPI command '/jobs/cpi/bin/cpi' for method 'fake-method' timed out after 1m0s"))
+				Expect(process.TerminatedNicely).To(BeTrue())
+			})
+
+			It("uses the timeout of the method when it is configured", func() {
+				go timeService.WaitForWatcherAndIncrement(time.Hour)
+
+				_, err := cpiCmdRunner.Run(context, "create_vm", apiVersion)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("timed out after 1h0m0s"))
+			})
+		})
+
+		Context("when the call is cancelled", func() {
+			var (
+				cancelCh chan struct{}
+				process  *fakesys.FakeProcess
+			)
+
+			BeforeEach(func() {
+				cancelCh = make(chan struct{})
+				options.Cancel = cancelCh
+				cpiCmdRunner = NewCPICmdRunner(cmdRunner, cpi, options, timeService, logger)
+
+				process = &fakesys.FakeProcess{
+					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+						p.WaitCh <- boshsys.Result{Error: errors.New("fake-terminated")}
+					},
+				}
+			})
+
+			It("terminates the running CPI", func() {
+				cmdRunner.AddProcess("/jobs/cpi/bin/cpi", process)
+				cmdRunner.SetCmdCallback("/jobs/cpi/bin/cpi", func() { close(cancelCh) })
+
+				_, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Cancelled external CPI command '/jobs/cpi/bin/cpi' for method 'fake-method'"))
+				Expect(process.TerminatedNicely).To(BeTrue())
+			})
+
+			It("does not start the CPI once cancelled", func() {
+				close(cancelCh)
+
+				_, err := cpiCmdRunner.Run(context, "fake-method", apiVersion)
+				Expect(err).To(HaveOccurred())
+				Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+			})
+		})
 	})
 })
@@ -0,0 +1,203 @@
+package cloud
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// CPICall is a single recorded CPI invocation
+type CPICall struct {
+	Time       time.Time     `json:"time"`
+	Method     string        `json:"method"`
+	Arguments  []interface{} `json:"arguments"`
+	Context    CmdContext    `json:"context"`
+	ApiVersion int           `json:"api_version"`
+
+	// Duration is in seconds
+	Duration float64    `json:"duration"`
+	Output   *CmdOutput `json:"output,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// recordingCPICmdRunner writes every CPI call with its response as a JSON line
+type recordingCPICmdRunner struct {
+	cpiCmdRunner CPICmdRunner
+	writer       io.Writer
+	writerLock   sync.Mutex
+	timeService  clock.Clock
+	logger       boshlog.Logger
+	logTag       string
+}
+
+func NewRecordingCPICmdRunner(cpiCmdRunner CPICmdRunner, writer io.Writer, timeService clock.Clock, logger boshlog.Logger) CPICmdRunner {
+	return &recordingCPICmdRunner{
+		cpiCmdRunner: cpiCmdRunner,
+		writer:       writer,
+		timeService:  timeService,
+		logger:       logger,
+		logTag:       "recordingCPICmdRunner",
+	}
+}
+
+func (r *recordingCPICmdRunner) Run(context CmdContext, method string, apiVersion int, args ...interface{}) (CmdOutput, error) {
+	startTime := r.timeService.Now()
+
+	cmdOutput, err := r.cpiCmdRunner.Run(context, method, apiVersion, args...)
+
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	call := CPICall{
+		Time:       startTime,
+		Method:     method,
+		Arguments:  args,
+		Context:    context,
+		ApiVersion: apiVersion,
+		Duration:   r.timeService.Since(startTime).Seconds(),
+	}
+
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.Output = &cmdOutput
+	}
+
+	r.record(call)
+
+	return cmdOutput, err
+}
+
+// record never fails the CPI call, losing a recording is preferable to interrupting a deploy
+func (r *recordingCPICmdRunner) record(call CPICall) {
+	callBytes, err := json.Marshal(call)
+	if err != nil {
+		r.logger.Error(r.logTag, "Failed to marshal CPI call '%s': %s", call.Method, err.Error())
+		return
+	}
+
+	r.writerLock.Lock()
+	defer r.writerLock.Unlock()
+
+	_, err = r.writer.Write(append(callBytes, '\n'))
+	if err != nil {
+		r.logger.Error(r.logTag, "Failed to record CPI call '%s': %s", call.Method, err.Error())
+	}
+}
+
+// ReadCPICalls reads a session written by the recording CPI cmd runner
+func ReadCPICalls(reader io.Reader) ([]CPICall, error) {
+	var calls []CPICall
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var call CPICall
+
+		err := json.Unmarshal(scanner.Bytes(), &call)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling recorded CPI call on line %d", line)
+		}
+
+		calls = append(calls, call)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, bosherr.WrapError(err, "Reading recorded CPI calls")
+	}
+
+	return calls, nil
+}
+
+// replayCPICmdRunner serves recorded CPI calls in order instead of running the CPI.
+// Calls must be made for the same methods in the same order as they were recorded.
+// Differing arguments are only logged since they commonly include generated IDs.
+// Only the CPI is replayed: agents on created VMs still have to be reachable.
+type replayCPICmdRunner struct {
+	calls     []CPICall
+	next      int
+	callsLock sync.Mutex
+	logger    boshlog.Logger
+	logTag    string
+}
+
+func NewReplayCPICmdRunner(calls []CPICall, logger boshlog.Logger) CPICmdRunner {
+	return &replayCPICmdRunner{
+		calls:  calls,
+		logger: logger,
+		logTag: "replayCPICmdRunner",
+	}
+}
+
+func (r *replayCPICmdRunner) Run(context CmdContext, method string, apiVersion int, args ...interface{}) (CmdOutput, error) {
+	r.callsLock.Lock()
+	defer r.callsLock.Unlock()
+
+	if r.next >= len(r.calls) {
+		return CmdOutput{}, bosherr.Errorf("Replaying CPI '%s' method: all %d recorded CPI calls were already replayed", method, len(r.calls))
+	}
+
+	call := r.calls[r.next]
+	r.next++
+
+	if call.Method != method {
+		return CmdOutput{}, bosherr.Errorf(
+			"Replaying CPI '%s' method: expected recorded call #%d to be for the same method but it is for '%s'", method, r.next, call.Method)
+	}
+
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	if !r.sameJSON(call.Arguments, args) {
+		r.logger.Warn(r.logTag, "Replaying CPI '%s' method with arguments '%#v' recorded with arguments '%#v'", method, args, call.Arguments)
+	}
+
+	if call.Error != "" {
+		return CmdOutput{}, bosherr.Error(call.Error)
+	}
+
+	if call.Output == nil {
+		return CmdOutput{}, bosherr.Errorf("Replaying CPI '%s' method: recorded call #%d has no output", method, r.next)
+	}
+
+	return *call.Output, nil
+}
+
+func (r *replayCPICmdRunner) sameJSON(recorded interface{}, actual interface{}) bool {
+	recordedBytes, err := json.Marshal(recorded)
+	if err != nil {
+		return false
+	}
+
+	actualBytes, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+
+	// round trip the actual value so that it is compared the way it was recorded
+	var actualValue interface{}
+	if err = json.Unmarshal(actualBytes, &actualValue); err != nil {
+		return false
+	}
+
+	actualBytes, err = json.Marshal(actualValue)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(recordedBytes, actualBytes)
+}
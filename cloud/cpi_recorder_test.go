@@ -0,0 +1,156 @@
+package cloud_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("CPI recording", func() {
+	var (
+		logger      boshlog.Logger
+		timeService *fakeclock.FakeClock
+		startTime   time.Time
+		context     CmdContext
+		out         *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		startTime = time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(startTime)
+		context = CmdContext{DirectorID: "fake-director-id"}
+		out = bytes.NewBufferString("")
+	})
+
+	Describe("recording CPI cmd runner", func() {
+		var (
+			fakeCPICmdRunner *fakebicloud.FakeCPICmdRunner
+			runner           CPICmdRunner
+		)
+
+		BeforeEach(func() {
+			fakeCPICmdRunner = fakebicloud.NewFakeCPICmdRunner()
+			runner = NewRecordingCPICmdRunner(fakeCPICmdRunner, out, timeService, logger)
+		})
+
+		It("records the call with its output and duration", func() {
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{{Result: "fake-vm-cid", Log: "fake-log"}}
+
+			cmdOutput, err := runner.Run(context, "create_vm", 1, "fake-agent-id", map[string]interface{}{"fake-key": "fake-value"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdOutput.Result).To(Equal("fake-vm-cid"))
+
+			calls, err := ReadCPICalls(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal([]CPICall{
+				{
+					Time:       startTime,
+					Method:     "create_vm",
+					Arguments:  []interface{}{"fake-agent-id", map[string]interface{}{"fake-key": "fake-value"}},
+					Context:    context,
+					ApiVersion: 1,
+					Output:     &CmdOutput{Result: "fake-vm-cid", Log: "fake-log"},
+				},
+			}))
+		})
+
+		It("records errors of the call", func() {
+			fakeCPICmdRunner.RunErrs = []error{errors.New("fake-run-error")}
+
+			_, err := runner.Run(context, "delete_vm", 1, "fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+
+			calls, err := ReadCPICalls(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(HaveLen(1))
+			Expect(calls[0].Error).To(Equal("fake-run-error"))
+			Expect(calls[0].Output).To(BeNil())
+		})
+
+		It("writes one line per call", func() {
+			_, err := runner.Run(context, "info", 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = runner.Run(context, "has_vm", 1, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(ContainSubstring(`"method":"info","arguments":[]`))
+		})
+	})
+
+	Describe("replay CPI cmd runner", func() {
+		var runner CPICmdRunner
+
+		BeforeEach(func() {
+			calls := []CPICall{
+				{Method: "create_vm", Arguments: []interface{}{"fake-agent-id"}, Output: &CmdOutput{Result: "fake-vm-cid"}},
+				{Method: "attach_disk", Arguments: []interface{}{"fake-vm-cid", "fake-disk-cid"}, Error: "fake-recorded-error"},
+			}
+			runner = NewReplayCPICmdRunner(calls, logger)
+		})
+
+		It("serves recorded calls in order", func() {
+			cmdOutput, err := runner.Run(context, "create_vm", 1, "other-agent-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdOutput.Result).To(Equal("fake-vm-cid"))
+
+			_, err = runner.Run(context, "attach_disk", 1, "fake-vm-cid", "fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-recorded-error"))
+		})
+
+		It("returns an error when calls are made for other methods", func() {
+			_, err := runner.Run(context, "delete_vm", 1, "fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Replaying CPI 'delete_vm' method: expected recorded call #1 to be for the same method but it is for 'create_vm'"))
+		})
+
+		It("returns an error once all calls are replayed", func() {
+			_, err := runner.Run(context, "create_vm", 1, "fake-agent-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = runner.Run(context, "attach_disk", 1, "fake-vm-cid", "fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+
+			_, err = runner.Run(context, "delete_vm", 1, "fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Replaying CPI 'delete_vm' method: all 2 recorded CPI calls were already replayed"))
+		})
+
+		It("replays sessions recorded by the recording CPI cmd runner", func() {
+			fakeCPICmdRunner := fakebicloud.NewFakeCPICmdRunner()
+			fakeCPICmdRunner.RunCmdOutputs = []CmdOutput{{Result: true}}
+			recorder := NewRecordingCPICmdRunner(fakeCPICmdRunner, out, timeService, logger)
+
+			_, err := recorder.Run(context, "has_vm", 1, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			calls, err := ReadCPICalls(out)
+			Expect(err).ToNot(HaveOccurred())
+
+			cmdOutput, err := NewReplayCPICmdRunner(calls, logger).Run(context, "has_vm", 1, "fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdOutput.Result).To(Equal(true))
+		})
+	})
+
+	Describe("ReadCPICalls", func() {
+		It("returns an error for invalid lines", func() {
+			_, err := ReadCPICalls(strings.NewReader("{\"method\":\"info\"}\nnot-json\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling recorded CPI call on line 2"))
+		})
+	})
+})
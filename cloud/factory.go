@@ -1,6 +1,7 @@
 package cloud
 
 import (
+	"code.cloudfoundry.org/clock"
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -12,21 +13,36 @@ type Factory interface {
 }
 
 type factory struct {
-	fs        boshsys.FileSystem
-	cmdRunner boshsys.CmdRunner
-	logger    boshlog.Logger
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	options     CPICmdRunnerOptions
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	// replayCmdRunner is shared by all clouds to replay calls in the recorded order
+	replayCmdRunner CPICmdRunner
 }
 
 func NewFactory(
 	fs boshsys.FileSystem,
 	cmdRunner boshsys.CmdRunner,
+	options CPICmdRunnerOptions,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) Factory {
-	return &factory{
-		fs:        fs,
-		cmdRunner: cmdRunner,
-		logger:    logger,
+	f := &factory{
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		options:     options,
+		timeService: timeService,
+		logger:      logger,
+	}
+
+	if options.Replaying {
+		f.replayCmdRunner = NewReplayCPICmdRunner(options.Replay, logger)
 	}
+
+	return f
 }
 
 func (f *factory) NewCloud(installation biinstall.Installation, directorID string, stemcellApiVersion int) (Cloud, error) {
@@ -43,6 +59,17 @@ func (f *factory) NewCloud(installation biinstall.Installation, directorID strin
 		return nil, bosherr.Errorf("Installed CPI job '%s' does not contain the required executable '%s'", cpiJob.Name, cmdPath)
 	}
 
-	cpiCmdRunner := NewCPICmdRunner(f.cmdRunner, cpi, f.logger)
+	var cpiCmdRunner CPICmdRunner
+
+	if f.replayCmdRunner != nil {
+		cpiCmdRunner = f.replayCmdRunner
+	} else {
+		cpiCmdRunner = NewCPICmdRunner(f.cmdRunner, cpi, f.options, f.timeService, f.logger)
+	}
+
+	if f.options.RecordTo != nil {
+		cpiCmdRunner = NewRecordingCPICmdRunner(cpiCmdRunner, f.options.RecordTo, f.timeService, f.logger)
+	}
+
 	return NewCloud(cpiCmdRunner, directorID, stemcellApiVersion, f.logger), nil
 }
@@ -14,6 +14,10 @@ type FakeCloud struct {
 	HasVMFound bool
 	HasVMErr   error
 
+	HasDiskInputs []HasDiskInput
+	HasDiskFound  map[string]bool
+	HasDiskErr    error
+
 	CreateVMInput CreateVMInput
 	CreateVMCID   string
 	CreateVMErr   error
@@ -46,6 +50,43 @@ type FakeCloud struct {
 	SetDiskMetadataMetadata cloud.DiskMetadata
 	SetDiskMetadataError    error
 
+	ResizeDiskInputs []ResizeDiskInput
+	ResizeDiskErr    error
+
+	UpdateDiskInputs []UpdateDiskInput
+	UpdateDiskCID    string
+	UpdateDiskErr    error
+
+	SnapshotDiskInputs      []SnapshotDiskInput
+	SnapshotDiskSnapshotCID string
+	SnapshotDiskErr         error
+
+	DeleteSnapshotInputs []DeleteSnapshotInput
+	DeleteSnapshotErr    error
+
+	GetDisksInput    GetDisksInput
+	GetDisksDiskCIDs []string
+	GetDisksErr      error
+
+	ListVMsInputs []cloud.VMMetadata
+	// ListVMsMetadata is the metadata of ListVMsCIDs, when it is set only matching filters return them
+	ListVMsMetadata cloud.VMMetadata
+	ListVMsCIDs     []string
+	ListVMsErr      error
+
+	ListDisksInputs []cloud.DiskMetadata
+	// ListDisksMetadata is the metadata of ListDisksCIDs, when it is set only matching filters return them
+	ListDisksMetadata cloud.DiskMetadata
+	ListDisksCIDs     []string
+	ListDisksErr      error
+
+	RebootVMInputs []RebootVMInput
+	RebootVMErr    error
+
+	CalculateVMCloudPropertiesInput  cloud.VMResources
+	CalculateVMCloudPropertiesResult biproperty.Map
+	CalculateVMCloudPropertiesErr    error
+
 	InfoResult cloud.CpiInfo
 	InfoError  error
 }
@@ -59,6 +100,10 @@ type HasVMInput struct {
 	VMCID string
 }
 
+type HasDiskInput struct {
+	DiskCID string
+}
+
 type CreateVMInput struct {
 	AgentID            string
 	StemcellCID        string
@@ -95,6 +140,34 @@ type DeleteStemcellInput struct {
 	StemcellCID string
 }
 
+type ResizeDiskInput struct {
+	DiskCID string
+	NewSize int
+}
+
+type UpdateDiskInput struct {
+	DiskCID         string
+	NewSize         int
+	CloudProperties biproperty.Map
+}
+
+type SnapshotDiskInput struct {
+	DiskCID  string
+	Metadata cloud.DiskMetadata
+}
+
+type DeleteSnapshotInput struct {
+	SnapshotCID string
+}
+
+type GetDisksInput struct {
+	VMCID string
+}
+
+type RebootVMInput struct {
+	VMCID string
+}
+
 func NewFakeCloud() *FakeCloud {
 	return &FakeCloud{
 		CreateStemcellInputs: []CreateStemcellInput{},
@@ -125,6 +198,13 @@ func (c *FakeCloud) HasVM(vmCID string) (bool, error) {
 	return c.HasVMFound, c.HasVMErr
 }
 
+func (c *FakeCloud) HasDisk(diskCID string) (bool, error) {
+	c.HasDiskInputs = append(c.HasDiskInputs, HasDiskInput{
+		DiskCID: diskCID,
+	})
+	return c.HasDiskFound[diskCID], c.HasDiskErr
+}
+
 func (c *FakeCloud) CreateVM(
 	agentID string,
 	stemcellCID string,
@@ -199,6 +279,82 @@ func (c *FakeCloud) DeleteDisk(diskCID string) error {
 	return c.DeleteDiskErr
 }
 
+func (c *FakeCloud) ResizeDisk(diskCID string, newSize int) error {
+	c.ResizeDiskInputs = append(c.ResizeDiskInputs, ResizeDiskInput{
+		DiskCID: diskCID,
+		NewSize: newSize,
+	})
+	return c.ResizeDiskErr
+}
+
+func (c *FakeCloud) UpdateDisk(diskCID string, newSize int, cloudProperties biproperty.Map) (string, error) {
+	c.UpdateDiskInputs = append(c.UpdateDiskInputs, UpdateDiskInput{
+		DiskCID:         diskCID,
+		NewSize:         newSize,
+		CloudProperties: cloudProperties,
+	})
+	return c.UpdateDiskCID, c.UpdateDiskErr
+}
+
+func (c *FakeCloud) SnapshotDisk(diskCID string, metadata cloud.DiskMetadata) (string, error) {
+	c.SnapshotDiskInputs = append(c.SnapshotDiskInputs, SnapshotDiskInput{
+		DiskCID:  diskCID,
+		Metadata: metadata,
+	})
+	return c.SnapshotDiskSnapshotCID, c.SnapshotDiskErr
+}
+
+func (c *FakeCloud) DeleteSnapshot(snapshotCID string) error {
+	c.DeleteSnapshotInputs = append(c.DeleteSnapshotInputs, DeleteSnapshotInput{
+		SnapshotCID: snapshotCID,
+	})
+	return c.DeleteSnapshotErr
+}
+
+func (c *FakeCloud) GetDisks(vmCID string) ([]string, error) {
+	c.GetDisksInput = GetDisksInput{
+		VMCID: vmCID,
+	}
+	return c.GetDisksDiskCIDs, c.GetDisksErr
+}
+
+func (c *FakeCloud) ListVMs(metadata cloud.VMMetadata) ([]string, error) {
+	c.ListVMsInputs = append(c.ListVMsInputs, metadata)
+	if c.ListVMsMetadata != nil && !metadataMatches(c.ListVMsMetadata, metadata) {
+		return []string{}, c.ListVMsErr
+	}
+	return c.ListVMsCIDs, c.ListVMsErr
+}
+
+func (c *FakeCloud) ListDisks(metadata cloud.DiskMetadata) ([]string, error) {
+	c.ListDisksInputs = append(c.ListDisksInputs, metadata)
+	if c.ListDisksMetadata != nil && !metadataMatches(c.ListDisksMetadata, metadata) {
+		return []string{}, c.ListDisksErr
+	}
+	return c.ListDisksCIDs, c.ListDisksErr
+}
+
+func metadataMatches(metadata, filter map[string]string) bool {
+	for key, value := range filter {
+		if metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *FakeCloud) RebootVM(vmCID string) error {
+	c.RebootVMInputs = append(c.RebootVMInputs, RebootVMInput{
+		VMCID: vmCID,
+	})
+	return c.RebootVMErr
+}
+
+func (c *FakeCloud) CalculateVMCloudProperties(vmResources cloud.VMResources) (biproperty.Map, error) {
+	c.CalculateVMCloudPropertiesInput = vmResources
+	return c.CalculateVMCloudPropertiesResult, c.CalculateVMCloudPropertiesErr
+}
+
 func (c *FakeCloud) Info() (cpiInfo cloud.CpiInfo, err error) {
 	return c.InfoResult, c.InfoError
 }
@@ -48,6 +48,19 @@ func (mr *MockCloudMockRecorder) AttachDisk(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachDisk", reflect.TypeOf((*MockCloud)(nil).AttachDisk), arg0, arg1)
 }
 
+// CalculateVMCloudProperties mocks base method
+func (m *MockCloud) CalculateVMCloudProperties(arg0 cloud.VMResources) (property.Map, error) {
+	ret := m.ctrl.Call(m, "CalculateVMCloudProperties", arg0)
+	ret0, _ := ret[0].(property.Map)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CalculateVMCloudProperties indicates an expected call of CalculateVMCloudProperties
+func (mr *MockCloudMockRecorder) CalculateVMCloudProperties(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateVMCloudProperties", reflect.TypeOf((*MockCloud)(nil).CalculateVMCloudProperties), arg0)
+}
+
 // CreateDisk mocks base method
 func (m *MockCloud) CreateDisk(arg0 int, arg1 property.Map, arg2 string) (string, error) {
 	ret := m.ctrl.Call(m, "CreateDisk", arg0, arg1, arg2)
@@ -99,6 +112,18 @@ func (mr *MockCloudMockRecorder) DeleteDisk(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDisk", reflect.TypeOf((*MockCloud)(nil).DeleteDisk), arg0)
 }
 
+// DeleteSnapshot mocks base method
+func (m *MockCloud) DeleteSnapshot(arg0 string) error {
+	ret := m.ctrl.Call(m, "DeleteSnapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot
+func (mr *MockCloudMockRecorder) DeleteSnapshot(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockCloud)(nil).DeleteSnapshot), arg0)
+}
+
 // DeleteStemcell mocks base method
 func (m *MockCloud) DeleteStemcell(arg0 string) error {
 	ret := m.ctrl.Call(m, "DeleteStemcell", arg0)
@@ -135,6 +160,32 @@ func (mr *MockCloudMockRecorder) DetachDisk(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachDisk", reflect.TypeOf((*MockCloud)(nil).DetachDisk), arg0, arg1)
 }
 
+// GetDisks mocks base method
+func (m *MockCloud) GetDisks(arg0 string) ([]string, error) {
+	ret := m.ctrl.Call(m, "GetDisks", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDisks indicates an expected call of GetDisks
+func (mr *MockCloudMockRecorder) GetDisks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDisks", reflect.TypeOf((*MockCloud)(nil).GetDisks), arg0)
+}
+
+// HasDisk mocks base method
+func (m *MockCloud) HasDisk(arg0 string) (bool, error) {
+	ret := m.ctrl.Call(m, "HasDisk", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasDisk indicates an expected call of HasDisk
+func (mr *MockCloudMockRecorder) HasDisk(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDisk", reflect.TypeOf((*MockCloud)(nil).HasDisk), arg0)
+}
+
 // HasVM mocks base method
 func (m *MockCloud) HasVM(arg0 string) (bool, error) {
 	ret := m.ctrl.Call(m, "HasVM", arg0)
@@ -161,6 +212,56 @@ func (mr *MockCloudMockRecorder) Info() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockCloud)(nil).Info))
 }
 
+// ListDisks mocks base method
+func (m *MockCloud) ListDisks(arg0 cloud.DiskMetadata) ([]string, error) {
+	ret := m.ctrl.Call(m, "ListDisks", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisks indicates an expected call of ListDisks
+func (mr *MockCloudMockRecorder) ListDisks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisks", reflect.TypeOf((*MockCloud)(nil).ListDisks), arg0)
+}
+
+// ListVMs mocks base method
+func (m *MockCloud) ListVMs(arg0 cloud.VMMetadata) ([]string, error) {
+	ret := m.ctrl.Call(m, "ListVMs", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVMs indicates an expected call of ListVMs
+func (mr *MockCloudMockRecorder) ListVMs(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVMs", reflect.TypeOf((*MockCloud)(nil).ListVMs), arg0)
+}
+
+// RebootVM mocks base method
+func (m *MockCloud) RebootVM(arg0 string) error {
+	ret := m.ctrl.Call(m, "RebootVM", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebootVM indicates an expected call of RebootVM
+func (mr *MockCloudMockRecorder) RebootVM(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebootVM", reflect.TypeOf((*MockCloud)(nil).RebootVM), arg0)
+}
+
+// ResizeDisk mocks base method
+func (m *MockCloud) ResizeDisk(arg0 string, arg1 int) error {
+	ret := m.ctrl.Call(m, "ResizeDisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeDisk indicates an expected call of ResizeDisk
+func (mr *MockCloudMockRecorder) ResizeDisk(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockCloud)(nil).ResizeDisk), arg0, arg1)
+}
+
 // SetDiskMetadata mocks base method
 func (m *MockCloud) SetDiskMetadata(arg0 string, arg1 cloud.DiskMetadata) error {
 	ret := m.ctrl.Call(m, "SetDiskMetadata", arg0, arg1)
@@ -185,6 +286,19 @@ func (mr *MockCloudMockRecorder) SetVMMetadata(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVMMetadata", reflect.TypeOf((*MockCloud)(nil).SetVMMetadata), arg0, arg1)
 }
 
+// SnapshotDisk mocks base method
+func (m *MockCloud) SnapshotDisk(arg0 string, arg1 cloud.DiskMetadata) (string, error) {
+	ret := m.ctrl.Call(m, "SnapshotDisk", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotDisk indicates an expected call of SnapshotDisk
+func (mr *MockCloudMockRecorder) SnapshotDisk(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotDisk", reflect.TypeOf((*MockCloud)(nil).SnapshotDisk), arg0, arg1)
+}
+
 // String mocks base method
 func (m *MockCloud) String() string {
 	ret := m.ctrl.Call(m, "String")
@@ -197,6 +311,19 @@ func (mr *MockCloudMockRecorder) String() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "String", reflect.TypeOf((*MockCloud)(nil).String))
 }
 
+// UpdateDisk mocks base method
+func (m *MockCloud) UpdateDisk(arg0 string, arg1 int, arg2 property.Map) (string, error) {
+	ret := m.ctrl.Call(m, "UpdateDisk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDisk indicates an expected call of UpdateDisk
+func (mr *MockCloudMockRecorder) UpdateDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDisk", reflect.TypeOf((*MockCloud)(nil).UpdateDisk), arg0, arg1, arg2)
+}
+
 // MockFactory is a mock of Factory interface
 type MockFactory struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CleanUpEnvCmd struct {
+	ui            boshui.UI
+	uuidGenerator boshuuid.Generator
+	envProvider   func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+}
+
+func NewCleanUpEnvCmd(ui boshui.UI, uuidGenerator boshuuid.Generator, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector) *CleanUpEnvCmd {
+	return &CleanUpEnvCmd{ui: ui, uuidGenerator: uuidGenerator, envProvider: envProvider}
+}
+
+func (c *CleanUpEnvCmd) Run(stage boshui.Stage, opts CleanUpEnvOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	deploymentName, err := inspector.DeploymentName()
+	if err != nil {
+		return err
+	}
+
+	// the state stays locked so that resources being created by a concurrent create-env are not deleted
+	return inspector.WithLockedState(func() error {
+		return inspector.WithCloud(stage, func(cloud bicloud.Cloud) error {
+			return c.cleanUp(stage, inspector, cloud, deploymentName)
+		})
+	})
+}
+
+func (c *CleanUpEnvCmd) cleanUp(stage boshui.Stage, inspector EnvInspector, cloud bicloud.Cloud, deploymentName string) error {
+	state, err := inspector.State()
+	if err != nil {
+		return err
+	}
+
+	// VMs and disks created before they were tagged with the director ID are not listed
+	vmCIDs, diskCIDs, err := c.listResources(cloud, state.DirectorID)
+	if err != nil {
+		return err
+	}
+
+	referencedVMCIDs := map[string]bool{}
+	for _, vm := range envVMs(state) {
+		referencedVMCIDs[vm.cid] = true
+	}
+
+	// orphaned disks are referenced until their retention expires and create-env deletes them
+	referencedDiskCIDs := map[string]bool{}
+	for _, disk := range state.Disks {
+		referencedDiskCIDs[disk.CID] = true
+	}
+
+	var unreferencedVMCIDs, unreferencedDiskCIDs []string
+
+	for _, cid := range vmCIDs {
+		if !referencedVMCIDs[cid] {
+			unreferencedVMCIDs = append(unreferencedVMCIDs, cid)
+		}
+	}
+
+	for _, cid := range diskCIDs {
+		if !referencedDiskCIDs[cid] {
+			unreferencedDiskCIDs = append(unreferencedDiskCIDs, cid)
+		}
+	}
+
+	if len(unreferencedVMCIDs) == 0 && len(unreferencedDiskCIDs) == 0 {
+		c.ui.PrintLinef("No VMs or disks of deployment '%s' are missing from the deployment state", deploymentName)
+		return nil
+	}
+
+	table := boshtbl.Table{
+		Content: "unreferenced resources",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("CID"),
+		},
+	}
+
+	for _, cid := range unreferencedVMCIDs {
+		table.Rows = append(table.Rows, []boshtbl.Value{boshtbl.NewValueString("VM"), boshtbl.NewValueString(cid)})
+	}
+
+	for _, cid := range unreferencedDiskCIDs {
+		table.Rows = append(table.Rows, []boshtbl.Value{boshtbl.NewValueString("disk"), boshtbl.NewValueString(cid)})
+	}
+
+	c.ui.PrintTable(table)
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	// VMs are deleted first so that disks attached to them can be deleted
+	for _, cid := range unreferencedVMCIDs {
+		vmCID := cid
+		err = stage.Perform(fmt.Sprintf("Deleting VM '%s'", vmCID), func() error {
+			return cloud.DeleteVM(vmCID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, cid := range unreferencedDiskCIDs {
+		diskCID := cid
+		err = stage.Perform(fmt.Sprintf("Deleting disk '%s'", diskCID), func() error {
+			return cloud.DeleteDisk(diskCID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listResources lists the VMs and disks tagged with the director ID of the environment.
+// A CPI that ignores the metadata filter would list resources of other environments,
+// so the CPI is first asked for resources of an unknown director ID, which must not exist.
+func (c *CleanUpEnvCmd) listResources(cloud bicloud.Cloud, directorID string) ([]string, []string, error) {
+	if directorID == "" {
+		return nil, nil, bosherr.Error("Deployment state does not have a director ID to find the VMs and disks of the environment by")
+	}
+
+	unknownDirectorID, err := c.uuidGenerator.Generate()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Generating director ID")
+	}
+
+	unknownVMCIDs, unknownDiskCIDs, err := listTaggedResources(cloud, unknownDirectorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(unknownVMCIDs) > 0 || len(unknownDiskCIDs) > 0 {
+		return nil, nil, bosherr.Error(
+			"Refusing to clean up the environment: the CPI lists VMs or disks that do not have the requested metadata, so resources of other environments could be deleted")
+	}
+
+	return listTaggedResources(cloud, directorID)
+}
+
+func listTaggedResources(cloud bicloud.Cloud, directorID string) ([]string, []string, error) {
+	vmCIDs, err := cloud.ListVMs(bicloud.VMMetadata{bivm.DirectorIDKey: directorID})
+	if err != nil {
+		return nil, nil, listResourcesError(err, "VMs")
+	}
+
+	diskCIDs, err := cloud.ListDisks(bicloud.DiskMetadata{bivm.DirectorIDKey: directorID})
+	if err != nil {
+		return nil, nil, listResourcesError(err, "disks")
+	}
+
+	return vmCIDs, diskCIDs, nil
+}
+
+func listResourcesError(err error, resources string) error {
+	if cloudErr, ok := err.(bicloud.Error); ok && cloudErr.Type() == bicloud.NotImplementedError {
+		return bosherr.WrapError(err,
+			"Cleaning up the environment requires a CPI that implements 'list_vms' and 'list_disks'")
+	}
+
+	return bosherr.WrapErrorf(err, "Listing %s of the environment", resources)
+}
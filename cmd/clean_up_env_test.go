@@ -0,0 +1,207 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("CleanUpEnvCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		fakeCloud     *fakebicloud.FakeCloud
+		ui            *fakeui.FakeUI
+		fakeStage     *fakeui.FakeStage
+		command       *CleanUpEnvCmd
+		opts          CleanUpEnvOpts
+		state         biconfig.DeploymentState
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		fakeCloud = fakebicloud.NewFakeCloud()
+		ui = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			return mockInspector
+		}
+
+		command = NewCleanUpEnvCmd(ui, &fakeuuid.FakeGenerator{GeneratedUUID: "fake-unknown-director-id"}, envProvider)
+
+		opts = CleanUpEnvOpts{
+			Args: CleanUpEnvArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+		}
+
+		mockInspector.EXPECT().DeploymentName().Return("fake-deployment", nil)
+
+		locked := false
+		mockInspector.EXPECT().WithLockedState(gomock.Any()).DoAndReturn(func(fn func() error) error {
+			locked = true
+			defer func() { locked = false }()
+			return fn()
+		})
+
+		mockInspector.EXPECT().WithCloud(fakeStage, gomock.Any()).DoAndReturn(
+			func(_ interface{}, fn func(bicloud.Cloud) error) error {
+				Expect(locked).To(BeTrue())
+				return fn(fakeCloud)
+			},
+		)
+
+		state = biconfig.DeploymentState{
+			DirectorID:   "fake-director-id",
+			CurrentVMCID: "fake-vm-cid",
+			Instances: []biconfig.InstanceRecord{
+				{Name: "fake-job", Index: 1, VMCID: "fake-instance-vm-cid"},
+			},
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+			},
+		}
+		mockInspector.EXPECT().State().DoAndReturn(func() (biconfig.DeploymentState, error) {
+			return state, nil
+		}).AnyTimes()
+
+		fakeCloud.ListVMsMetadata = bicloud.VMMetadata{"director_id": "fake-director-id", "deployment": "fake-deployment"}
+		fakeCloud.ListDisksMetadata = bicloud.DiskMetadata{"director_id": "fake-director-id", "deployment": "fake-deployment"}
+		fakeCloud.ListVMsCIDs = []string{"fake-vm-cid", "fake-instance-vm-cid", "fake-leaked-vm-cid"}
+		fakeCloud.ListDisksCIDs = []string{"fake-disk-cid", "fake-leaked-disk-cid"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("lists resources of the environment by the director id of its state", func() {
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeCloud.ListVMsInputs).To(Equal([]bicloud.VMMetadata{
+			{"director_id": "fake-unknown-director-id"},
+			{"director_id": "fake-director-id"},
+		}))
+		Expect(fakeCloud.ListDisksInputs).To(Equal([]bicloud.DiskMetadata{
+			{"director_id": "fake-unknown-director-id"},
+			{"director_id": "fake-director-id"},
+		}))
+	})
+
+	It("refuses to delete anything when the CPI does not filter by metadata", func() {
+		fakeCloud.ListVMsMetadata = nil
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Refusing to clean up the environment"))
+
+		Expect(ui.AskedConfirmationCalled).To(BeFalse())
+		Expect(fakeStage.PerformCalls).To(BeEmpty())
+	})
+
+	It("refuses to delete anything when the state does not have a director id", func() {
+		state.DirectorID = ""
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Deployment state does not have a director ID"))
+
+		Expect(fakeCloud.ListVMsInputs).To(BeEmpty())
+	})
+
+	It("deletes resources missing from the state after confirmation", func() {
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "unreferenced resources",
+
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Type"),
+				boshtbl.NewHeader("CID"),
+			},
+
+			Rows: [][]boshtbl.Value{
+				{boshtbl.NewValueString("VM"), boshtbl.NewValueString("fake-leaked-vm-cid")},
+				{boshtbl.NewValueString("disk"), boshtbl.NewValueString("fake-leaked-disk-cid")},
+			},
+		}))
+
+		Expect(ui.AskedConfirmationCalled).To(BeTrue())
+
+		Expect(fakeCloud.DeleteVMInput).To(Equal(fakebicloud.DeleteVMInput{VMCID: "fake-leaked-vm-cid"}))
+		Expect(fakeCloud.DeleteDiskInputs).To(Equal([]fakebicloud.DeleteDiskInput{{DiskCID: "fake-leaked-disk-cid"}}))
+
+		Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+			{Name: "Deleting VM 'fake-leaked-vm-cid'"},
+			{Name: "Deleting disk 'fake-leaked-disk-cid'"},
+		}))
+	})
+
+	It("does not delete anything when confirmation is declined", func() {
+		ui.AskedConfirmationErr = errors.New("fake-confirmation-err")
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-confirmation-err"))
+
+		Expect(fakeCloud.DeleteDiskInputs).To(BeEmpty())
+		Expect(fakeStage.PerformCalls).To(BeEmpty())
+	})
+
+	It("does not ask for confirmation when all resources are in the state", func() {
+		fakeCloud.ListVMsCIDs = []string{"fake-vm-cid"}
+		fakeCloud.ListDisksCIDs = []string{"fake-disk-cid"}
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.AskedConfirmationCalled).To(BeFalse())
+		Expect(ui.Said).To(ContainElement("No VMs or disks of deployment 'fake-deployment' are missing from the deployment state"))
+	})
+
+	It("returns an error when listing VMs fails", func() {
+		fakeCloud.ListVMsErr = errors.New("fake-list-vms-err")
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Listing VMs of the environment"))
+		Expect(ui.AskedConfirmationCalled).To(BeFalse())
+	})
+
+	It("explains that the CPI has to support listing resources when it does not", func() {
+		fakeCloud.ListDisksErr = bicloud.NewCPIError("list_disks", bicloud.CmdError{
+			Type:    "Bosh::Clouds::NotImplemented",
+			Message: "list_disks is not implemented",
+		})
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Cleaning up the environment requires a CPI that implements 'list_vms' and 'list_disks'"))
+		Expect(ui.AskedConfirmationCalled).To(BeFalse())
+	})
+
+	It("returns an error when deleting a VM fails", func() {
+		fakeCloud.DeleteVMErr = errors.New("fake-delete-vm-err")
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-delete-vm-err"))
+		Expect(fakeCloud.DeleteDiskInputs).To(BeEmpty())
+	})
+})
@@ -2,23 +2,30 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/cppforlife/go-patch/patch"
 
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	"github.com/cloudfoundry/bosh-cli/crypto"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 
 	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 )
 
@@ -62,21 +69,133 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewEnvironmentsCmd(c.config(), deps.UI).Run()
 
 	case *CreateEnvOpts:
+		compiledPackageCache, err := c.compiledPackageCache(opts.CompiledPackageCache)
+		if err != nil {
+			return err
+		}
+
+		cpiOptions, err := c.cpiCmdRunnerOptions(opts.CPIFlags)
+		if err != nil {
+			return err
+		}
+
+		if opts.ReplaceNamedDisks && opts.OrphanedDiskRetention <= 0 {
+			return bosherr.Error("Replacing named disks requires keeping the replaced disks with --orphaned-disk-retention")
+		}
+
+		diskDeployerOptions := bivm.DiskDeployerOptions{
+			RecreatePersistentDisks: opts.RecreatePersistentDisks,
+			SnapshotBeforeMigration: opts.SnapshotDisks,
+			OrphanedDiskRetention:   opts.OrphanedDiskRetention,
+			ReplaceNamedDisks:       opts.ReplaceNamedDisks,
+		}
+
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.RecreatePersistentDisks).Preparer()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, diskDeployerOptions, c.BoshOpts.Parallel, compiledPackageCache, cpiOptions).Preparer()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
+		stage := c.stage()
 		return NewCreateEnvCmd(deps.UI, envProvider).Run(stage, *opts)
 
 	case *DeleteEnvOpts:
+		cpiOptions, err := c.cpiCmdRunnerOptions(opts.CPIFlags)
+		if err != nil {
+			return err
+		}
+
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).Deleter()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, cpiOptions).Deleter()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
+		stage := c.stage()
 		return NewDeleteEnvCmd(deps.UI, envProvider).Run(stage, *opts)
 
+	case *ValidateEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{}).Preparer()
+		}
+
+		stage := c.stage()
+		return NewValidateEnvCmd(deps.UI, envProvider).Run(stage, *opts)
+
+	case *EnvStateHistoryOpts:
+		envFactory := NewEnvFactory(deps, opts.Args.Manifest, opts.StatePath, nil, nil, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{})
+		return NewEnvStateHistoryCmd(deps.UI, envFactory.StateHistory()).Run()
+
+	case *EnvStateRestoreOpts:
+		envFactory := NewEnvFactory(deps, opts.Args.Manifest, opts.StatePath, nil, nil, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{})
+		return NewEnvStateRestoreCmd(deps.UI, envFactory.StateService(), envFactory.StateHistory(), deps.Logger).Run(*opts)
+
+	case *EnvVMsOpts:
+		cpiOptions, err := c.cpiCmdRunnerOptions(opts.CPIFlags)
+		if err != nil {
+			return err
+		}
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, cpiOptions).Inspector()
+		}
+
+		stage := c.stage()
+		return NewEnvVMsCmd(deps.UI, envProvider).Run(stage, *opts)
+
+	case *EnvDisksOpts:
+		cpiOptions, err := c.cpiCmdRunnerOptions(opts.CPIFlags)
+		if err != nil {
+			return err
+		}
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, cpiOptions).Inspector()
+		}
+
+		stage := c.stage()
+		return NewEnvDisksCmd(deps.UI, envProvider).Run(stage, *opts)
+
+	case *CleanUpEnvOpts:
+		cpiOptions, err := c.cpiCmdRunnerOptions(opts.CPIFlags)
+		if err != nil {
+			return err
+		}
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, cpiOptions).Inspector()
+		}
+
+		stage := c.stage()
+		return NewCleanUpEnvCmd(deps.UI, deps.UUIDGen, envProvider).Run(stage, *opts)
+
+	case *EnvStatusOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{}).Inspector()
+		}
+
+		return NewEnvStatusCmd(deps.UI, envProvider).Run(*opts)
+
+	case *StopEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{}).Inspector()
+		}
+
+		stage := c.stage()
+		return NewStopEnvCmd(envProvider, deps.Time, deps.Logger).Run(stage, *opts)
+
+	case *StartEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{}).Inspector()
+		}
+
+		stage := c.stage()
+		return NewStartEnvCmd(envProvider, deps.Time, deps.Logger).Run(stage, *opts)
+
+	case *RestartEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, bivm.DiskDeployerOptions{}, c.BoshOpts.Parallel, nil, bicloud.CPICmdRunnerOptions{}).Inspector()
+		}
+
+		stage := c.stage()
+		return NewRestartEnvCmd(envProvider, deps.Time, deps.Logger).Run(stage, *opts)
+
 	case *AliasEnvOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
 			return NewSessionFromOpts(c.BoshOpts, config, deps.UI, true, false, deps.FS, deps.Logger)
@@ -113,7 +232,12 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *TaskOpts:
 		eventsTaskReporter := boshuit.NewReporter(deps.UI, true)
 		plainTaskReporter := boshuit.NewReporter(deps.UI, false)
-		return NewTaskCmd(eventsTaskReporter, plainTaskReporter, c.director()).Run(*opts)
+
+		if len(opts.FromFile) > 0 {
+			return NewTaskReplayCmd(eventsTaskReporter, plainTaskReporter, deps.FS).Run(*opts)
+		}
+
+		return NewTaskCmd(eventsTaskReporter, plainTaskReporter, c.director(), deps.FS).Run(*opts)
 
 	case *TasksOpts:
 		return NewTasksCmd(deps.UI, c.director()).Run(*opts)
@@ -451,6 +575,84 @@ func (c Cmd) Execute() (cmdErr error) {
 		return fmt.Errorf("Unhandled command: %#v", c.Opts)
 	}
 }
+
+func (c Cmd) stage() boshui.Stage {
+	// Events are written to stderr so that they do not mix
+	// with regular output or the document printed with --json
+	if c.BoshOpts.JSONEventsOpt {
+		return boshui.NewJSONEventsStage(os.Stderr, c.deps.Time, c.deps.Logger)
+	}
+
+	return boshui.NewStage(c.deps.UI, c.deps.Time, c.deps.Logger)
+}
+
+func (c Cmd) compiledPackageCache(location string) (bistatepkg.CompiledPackageCache, error) {
+	if len(location) == 0 {
+		return nil, nil
+	}
+
+	store, err := bistatepkg.NewCompiledPackageCacheStore(location, c.deps.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	return bistatepkg.NewCompiledPackageCache(store, c.deps.FS, c.deps.Logger), nil
+}
+
+func (c Cmd) cpiCmdRunnerOptions(flags CPIFlags) (bicloud.CPICmdRunnerOptions, error) {
+	options := flags.AsCPICmdRunnerOptions(c.cancelOnInterrupt())
+
+	if len(flags.ReplayCPI) > 0 {
+		file, err := c.deps.FS.OpenFile(flags.ReplayCPI, os.O_RDONLY, 0)
+		if err != nil {
+			return options, bosherr.WrapErrorf(err, "Opening recorded CPI calls '%s'", flags.ReplayCPI)
+		}
+
+		defer file.Close()
+
+		options.Replay, err = bicloud.ReadCPICalls(file)
+		if err != nil {
+			return options, bosherr.WrapErrorf(err, "Reading recorded CPI calls '%s'", flags.ReplayCPI)
+		}
+
+		if len(options.Replay) == 0 {
+			return options, bosherr.Errorf("Recorded CPI calls '%s' do not contain any calls", flags.ReplayCPI)
+		}
+	}
+
+	if len(flags.RecordCPI) > 0 {
+		// the file stays open until the CLI exits, calls are written as they finish.
+		// A previous recording is replaced, so that a replay only sees the calls of one run.
+		file, err := c.deps.FS.OpenFile(flags.RecordCPI, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return options, bosherr.WrapErrorf(err, "Opening CPI recording '%s'", flags.RecordCPI)
+		}
+
+		options.RecordTo = file
+	}
+
+	return options, nil
+}
+
+// cancelOnInterrupt cancels running CPI calls on the first interrupt, so that
+// the environment state is saved before exiting. Another interrupt exits right away.
+func (c Cmd) cancelOnInterrupt() <-chan struct{} {
+	cancelCh := make(chan struct{})
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signalCh
+		signal.Stop(signalCh)
+
+		c.deps.UI.ErrorLinef("\nReceived %s, cancelling running CPI calls...", sig)
+		close(cancelCh)
+	}()
+
+	return cancelCh
+}
+
 func (c Cmd) configureUI() {
 	c.deps.UI.EnableTTY(c.BoshOpts.TTYOpt)
 
@@ -3,6 +3,7 @@ package cmdfakes
 
 import (
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry/bosh-cli/cmd"
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
@@ -54,6 +55,24 @@ type FakeSessionContext struct {
 	deploymentReturnsOnCall map[int]struct {
 		result1 string
 	}
+	TaskPollIntervalStub        func() time.Duration
+	taskPollIntervalMutex       sync.RWMutex
+	taskPollIntervalArgsForCall []struct{}
+	taskPollIntervalReturns     struct {
+		result1 time.Duration
+	}
+	taskPollIntervalReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
+	TaskTimeoutStub        func() time.Duration
+	taskTimeoutMutex       sync.RWMutex
+	taskTimeoutArgsForCall []struct{}
+	taskTimeoutReturns     struct {
+		result1 time.Duration
+	}
+	taskTimeoutReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -258,6 +277,86 @@ func (fake *FakeSessionContext) DeploymentReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeSessionContext) TaskPollInterval() time.Duration {
+	fake.taskPollIntervalMutex.Lock()
+	ret, specificReturn := fake.taskPollIntervalReturnsOnCall[len(fake.taskPollIntervalArgsForCall)]
+	fake.taskPollIntervalArgsForCall = append(fake.taskPollIntervalArgsForCall, struct{}{})
+	fake.recordInvocation("TaskPollInterval", []interface{}{})
+	fake.taskPollIntervalMutex.Unlock()
+	if fake.TaskPollIntervalStub != nil {
+		return fake.TaskPollIntervalStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.taskPollIntervalReturns.result1
+}
+
+func (fake *FakeSessionContext) TaskPollIntervalCallCount() int {
+	fake.taskPollIntervalMutex.RLock()
+	defer fake.taskPollIntervalMutex.RUnlock()
+	return len(fake.taskPollIntervalArgsForCall)
+}
+
+func (fake *FakeSessionContext) TaskPollIntervalReturns(result1 time.Duration) {
+	fake.TaskPollIntervalStub = nil
+	fake.taskPollIntervalReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeSessionContext) TaskPollIntervalReturnsOnCall(i int, result1 time.Duration) {
+	fake.TaskPollIntervalStub = nil
+	if fake.taskPollIntervalReturnsOnCall == nil {
+		fake.taskPollIntervalReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.taskPollIntervalReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeSessionContext) TaskTimeout() time.Duration {
+	fake.taskTimeoutMutex.Lock()
+	ret, specificReturn := fake.taskTimeoutReturnsOnCall[len(fake.taskTimeoutArgsForCall)]
+	fake.taskTimeoutArgsForCall = append(fake.taskTimeoutArgsForCall, struct{}{})
+	fake.recordInvocation("TaskTimeout", []interface{}{})
+	fake.taskTimeoutMutex.Unlock()
+	if fake.TaskTimeoutStub != nil {
+		return fake.TaskTimeoutStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.taskTimeoutReturns.result1
+}
+
+func (fake *FakeSessionContext) TaskTimeoutCallCount() int {
+	fake.taskTimeoutMutex.RLock()
+	defer fake.taskTimeoutMutex.RUnlock()
+	return len(fake.taskTimeoutArgsForCall)
+}
+
+func (fake *FakeSessionContext) TaskTimeoutReturns(result1 time.Duration) {
+	fake.TaskTimeoutStub = nil
+	fake.taskTimeoutReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeSessionContext) TaskTimeoutReturnsOnCall(i int, result1 time.Duration) {
+	fake.TaskTimeoutStub = nil
+	if fake.taskTimeoutReturnsOnCall == nil {
+		fake.taskTimeoutReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.taskTimeoutReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
 func (fake *FakeSessionContext) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -271,6 +370,10 @@ func (fake *FakeSessionContext) Invocations() map[string][][]interface{} {
 	defer fake.credentialsMutex.RUnlock()
 	fake.deploymentMutex.RLock()
 	defer fake.deploymentMutex.RUnlock()
+	fake.taskPollIntervalMutex.RLock()
+	defer fake.taskPollIntervalMutex.RUnlock()
+	fake.taskTimeoutMutex.RLock()
+	defer fake.taskTimeoutMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"time"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+// cpiRetryDelay is the pause between attempts of CPI calls failing with retryable errors
+const cpiRetryDelay = 5 * time.Second
+
+// Shared
+type CPIFlags struct {
+	CPITimeout        time.Duration         `long:"cpi-timeout" value-name:"DURATION" description:"Abort CPI calls taking longer than the duration (e.g. 30m)"`
+	CPIMethodTimeouts []CPIMethodTimeoutArg `long:"cpi-method-timeout" value-name:"METHOD=DURATION" description:"Abort calls of the CPI method taking longer than the duration (e.g. create_vm=1h)"`
+	CPIRetries        int                   `long:"cpi-retries" value-name:"NUMBER" description:"Retry CPI calls failing with errors the CPI marks as retryable" default:"3"`
+	RecordCPI         string                `long:"record-cpi" value-name:"PATH" description:"Record CPI calls and responses to a JSON lines file, replacing its contents"`
+	ReplayCPI         string                `long:"replay-cpi" value-name:"PATH" description:"Respond to CPI calls from a file recorded with --record-cpi instead of running the CPI (agents on created VMs must still be reachable)"`
+}
+
+func (f CPIFlags) AsCPICmdRunnerOptions(cancelCh <-chan struct{}) bicloud.CPICmdRunnerOptions {
+	methodTimeouts := map[string]time.Duration{}
+
+	for _, methodTimeout := range f.CPIMethodTimeouts {
+		methodTimeouts[methodTimeout.Method] = methodTimeout.Timeout
+	}
+
+	return bicloud.CPICmdRunnerOptions{
+		Timeout:        f.CPITimeout,
+		MethodTimeouts: methodTimeouts,
+		Retries:        f.CPIRetries,
+		RetryDelay:     cpiRetryDelay,
+		Cancel:         cancelCh,
+		Replaying:      len(f.ReplayCPI) > 0,
+	}
+}
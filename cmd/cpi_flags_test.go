@@ -0,0 +1,51 @@
+package cmd_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("CPIFlags", func() {
+	Describe("AsCPICmdRunnerOptions", func() {
+		It("returns timeouts, retries and the cancel channel", func() {
+			flags := CPIFlags{
+				CPITimeout: 30 * time.Minute,
+				CPIMethodTimeouts: []CPIMethodTimeoutArg{
+					{Method: "create_vm", Timeout: time.Hour},
+					{Method: "attach_disk", Timeout: 10 * time.Minute},
+				},
+				CPIRetries: 2,
+			}
+
+			cancelCh := make(chan struct{})
+
+			options := flags.AsCPICmdRunnerOptions(cancelCh)
+			Expect(options.Timeout).To(Equal(30 * time.Minute))
+			Expect(options.MethodTimeouts).To(Equal(map[string]time.Duration{
+				"create_vm":   time.Hour,
+				"attach_disk": 10 * time.Minute,
+			}))
+			Expect(options.Retries).To(Equal(2))
+			Expect(options.RetryDelay).To(Equal(5 * time.Second))
+			Expect(options.Cancel).To(Equal((<-chan struct{})(cancelCh)))
+		})
+
+		It("replays CPI calls when a recording is given", func() {
+			options := CPIFlags{ReplayCPI: "/recording.jsonl"}.AsCPICmdRunnerOptions(nil)
+			Expect(options.Replaying).To(BeTrue())
+		})
+
+		It("does not limit CPI calls by default", func() {
+			options := CPIFlags{}.AsCPICmdRunnerOptions(nil)
+			Expect(options).To(Equal(bicloud.CPICmdRunnerOptions{
+				MethodTimeouts: map[string]time.Duration{},
+				RetryDelay:     5 * time.Second,
+			}))
+		})
+	})
+})
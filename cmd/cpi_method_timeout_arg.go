@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type CPIMethodTimeoutArg struct {
+	Method  string
+	Timeout time.Duration
+}
+
+func (a *CPIMethodTimeoutArg) UnmarshalFlag(data string) error {
+	pieces := strings.SplitN(data, "=", 2)
+	if len(pieces) != 2 || len(pieces[0]) == 0 {
+		return bosherr.Errorf("Expected CPI method timeout '%s' to be in format 'method=duration'", data)
+	}
+
+	timeout, err := time.ParseDuration(pieces[1])
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing timeout of CPI method '%s'", pieces[0])
+	}
+
+	*a = CPIMethodTimeoutArg{Method: pieces[0], Timeout: timeout}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("CPIMethodTimeoutArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			arg *CPIMethodTimeoutArg
+		)
+
+		BeforeEach(func() {
+			arg = &CPIMethodTimeoutArg{}
+		})
+
+		It("sets method and timeout", func() {
+			err := arg.UnmarshalFlag("create_vm=1h30m")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*arg).To(Equal(CPIMethodTimeoutArg{Method: "create_vm", Timeout: 90 * time.Minute}))
+		})
+
+		It("returns error if the method is missing", func() {
+			err := arg.UnmarshalFlag("=1h")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected CPI method timeout '=1h' to be in format 'method=duration'"))
+		})
+
+		It("returns error if the timeout is missing", func() {
+			err := arg.UnmarshalFlag("create_vm")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected CPI method timeout 'create_vm' to be in format 'method=duration'"))
+		})
+
+		It("returns error if the timeout is not a duration", func() {
+			err := arg.UnmarshalFlag("create_vm=soon")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing timeout of CPI method 'create_vm'"))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package cmd_test
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 
@@ -41,6 +42,7 @@ import (
 	fakebiinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest/fakes"
 	mock_install "github.com/cloudfoundry/bosh-cli/installation/mocks"
 	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	mock_registry "github.com/cloudfoundry/bosh-cli/registry/mocks"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
@@ -73,12 +75,13 @@ var _ = Describe("CreateEnvCmd", func() {
 
 	Describe("Run", func() {
 		var (
-			command       *bicmd.CreateEnvCmd
-			fs            *fakesys.FakeFileSystem
-			stdOut        *gbytes.Buffer
-			stdErr        *gbytes.Buffer
-			userInterface biui.UI
-			manifestSHA   string
+			command         *bicmd.CreateEnvCmd
+			validateCommand *bicmd.ValidateEnvCmd
+			fs              *fakesys.FakeFileSystem
+			stdOut          *gbytes.Buffer
+			stdErr          *gbytes.Buffer
+			userInterface   biui.UI
+			manifestSHA     string
 
 			mockDeployer              *mock_deployment.MockDeployer
 			mockInstaller             *mock_install.MockInstaller
@@ -149,10 +152,15 @@ var _ = Describe("CreateEnvCmd", func() {
 
 			expectedRegistryConfig biinstallmanifest.Registry
 			expectedDeployError    error
+
+			stemcellFormats    []string
+			cpiStemcellFormats []string
 		)
 
 		BeforeEach(func() {
 			expectedDeployError = nil
+			stemcellFormats = nil
+			cpiStemcellFormats = nil
 			expectedSkipDrain = false
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			stdOut = gbytes.NewBuffer()
@@ -176,6 +184,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			releaseManager = biinstall.NewReleaseManager(logger)
 
 			mockRegistryServerManager = mock_registry.NewMockServerManager(mockCtrl)
+			mockRegistryServerManager.EXPECT().Cleanup().AnyTimes()
 			mockRegistryServer = mock_registry.NewMockServer(mockCtrl)
 
 			mockAgentClientFactory = mock_httpagent.NewMockAgentClientFactory(mockCtrl)
@@ -373,10 +382,14 @@ var _ = Describe("CreateEnvCmd", func() {
 					deploymentManifestParser,
 					tempRootConfigurator,
 					targetProvider,
+					biinstall.NewUninstaller(fs, logger),
+					deployment.NewPlanner(),
+					biconfig.NewFileSystemManifestRedactionKeyStore("/fake-manifest-keys", fs, fakeuuid.NewFakeGenerator()),
 				)
 			}
 
 			command = bicmd.NewCreateEnvCmd(userInterface, doGet)
+			validateCommand = bicmd.NewValidateEnvCmd(userInterface, doGet)
 
 			expectLegacyMigrate = mockLegacyDeploymentStateMigrator.EXPECT().MigrateIfExists(filepath.Join("/", "path", "to", "bosh-deployments.yml")).AnyTimes()
 
@@ -386,6 +399,7 @@ var _ = Describe("CreateEnvCmd", func() {
 					Version:         "fake-stemcell-version",
 					SHA1:            "fake-stemcell-sha1",
 					ApiVersion:      stemcellApiVersion,
+					StemcellFormats: stemcellFormats,
 					CloudProperties: biproperty.Map{},
 				},
 				"fake-extracted-path",
@@ -399,7 +413,7 @@ var _ = Describe("CreateEnvCmd", func() {
 				"fake-stemcell-cid", "fake-stemcell-name", "fake-stemcell-version", stemcellApiVersion)
 
 			mockCloud = mock_cloud.NewMockCloud(mockCloudCtrl)
-			mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: cpiApiVersion}, nil).AnyTimes()
+			mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: cpiApiVersion, StemcellFormats: cpiStemcellFormats}, nil).AnyTimes()
 			mockCloud.EXPECT().String().AnyTimes()
 
 			fakeStemcellExtractor.SetExtractBehavior(stemcellTarballPath, extractedStemcell, nil)
@@ -444,9 +458,10 @@ var _ = Describe("CreateEnvCmd", func() {
 				expectedRegistryConfig,
 				fakeVMManager,
 				mockBlobstore,
+				gomock.Any(),
 				expectedSkipDrain,
 				gomock.Any(),
-			).Do(func(_, _, _, _, _, _, _ interface{}, stage biui.Stage) {
+			).Do(func(_, _, _, _, _, _, _, _ interface{}, stage biui.Stage) {
 				Expect(fakeStage.SubStages).To(ContainElement(stage))
 			}).Return(nil, expectedDeployError).AnyTimes()
 
@@ -605,7 +620,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 
 			It("should not start or attempt to stop the registry", func() {
-				mockRegistryServerManager.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockRegistryServerManager.EXPECT().Start(gomock.Any()).Times(0)
 				mockRegistryServer.EXPECT().Stop().Times(0)
 
 				err := command.Run(fakeStage, defaultCreateEnvOpts)
@@ -614,7 +629,7 @@ var _ = Describe("CreateEnvCmd", func() {
 
 			itStartsStopsRegistry := func() {
 				It("starts & stops the registry", func() {
-					mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+					mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(mockRegistryServer, nil)
 					mockRegistryServer.EXPECT().Stop()
 
 					err := command.Run(fakeStage, defaultCreateEnvOpts)
@@ -622,7 +637,7 @@ var _ = Describe("CreateEnvCmd", func() {
 				})
 
 				It("adds a new 'Starting registry' event logger stage", func() {
-					mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+					mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(mockRegistryServer, nil)
 					mockRegistryServer.EXPECT().Stop()
 
 					err := command.Run(fakeStage, defaultCreateEnvOpts)
@@ -721,6 +736,40 @@ var _ = Describe("CreateEnvCmd", func() {
 			}))
 		})
 
+		It("stores the redacted deployment manifest", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := setupDeploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deploymentState.CurrentManifest).To(Equal("test: true\n"))
+		})
+
+		It("keeps the key the manifest is redacted with out of the deployment state", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join("/fake-manifest-keys", directorID))).To(BeTrue())
+		})
+
+		Context("when a manifest has been deployed before", func() {
+			JustBeforeEach(func() {
+				err := setupDeploymentStateService.Save(biconfig.DeploymentState{
+					DirectorID:      directorID,
+					CurrentManifest: "test: false\n",
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("prints the manifest diff", func() {
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say(regexp.QuoteMeta("- test: false")))
+				Expect(stdOut).To(gbytes.Say(regexp.QuoteMeta("+ test: true")))
+			})
+		})
+
 		It("deletes unused stemcells", func() {
 			expectStemcellDeleteUnused.Times(1)
 
@@ -793,6 +842,193 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 		})
 
+		Context("when validating the environment", func() {
+			var validateEnvOpts bicmd.ValidateEnvOpts
+
+			BeforeEach(func() {
+				validateEnvOpts = bicmd.ValidateEnvOpts{
+					Args: bicmd.ValidateEnvArgs{
+						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+					},
+				}
+
+				err := fs.WriteFileString(deploymentStatePath, `{"director_id":"generated-director-uuid","installation_id":"fake-installation-id"}`)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("validates the manifests, installs the CPI and does not deploy", func() {
+				expectInstall.Times(1)
+				expectDeploy.Times(0)
+				expectStemcellUpload.Times(0)
+
+				err := validateCommand.Run(fakeStage, validateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeDeploymentValidator.ValidateInputs).To(HaveLen(1))
+				Expect(fakeDeploymentValidator.ValidateReleaseJobsInputs).To(HaveLen(1))
+				Expect(fakeStage.PerformCalls).To(ContainElement(&fakebiui.PerformCall{Name: "Validating stemcell format"}))
+				Expect(stdOut).To(gbytes.Say("Deployment manifest is valid"))
+			})
+
+			It("does not save the deployment state or take its lock", func() {
+				err := fs.WriteFileString(deploymentStatePath, `{"installation_id":"fake-installation-id"}`)
+				Expect(err).ToNot(HaveOccurred())
+				err = fs.WriteFileString(deploymentStatePath+".lock", "123@other-host")
+				Expect(err).ToNot(HaveOccurred())
+
+				expectNewCloud.Times(0)
+				mockCloudFactory.EXPECT().NewCloud(gomock.Any(), "", stemcellApiVersion).Return(mockCloud, nil)
+
+				err = validateCommand.Run(fakeStage, validateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fs.ReadFileString(deploymentStatePath)).To(Equal(`{"installation_id":"fake-installation-id"}`))
+				Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeTrue())
+			})
+
+			Context("when the deployment state file does not exist", func() {
+				BeforeEach(func() {
+					fs.RemoveAll(deploymentStatePath)
+				})
+
+				It("does not create a deployment state and removes the temporary CPI installation", func() {
+					expectNewCloud.Times(0)
+					mockCloudFactory.EXPECT().NewCloud(gomock.Any(), "", stemcellApiVersion).Return(mockCloud, nil)
+
+					err := fs.MkdirAll(filepath.Join("fake-install-dir", "fake-installation-id"), os.ModePerm)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = validateCommand.Run(fakeStage, validateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
+					Expect(fs.FileExists(filepath.Join("fake-install-dir", "fake-installation-id"))).To(BeFalse())
+				})
+			})
+
+			Context("when the CPI supports the stemcell format", func() {
+				BeforeEach(func() {
+					stemcellFormats = []string{"fake-format-1", "fake-format-2"}
+					cpiStemcellFormats = []string{"fake-format-2"}
+				})
+
+				It("succeeds", func() {
+					err := validateCommand.Run(fakeStage, validateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when the CPI does not support the stemcell format", func() {
+				BeforeEach(func() {
+					stemcellFormats = []string{"fake-format-1"}
+					cpiStemcellFormats = []string{"fake-format-2", "fake-format-3"}
+				})
+
+				It("returns an error", func() {
+					err := validateCommand.Run(fakeStage, validateEnvOpts)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("CPI does not support any of the stemcell formats 'fake-format-1', supported formats are 'fake-format-2', 'fake-format-3'"))
+				})
+			})
+		})
+
+		Context("when dry-run is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.DryRun = true
+			})
+
+			It("sets the temp root in a temporary installation and removes it", func() {
+				err := fs.MkdirAll(filepath.Join("fake-install-dir", "fake-installation-id"), os.ModePerm)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fs.TempRootPath).To(Equal(filepath.Join("fake-install-dir", "fake-installation-id", "tmp")))
+				Expect(fs.FileExists(filepath.Join("fake-install-dir", "fake-installation-id"))).To(BeFalse())
+			})
+
+			It("does not save the deployment state", func() {
+				err := fs.WriteFileString(deploymentStatePath, `{"current_vm_cid":"fake-vm-cid"}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fs.ReadFileString(deploymentStatePath)).To(Equal(`{"current_vm_cid":"fake-vm-cid"}`))
+			})
+
+			It("does not install the CPI or deploy", func() {
+				expectInstall.Times(0)
+				expectNewCloud.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when the deployment state file does not exist", func() {
+				BeforeEach(func() {
+					fs.RemoveAll(deploymentStatePath)
+				})
+
+				It("prints that the stemcell will be uploaded and the VM created", func() {
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(stdOut).To(gbytes.Say("Stemcell 'fake-stemcell-name/fake-stemcell-version' will be uploaded"))
+					Expect(stdOut).To(gbytes.Say("VM will be created"))
+				})
+
+				It("does not create a deployment state", func() {
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
+				})
+			})
+
+			Context("when deployment has not changed", func() {
+				JustBeforeEach(func() {
+					previousDeploymentState := biconfig.DeploymentState{
+						DirectorID:        directorID,
+						InstallationID:    "fake-installation-id",
+						CurrentVMCID:      "fake-vm-cid",
+						CurrentReleaseIDs: []string{"my-release-id-1"},
+						Releases: []biconfig.ReleaseRecord{{
+							ID:      "my-release-id-1",
+							Name:    cpiRelease.Name(),
+							Version: cpiRelease.Version(),
+						}},
+						CurrentStemcellID: "my-stemcellRecordID",
+						Stemcells: []biconfig.StemcellRecord{{
+							ID:      "my-stemcellRecordID",
+							Name:    cloudStemcell.Name(),
+							Version: cloudStemcell.Version(),
+						}},
+						CurrentManifestSHA: manifestSHA,
+					}
+
+					err := setupDeploymentStateService.Save(previousDeploymentState)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("prints that deploy would be skipped", func() {
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes. Deploy would be skipped."))
+				})
+
+				It("prints that the VM will be recreated if `recreate` flag is specified", func() {
+					defaultCreateEnvOpts.Recreate = true
+
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stdOut).To(gbytes.Say("VM will be recreated"))
+				})
+			})
+		})
+
 		Context("when parsing the cpi deployment manifest fails", func() {
 			JustBeforeEach(func() {
 				manifest := bideplmanifest.Manifest{}
@@ -1067,6 +1303,26 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 		})
 
+		Context("when the deployment state is locked", func() {
+			BeforeEach(func() {
+				fs.WriteFileString(deploymentStatePath+".lock", "123@other-host")
+			})
+
+			It("returns an error without deploying", func() {
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("is locked by '123@other-host'"))
+			})
+		})
+
+		It("releases the deployment state lock", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+
 		Context("when the deployment manifest is invalid", func() {
 			BeforeEach(func() {
 				fakeDeploymentValidator.SetValidateBehavior([]fakebideplval.ValidateOutput{
@@ -1136,6 +1392,7 @@ var _ = Describe("CreateEnvCmd", func() {
 					installationManifest.Registry,
 					fakeVMManager,
 					mockBlobstore,
+					gomock.Any(),
 					expectedSkipDrain,
 					gomock.Any(),
 				).Return(nil, expectedDeployError).AnyTimes()
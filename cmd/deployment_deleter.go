@@ -3,7 +3,6 @@ package cmd
 import (
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -90,11 +89,27 @@ type deploymentDeleter struct {
 func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
-	if !c.deploymentStateService.Exists() {
+	exists, err := c.deploymentStateService.Exists()
+	if err != nil {
+		return bosherr.WrapError(err, "Checking deployment state")
+	}
+
+	if !exists {
 		c.ui.BeginLinef("No deployment state file found.\n")
 		return nil
 	}
 
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+	defer func() {
+		err := c.deploymentStateService.Unlock()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", err.Error())
+		}
+	}()
+
 	deploymentState, err := c.deploymentStateService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading deployment state")
@@ -147,7 +162,7 @@ func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (
 
 	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
 		return localCpiInstallation.WithRunningRegistry(c.logger, stage, func() error {
-			err = c.findAndDeleteDeployment(skipDrain, stage, localCpiInstallation, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+			err = c.findAndDeleteDeployment(skipDrain, stage, localCpiInstallation, deploymentState.DirectorID, installationManifest)
 
 			if err != nil {
 				return err
@@ -167,8 +182,8 @@ func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (
 	return err
 }
 
-func (c *deploymentDeleter) findAndDeleteDeployment(skipDrain bool, stage biui.Stage, installation biinstall.Installation, directorID, installationMbus, caCert string) error {
-	deploymentManager, err := c.deploymentManager(installation, directorID, installationMbus, caCert)
+func (c *deploymentDeleter) findAndDeleteDeployment(skipDrain bool, stage biui.Stage, installation biinstall.Installation, directorID string, installationManifest biinstallmanifest.Manifest) error {
+	deploymentManager, err := c.deploymentManager(installation, directorID, installationManifest)
 	if err != nil {
 		return err
 	}
@@ -200,7 +215,7 @@ func (c *deploymentDeleter) findCurrentDeploymentAndDelete(skipDrain bool, stage
 	})
 }
 
-func (c *deploymentDeleter) deploymentManager(installation biinstall.Installation, directorID, installationMbus, caCert string) (bidepl.Manager, error) {
+func (c *deploymentDeleter) deploymentManager(installation biinstall.Installation, directorID string, installationManifest biinstallmanifest.Manifest) (bidepl.Manager, error) {
 	c.logger.Debug(c.logTag, "Creating cloud client...")
 
 	stemcellApiVersion := 1
@@ -221,16 +236,18 @@ func (c *deploymentDeleter) deploymentManager(installation biinstall.Installatio
 
 	c.logger.Debug(c.logTag, "Creating agent client...")
 
-	agentClient, _ := c.agentClientFactory.NewAgentClient(directorID, installationMbus, caCert)
+	agentClient, _ := c.agentClientFactory.NewAgentClient(directorID, installationManifest.Mbus, installationManifest.Cert.CA)
 
 	c.logger.Debug(c.logTag, "Creating blobstore client...")
 
-	blobstore, err := c.blobstoreFactory.Create(installationMbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	blobstore, err := newInstallationBlobstore(c.blobstoreFactory, installationManifest)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating blobstore client")
 	}
 
 	c.logger.Debug(c.logTag, "Creating deployment manager...")
 
-	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore), nil
+	instanceClientFactory := newInstanceClientFactory(directorID, installationManifest, c.agentClientFactory, c.blobstoreFactory, blobstore)
+
+	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore, instanceClientFactory), nil
 }
@@ -29,6 +29,7 @@ import (
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	mock_install "github.com/cloudfoundry/bosh-cli/installation/mocks"
 	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	birel "github.com/cloudfoundry/bosh-cli/release"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
@@ -208,7 +209,7 @@ cloud_provider:
 			releaseSetValidator := birelsetmanifest.NewValidator(logger)
 			releaseSetParser := birelsetmanifest.NewParser(fs, logger, releaseSetValidator)
 			installationValidator := biinstallmanifest.NewValidator(logger)
-			installationParser := biinstallmanifest.NewParser(fs, fakeUUIDGenerator, logger, installationValidator)
+			installationParser := biinstallmanifest.NewParser(fs, fakeUUIDGenerator, biregistry.NewCertificateProvider(fs, "/fake-registry.json"), logger, installationValidator)
 			tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
 			tarballProvider := bitarball.NewProvider(tarballCache, fs, nil, 1, 0, logger)
 			deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""))
@@ -256,7 +257,7 @@ cloud_provider:
 		}
 
 		var expectDeleteAndCleanup = func(skipDrain, defaultUninstallerUsed bool) {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager)
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager)
 			mockDeploymentManager.EXPECT().FindCurrent().Return(mockDeployment, true, nil)
 
 			gomock.InOrder(
@@ -271,7 +272,7 @@ cloud_provider:
 		}
 
 		var expectCleanup = func() {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager).AnyTimes()
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager).AnyTimes()
 			mockDeploymentManager.EXPECT().FindCurrent().Return(nil, false, nil).AnyTimes()
 
 			mockDeploymentManager.EXPECT().Cleanup(fakeStage)
@@ -494,6 +495,27 @@ cloud_provider:
 					Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
 				})
 
+				It("releases the deployment state lock", func() {
+					expectDeleteAndCleanup(skipDrain, true)
+
+					err := newDeploymentDeleter().DeleteDeployment(skipDrain, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+				})
+
+				Context("when the deployment state is locked", func() {
+					BeforeEach(func() {
+						fs.WriteFileString(deploymentStatePath+".lock", "123@other-host")
+					})
+
+					It("returns an error", func() {
+						err := newDeploymentDeleter().DeleteDeployment(skipDrain, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("is locked by '123@other-host'"))
+					})
+				})
+
 				It("skips draining if specified", func() {
 					skipDrain = true
 					expectDeleteAndCleanup(skipDrain, true)
@@ -548,7 +570,7 @@ cloud_provider:
 
 			Context("when the call to delete the deployment returns an error", func() {
 				It("returns the error", func() {
-					mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager)
+					mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager)
 					mockDeploymentManager.EXPECT().FindCurrent().Return(mockDeployment, true, nil)
 
 					deleteError := bosherr.Error("delete error")
@@ -13,7 +13,7 @@ import (
 )
 
 type DeploymentManifestParser interface {
-	GetDeploymentManifest(path string, vars boshtpl.Variables, op patch.Op, releaseSetManifest birelsetmanifest.Manifest, stage biui.Stage) (bideplmanifest.Manifest, string, error)
+	GetDeploymentManifest(path string, vars boshtpl.Variables, op patch.Op, releaseSetManifest birelsetmanifest.Manifest, stage biui.Stage) (bideplmanifest.Manifest, bidepltpl.InterpolatedTemplate, error)
 }
 
 type deploymentManifestParser struct {
@@ -36,9 +36,9 @@ func NewDeploymentManifestParser(
 	}
 }
 
-func (y deploymentManifestParser) GetDeploymentManifest(path string, vars boshtpl.Variables, op patch.Op, releaseSetManifest birelsetmanifest.Manifest, stage biui.Stage) (bideplmanifest.Manifest, string, error) {
+func (y deploymentManifestParser) GetDeploymentManifest(path string, vars boshtpl.Variables, op patch.Op, releaseSetManifest birelsetmanifest.Manifest, stage biui.Stage) (bideplmanifest.Manifest, bidepltpl.InterpolatedTemplate, error) {
 	var deploymentManifest bideplmanifest.Manifest
-	var manifestSHA string
+	var interpolatedTemplate bidepltpl.InterpolatedTemplate
 
 	err := stage.Perform("Validating deployment manifest", func() error {
 		var err error
@@ -48,13 +48,11 @@ func (y deploymentManifestParser) GetDeploymentManifest(path string, vars boshtp
 			return bosherr.WrapErrorf(err, "Evaluating manifest")
 		}
 
-		interpolatedTemplate, err := template.Evaluate(vars, op)
+		interpolatedTemplate, err = template.Evaluate(vars, op)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Evaluating manifest '%s'", path)
 		}
 
-		manifestSHA = interpolatedTemplate.SHA()
-
 		deploymentManifest, err = y.deploymentParser.Parse(interpolatedTemplate, path)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", path)
@@ -73,8 +71,8 @@ func (y deploymentManifestParser) GetDeploymentManifest(path string, vars boshtp
 		return nil
 	})
 	if err != nil {
-		return bideplmanifest.Manifest{}, "", err
+		return bideplmanifest.Manifest{}, bidepltpl.InterpolatedTemplate{}, err
 	}
 
-	return deploymentManifest, manifestSHA, nil
+	return deploymentManifest, interpolatedTemplate, nil
 }
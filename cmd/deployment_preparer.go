@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
@@ -13,6 +15,7 @@ import (
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
@@ -47,6 +50,9 @@ func NewDeploymentPreparer(
 	deploymentManifestParser DeploymentManifestParser,
 	tempRootConfigurator TempRootConfigurator,
 	targetProvider biinstall.TargetProvider,
+	uninstaller biinstall.Uninstaller,
+	planner bidepl.Planner,
+	manifestRedactionKeyStore biconfig.ManifestRedactionKeyStore,
 ) DeploymentPreparer {
 	return DeploymentPreparer{
 		ui:                                      ui,
@@ -72,6 +78,9 @@ func NewDeploymentPreparer(
 		deploymentManifestParser:                deploymentManifestParser,
 		tempRootConfigurator:                    tempRootConfigurator,
 		targetProvider:                          targetProvider,
+		uninstaller:                             uninstaller,
+		planner:                                 planner,
+		manifestRedactionKeyStore:               manifestRedactionKeyStore,
 	}
 }
 
@@ -99,12 +108,35 @@ type DeploymentPreparer struct {
 	deploymentManifestParser                DeploymentManifestParser
 	tempRootConfigurator                    TempRootConfigurator
 	targetProvider                          biinstall.TargetProvider
+	uninstaller                             biinstall.Uninstaller
+	planner                                 bidepl.Planner
+	manifestRedactionKeyStore               biconfig.ManifestRedactionKeyStore
 }
 
-func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool) (err error) {
+func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool, dryRun bool, noRedact bool) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
-	if !c.deploymentStateService.Exists() {
+	if dryRun {
+		return c.planDeployment(stage, recreate, recreatePersistentDisks, noRedact)
+	}
+
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+	defer func() {
+		err := c.deploymentStateService.Unlock()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", err.Error())
+		}
+	}()
+
+	exists, err := c.deploymentStateService.Exists()
+	if err != nil {
+		return bosherr.WrapError(err, "Checking deployment state")
+	}
+
+	if !exists {
 		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
 		if err != nil {
 			return bosherr.WrapError(err, "Migrating legacy deployment state file")
@@ -136,48 +168,37 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 		}
 	}()
 
-	var (
-		extractedStemcell    bistemcell.ExtractedStemcell
-		deploymentManifest   bideplmanifest.Manifest
-		installationManifest biinstallmanifest.Manifest
-		manifestSHA          string
-	)
-	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
-		var releaseSetManifest birelsetmanifest.Manifest
-		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
-		if err != nil {
-			return err
-		}
+	extractedStemcell, deploymentManifest, installationManifest, interpolatedTemplate, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+	defer c.cleanupStemcell(extractedStemcell)
 
-		for _, releaseRef := range releaseSetManifest.Releases {
-			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
-			if err != nil {
-				return err
-			}
-		}
+	manifestSHA := interpolatedTemplate.SHA()
 
-		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
-		if err != nil {
-			return err
-		}
+	// the stored manifest may have been redacted on another machine, with a key that is not known here
+	previousRedactionKey, found, err := c.manifestRedactionKeyStore.Find(deploymentState.DirectorID)
+	if err != nil {
+		return bosherr.WrapError(err, "Finding manifest redaction key")
+	}
 
-		deploymentManifest, manifestSHA, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
+	redactionKey := previousRedactionKey
+	if !found {
+		redactionKey, err = c.manifestRedactionKeyStore.Create(deploymentState.DirectorID)
 		if err != nil {
-			return err
+			return bosherr.WrapError(err, "Creating manifest redaction key")
 		}
+	}
 
-		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
-		return err
-	})
+	redactedManifest, err := bideplmanifest.RedactManifest(interpolatedTemplate.Content(), redactionKey)
+	if err != nil {
+		return bosherr.WrapError(err, "Redacting deployment manifest")
+	}
+
+	err = c.printManifestDiff(deploymentState, interpolatedTemplate.Content(), previousRedactionKey, noRedact)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		deleteErr := extractedStemcell.Cleanup()
-		if deleteErr != nil {
-			c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
-		}
-	}()
 
 	isDeployed, err := c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), extractedStemcell)
 	if err != nil {
@@ -205,6 +226,7 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 				installationManifest,
 				deploymentManifest,
 				manifestSHA,
+				redactedManifest,
 				skipDrain,
 				stage,
 				cloud,
@@ -235,6 +257,7 @@ func (c *DeploymentPreparer) deploy(
 	installationManifest biinstallmanifest.Manifest,
 	deploymentManifest bideplmanifest.Manifest,
 	manifestSHA string,
+	redactedManifest []byte,
 	skipDrain bool,
 	stage biui.Stage,
 	cloud bicloud.Cloud,
@@ -253,11 +276,15 @@ func (c *DeploymentPreparer) deploy(
 	}
 	vmManager := c.vmManagerFactory.NewManager(cloud, agentClient)
 
-	blobstore, err := c.blobstoreFactory.Create(installationManifest.Mbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	blobstore, err := newInstallationBlobstore(c.blobstoreFactory, installationManifest)
 	if err != nil {
 		return bosherr.WrapError(err, "Creating blobstore client")
 	}
 
+	if !installationManifest.Blobstore.IsDAV() {
+		deploymentManifest = deploymentManifest.WithAgentBlobstore(installationManifest.Blobstore.Provider, installationManifest.Blobstore.Options)
+	}
+
 	err = stage.PerformComplex("deploying", func(deployStage biui.Stage) error {
 		err = c.deploymentRecord.Clear()
 		if err != nil {
@@ -276,6 +303,7 @@ func (c *DeploymentPreparer) deploy(
 			registrySettings,
 			vmManager,
 			blobstore,
+			newInstanceClientFactory(deploymentState.DirectorID, installationManifest, c.agentClientFactory, c.blobstoreFactory, blobstore),
 			skipDrain,
 			deployStage,
 		)
@@ -288,6 +316,11 @@ func (c *DeploymentPreparer) deploy(
 			return bosherr.WrapError(err, "Updating deployment record")
 		}
 
+		err = c.deploymentRecord.UpdateManifest(string(redactedManifest))
+		if err != nil {
+			return bosherr.WrapError(err, "Updating deployment record")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -304,6 +337,284 @@ func (c *DeploymentPreparer) deploy(
 	return nil
 }
 
+func (c *DeploymentPreparer) planDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, noRedact bool) error {
+	deploymentState, err := c.deploymentStateService.Read()
+	if err != nil {
+		return bosherr.WrapError(err, "Reading deployment state")
+	}
+
+	// Releases and the stemcell are extracted into a temporary installation
+	// to leave the installation of a concurrent create-env untouched
+	target, err := c.targetProvider.NewTemporaryTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	defer func() {
+		err := c.uninstaller.Uninstall(target)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Uninstalling temporary installation: %s", err.Error())
+		}
+	}()
+
+	err = c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	extractedStemcell, deploymentManifest, _, interpolatedTemplate, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+	defer c.cleanupStemcell(extractedStemcell)
+
+	redactionKey, _, err := c.manifestRedactionKeyStore.Find(deploymentState.DirectorID)
+	if err != nil {
+		return bosherr.WrapError(err, "Finding manifest redaction key")
+	}
+
+	err = c.printManifestDiff(deploymentState, interpolatedTemplate.Content(), redactionKey, noRedact)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.planner.Plan(
+		deploymentState,
+		deploymentManifest,
+		interpolatedTemplate.SHA(),
+		c.releaseManager.List(),
+		extractedStemcell,
+		recreate,
+		recreatePersistentDisks,
+	)
+	if err != nil {
+		return bosherr.WrapError(err, "Planning deployment")
+	}
+
+	c.printPlan(plan)
+
+	return nil
+}
+
+// ValidateDeployment runs the same validation as a deploy and checks that the CPI
+// supports the stemcell without creating anything in the IaaS
+func (c *DeploymentPreparer) ValidateDeployment(stage biui.Stage) error {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	// Validation neither modifies the deployment state nor touches its installation,
+	// so that it does not need to take the lock held by a concurrent create-env
+	deploymentState, err := c.deploymentStateService.Read()
+	if err != nil {
+		return bosherr.WrapError(err, "Reading deployment state")
+	}
+
+	target, err := c.targetProvider.NewTemporaryTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	defer func() {
+		err := c.uninstaller.Uninstall(target)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Uninstalling temporary CPI installation: %s", err.Error())
+		}
+	}()
+
+	err = c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	extractedStemcell, _, installationManifest, _, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+	defer c.cleanupStemcell(extractedStemcell)
+
+	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
+		cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID, c.stemcellApiVersion(extractedStemcell))
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		return stage.Perform("Validating stemcell format", func() error {
+			cpiInfo, err := cloud.Info()
+			if err != nil {
+				return bosherr.WrapError(err, "Error getting CPI info")
+			}
+
+			return c.validateStemcellFormats(extractedStemcell.Manifest().StemcellFormats, cpiInfo.StemcellFormats)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	c.ui.BeginLinef("Deployment manifest is valid\n")
+
+	return nil
+}
+
+func (c *DeploymentPreparer) validateStemcellFormats(stemcellFormats []string, cpiFormats []string) error {
+	// Older stemcells and CPIs do not declare formats
+	if len(stemcellFormats) == 0 || len(cpiFormats) == 0 {
+		return nil
+	}
+
+	for _, stemcellFormat := range stemcellFormats {
+		for _, cpiFormat := range cpiFormats {
+			if stemcellFormat == cpiFormat {
+				return nil
+			}
+		}
+	}
+
+	return bosherr.Errorf(
+		"CPI does not support any of the stemcell formats '%s', supported formats are '%s'",
+		strings.Join(stemcellFormats, "', '"),
+		strings.Join(cpiFormats, "', '"),
+	)
+}
+
+func (c *DeploymentPreparer) printPlan(plan bidepl.Plan) {
+	if !plan.HasChanges() {
+		c.ui.BeginLinef("No deployment, stemcell or release changes. Deploy would be skipped.\n")
+		return
+	}
+
+	c.ui.BeginLinef("Dry run, no changes will be made:\n")
+
+	if plan.UploadStemcell {
+		c.ui.BeginLinef("  - Stemcell '%s' will be uploaded\n", plan.Stemcell)
+	}
+
+	if plan.CreateVM {
+		c.ui.BeginLinef("  - VM will be created\n")
+	} else if plan.RecreateVM {
+		c.ui.BeginLinef("  - VM will be recreated\n")
+	}
+
+	if plan.CreateDisk {
+		c.ui.BeginLinef("  - Persistent disk of %d MB will be created\n", plan.DiskSize)
+	} else if plan.MigrateDisk {
+		c.ui.BeginLinef("  - Persistent disk will be migrated from %d MB to %d MB\n", plan.PreviousDiskSize, plan.DiskSize)
+	}
+
+	for _, namedDisk := range plan.NamedDisks {
+		if namedDisk.CreateDisk {
+			c.ui.BeginLinef("  - Persistent disk '%s' of %d MB will be created\n", namedDisk.Name, namedDisk.DiskSize)
+		} else if namedDisk.MigrateDisk {
+			c.ui.BeginLinef("  - Persistent disk '%s' will be replaced, changing from %d MB to %d MB\n", namedDisk.Name, namedDisk.PreviousDiskSize, namedDisk.DiskSize)
+		}
+	}
+
+	for _, instance := range plan.Instances {
+		if instance.CreateVM {
+			c.ui.BeginLinef("  - VM of instance '%s/%d' will be created\n", instance.Name, instance.Index)
+		} else if instance.RecreateVM {
+			c.ui.BeginLinef("  - VM of instance '%s/%d' will be recreated\n", instance.Name, instance.Index)
+		} else if instance.DeleteVM {
+			c.ui.BeginLinef("  - VM of instance '%s/%d' will be deleted\n", instance.Name, instance.Index)
+		}
+
+		if instance.CreateDisk {
+			c.ui.BeginLinef("  - Persistent disk of instance '%s/%d' of %d MB will be created\n", instance.Name, instance.Index, instance.DiskSize)
+		} else if instance.MigrateDisk {
+			c.ui.BeginLinef("  - Persistent disk of instance '%s/%d' will be migrated from %d MB to %d MB\n", instance.Name, instance.Index, instance.PreviousDiskSize, instance.DiskSize)
+		}
+
+		for _, namedDisk := range instance.NamedDisks {
+			if namedDisk.CreateDisk {
+				c.ui.BeginLinef("  - Persistent disk '%s' of instance '%s/%d' of %d MB will be created\n", namedDisk.Name, instance.Name, instance.Index, namedDisk.DiskSize)
+			} else if namedDisk.MigrateDisk {
+				c.ui.BeginLinef("  - Persistent disk '%s' of instance '%s/%d' will be replaced, changing from %d MB to %d MB\n", namedDisk.Name, instance.Name, instance.Index, namedDisk.PreviousDiskSize, namedDisk.DiskSize)
+			}
+		}
+	}
+
+	if len(plan.PackagesToCompile) > 0 {
+		c.ui.BeginLinef("  - Packages to compile:\n")
+		for _, pkg := range plan.PackagesToCompile {
+			c.ui.BeginLinef("    - %s\n", pkg)
+		}
+	}
+}
+
+func (c *DeploymentPreparer) validate(stage biui.Stage) (
+	extractedStemcell bistemcell.ExtractedStemcell,
+	deploymentManifest bideplmanifest.Manifest,
+	installationManifest biinstallmanifest.Manifest,
+	interpolatedTemplate bidepltpl.InterpolatedTemplate,
+	err error,
+) {
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		for _, releaseRef := range releaseSetManifest.Releases {
+			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		deploymentManifest, interpolatedTemplate, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
+		return err
+	})
+	return
+}
+
+// printManifestDiff compares with the stored manifest that was redacted with redactionKey,
+// which is nil if the key is not known
+func (c *DeploymentPreparer) printManifestDiff(deploymentState biconfig.DeploymentState, manifest []byte, redactionKey []byte, noRedact bool) error {
+	if deploymentState.CurrentManifest == "" {
+		return nil
+	}
+
+	lines, err := bideplmanifest.DiffManifests([]byte(deploymentState.CurrentManifest), manifest, redactionKey, !noRedact)
+	if err != nil {
+		return bosherr.WrapError(err, "Diffing deployment manifest")
+	}
+
+	NewDiff(lines).Print(c.ui)
+
+	return nil
+}
+
+func (c *DeploymentPreparer) cleanupStemcell(extractedStemcell bistemcell.ExtractedStemcell) {
+	deleteErr := extractedStemcell.Cleanup()
+	if deleteErr != nil {
+		c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+	}
+}
+
 func (c *DeploymentPreparer) stemcellApiVersion(stemcell bistemcell.ExtractedStemcell) int {
 	stemcellApiVersion := stemcell.Manifest().ApiVersion
 	if stemcellApiVersion == 0 {
@@ -311,3 +622,11 @@ func (c *DeploymentPreparer) stemcellApiVersion(stemcell bistemcell.ExtractedSte
 	}
 	return stemcellApiVersion
 }
+
+func newInstallationBlobstore(blobstoreFactory biblobstore.Factory, installationManifest biinstallmanifest.Manifest) (biblobstore.Blobstore, error) {
+	if installationManifest.Blobstore.IsDAV() {
+		return blobstoreFactory.Create(installationManifest.Mbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	}
+
+	return blobstoreFactory.CreateFromProvider(installationManifest.Blobstore.Provider, installationManifest.Blobstore.Options)
+}
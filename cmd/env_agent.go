@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+)
+
+// EnvAgent talks to the agent of the VM of an environment through the installation mbus URL
+type EnvAgent interface {
+	biagentclient.AgentClient
+
+	// State returns the full agent state including processes and vitals,
+	// which are not part of the state returned by the agent client
+	State() (boshdir.VMInfo, error)
+}
+
+type envAgent struct {
+	biagentclient.AgentClient
+
+	directorID string
+	endpoint   string
+	httpClient *bihttpclient.HTTPClient
+}
+
+func NewEnvAgent(
+	agentClient biagentclient.AgentClient,
+	directorID string,
+	mbusURL string,
+	caCert string,
+	logger boshlog.Logger,
+) (EnvAgent, error) {
+	client := bihttpclient.DefaultClient
+
+	if caCert != "" {
+		caCertPool, err := crypto.CertPoolFromPEM([]byte(caCert))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing mbus CA certificate")
+		}
+		client = bihttpclient.CreateDefaultClient(caCertPool)
+	}
+
+	return envAgent{
+		AgentClient: agentClient,
+		directorID:  directorID,
+		endpoint:    fmt.Sprintf("%s/agent", mbusURL),
+		httpClient:  bihttpclient.NewHTTPClient(client, logger),
+	}, nil
+}
+
+type envAgentRequest struct {
+	Method    string        `json:"method"`
+	Arguments []interface{} `json:"arguments"`
+	ReplyTo   string        `json:"reply_to"`
+}
+
+type envAgentStateResponse struct {
+	Value struct {
+		AgentID string `json:"agent_id"`
+
+		Job struct {
+			Name string `json:"name"`
+		} `json:"job"`
+
+		ID       string `json:"id"`
+		Index    *int   `json:"index"`
+		JobState string `json:"job_state"`
+
+		Networks map[string]struct {
+			IP string `json:"ip"`
+		} `json:"networks"`
+
+		Processes []boshdir.VMInfoProcess `json:"processes"`
+		Vitals    boshdir.VMInfoVitals    `json:"vitals"`
+	} `json:"value"`
+
+	Exception *struct {
+		Message string `json:"message"`
+	} `json:"exception"`
+}
+
+func (a envAgent) State() (boshdir.VMInfo, error) {
+	requestBody, err := json.Marshal(envAgentRequest{
+		Method:    "get_state",
+		Arguments: []interface{}{"full"},
+		ReplyTo:   a.directorID,
+	})
+	if err != nil {
+		return boshdir.VMInfo{}, bosherr.WrapError(err, "Marshaling agent request")
+	}
+
+	httpResponse, err := a.httpClient.PostCustomized(a.endpoint, requestBody, func(r *http.Request) {
+		r.Header["Content-type"] = []string{"application/json"}
+	})
+	if err != nil {
+		return boshdir.VMInfo{}, bosherr.WrapError(err, "Sending get_state to the agent")
+	}
+
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return boshdir.VMInfo{}, bosherr.Errorf("Agent responded with non-successful status code: %d", httpResponse.StatusCode)
+	}
+
+	responseBody, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return boshdir.VMInfo{}, bosherr.WrapError(err, "Reading agent response")
+	}
+
+	var response envAgentStateResponse
+
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return boshdir.VMInfo{}, bosherr.WrapError(err, "Unmarshaling agent response")
+	}
+
+	if response.Exception != nil {
+		return boshdir.VMInfo{}, bosherr.Errorf("Agent responded with error: %s", response.Exception.Message)
+	}
+
+	state := response.Value
+
+	var ips []string
+	for _, network := range state.Networks {
+		ips = append(ips, network.IP)
+	}
+	sort.Strings(ips)
+
+	return boshdir.VMInfo{
+		AgentID:      state.AgentID,
+		JobName:      state.Job.Name,
+		ID:           state.ID,
+		Index:        state.Index,
+		ProcessState: state.JobState,
+		Bootstrap:    true,
+		IPs:          ips,
+		Processes:    state.Processes,
+		Vitals:       state.Vitals,
+	}, nil
+}
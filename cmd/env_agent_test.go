@@ -0,0 +1,136 @@
+package cmd_test
+
+import (
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+)
+
+var _ = Describe("EnvAgent", func() {
+	var (
+		server *ghttp.Server
+		agent  EnvAgent
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		var err error
+		agent, err = NewEnvAgent(nil, "fake-director-id", server.URL(), "", boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("State", func() {
+		It("requests the full state and converts it to VM info", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(map[string]interface{}{
+						"method":    "get_state",
+						"arguments": []string{"full"},
+						"reply_to":  "fake-director-id",
+					}),
+					ghttp.RespondWith(http.StatusOK, `{
+						"value": {
+							"agent_id": "fake-agent-id",
+							"job": {"name": "fake-job"},
+							"id": "fake-id",
+							"index": 0,
+							"job_state": "running",
+							"networks": {
+								"private": {"ip": "10.0.0.6"},
+								"public": {"ip": "10.0.1.6"}
+							},
+							"processes": [
+								{"name": "fake-process", "state": "running", "uptime": {"secs": 10}, "mem": {"kb": 100, "percent": 0.5}, "cpu": {"total": 1.5}}
+							],
+							"vitals": {
+								"cpu": {"sys": "1.0", "user": "2.0", "wait": "3.0"},
+								"disk": {"system": {"percent": "40", "inode_percent": "10"}},
+								"load": ["0.01", "0.02", "0.03"],
+								"mem": {"kb": "1024", "percent": "20"},
+								"swap": {"kb": "0", "percent": "0"},
+								"uptime": {"secs": 20}
+							}
+						}
+					}`),
+				),
+			)
+
+			info, err := agent.State()
+			Expect(err).ToNot(HaveOccurred())
+
+			index := 0
+			processUptime := uint64(10)
+			processMem := uint64(100)
+			processMemPercent := 0.5
+			processCPU := 1.5
+			uptime := uint64(20)
+
+			Expect(info).To(Equal(boshdir.VMInfo{
+				AgentID:      "fake-agent-id",
+				JobName:      "fake-job",
+				ID:           "fake-id",
+				Index:        &index,
+				ProcessState: "running",
+				Bootstrap:    true,
+				IPs:          []string{"10.0.0.6", "10.0.1.6"},
+				Processes: []boshdir.VMInfoProcess{
+					{
+						Name:   "fake-process",
+						State:  "running",
+						CPU:    boshdir.VMInfoVitalsCPU{Total: &processCPU},
+						Mem:    boshdir.VMInfoVitalsMemIntSize{KB: &processMem, Percent: &processMemPercent},
+						Uptime: boshdir.VMInfoVitalsUptime{Seconds: &processUptime},
+					},
+				},
+				Vitals: boshdir.VMInfoVitals{
+					CPU:    boshdir.VMInfoVitalsCPU{Sys: "1.0", User: "2.0", Wait: "3.0"},
+					Mem:    boshdir.VMInfoVitalsMemSize{KB: "1024", Percent: "20"},
+					Swap:   boshdir.VMInfoVitalsMemSize{KB: "0", Percent: "0"},
+					Uptime: boshdir.VMInfoVitalsUptime{Seconds: &uptime},
+					Load:   []string{"0.01", "0.02", "0.03"},
+					Disk: map[string]boshdir.VMInfoVitalsDiskSize{
+						"system": {Percent: "40", InodePercent: "10"},
+					},
+				},
+			}))
+		})
+
+		It("returns an error when the agent responds with an exception", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"exception": {"message": "fake-agent-err"}}`),
+			)
+
+			_, err := agent.State()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Agent responded with error: fake-agent-err"))
+		})
+
+		It("returns an error when the agent responds with a non-successful status code", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+			)
+
+			_, err := agent.State()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Agent responded with non-successful status code: 401"))
+		})
+	})
+
+	It("returns an error when the CA certificate is invalid", func() {
+		_, err := NewEnvAgent(nil, "fake-director-id", server.URL(), "invalid-ca", boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing mbus CA certificate"))
+	})
+})
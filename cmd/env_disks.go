@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type EnvDisksCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+}
+
+func NewEnvDisksCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector) *EnvDisksCmd {
+	return &EnvDisksCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *EnvDisksCmd) Run(stage boshui.Stage, opts EnvDisksOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	state, err := inspector.State()
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+
+	if opts.Check {
+		err = inspector.WithCloud(stage, func(cloud bicloud.Cloud) error {
+			for _, disk := range state.Disks {
+				exists, err := cloud.HasDisk(disk.CID)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Checking existence of disk '%s'", disk.CID)
+				}
+
+				found[disk.CID] = exists
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	table := boshtbl.Table{
+		Content: "disks",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Disk CID"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("In Use"),
+			boshtbl.NewHeader("Orphaned Until"),
+		},
+	}
+
+	if opts.Check {
+		table.Header = append(table.Header, boshtbl.NewHeader("In IaaS"))
+	}
+
+	inUse := envDiskIDsInUse(state)
+
+	var missing int
+
+	for _, disk := range state.Disks {
+		row := []boshtbl.Value{
+			boshtbl.NewValueString(disk.CID),
+			boshtbl.NewValueString(disk.Name),
+			boshtbl.NewValueMegaBytes(uint64(disk.Size)),
+			boshtbl.NewValueBool(inUse[disk.ID]),
+		}
+
+		if disk.OrphanedUntil != nil {
+			row = append(row, boshtbl.NewValueTime(*disk.OrphanedUntil))
+		} else {
+			row = append(row, boshtbl.NewValueString(""))
+		}
+
+		if opts.Check {
+			row = append(row, boshtbl.NewValueFmt(boshtbl.NewValueBool(found[disk.CID]), !found[disk.CID]))
+
+			if !found[disk.CID] {
+				missing++
+			}
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	c.ui.PrintTable(table)
+
+	if len(state.Snapshots) > 0 {
+		snapshotsTable := boshtbl.Table{
+			Content: "snapshots",
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Snapshot CID"),
+				boshtbl.NewHeader("Disk CID"),
+				boshtbl.NewHeader("Created At"),
+			},
+			SortBy: []boshtbl.ColumnSort{{Column: 2}},
+		}
+
+		for _, snapshot := range state.Snapshots {
+			snapshotsTable.Rows = append(snapshotsTable.Rows, []boshtbl.Value{
+				boshtbl.NewValueString(snapshot.CID),
+				boshtbl.NewValueString(snapshot.DiskCID),
+				boshtbl.NewValueTime(snapshot.CreatedAt),
+			})
+		}
+
+		c.ui.PrintTable(snapshotsTable)
+	}
+
+	if missing > 0 {
+		return bosherr.Errorf("Deployment state references %d disk(s) missing from the IaaS", missing)
+	}
+
+	return nil
+}
+
+// envDiskIDsInUse returns the IDs of disks that are current for any instance
+func envDiskIDsInUse(state biconfig.DeploymentState) map[string]bool {
+	inUse := map[string]bool{state.CurrentDiskID: true}
+
+	for _, id := range state.CurrentNamedDiskIDs {
+		inUse[id] = true
+	}
+
+	for _, instance := range state.Instances {
+		inUse[instance.DiskID] = true
+
+		for _, id := range instance.NamedDiskIDs {
+			inUse[id] = true
+		}
+	}
+
+	delete(inUse, "")
+
+	return inUse
+}
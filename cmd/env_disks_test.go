@@ -0,0 +1,214 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EnvDisksCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		fakeCloud     *fakebicloud.FakeCloud
+		ui            *fakeui.FakeUI
+		fakeStage     *fakeui.FakeStage
+		command       *EnvDisksCmd
+		opts          EnvDisksOpts
+		orphanedUntil time.Time
+		state         biconfig.DeploymentState
+		stateErr      error
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		fakeCloud = fakebicloud.NewFakeCloud()
+		ui = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			return mockInspector
+		}
+
+		command = NewEnvDisksCmd(ui, envProvider)
+
+		opts = EnvDisksOpts{
+			Args: EnvDisksArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+		}
+
+		stateErr = nil
+		orphanedUntil = time.Date(2017, time.March, 3, 12, 0, 0, 0, time.UTC)
+
+		state = biconfig.DeploymentState{
+			CurrentDiskID:       "fake-disk-id",
+			CurrentNamedDiskIDs: map[string]string{"fake-name": "fake-named-disk-id"},
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024},
+				{ID: "fake-named-disk-id", CID: "fake-named-disk-cid", Size: 2048, Name: "fake-name"},
+				{ID: "fake-orphaned-disk-id", CID: "fake-orphaned-disk-cid", Size: 512, OrphanedUntil: &orphanedUntil},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		mockInspector.EXPECT().State().Return(state, stateErr)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("lists disks tracked by the state", func() {
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Tables).To(HaveLen(1))
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "disks",
+
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Disk CID"),
+				boshtbl.NewHeader("Name"),
+				boshtbl.NewHeader("Size"),
+				boshtbl.NewHeader("In Use"),
+				boshtbl.NewHeader("Orphaned Until"),
+			},
+
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-disk-cid"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueMegaBytes(1024),
+					boshtbl.NewValueBool(true),
+					boshtbl.NewValueString(""),
+				},
+				{
+					boshtbl.NewValueString("fake-named-disk-cid"),
+					boshtbl.NewValueString("fake-name"),
+					boshtbl.NewValueMegaBytes(2048),
+					boshtbl.NewValueBool(true),
+					boshtbl.NewValueString(""),
+				},
+				{
+					boshtbl.NewValueString("fake-orphaned-disk-cid"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueMegaBytes(512),
+					boshtbl.NewValueBool(false),
+					boshtbl.NewValueTime(orphanedUntil),
+				},
+			},
+		}))
+	})
+
+	Context("when the state has snapshots", func() {
+		BeforeEach(func() {
+			state.Snapshots = []biconfig.SnapshotRecord{
+				{ID: "fake-snapshot-id", CID: "fake-snapshot-cid", DiskCID: "fake-orphaned-disk-cid", CreatedAt: orphanedUntil},
+			}
+		})
+
+		It("lists snapshots", func() {
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(2))
+			Expect(ui.Tables[1]).To(Equal(boshtbl.Table{
+				Content: "snapshots",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Snapshot CID"),
+					boshtbl.NewHeader("Disk CID"),
+					boshtbl.NewHeader("Created At"),
+				},
+
+				SortBy: []boshtbl.ColumnSort{{Column: 2}},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("fake-snapshot-cid"),
+						boshtbl.NewValueString("fake-orphaned-disk-cid"),
+						boshtbl.NewValueTime(orphanedUntil),
+					},
+				},
+			}))
+		})
+	})
+
+	Context("when checking disks against the IaaS", func() {
+		BeforeEach(func() {
+			opts.Check = true
+
+			mockInspector.EXPECT().WithCloud(fakeStage, gomock.Any()).DoAndReturn(
+				func(_ interface{}, fn func(bicloud.Cloud) error) error {
+					return fn(fakeCloud)
+				},
+			)
+		})
+
+		It("shows whether disks exist", func() {
+			fakeCloud.HasDiskFound = map[string]bool{
+				"fake-disk-cid":          true,
+				"fake-named-disk-cid":    true,
+				"fake-orphaned-disk-cid": true,
+			}
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.HasDiskInputs).To(Equal([]fakebicloud.HasDiskInput{
+				{DiskCID: "fake-disk-cid"},
+				{DiskCID: "fake-named-disk-cid"},
+				{DiskCID: "fake-orphaned-disk-cid"},
+			}))
+
+			Expect(ui.Table.Header[5]).To(Equal(boshtbl.NewHeader("In IaaS")))
+			Expect(ui.Table.Rows[0][5]).To(Equal(boshtbl.NewValueFmt(boshtbl.NewValueBool(true), false)))
+		})
+
+		It("returns an error when disks are missing", func() {
+			fakeCloud.HasDiskFound = map[string]bool{"fake-disk-cid": true}
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state references 2 disk(s) missing from the IaaS"))
+
+			Expect(ui.Table.Rows[1][5]).To(Equal(boshtbl.NewValueFmt(boshtbl.NewValueBool(false), true)))
+		})
+
+		It("returns an error when checking a disk fails", func() {
+			fakeCloud.HasDiskErr = errors.New("fake-has-disk-err")
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking existence of disk 'fake-disk-cid'"))
+		})
+	})
+
+	Context("when loading the state fails", func() {
+		BeforeEach(func() {
+			stateErr = errors.New("fake-state-err")
+		})
+
+		It("returns an error", func() {
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-state-err"))
+			Expect(ui.Tables).To(BeEmpty())
+		})
+	})
+})
@@ -43,6 +43,8 @@ type envFactory struct {
 	manifestOp   patch.Op
 
 	deploymentStateService     biconfig.DeploymentStateService
+	deploymentStateHistory     biconfig.DeploymentStateHistory
+	manifestRedactionKeyStore  biconfig.ManifestRedactionKeyStore
 	installationManifestParser ReleaseSetAndInstallationManifestParser
 
 	releaseManager  boshinst.ReleaseManager
@@ -53,9 +55,11 @@ type envFactory struct {
 	targetProvider boshinst.TargetProvider
 	cloudFactory   bicloud.Factory
 
-	diskManagerFactory     bidisk.ManagerFactory
-	vmManagerFactory       bivm.ManagerFactory
-	stemcellManagerFactory bistemcell.ManagerFactory
+	diskManagerFactory       bidisk.ManagerFactory
+	vmManagerFactory         bivm.ManagerFactory
+	instanceVMManagerFactory bivm.InstanceManagerFactory
+	stemcellManagerFactory   bistemcell.ManagerFactory
+	instanceRepo             biconfig.InstanceRepo
 
 	instanceManagerFactory   biinstance.ManagerFactory
 	deploymentManagerFactory bidepl.ManagerFactory
@@ -72,7 +76,10 @@ func NewEnvFactory(
 	statePath string,
 	manifestVars boshtpl.Variables,
 	manifestOp patch.Op,
-	recreatePersistentDisks bool,
+	diskDeployerOptions bivm.DiskDeployerOptions,
+	parallel int,
+	compiledPackageCache bistatepkg.CompiledPackageCache,
+	cpiOptions bicloud.CPICmdRunnerOptions,
 ) *envFactory {
 	f := envFactory{
 		deps:         deps,
@@ -112,14 +119,30 @@ func NewEnvFactory(
 		}
 	}
 
-	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
-		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
+	deploymentStatePath := biconfig.DeploymentStatePath(manifestPath, statePath)
 
 	{
-		registryServer := biregistry.NewServerManager(deps.Logger)
+
+		f.deploymentStateHistory = biconfig.NewFileSystemDeploymentStateHistory(
+			biconfig.DeploymentStateHistoryPath(deploymentStatePath, workspaceRootPath), deps.FS, deps.Time, deps.Logger)
+
+		f.deploymentStateService = biconfig.NewHistoryDeploymentStateService(
+			biconfig.NewDeploymentStateService(
+				deps.FS, deps.UUIDGen, deps.CmdRunner, deps.Logger, deploymentStatePath, workspaceRootPath),
+			f.deploymentStateHistory,
+			deps.Logger,
+		)
+
+		f.manifestRedactionKeyStore = biconfig.NewFileSystemManifestRedactionKeyStore(
+			biconfig.ManifestRedactionKeysPath(workspaceRootPath), deps.FS, deps.UUIDGen)
+	}
+
+	{
+		registryServer := biregistry.NewServerManager(
+			deps.FS, biconfig.DeploymentRegistryPath(deploymentStatePath, workspaceRootPath), deps.Logger)
 		installerFactory := boshinst.NewInstallerFactory(
 			deps.UI, deps.CmdRunner, deps.Compressor, releaseJobResolver,
-			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms)
+			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms, parallel, compiledPackageCache)
 
 		f.cpiInstaller = bicpirel.CpiInstaller{
 			ReleaseManager:   f.releaseManager,
@@ -136,12 +159,18 @@ func NewEnvFactory(
 		stemcellRepo := biconfig.NewStemcellRepo(f.deploymentStateService, deps.UUIDGen)
 		vmRepo := biconfig.NewVMRepo(f.deploymentStateService)
 
-		f.diskManagerFactory = bidisk.NewManagerFactory(diskRepo, deps.Logger)
-		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, diskRepo, deps.Logger, recreatePersistentDisks)
+		snapshotRepo := biconfig.NewSnapshotRepo(f.deploymentStateService, deps.UUIDGen)
+
+		f.diskManagerFactory = bidisk.NewManagerFactory(diskRepo, snapshotRepo, deps.Time, deps.Logger)
+		diskDeployer := bivm.NewDiskDeployer(
+			f.diskManagerFactory, diskRepo, snapshotRepo, deps.Time, deps.Logger, diskDeployerOptions)
 
 		f.stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 		f.vmManagerFactory = bivm.NewManagerFactory(
-			vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
+			f.deploymentStateService, vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
+		f.instanceVMManagerFactory = bivm.NewInstanceManagerFactory(
+			f.deploymentStateService, stemcellRepo, deps.UUIDGen, deps.FS, deps.Logger, diskDeployerOptions)
+		f.instanceRepo = biconfig.NewInstanceRepo(f.deploymentStateService)
 
 		deploymentRepo := biconfig.NewDeploymentRepo(f.deploymentStateService)
 		releaseRepo := biconfig.NewReleaseRepo(f.deploymentStateService, deps.UUIDGen)
@@ -149,10 +178,10 @@ func NewEnvFactory(
 	}
 
 	{
-		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
+		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Time, deps.Logger)
 		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
 		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
-		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, cpiOptions, deps.Time, deps.Logger)
 	}
 
 	{
@@ -179,7 +208,13 @@ func NewEnvFactory(
 		releaseSetParser := birelsetmanifest.NewParser(deps.FS, deps.Logger, releaseSetValidator)
 
 		installValidator := boshinstmanifest.NewValidator(deps.Logger)
-		installParser := boshinstmanifest.NewParser(deps.FS, deps.UUIDGen, deps.Logger, installValidator)
+		installParser := boshinstmanifest.NewParser(
+			deps.FS,
+			deps.UUIDGen,
+			biregistry.NewCertificateProvider(deps.FS, biconfig.DeploymentRegistryPath(deploymentStatePath, workspaceRootPath)),
+			deps.Logger,
+			installValidator,
+		)
 
 		f.installationManifestParser = ReleaseSetAndInstallationManifestParser{
 			ReleaseSetParser:   releaseSetParser,
@@ -211,7 +246,9 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		f.blobstoreFactory,
 		bidepl.NewDeployer(
 			f.vmManagerFactory,
+			f.instanceVMManagerFactory,
 			f.instanceManagerFactory,
+			f.instanceRepo,
 			f.deploymentFactory,
 			f.deps.Logger,
 		),
@@ -230,9 +267,20 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		),
 		NewTempRootConfigurator(f.deps.FS),
 		f.targetProvider,
+		boshinst.NewUninstaller(f.deps.FS, f.deps.Logger),
+		bidepl.NewPlanner(),
+		f.manifestRedactionKeyStore,
 	)
 }
 
+func (f *envFactory) StateService() biconfig.DeploymentStateService {
+	return f.deploymentStateService
+}
+
+func (f *envFactory) StateHistory() biconfig.DeploymentStateHistory {
+	return f.deploymentStateHistory
+}
+
 func (f *envFactory) Deleter() DeploymentDeleter {
 	return NewDeploymentDeleter(
 		f.deps.UI,
@@ -245,7 +293,9 @@ func (f *envFactory) Deleter() DeploymentDeleter {
 		f.blobstoreFactory,
 		bidepl.NewManagerFactory(
 			f.vmManagerFactory,
+			f.instanceVMManagerFactory,
 			f.instanceManagerFactory,
+			f.instanceRepo,
 			f.diskManagerFactory,
 			f.stemcellManagerFactory,
 			f.deploymentFactory,
@@ -261,3 +311,24 @@ func (f *envFactory) Deleter() DeploymentDeleter {
 		f.targetProvider,
 	)
 }
+
+func (f *envFactory) Inspector() EnvInspector {
+	return NewEnvInspector(
+		"EnvInspector",
+		f.deps.Logger,
+		f.deploymentStateService,
+		f.releaseManager,
+		f.cloudFactory,
+		f.agentClientFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		bideplmanifest.NewParser(f.deps.FS, f.deps.Logger),
+		bidepltpl.NewDeploymentTemplateFactory(f.deps.FS),
+		f.cpiInstaller,
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+	)
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// EnvInspector gives read access to the state of an environment,
+// to the CPI to compare that state with the IaaS and to the agent of its VM
+type EnvInspector interface {
+	State() (biconfig.DeploymentState, error)
+	DeploymentName() (string, error)
+	DeploymentManifest() (bideplmanifest.Manifest, error)
+	WithLockedState(fn func() error) error
+	WithCloud(stage biui.Stage, fn func(bicloud.Cloud) error) error
+	Agent() (EnvAgent, error)
+}
+
+func NewEnvInspector(
+	logTag string,
+	logger boshlog.Logger,
+	deploymentStateService biconfig.DeploymentStateService,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	agentClientFactory bihttpagent.AgentClientFactory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	deploymentParser bideplmanifest.Parser,
+	templateFactory bidepltpl.DeploymentTemplateFactory,
+	cpiInstaller bicpirel.CpiInstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+) EnvInspector {
+	return &envInspector{
+		logTag:                                  logTag,
+		logger:                                  logger,
+		deploymentStateService:                  deploymentStateService,
+		releaseManager:                          releaseManager,
+		cloudFactory:                            cloudFactory,
+		agentClientFactory:                      agentClientFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		deploymentParser:                        deploymentParser,
+		templateFactory:                         templateFactory,
+		cpiInstaller:                            cpiInstaller,
+		releaseFetcher:                          releaseFetcher,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+		tempRootConfigurator:                    tempRootConfigurator,
+		targetProvider:                          targetProvider,
+	}
+}
+
+type envInspector struct {
+	logTag                                  string
+	logger                                  boshlog.Logger
+	deploymentStateService                  biconfig.DeploymentStateService
+	releaseManager                          biinstall.ReleaseManager
+	cloudFactory                            bicloud.Factory
+	agentClientFactory                      bihttpagent.AgentClientFactory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	deploymentParser                        bideplmanifest.Parser
+	templateFactory                         bidepltpl.DeploymentTemplateFactory
+	cpiInstaller                            bicpirel.CpiInstaller
+	releaseFetcher                          biinstall.ReleaseFetcher
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	tempRootConfigurator                    TempRootConfigurator
+	targetProvider                          biinstall.TargetProvider
+}
+
+func (i *envInspector) State() (biconfig.DeploymentState, error) {
+	exists, err := i.deploymentStateService.Exists()
+	if err != nil {
+		return biconfig.DeploymentState{}, bosherr.WrapError(err, "Checking deployment state")
+	}
+
+	if !exists {
+		return biconfig.DeploymentState{}, bosherr.Errorf("Deployment state '%s' does not exist", i.deploymentStateService.Path())
+	}
+
+	deploymentState, err := i.deploymentStateService.Load()
+	if err != nil {
+		return biconfig.DeploymentState{}, bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	return deploymentState, nil
+}
+
+func (i *envInspector) DeploymentName() (string, error) {
+	deploymentManifest, err := i.DeploymentManifest()
+	if err != nil {
+		return "", err
+	}
+
+	return deploymentManifest.Name, nil
+}
+
+// DeploymentManifest parses the deployment manifest without validating it,
+// which would require all releases to be downloaded
+func (i *envInspector) DeploymentManifest() (bideplmanifest.Manifest, error) {
+	template, err := i.templateFactory.NewDeploymentTemplateFromPath(i.deploymentManifestPath)
+	if err != nil {
+		return bideplmanifest.Manifest{}, bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	interpolatedTemplate, err := template.Evaluate(i.deploymentVars, i.deploymentOp)
+	if err != nil {
+		return bideplmanifest.Manifest{}, bosherr.WrapErrorf(err, "Evaluating manifest '%s'", i.deploymentManifestPath)
+	}
+
+	deploymentManifest, err := i.deploymentParser.Parse(interpolatedTemplate, i.deploymentManifestPath)
+	if err != nil {
+		return bideplmanifest.Manifest{}, bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", i.deploymentManifestPath)
+	}
+
+	return deploymentManifest, nil
+}
+
+func (i *envInspector) WithLockedState(fn func() error) error {
+	err := i.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		err := i.deploymentStateService.Unlock()
+		if err != nil {
+			i.logger.Warn(i.logTag, "Unlocking deployment state: %s", err.Error())
+		}
+	}()
+
+	return fn()
+}
+
+func (i *envInspector) WithCloud(stage biui.Stage, fn func(bicloud.Cloud) error) error {
+	deploymentState, err := i.State()
+	if err != nil {
+		return err
+	}
+
+	target, err := i.targetProvider.NewTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	err = i.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), i.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := i.releaseManager.DeleteAll()
+		if err != nil {
+			i.logger.Warn(i.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	var installationManifest biinstallmanifest.Manifest
+
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = i.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(i.deploymentManifestPath, i.deploymentVars, i.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		cpiReleaseName := installationManifest.Template.Release
+		cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+		if !found {
+			return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+		}
+
+		err = i.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+		if err != nil {
+			return err
+		}
+
+		return i.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
+		return localCpiInstallation.WithRunningRegistry(i.logger, stage, func() error {
+			stemcellApiVersion := 1
+			for _, s := range deploymentState.Stemcells {
+				if deploymentState.CurrentStemcellID == s.ID {
+					stemcellApiVersion = s.ApiVersion
+					break
+				}
+			}
+
+			cloud, err := i.cloudFactory.NewCloud(localCpiInstallation, deploymentState.DirectorID, stemcellApiVersion)
+			if err != nil {
+				return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+			}
+
+			return fn(cloud)
+		})
+	})
+}
+
+// Agent reaches the agent of the first instance, which is the VM whose CID is recorded in the state,
+// without installing the CPI
+func (i *envInspector) Agent() (EnvAgent, error) {
+	deploymentState, err := i.State()
+	if err != nil {
+		return nil, err
+	}
+
+	_, installationManifest, err := i.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(i.deploymentManifestPath, i.deploymentVars, i.deploymentOp)
+	if err != nil {
+		return nil, err
+	}
+
+	agentClient, err := i.agentClientFactory.NewAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating agent client")
+	}
+
+	return NewEnvAgent(agentClient, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA, i.logger)
+}
@@ -0,0 +1,115 @@
+package cmd_test
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("EnvInspector", func() {
+	var (
+		fs           *fakesys.FakeFileSystem
+		stateService biconfig.DeploymentStateService
+		inspector    EnvInspector
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		stateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeuuid.NewFakeGenerator(), logger, "/path/to/state.json")
+
+		inspector = NewEnvInspector(
+			"EnvInspector",
+			logger,
+			stateService,
+			nil,
+			nil,
+			nil,
+			"/path/to/manifest.yml",
+			boshtpl.StaticVariables{"name": "fake-deployment"},
+			patch.Ops{},
+			bideplmanifest.NewParser(fs, logger),
+			bidepltpl.NewDeploymentTemplateFactory(fs),
+			bicpirel.CpiInstaller{},
+			biinstall.ReleaseFetcher{},
+			ReleaseSetAndInstallationManifestParser{},
+			nil,
+			nil,
+		)
+	})
+
+	Describe("State", func() {
+		It("loads the deployment state", func() {
+			Expect(stateService.Save(biconfig.DeploymentState{CurrentVMCID: "fake-vm-cid"})).To(Succeed())
+
+			state, err := inspector.State()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.CurrentVMCID).To(Equal("fake-vm-cid"))
+		})
+
+		It("returns an error when the deployment state does not exist", func() {
+			_, err := inspector.State()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/path/to/state.json' does not exist"))
+		})
+	})
+
+	Describe("DeploymentName", func() {
+		It("returns the name of the interpolated deployment manifest", func() {
+			Expect(fs.WriteFileString("/path/to/manifest.yml", "name: ((name))")).To(Succeed())
+
+			name, err := inspector.DeploymentName()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("fake-deployment"))
+		})
+
+		It("returns an error when the manifest cannot be read", func() {
+			_, err := inspector.DeploymentName()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+		})
+	})
+
+	Describe("DeploymentManifest", func() {
+		It("returns the manifest with update defaults", func() {
+			Expect(fs.WriteFileString("/path/to/manifest.yml", "name: ((name))")).To(Succeed())
+
+			manifest, err := inspector.DeploymentManifest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Name).To(Equal("fake-deployment"))
+			Expect(manifest.Update.UpdateWatchTime).To(Equal(bideplmanifest.WatchTime{Start: 0, End: 300000}))
+		})
+	})
+
+	Describe("Agent", func() {
+		It("returns an error when the deployment state does not exist", func() {
+			_, err := inspector.Agent()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/path/to/state.json' does not exist"))
+		})
+	})
+
+	Describe("WithLockedState", func() {
+		It("runs the function while the deployment state is locked", func() {
+			called := false
+
+			err := inspector.WithLockedState(func() error {
+				called = true
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(called).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// envJobs controls the jobs on the VM of the first instance of an environment
+// the same way create-env and delete-env do when updating or deleting it.
+// Only the first instance is reachable through the installation mbus URL,
+// jobs of other instances are not controlled.
+type envJobs struct {
+	agent           EnvAgent
+	vm              bivm.VM
+	instanceName    string
+	updateWatchTime bideplmanifest.WatchTime
+	timeService     clock.Clock
+	logger          boshlog.Logger
+}
+
+func newEnvJobs(inspector EnvInspector, timeService clock.Clock, logger boshlog.Logger) (envJobs, error) {
+	deploymentManifest, err := inspector.DeploymentManifest()
+	if err != nil {
+		return envJobs{}, err
+	}
+
+	if len(deploymentManifest.Jobs) == 0 {
+		return envJobs{}, bosherr.Errorf("Deployment manifest does not contain any jobs")
+	}
+
+	agent, err := inspector.Agent()
+	if err != nil {
+		return envJobs{}, err
+	}
+
+	// jobs are controlled through the agent only, the VM is not changed in the IaaS or the state
+	vm := bivm.NewVM("", nil, nil, nil, agent, nil, timeService, nil, logger)
+
+	return envJobs{
+		agent:           agent,
+		vm:              vm,
+		instanceName:    fmt.Sprintf("%s/0", deploymentManifest.Jobs[0].Name),
+		updateWatchTime: deploymentManifest.Update.UpdateWatchTime,
+		timeService:     timeService,
+		logger:          logger,
+	}, nil
+}
+
+func (j envJobs) Drain(stage boshui.Stage) error {
+	stepName := fmt.Sprintf("Draining jobs on instance '%s'", j.instanceName)
+	return stage.Perform(stepName, j.vm.Drain)
+}
+
+func (j envJobs) Stop(stage boshui.Stage) error {
+	stepName := fmt.Sprintf("Stopping jobs on instance '%s'", j.instanceName)
+	return stage.Perform(stepName, j.vm.Stop)
+}
+
+func (j envJobs) Start(stage boshui.Stage) error {
+	stepName := fmt.Sprintf("Starting jobs on instance '%s'", j.instanceName)
+	err := stage.Perform(stepName, j.vm.Start)
+	if err != nil {
+		return err
+	}
+
+	return j.waitUntilRunning(stage)
+}
+
+func (j envJobs) waitUntilRunning(stage boshui.Stage) error {
+	start := time.Duration(j.updateWatchTime.Start) * time.Millisecond
+	end := time.Duration(j.updateWatchTime.End) * time.Millisecond
+	delayBetweenAttempts := 1 * time.Second
+
+	stepName := fmt.Sprintf("Waiting for instance '%s' to be running", j.instanceName)
+	return stage.Perform(stepName, func() error {
+		j.timeService.Sleep(start)
+
+		// attempts are made until the end of the update watch time as measured by the same clock
+		getStateRetryable := biagentclient.NewGetStateRetryable(j.agent)
+		return boshretry.NewTimeoutRetryStrategy(end-start, delayBetweenAttempts, getStateRetryable, j.timeService, j.logger).Try()
+	})
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type EnvStateHistoryCmd struct {
+	ui      boshui.UI
+	history biconfig.DeploymentStateHistory
+}
+
+func NewEnvStateHistoryCmd(ui boshui.UI, history biconfig.DeploymentStateHistory) EnvStateHistoryCmd {
+	return EnvStateHistoryCmd{ui: ui, history: history}
+}
+
+func (c EnvStateHistoryCmd) Run() error {
+	snapshots, err := c.history.List()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "saved states",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Time"),
+			boshtbl.NewHeader("Manifest SHA"),
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("VM CID"),
+			boshtbl.NewHeader("Disk CIDs"),
+			boshtbl.NewHeader("Stemcell CID"),
+		},
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0},
+			{Column: 3, Asc: true}, // first instance has no name so that it is listed first
+		},
+	}
+
+	for _, snapshot := range snapshots {
+		vms := envVMs(snapshot.State)
+		if len(vms) == 0 {
+			vms = []envVM{{}}
+		}
+
+		for _, vm := range vms {
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				boshtbl.NewValueInt(snapshot.ID),
+				boshtbl.NewValueTime(snapshot.Timestamp),
+				boshtbl.NewValueString(snapshot.State.CurrentManifestSHA),
+				boshtbl.NewValueString(vm.instance),
+				boshtbl.NewValueString(vm.cid),
+				boshtbl.NewValueStrings(envDiskCIDs(snapshot.State, vm.diskIDs)),
+				boshtbl.NewValueString(snapshot.StemcellCID()),
+			})
+		}
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
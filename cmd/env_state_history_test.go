@@ -0,0 +1,116 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("EnvStateHistoryCmd", func() {
+	var (
+		historyPath string
+		timeService *fakeclock.FakeClock
+		history     biconfig.DeploymentStateHistory
+		ui          *fakeui.FakeUI
+		command     EnvStateHistoryCmd
+	)
+
+	BeforeEach(func() {
+		var err error
+		historyPath, err = ioutil.TempDir("", "env-state-history")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+		history = biconfig.NewFileSystemDeploymentStateHistory(historyPath, boshsys.NewOsFileSystem(logger), timeService, logger)
+
+		ui = &fakeui.FakeUI{}
+		command = NewEnvStateHistoryCmd(ui, history)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(historyPath)
+	})
+
+	It("lists every instance of saved states with their disks", func() {
+		Expect(history.Record(biconfig.DeploymentState{
+			CurrentManifestSHA:  "fake-manifest-sha",
+			CurrentVMCID:        "fake-vm-cid",
+			CurrentDiskID:       "fake-disk-id",
+			CurrentNamedDiskIDs: map[string]string{"fake-name": "fake-named-disk-id"},
+			CurrentStemcellID:   "fake-stemcell-id",
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+				{ID: "fake-named-disk-id", CID: "fake-named-disk-cid", Name: "fake-name"},
+				{ID: "fake-other-disk-id", CID: "fake-other-disk-cid"},
+			},
+			Stemcells: []biconfig.StemcellRecord{{ID: "fake-stemcell-id", CID: "fake-stemcell-cid"}},
+			Instances: []biconfig.InstanceRecord{
+				{Name: "fake-job", Index: 1, VMCID: "fake-other-vm-cid", DiskID: "fake-other-disk-id"},
+			},
+		})).To(Succeed())
+
+		timeService.Increment(time.Hour)
+		Expect(history.Record(biconfig.DeploymentState{})).To(Succeed())
+
+		err := command.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "saved states",
+
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("ID"),
+				boshtbl.NewHeader("Time"),
+				boshtbl.NewHeader("Manifest SHA"),
+				boshtbl.NewHeader("Instance"),
+				boshtbl.NewHeader("VM CID"),
+				boshtbl.NewHeader("Disk CIDs"),
+				boshtbl.NewHeader("Stemcell CID"),
+			},
+
+			SortBy: []boshtbl.ColumnSort{{Column: 0}, {Column: 3, Asc: true}},
+
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueInt(1),
+					boshtbl.NewValueTime(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)),
+					boshtbl.NewValueString("fake-manifest-sha"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString("fake-vm-cid"),
+					boshtbl.NewValueStrings([]string{"fake-disk-cid", "fake-named-disk-cid"}),
+					boshtbl.NewValueString("fake-stemcell-cid"),
+				},
+				{
+					boshtbl.NewValueInt(1),
+					boshtbl.NewValueTime(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)),
+					boshtbl.NewValueString("fake-manifest-sha"),
+					boshtbl.NewValueString("fake-job/1"),
+					boshtbl.NewValueString("fake-other-vm-cid"),
+					boshtbl.NewValueStrings([]string{"fake-other-disk-cid"}),
+					boshtbl.NewValueString("fake-stemcell-cid"),
+				},
+				{
+					boshtbl.NewValueInt(2),
+					boshtbl.NewValueTime(time.Date(2017, time.March, 1, 11, 0, 0, 0, time.UTC)),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueStrings(nil),
+					boshtbl.NewValueString(""),
+				},
+			},
+		}))
+	})
+})
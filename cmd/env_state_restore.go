@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type EnvStateRestoreCmd struct {
+	ui                     boshui.UI
+	deploymentStateService biconfig.DeploymentStateService
+	history                biconfig.DeploymentStateHistory
+	logger                 boshlog.Logger
+	logTag                 string
+}
+
+func NewEnvStateRestoreCmd(
+	ui boshui.UI,
+	deploymentStateService biconfig.DeploymentStateService,
+	history biconfig.DeploymentStateHistory,
+	logger boshlog.Logger,
+) EnvStateRestoreCmd {
+	return EnvStateRestoreCmd{
+		ui:                     ui,
+		deploymentStateService: deploymentStateService,
+		history:                history,
+		logger:                 logger,
+		logTag:                 "EnvStateRestoreCmd",
+	}
+}
+
+func (c EnvStateRestoreCmd) Run(opts EnvStateRestoreOpts) error {
+	snapshot, found, err := c.history.Find(opts.Args.ID)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return bosherr.Errorf("Saved state '%d' does not exist", opts.Args.ID)
+	}
+
+	c.ui.PrintLinef("Restoring state '%s' to saved state '%d' from '%s'",
+		c.deploymentStateService.Path(), snapshot.ID, snapshot.Timestamp.Format("2006-01-02 15:04:05 MST"))
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err := c.deploymentStateService.Unlock()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", err.Error())
+		}
+	}()
+
+	deploymentState := snapshot.State
+
+	// Keep the version of the current state so that remote backends accept the save
+	exists, err := c.deploymentStateService.Exists()
+	if err != nil {
+		return bosherr.WrapError(err, "Checking deployment state")
+	}
+
+	if exists {
+		currentState, err := c.deploymentStateService.Load()
+		if err != nil {
+			return bosherr.WrapError(err, "Loading current deployment state")
+		}
+
+		deploymentState.Version = currentState.Version
+	}
+
+	err = c.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving restored deployment state")
+	}
+
+	return nil
+}
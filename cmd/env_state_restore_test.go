@@ -0,0 +1,128 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("EnvStateRestoreCmd", func() {
+	var (
+		historyPath string
+		fs          *fakesys.FakeFileSystem
+		history     biconfig.DeploymentStateHistory
+		ui          *fakeui.FakeUI
+		command     EnvStateRestoreCmd
+		opts        EnvStateRestoreOpts
+	)
+
+	BeforeEach(func() {
+		var err error
+		historyPath, err = ioutil.TempDir("", "env-state-restore")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		timeService := fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+		history = biconfig.NewFileSystemDeploymentStateHistory(historyPath, boshsys.NewOsFileSystem(logger), timeService, logger)
+
+		fs = fakesys.NewFakeFileSystem()
+		stateService := biconfig.NewHistoryDeploymentStateService(
+			biconfig.NewFileSystemDeploymentStateService(fs, fakeuuid.NewFakeGenerator(), logger, "/path/to/state.json"),
+			history,
+			logger,
+		)
+
+		ui = &fakeui.FakeUI{}
+		command = NewEnvStateRestoreCmd(ui, stateService, history, logger)
+		opts = EnvStateRestoreOpts{Args: EnvStateRestoreArgs{Manifest: "/path/to/manifest.yml", ID: 1}}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(historyPath)
+	})
+
+	storedState := func() biconfig.DeploymentState {
+		contents, err := fs.ReadFile("/path/to/state.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var deploymentState biconfig.DeploymentState
+		Expect(json.Unmarshal(contents, &deploymentState)).To(Succeed())
+		return deploymentState
+	}
+
+	Context("when the saved state exists", func() {
+		BeforeEach(func() {
+			Expect(history.Record(biconfig.DeploymentState{DirectorID: "fake-director-id", CurrentVMCID: "fake-old-vm-cid"})).To(Succeed())
+			Expect(fs.WriteFileString("/path/to/state.json", `{"director_id":"fake-director-id","current_vm_cid":"fake-new-vm-cid","version":4}`)).To(Succeed())
+		})
+
+		It("overwrites the current state with the saved state", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.AskedConfirmationCalled).To(BeTrue())
+			Expect(storedState()).To(Equal(biconfig.DeploymentState{
+				DirectorID:   "fake-director-id",
+				CurrentVMCID: "fake-old-vm-cid",
+				Version:      4,
+			}))
+		})
+
+		It("records the restored state as the latest saved state", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			snapshot, found, err := history.Find(2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(snapshot.State.CurrentVMCID).To(Equal("fake-old-vm-cid"))
+		})
+
+		It("releases the state lock", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/path/to/state.json.lock")).To(BeFalse())
+		})
+
+		It("does not restore the state when confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("stop"))
+
+			Expect(storedState().CurrentVMCID).To(Equal("fake-new-vm-cid"))
+		})
+
+		It("returns an error when the state is locked", func() {
+			Expect(fs.WriteFileString("/path/to/state.json.lock", "123@other-host")).To(Succeed())
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is locked by '123@other-host'"))
+
+			Expect(storedState().CurrentVMCID).To(Equal("fake-new-vm-cid"))
+		})
+	})
+
+	It("returns an error when the saved state does not exist", func() {
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Saved state '1' does not exist"))
+	})
+})
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type EnvStatusCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+}
+
+func NewEnvStatusCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector) *EnvStatusCmd {
+	return &EnvStatusCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *EnvStatusCmd) Run(opts EnvStatusOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	deploymentName, err := inspector.DeploymentName()
+	if err != nil {
+		return err
+	}
+
+	state, err := inspector.State()
+	if err != nil {
+		return err
+	}
+
+	agent, err := inspector.Agent()
+	if err != nil {
+		return err
+	}
+
+	info, err := agent.State()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting state of agent on VM '%s'", state.CurrentVMCID)
+	}
+
+	// CIDs are shown as recorded in the state, which is what create-env and delete-env act on
+	info.VMID = state.CurrentVMCID
+
+	info.DiskIDs = envDiskCIDs(state, envInstanceDiskIDs(state.CurrentDiskID, state.CurrentNamedDiskIDs))
+
+	instTable := InstanceTable{
+		Processes: true,
+		Details:   opts.Details,
+		Vitals:    true,
+	}
+
+	row := instTable.AsValues(instTable.ForVMInfo(info))
+
+	section := boshtbl.Section{
+		FirstColumn: row[0],
+		Rows:        [][]boshtbl.Value{row},
+	}
+
+	for _, p := range info.Processes {
+		section.Rows = append(section.Rows, instTable.AsValues(instTable.ForProcess(p)))
+	}
+
+	table := boshtbl.Table{
+		Title: fmt.Sprintf("Deployment '%s'", deploymentName),
+
+		Content: "instances",
+
+		Header: instTable.Headers(),
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+			{Column: 1, Asc: true}, // sort by process so that VM row is first
+		},
+
+		Sections: []boshtbl.Section{section},
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EnvStatusCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		mockAgent     *mock_cmd.MockEnvAgent
+		ui            *fakeui.FakeUI
+		command       *EnvStatusCmd
+		opts          EnvStatusOpts
+		statePath     string
+		info          boshdir.VMInfo
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		mockAgent = mock_cmd.NewMockEnvAgent(mockCtrl)
+		ui = &fakeui.FakeUI{}
+
+		envProvider := func(manifestPath string, statePath_ string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			statePath = statePath_
+			return mockInspector
+		}
+
+		command = NewEnvStatusCmd(ui, envProvider)
+
+		opts = EnvStatusOpts{
+			Args:      EnvStatusArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		index := 0
+
+		info = boshdir.VMInfo{
+			AgentID:      "fake-agent-id",
+			JobName:      "fake-job",
+			ID:           "fake-id",
+			Index:        &index,
+			ProcessState: "running",
+			Bootstrap:    true,
+			IPs:          []string{"10.0.0.6"},
+			Processes: []boshdir.VMInfoProcess{
+				{Name: "fake-process", State: "running"},
+				{Name: "fake-failing-process", State: "failing"},
+			},
+			Vitals: boshdir.VMInfoVitals{
+				Load: []string{"0.01", "0.02", "0.03"},
+			},
+		}
+
+		mockInspector.EXPECT().DeploymentName().Return("fake-deployment", nil).AnyTimes()
+
+		mockInspector.EXPECT().State().Return(biconfig.DeploymentState{
+			CurrentVMCID:        "fake-vm-cid",
+			CurrentDiskID:       "fake-disk-id",
+			CurrentNamedDiskIDs: map[string]string{"fake-name": "fake-named-disk-id"},
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+				{ID: "fake-named-disk-id", CID: "fake-named-disk-cid", Name: "fake-name"},
+			},
+		}, nil).AnyTimes()
+
+		mockInspector.EXPECT().Agent().Return(mockAgent, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("shows processes and vitals reported by the agent", func() {
+		mockAgent.EXPECT().State().Return(info, nil)
+
+		err := command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statePath).To(Equal("/fake-state.json"))
+
+		instTable := InstanceTable{Processes: true, Vitals: true}
+
+		info.VMID = "fake-vm-cid"
+		info.DiskIDs = []string{"fake-disk-cid", "fake-named-disk-cid"}
+
+		Expect(ui.Table.Title).To(Equal("Deployment 'fake-deployment'"))
+		Expect(ui.Table.Content).To(Equal("instances"))
+		Expect(ui.Table.Header).To(Equal(instTable.Headers()))
+		Expect(ui.Table.Sections).To(Equal([]boshtbl.Section{
+			{
+				FirstColumn: boshtbl.NewValueString("fake-job/fake-id"),
+				Rows: [][]boshtbl.Value{
+					instTable.AsValues(instTable.ForVMInfo(info)),
+					instTable.AsValues(instTable.ForProcess(info.Processes[0])),
+					instTable.AsValues(instTable.ForProcess(info.Processes[1])),
+				},
+			},
+		}))
+	})
+
+	It("shows CIDs recorded in the state when details are requested", func() {
+		opts.Details = true
+
+		mockAgent.EXPECT().State().Return(info, nil)
+
+		err := command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Table.Header).To(ContainElement(boshtbl.NewHeader("VM CID")))
+		Expect(ui.Table.Sections[0].Rows[0]).To(ContainElement(boshtbl.NewValueString("fake-vm-cid")))
+		Expect(ui.Table.Sections[0].Rows[0]).To(ContainElement(boshtbl.NewValueStrings([]string{"fake-disk-cid", "fake-named-disk-cid"})))
+	})
+
+	It("returns an error when the agent state cannot be fetched", func() {
+		mockAgent.EXPECT().State().Return(boshdir.VMInfo{}, errors.New("fake-agent-err"))
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Getting state of agent on VM 'fake-vm-cid'"))
+		Expect(err.Error()).To(ContainSubstring("fake-agent-err"))
+		Expect(ui.Tables).To(BeEmpty())
+	})
+})
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type EnvVMsCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+}
+
+func NewEnvVMsCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector) *EnvVMsCmd {
+	return &EnvVMsCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *EnvVMsCmd) Run(stage boshui.Stage, opts EnvVMsOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	state, err := inspector.State()
+	if err != nil {
+		return err
+	}
+
+	vms := envVMs(state)
+
+	found := map[string]bool{}
+
+	if opts.Check {
+		err = inspector.WithCloud(stage, func(cloud bicloud.Cloud) error {
+			for _, vm := range vms {
+				exists, err := cloud.HasVM(vm.cid)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Checking existence of VM '%s'", vm.cid)
+				}
+
+				found[vm.cid] = exists
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	table := boshtbl.Table{
+		Content: "vms",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("VM CID"),
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Disk CIDs"),
+			boshtbl.NewHeader("Stemcell"),
+			boshtbl.NewHeader("Releases"),
+		},
+	}
+
+	if opts.Check {
+		table.Header = append(table.Header, boshtbl.NewHeader("In IaaS"))
+	}
+
+	stemcell := envCurrentStemcell(state)
+	releases := envCurrentReleases(state)
+
+	var missing int
+
+	for _, vm := range vms {
+		row := []boshtbl.Value{
+			boshtbl.NewValueString(vm.cid),
+			boshtbl.NewValueString(vm.instance),
+			boshtbl.NewValueStrings(envDiskCIDs(state, vm.diskIDs)),
+			boshtbl.NewValueString(stemcell),
+			boshtbl.NewValueStrings(releases),
+		}
+
+		if opts.Check {
+			row = append(row, boshtbl.NewValueFmt(boshtbl.NewValueBool(found[vm.cid]), !found[vm.cid]))
+
+			if !found[vm.cid] {
+				missing++
+			}
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	c.ui.PrintTable(table)
+
+	if missing > 0 {
+		return bosherr.Errorf("Deployment state references %d VM(s) missing from the IaaS", missing)
+	}
+
+	return nil
+}
+
+type envVM struct {
+	cid      string
+	instance string
+	diskIDs  []string
+}
+
+// envVMs returns the VM of the first instance, which is tracked separately, followed by the VMs of other instances
+func envVMs(state biconfig.DeploymentState) []envVM {
+	var vms []envVM
+
+	if state.CurrentVMCID != "" {
+		vms = append(vms, envVM{
+			cid:     state.CurrentVMCID,
+			diskIDs: envInstanceDiskIDs(state.CurrentDiskID, state.CurrentNamedDiskIDs),
+		})
+	}
+
+	for _, instance := range state.Instances {
+		if instance.VMCID == "" {
+			continue
+		}
+
+		vms = append(vms, envVM{
+			cid:      instance.VMCID,
+			instance: fmt.Sprintf("%s/%d", instance.Name, instance.Index),
+			diskIDs:  envInstanceDiskIDs(instance.DiskID, instance.NamedDiskIDs),
+		})
+	}
+
+	return vms
+}
+
+// envInstanceDiskIDs returns the default persistent disk of an instance followed by its named disks sorted by name
+func envInstanceDiskIDs(diskID string, namedDiskIDs map[string]string) []string {
+	var diskIDs []string
+
+	if diskID != "" {
+		diskIDs = append(diskIDs, diskID)
+	}
+
+	var diskNames []string
+	for name := range namedDiskIDs {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+
+	for _, name := range diskNames {
+		diskIDs = append(diskIDs, namedDiskIDs[name])
+	}
+
+	return diskIDs
+}
+
+func envDiskCIDs(state biconfig.DeploymentState, diskIDs []string) []string {
+	var diskCIDs []string
+
+	for _, diskID := range diskIDs {
+		for _, disk := range state.Disks {
+			if disk.ID == diskID {
+				diskCIDs = append(diskCIDs, disk.CID)
+			}
+		}
+	}
+
+	return diskCIDs
+}
+
+func envCurrentStemcell(state biconfig.DeploymentState) string {
+	for _, stemcell := range state.Stemcells {
+		if stemcell.ID == state.CurrentStemcellID {
+			return fmt.Sprintf("%s/%s", stemcell.Name, stemcell.Version)
+		}
+	}
+
+	return ""
+}
+
+func envCurrentReleases(state biconfig.DeploymentState) []string {
+	var releases []string
+
+	for _, id := range state.CurrentReleaseIDs {
+		for _, release := range state.Releases {
+			if release.ID == id {
+				releases = append(releases, fmt.Sprintf("%s/%s", release.Name, release.Version))
+			}
+		}
+	}
+
+	return releases
+}
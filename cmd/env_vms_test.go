@@ -0,0 +1,166 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EnvVMsCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		fakeCloud     *fakebicloud.FakeCloud
+		ui            *fakeui.FakeUI
+		fakeStage     *fakeui.FakeStage
+		command       *EnvVMsCmd
+		opts          EnvVMsOpts
+		statePath     string
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		fakeCloud = fakebicloud.NewFakeCloud()
+		ui = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider := func(manifestPath string, statePath_ string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			statePath = statePath_
+			return mockInspector
+		}
+
+		command = NewEnvVMsCmd(ui, envProvider)
+
+		opts = EnvVMsOpts{
+			Args:      EnvVMsArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		mockInspector.EXPECT().State().Return(biconfig.DeploymentState{
+			CurrentVMCID:  "fake-vm-cid",
+			CurrentDiskID: "fake-disk-id",
+			CurrentNamedDiskIDs: map[string]string{
+				"fake-name-2": "fake-named-disk-id-2",
+				"fake-name-1": "fake-named-disk-id-1",
+			},
+			CurrentStemcellID: "fake-stemcell-id",
+			CurrentReleaseIDs: []string{"fake-release-id"},
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+				{ID: "fake-other-disk-id", CID: "fake-other-disk-cid"},
+				{ID: "fake-named-disk-id-1", CID: "fake-named-disk-cid-1", Name: "fake-name-1"},
+				{ID: "fake-named-disk-id-2", CID: "fake-named-disk-cid-2", Name: "fake-name-2"},
+				{ID: "fake-other-named-disk-id", CID: "fake-other-named-disk-cid", Name: "fake-name-1"},
+			},
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell", Version: "1", CID: "fake-stemcell-cid"},
+			},
+			Releases: []biconfig.ReleaseRecord{
+				{ID: "fake-release-id", Name: "fake-release", Version: "2"},
+			},
+			Instances: []biconfig.InstanceRecord{
+				{
+					Name:         "fake-job",
+					Index:        1,
+					VMCID:        "fake-other-vm-cid",
+					DiskID:       "fake-other-disk-id",
+					NamedDiskIDs: map[string]string{"fake-name-1": "fake-other-named-disk-id"},
+				},
+			},
+		}, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("lists VMs tracked by the state with their default and named disks", func() {
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statePath).To(Equal("/fake-state.json"))
+
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "vms",
+
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("VM CID"),
+				boshtbl.NewHeader("Instance"),
+				boshtbl.NewHeader("Disk CIDs"),
+				boshtbl.NewHeader("Stemcell"),
+				boshtbl.NewHeader("Releases"),
+			},
+
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-vm-cid"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueStrings([]string{"fake-disk-cid", "fake-named-disk-cid-1", "fake-named-disk-cid-2"}),
+					boshtbl.NewValueString("fake-stemcell/1"),
+					boshtbl.NewValueStrings([]string{"fake-release/2"}),
+				},
+				{
+					boshtbl.NewValueString("fake-other-vm-cid"),
+					boshtbl.NewValueString("fake-job/1"),
+					boshtbl.NewValueStrings([]string{"fake-other-disk-cid", "fake-other-named-disk-cid"}),
+					boshtbl.NewValueString("fake-stemcell/1"),
+					boshtbl.NewValueStrings([]string{"fake-release/2"}),
+				},
+			},
+		}))
+	})
+
+	Context("when checking VMs against the IaaS", func() {
+		BeforeEach(func() {
+			opts.Check = true
+
+			mockInspector.EXPECT().WithCloud(fakeStage, gomock.Any()).DoAndReturn(
+				func(_ interface{}, fn func(bicloud.Cloud) error) error {
+					return fn(fakeCloud)
+				},
+			)
+		})
+
+		It("shows whether VMs exist", func() {
+			fakeCloud.HasVMFound = true
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Header[5]).To(Equal(boshtbl.NewHeader("In IaaS")))
+			Expect(ui.Table.Rows[0][5]).To(Equal(boshtbl.NewValueFmt(boshtbl.NewValueBool(true), false)))
+		})
+
+		It("returns an error when VMs are missing", func() {
+			fakeCloud.HasVMFound = false
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state references 2 VM(s) missing from the IaaS"))
+
+			Expect(ui.Table.Rows[1][5]).To(Equal(boshtbl.NewValueFmt(boshtbl.NewValueBool(false), true)))
+		})
+
+		It("returns an error when checking a VM fails", func() {
+			fakeCloud.HasVMErr = errors.New("fake-has-vm-err")
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking existence of VM 'fake-vm-cid'"))
+			Expect(err.Error()).To(ContainSubstring("fake-has-vm-err"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -390,6 +391,11 @@ var _ = Describe("Factory", func() {
 			boshOpts.UpdateConfig = UpdateConfigOpts{}
 			boshOpts.DeleteConfig = DeleteConfigOpts{}
 			boshOpts.Curl = CurlOpts{}
+			boshOpts.CreateEnv = CreateEnvOpts{}
+			boshOpts.DeleteEnv = DeleteEnvOpts{}
+			boshOpts.EnvVMs = EnvVMsOpts{}
+			boshOpts.EnvDisks = EnvDisksOpts{}
+			boshOpts.CleanUpEnv = CleanUpEnvOpts{}
 			return boshOpts
 		}
 
@@ -399,8 +405,9 @@ var _ = Describe("Factory", func() {
 
 			// Check against entire BoshOpts to avoid future missing assertions
 			Expect(clearNonGlobalOpts(cmd.BoshOpts)).To(Equal(BoshOpts{
-				ConfigPathOpt: "~/.bosh/config",
-				Parallel:      5,
+				ConfigPathOpt:       "~/.bosh/config",
+				Parallel:            5,
+				TaskPollIntervalOpt: 500 * time.Millisecond,
 			}))
 		})
 
@@ -417,6 +424,8 @@ var _ = Describe("Factory", func() {
 				"--no-color",
 				"--non-interactive",
 				"--parallel", "123",
+				"--task-poll-interval", "2s",
+				"--task-timeout", "1h",
 				"locks",
 			}
 
@@ -424,17 +433,19 @@ var _ = Describe("Factory", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(clearNonGlobalOpts(cmd.BoshOpts)).To(Equal(BoshOpts{
-				ConfigPathOpt:     "config",
-				EnvironmentOpt:    "env",
-				CACertOpt:         CACertArg{Content: "BEGIN ca-cert"},
-				ClientOpt:         "client",
-				ClientSecretOpt:   "client-secret",
-				DeploymentOpt:     "dep",
-				JSONOpt:           true,
-				TTYOpt:            true,
-				NoColorOpt:        true,
-				NonInteractiveOpt: true,
-				Parallel:          123,
+				ConfigPathOpt:       "config",
+				EnvironmentOpt:      "env",
+				CACertOpt:           CACertArg{Content: "BEGIN ca-cert"},
+				ClientOpt:           "client",
+				ClientSecretOpt:     "client-secret",
+				DeploymentOpt:       "dep",
+				JSONOpt:             true,
+				TTYOpt:              true,
+				NoColorOpt:          true,
+				NonInteractiveOpt:   true,
+				Parallel:            123,
+				TaskPollIntervalOpt: 2 * time.Second,
+				TaskTimeoutOpt:      time.Hour,
 			}))
 		})
 
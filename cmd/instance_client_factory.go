@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"net"
+	"net/url"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+)
+
+// instanceClientFactory reaches additional instances through the installation mbus URL
+// with its host replaced by the address of the instance.
+type instanceClientFactory struct {
+	directorID           string
+	installationManifest biinstallmanifest.Manifest
+	agentClientFactory   bihttpagent.AgentClientFactory
+	blobstoreFactory     biblobstore.Factory
+	sharedBlobstore      biblobstore.Blobstore
+}
+
+func newInstanceClientFactory(
+	directorID string,
+	installationManifest biinstallmanifest.Manifest,
+	agentClientFactory bihttpagent.AgentClientFactory,
+	blobstoreFactory biblobstore.Factory,
+	sharedBlobstore biblobstore.Blobstore,
+) bidepl.InstanceClientFactory {
+	return instanceClientFactory{
+		directorID:           directorID,
+		installationManifest: installationManifest,
+		agentClientFactory:   agentClientFactory,
+		blobstoreFactory:     blobstoreFactory,
+		sharedBlobstore:      sharedBlobstore,
+	}
+}
+
+func (f instanceClientFactory) NewAgentClient(address string) (biagentclient.AgentClient, error) {
+	mbusURL, err := f.instanceMbusURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.agentClientFactory.NewAgentClient(f.directorID, mbusURL, f.installationManifest.Cert.CA)
+}
+
+func (f instanceClientFactory) NewBlobstore(address string) (biblobstore.Blobstore, error) {
+	if !f.installationManifest.Blobstore.IsDAV() {
+		return f.sharedBlobstore, nil
+	}
+
+	mbusURL, err := f.instanceMbusURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.blobstoreFactory.Create(mbusURL, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+}
+
+func (f instanceClientFactory) instanceMbusURL(address string) (string, error) {
+	parsedURL, err := url.Parse(f.installationManifest.Mbus)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing mbus URL")
+	}
+
+	if port := parsedURL.Port(); port != "" {
+		parsedURL.Host = net.JoinHostPort(address, port)
+	} else {
+		parsedURL.Host = address
+	}
+
+	return parsedURL.String(), nil
+}
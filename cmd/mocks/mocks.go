@@ -1,10 +1,17 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter)
+// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter,EnvAgent,EnvInspector)
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	agentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	applyspec "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	cloud "github.com/cloudfoundry/bosh-cli/cloud"
+	cmd "github.com/cloudfoundry/bosh-cli/cmd"
+	config "github.com/cloudfoundry/bosh-cli/config"
+	manifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	director "github.com/cloudfoundry/bosh-cli/director"
 	ui "github.com/cloudfoundry/bosh-cli/ui"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -44,3 +51,336 @@ func (m *MockDeploymentDeleter) DeleteDeployment(arg0 bool, arg1 ui.Stage) error
 func (mr *MockDeploymentDeleterMockRecorder) DeleteDeployment(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeployment", reflect.TypeOf((*MockDeploymentDeleter)(nil).DeleteDeployment), arg0, arg1)
 }
+
+// MockEnvAgent is a mock of EnvAgent interface
+type MockEnvAgent struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvAgentMockRecorder
+}
+
+// MockEnvAgentMockRecorder is the mock recorder for MockEnvAgent
+type MockEnvAgentMockRecorder struct {
+	mock *MockEnvAgent
+}
+
+// NewMockEnvAgent creates a new mock instance
+func NewMockEnvAgent(ctrl *gomock.Controller) *MockEnvAgent {
+	mock := &MockEnvAgent{ctrl: ctrl}
+	mock.recorder = &MockEnvAgentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEnvAgent) EXPECT() *MockEnvAgentMockRecorder {
+	return m.recorder
+}
+
+// AddPersistentDisk mocks base method
+func (m *MockEnvAgent) AddPersistentDisk(arg0 string, arg1 interface{}) error {
+	ret := m.ctrl.Call(m, "AddPersistentDisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPersistentDisk indicates an expected call of AddPersistentDisk
+func (mr *MockEnvAgentMockRecorder) AddPersistentDisk(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPersistentDisk", reflect.TypeOf((*MockEnvAgent)(nil).AddPersistentDisk), arg0, arg1)
+}
+
+// Apply mocks base method
+func (m *MockEnvAgent) Apply(arg0 applyspec.ApplySpec) error {
+	ret := m.ctrl.Call(m, "Apply", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Apply indicates an expected call of Apply
+func (mr *MockEnvAgentMockRecorder) Apply(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockEnvAgent)(nil).Apply), arg0)
+}
+
+// CompilePackage mocks base method
+func (m *MockEnvAgent) CompilePackage(packageSource agentclient.BlobRef, compiledPackageDependencies []agentclient.BlobRef) (agentclient.BlobRef, error) {
+	ret := m.ctrl.Call(m, "CompilePackage", packageSource, compiledPackageDependencies)
+	ret0, _ := ret[0].(agentclient.BlobRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompilePackage indicates an expected call of CompilePackage
+func (mr *MockEnvAgentMockRecorder) CompilePackage(packageSource, compiledPackageDependencies interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompilePackage", reflect.TypeOf((*MockEnvAgent)(nil).CompilePackage), packageSource, compiledPackageDependencies)
+}
+
+// DeleteARPEntries mocks base method
+func (m *MockEnvAgent) DeleteARPEntries(ips []string) error {
+	ret := m.ctrl.Call(m, "DeleteARPEntries", ips)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteARPEntries indicates an expected call of DeleteARPEntries
+func (mr *MockEnvAgentMockRecorder) DeleteARPEntries(ips interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteARPEntries", reflect.TypeOf((*MockEnvAgent)(nil).DeleteARPEntries), ips)
+}
+
+// Drain mocks base method
+func (m *MockEnvAgent) Drain(arg0 string) (int64, error) {
+	ret := m.ctrl.Call(m, "Drain", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Drain indicates an expected call of Drain
+func (mr *MockEnvAgentMockRecorder) Drain(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drain", reflect.TypeOf((*MockEnvAgent)(nil).Drain), arg0)
+}
+
+// GetState mocks base method
+func (m *MockEnvAgent) GetState() (agentclient.AgentState, error) {
+	ret := m.ctrl.Call(m, "GetState")
+	ret0, _ := ret[0].(agentclient.AgentState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetState indicates an expected call of GetState
+func (mr *MockEnvAgentMockRecorder) GetState() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockEnvAgent)(nil).GetState))
+}
+
+// ListDisk mocks base method
+func (m *MockEnvAgent) ListDisk() ([]string, error) {
+	ret := m.ctrl.Call(m, "ListDisk")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisk indicates an expected call of ListDisk
+func (mr *MockEnvAgentMockRecorder) ListDisk() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisk", reflect.TypeOf((*MockEnvAgent)(nil).ListDisk))
+}
+
+// MigrateDisk mocks base method
+func (m *MockEnvAgent) MigrateDisk() error {
+	ret := m.ctrl.Call(m, "MigrateDisk")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateDisk indicates an expected call of MigrateDisk
+func (mr *MockEnvAgentMockRecorder) MigrateDisk() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateDisk", reflect.TypeOf((*MockEnvAgent)(nil).MigrateDisk))
+}
+
+// MountDisk mocks base method
+func (m *MockEnvAgent) MountDisk(arg0 string) error {
+	ret := m.ctrl.Call(m, "MountDisk", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MountDisk indicates an expected call of MountDisk
+func (mr *MockEnvAgentMockRecorder) MountDisk(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MountDisk", reflect.TypeOf((*MockEnvAgent)(nil).MountDisk), arg0)
+}
+
+// Ping mocks base method
+func (m *MockEnvAgent) Ping() (string, error) {
+	ret := m.ctrl.Call(m, "Ping")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ping indicates an expected call of Ping
+func (mr *MockEnvAgentMockRecorder) Ping() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockEnvAgent)(nil).Ping))
+}
+
+// RemovePersistentDisk mocks base method
+func (m *MockEnvAgent) RemovePersistentDisk(arg0 string) error {
+	ret := m.ctrl.Call(m, "RemovePersistentDisk", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePersistentDisk indicates an expected call of RemovePersistentDisk
+func (mr *MockEnvAgentMockRecorder) RemovePersistentDisk(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePersistentDisk", reflect.TypeOf((*MockEnvAgent)(nil).RemovePersistentDisk), arg0)
+}
+
+// RunScript mocks base method
+func (m *MockEnvAgent) RunScript(scriptName string, options map[string]interface{}) error {
+	ret := m.ctrl.Call(m, "RunScript", scriptName, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunScript indicates an expected call of RunScript
+func (mr *MockEnvAgentMockRecorder) RunScript(scriptName, options interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunScript", reflect.TypeOf((*MockEnvAgent)(nil).RunScript), scriptName, options)
+}
+
+// Start mocks base method
+func (m *MockEnvAgent) Start() error {
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start
+func (mr *MockEnvAgentMockRecorder) Start() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockEnvAgent)(nil).Start))
+}
+
+// State mocks base method
+func (m *MockEnvAgent) State() (director.VMInfo, error) {
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(director.VMInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// State indicates an expected call of State
+func (mr *MockEnvAgentMockRecorder) State() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockEnvAgent)(nil).State))
+}
+
+// Stop mocks base method
+func (m *MockEnvAgent) Stop() error {
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockEnvAgentMockRecorder) Stop() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockEnvAgent)(nil).Stop))
+}
+
+// SyncDNS mocks base method
+func (m *MockEnvAgent) SyncDNS(blobID, sha1 string, version uint64) (string, error) {
+	ret := m.ctrl.Call(m, "SyncDNS", blobID, sha1, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncDNS indicates an expected call of SyncDNS
+func (mr *MockEnvAgentMockRecorder) SyncDNS(blobID, sha1, version interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDNS", reflect.TypeOf((*MockEnvAgent)(nil).SyncDNS), blobID, sha1, version)
+}
+
+// UnmountDisk mocks base method
+func (m *MockEnvAgent) UnmountDisk(arg0 string) error {
+	ret := m.ctrl.Call(m, "UnmountDisk", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnmountDisk indicates an expected call of UnmountDisk
+func (mr *MockEnvAgentMockRecorder) UnmountDisk(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmountDisk", reflect.TypeOf((*MockEnvAgent)(nil).UnmountDisk), arg0)
+}
+
+// MockEnvInspector is a mock of EnvInspector interface
+type MockEnvInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvInspectorMockRecorder
+}
+
+// MockEnvInspectorMockRecorder is the mock recorder for MockEnvInspector
+type MockEnvInspectorMockRecorder struct {
+	mock *MockEnvInspector
+}
+
+// NewMockEnvInspector creates a new mock instance
+func NewMockEnvInspector(ctrl *gomock.Controller) *MockEnvInspector {
+	mock := &MockEnvInspector{ctrl: ctrl}
+	mock.recorder = &MockEnvInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEnvInspector) EXPECT() *MockEnvInspectorMockRecorder {
+	return m.recorder
+}
+
+// Agent mocks base method
+func (m *MockEnvInspector) Agent() (cmd.EnvAgent, error) {
+	ret := m.ctrl.Call(m, "Agent")
+	ret0, _ := ret[0].(cmd.EnvAgent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Agent indicates an expected call of Agent
+func (mr *MockEnvInspectorMockRecorder) Agent() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Agent", reflect.TypeOf((*MockEnvInspector)(nil).Agent))
+}
+
+// DeploymentManifest mocks base method
+func (m *MockEnvInspector) DeploymentManifest() (manifest.Manifest, error) {
+	ret := m.ctrl.Call(m, "DeploymentManifest")
+	ret0, _ := ret[0].(manifest.Manifest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeploymentManifest indicates an expected call of DeploymentManifest
+func (mr *MockEnvInspectorMockRecorder) DeploymentManifest() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeploymentManifest", reflect.TypeOf((*MockEnvInspector)(nil).DeploymentManifest))
+}
+
+// DeploymentName mocks base method
+func (m *MockEnvInspector) DeploymentName() (string, error) {
+	ret := m.ctrl.Call(m, "DeploymentName")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeploymentName indicates an expected call of DeploymentName
+func (mr *MockEnvInspectorMockRecorder) DeploymentName() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeploymentName", reflect.TypeOf((*MockEnvInspector)(nil).DeploymentName))
+}
+
+// State mocks base method
+func (m *MockEnvInspector) State() (config.DeploymentState, error) {
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(config.DeploymentState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// State indicates an expected call of State
+func (mr *MockEnvInspectorMockRecorder) State() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockEnvInspector)(nil).State))
+}
+
+// WithCloud mocks base method
+func (m *MockEnvInspector) WithCloud(arg0 ui.Stage, arg1 func(cloud.Cloud) error) error {
+	ret := m.ctrl.Call(m, "WithCloud", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithCloud indicates an expected call of WithCloud
+func (mr *MockEnvInspectorMockRecorder) WithCloud(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCloud", reflect.TypeOf((*MockEnvInspector)(nil).WithCloud), arg0, arg1)
+}
+
+// WithLockedState mocks base method
+func (m *MockEnvInspector) WithLockedState(arg0 func() error) error {
+	ret := m.ctrl.Call(m, "WithLockedState", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithLockedState indicates an expected call of WithLockedState
+func (mr *MockEnvInspectorMockRecorder) WithLockedState(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLockedState", reflect.TypeOf((*MockEnvInspector)(nil).WithLockedState), arg0)
+}
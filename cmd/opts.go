@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -20,6 +22,9 @@ type BoshOpts struct {
 	Sha2           bool      `long:"sha2"                  description:"Use SHA256 checksums" env:"BOSH_SHA2"`
 	Parallel       int       `long:"parallel" description:"The max number of parallel operations" default:"5"`
 
+	TaskPollIntervalOpt time.Duration `long:"task-poll-interval" description:"Initial interval between checks of director task state, increased while task has no new output" default:"500ms"`
+	TaskTimeoutOpt      time.Duration `long:"task-timeout" description:"Cancel director tasks started by the command that do not finish within given duration (e.g. 2h)"`
+
 	// Hidden
 	UsernameOpt string `long:"user" hidden:"true" env:"BOSH_USER"`
 
@@ -32,6 +37,7 @@ type BoshOpts struct {
 	// Output formatting
 	ColumnOpt         []ColumnOpt `long:"column"                    description:"Filter to show only given column(s)"`
 	JSONOpt           bool        `long:"json"                      description:"Output as JSON"`
+	JSONEventsOpt     bool        `long:"json-events"               description:"Stream create-env and delete-env stages as JSON lines to stderr"`
 	TTYOpt            bool        `long:"tty"                       description:"Force TTY-like output"`
 	NoColorOpt        bool        `long:"no-color"                  description:"Toggle colorized output"`
 	NonInteractiveOpt bool        `long:"non-interactive" short:"n" description:"Don't ask for user input" env:"BOSH_NON_INTERACTIVE"`
@@ -48,6 +54,17 @@ type BoshOpts struct {
 	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
 	UnaliasEnv   UnaliasEnvOpts   `command:"unalias-env"              description:"Remove an aliased environment"`
 
+	EnvStateHistory EnvStateHistoryOpts `command:"env-state-history" description:"List saved versions of BOSH environment state"`
+	EnvStateRestore EnvStateRestoreOpts `command:"env-state-restore" description:"Restore saved version of BOSH environment state"`
+	ValidateEnv     ValidateEnvOpts     `command:"validate-env" description:"Validate BOSH environment manifest without creating anything"`
+	EnvVMs          EnvVMsOpts          `command:"env-vms" description:"List VMs tracked by BOSH environment state"`
+	EnvDisks        EnvDisksOpts        `command:"env-disks" description:"List disks tracked by BOSH environment state"`
+	CleanUpEnv      CleanUpEnvOpts      `command:"clean-up-env" description:"Delete VMs and disks of BOSH environment missing from its state"`
+	EnvStatus       EnvStatusOpts       `command:"env-status" description:"Show agent, process states and vitals of BOSH environment VM"`
+	StopEnv         StopEnvOpts         `command:"stop-env" description:"Stop jobs on first instance of BOSH environment"`
+	StartEnv        StartEnvOpts        `command:"start-env" description:"Start jobs on first instance of BOSH environment"`
+	RestartEnv      RestartEnvOpts      `command:"restart-env" description:"Restart jobs on first instance of BOSH environment"`
+
 	// Authentication
 	LogIn  LogInOpts  `command:"log-in"  alias:"l" alias:"login"  description:"Log in"`
 	LogOut LogOutOpts `command:"log-out"           alias:"logout" description:"Log out"`
@@ -188,10 +205,17 @@ type CreateEnvOpts struct {
 	Args CreateEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	SkipDrain               bool   `long:"skip-drain" description:"Skip running drain scripts"`
-	StatePath               string `long:"state" value-name:"PATH" description:"State file path"`
-	Recreate                bool   `long:"recreate" description:"Recreate VM in deployment"`
-	RecreatePersistentDisks bool   `long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`
+	CPIFlags
+	SkipDrain               bool          `long:"skip-drain" description:"Skip running drain scripts"`
+	StatePath               string        `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	Recreate                bool          `long:"recreate" description:"Recreate VM in deployment"`
+	RecreatePersistentDisks bool          `long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`
+	SnapshotDisks           bool          `long:"snapshot-disks" description:"Snapshot persistent disks before migrating them to new disks"`
+	OrphanedDiskRetention   time.Duration `long:"orphaned-disk-retention" value-name:"DURATION" description:"Keep persistent disks replaced by a migration for this long instead of deleting them"`
+	ReplaceNamedDisks       bool          `long:"replace-named-disks" description:"Replace named persistent disks that need migration with empty disks, requires --orphaned-disk-retention"`
+	DryRun                  bool          `long:"dry-run" description:"Show planned changes without altering the environment"`
+	NoRedact                bool          `long:"no-redact" description:"Show non-redacted manifest diff"`
+	CompiledPackageCache    string        `long:"compiled-package-cache" value-name:"PATH" description:"Directory or s3://BUCKET/PREFIX to share compiled CPI packages between installations" env:"BOSH_COMPILED_PACKAGE_CACHE"`
 	cmd
 }
 
@@ -203,8 +227,9 @@ type DeleteEnvOpts struct {
 	Args DeleteEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
+	CPIFlags
 	SkipDrain bool   `long:"skip-drain" description:"Skip running drain scripts"`
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
 	cmd
 }
 
@@ -212,6 +237,131 @@ type DeleteEnvArgs struct {
 	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type ValidateEnvOpts struct {
+	Args ValidateEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	cmd
+}
+
+type ValidateEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvStateHistoryOpts struct {
+	Args      EnvStateHistoryArgs `positional-args:"true" required:"true"`
+	StatePath string              `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH; history of remote states is only kept on this machine"`
+	cmd
+}
+
+type EnvStateHistoryArgs struct {
+	Manifest string `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvStateRestoreOpts struct {
+	Args      EnvStateRestoreArgs `positional-args:"true" required:"true"`
+	StatePath string              `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH; history of remote states is only kept on this machine"`
+	cmd
+}
+
+type EnvStateRestoreArgs struct {
+	Manifest string `positional-arg-name:"PATH" description:"Path to a manifest file"`
+	ID       int    `positional-arg-name:"ID"   description:"Saved state version ID"`
+}
+
+type EnvVMsOpts struct {
+	Args EnvVMsArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	CPIFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	Check     bool   `long:"check" description:"Check that VMs still exist in the IaaS"`
+	cmd
+}
+
+type EnvVMsArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvDisksOpts struct {
+	Args EnvDisksArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	CPIFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	Check     bool   `long:"check" description:"Check that disks still exist in the IaaS"`
+	cmd
+}
+
+type EnvDisksArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type CleanUpEnvOpts struct {
+	Args CleanUpEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	CPIFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	cmd
+}
+
+type CleanUpEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvStatusOpts struct {
+	Args EnvStatusArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	Details   bool   `long:"details" short:"i" description:"Show details including VM CID, persistent disk CID, etc."`
+	cmd
+}
+
+type EnvStatusArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type StopEnvOpts struct {
+	Args StopEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	SkipDrain bool   `long:"skip-drain" description:"Skip running drain scripts"`
+	cmd
+}
+
+type StopEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type StartEnvOpts struct {
+	Args StartEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	cmd
+}
+
+type StartEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type RestartEnvOpts struct {
+	Args RestartEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`
+	SkipDrain bool   `long:"skip-drain" description:"Skip running drain scripts"`
+	cmd
+}
+
+type RestartEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
 // Environment
 
 type EnvironmentOpts struct {
@@ -267,6 +417,11 @@ type TaskOpts struct {
 	All        bool `long:"all" short:"a" description:"Include all task types (ssh, logs, vms, etc)"`
 	Deployment string
 
+	FollowAll bool `long:"follow-all" description:"Track event logs of all current tasks"`
+
+	Export   string `long:"export"    value-name:"DIR" description:"Download event, CPI, debug and result logs with task details into directory"`
+	FromFile string `long:"from-file" value-name:"DIR" description:"Show logs of a task exported with --export instead of fetching them from director"`
+
 	cmd
 }
 
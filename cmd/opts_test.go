@@ -136,6 +136,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("TaskPollIntervalOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("TaskPollIntervalOpt", opts)).To(Equal(
+					`long:"task-poll-interval" description:"Initial interval between checks of director task state, increased while task has no new output" default:"500ms"`,
+				))
+			})
+		})
+
+		Describe("TaskTimeoutOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("TaskTimeoutOpt", opts)).To(Equal(
+					`long:"task-timeout" description:"Cancel director tasks started by the command that do not finish within given duration (e.g. 2h)"`,
+				))
+			})
+		})
+
 		Describe("CACertOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CACertOpt", opts)).To(Equal(
@@ -176,6 +192,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("JSONEventsOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("JSONEventsOpt", opts)).To(Equal(
+					`long:"json-events" description:"Stream create-env and delete-env stages as JSON lines to stderr"`,
+				))
+			})
+		})
+
 		Describe("JSONOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("JSONOpt", opts)).To(Equal(
@@ -224,6 +248,86 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("EnvStateHistory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvStateHistory", opts)).To(Equal(
+					`command:"env-state-history" description:"List saved versions of BOSH environment state"`,
+				))
+			})
+		})
+
+		Describe("EnvStateRestore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvStateRestore", opts)).To(Equal(
+					`command:"env-state-restore" description:"Restore saved version of BOSH environment state"`,
+				))
+			})
+		})
+
+		Describe("ValidateEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ValidateEnv", opts)).To(Equal(
+					`command:"validate-env" description:"Validate BOSH environment manifest without creating anything"`,
+				))
+			})
+		})
+
+		Describe("EnvVMs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvVMs", opts)).To(Equal(
+					`command:"env-vms" description:"List VMs tracked by BOSH environment state"`,
+				))
+			})
+		})
+
+		Describe("EnvDisks", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvDisks", opts)).To(Equal(
+					`command:"env-disks" description:"List disks tracked by BOSH environment state"`,
+				))
+			})
+		})
+
+		Describe("CleanUpEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CleanUpEnv", opts)).To(Equal(
+					`command:"clean-up-env" description:"Delete VMs and disks of BOSH environment missing from its state"`,
+				))
+			})
+		})
+
+		Describe("EnvStatus", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvStatus", opts)).To(Equal(
+					`command:"env-status" description:"Show agent, process states and vitals of BOSH environment VM"`,
+				))
+			})
+		})
+
+		Describe("StopEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("StopEnv", opts)).To(Equal(
+					`command:"stop-env" description:"Stop jobs on first instance of BOSH environment"`,
+				))
+			})
+		})
+
+		Describe("StartEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("StartEnv", opts)).To(Equal(
+					`command:"start-env" description:"Start jobs on first instance of BOSH environment"`,
+				))
+			})
+		})
+
+		Describe("RestartEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RestartEnv", opts)).To(Equal(
+					`command:"restart-env" description:"Restart jobs on first instance of BOSH environment"`,
+				))
+			})
+		})
+
 		Describe("Environment", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Environment", opts)).To(Equal(
@@ -832,7 +936,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
 			))
 		})
 
@@ -848,11 +952,47 @@ var _ = Describe("Opts", func() {
 			))
 		})
 
+		It("has --snapshot-disks", func() {
+			Expect(getStructTagForName("SnapshotDisks", opts)).To(Equal(
+				`long:"snapshot-disks" description:"Snapshot persistent disks before migrating them to new disks"`,
+			))
+		})
+
+		It("has --orphaned-disk-retention", func() {
+			Expect(getStructTagForName("OrphanedDiskRetention", opts)).To(Equal(
+				`long:"orphaned-disk-retention" value-name:"DURATION" description:"Keep persistent disks replaced by a migration for this long instead of deleting them"`,
+			))
+		})
+
+		It("has --replace-named-disks", func() {
+			Expect(getStructTagForName("ReplaceNamedDisks", opts)).To(Equal(
+				`long:"replace-named-disks" description:"Replace named persistent disks that need migration with empty disks, requires --orphaned-disk-retention"`,
+			))
+		})
+
 		It("has --skip-drain", func() {
 			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
 				`long:"skip-drain" description:"Skip running drain scripts"`,
 			))
 		})
+
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Show planned changes without altering the environment"`,
+			))
+		})
+
+		It("has --no-redact", func() {
+			Expect(getStructTagForName("NoRedact", opts)).To(Equal(
+				`long:"no-redact" description:"Show non-redacted manifest diff"`,
+			))
+		})
+
+		It("has --compiled-package-cache", func() {
+			Expect(getStructTagForName("CompiledPackageCache", opts)).To(Equal(
+				`long:"compiled-package-cache" value-name:"PATH" description:"Directory or s3://BUCKET/PREFIX to share compiled CPI packages between installations" env:"BOSH_COMPILED_PACKAGE_CACHE"`,
+			))
+		})
 	})
 
 	Describe("CreateEnvArgs", func() {
@@ -886,7 +1026,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
 			))
 		})
 
@@ -913,6 +1053,260 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("ValidateEnvOpts", func() {
+		var opts *ValidateEnvOpts
+
+		BeforeEach(func() {
+			opts = &ValidateEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+	})
+
+	Describe("EnvStateHistoryOpts", func() {
+		var opts *EnvStateHistoryOpts
+
+		BeforeEach(func() {
+			opts = &EnvStateHistoryOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH; history of remote states is only kept on this machine"`,
+			))
+		})
+	})
+
+	Describe("EnvStateRestoreOpts", func() {
+		var opts *EnvStateRestoreOpts
+
+		BeforeEach(func() {
+			opts = &EnvStateRestoreOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH; history of remote states is only kept on this machine"`,
+			))
+		})
+	})
+
+	Describe("EnvVMsOpts", func() {
+		var opts *EnvVMsOpts
+
+		BeforeEach(func() {
+			opts = &EnvVMsOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+
+		It("has --check", func() {
+			Expect(getStructTagForName("Check", opts)).To(Equal(
+				`long:"check" description:"Check that VMs still exist in the IaaS"`,
+			))
+		})
+	})
+
+	Describe("EnvDisksOpts", func() {
+		var opts *EnvDisksOpts
+
+		BeforeEach(func() {
+			opts = &EnvDisksOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+
+		It("has --check", func() {
+			Expect(getStructTagForName("Check", opts)).To(Equal(
+				`long:"check" description:"Check that disks still exist in the IaaS"`,
+			))
+		})
+	})
+
+	Describe("CleanUpEnvOpts", func() {
+		var opts *CleanUpEnvOpts
+
+		BeforeEach(func() {
+			opts = &CleanUpEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+	})
+
+	Describe("EnvStatusOpts", func() {
+		var opts *EnvStatusOpts
+
+		BeforeEach(func() {
+			opts = &EnvStatusOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+
+		It("has --details", func() {
+			Expect(getStructTagForName("Details", opts)).To(Equal(
+				`long:"details" short:"i" description:"Show details including VM CID, persistent disk CID, etc."`,
+			))
+		})
+	})
+
+	Describe("StopEnvOpts", func() {
+		var opts *StopEnvOpts
+
+		BeforeEach(func() {
+			opts = &StopEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+
+		It("has --skip-drain", func() {
+			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
+				`long:"skip-drain" description:"Skip running drain scripts"`,
+			))
+		})
+	})
+
+	Describe("StartEnvOpts", func() {
+		var opts *StartEnvOpts
+
+		BeforeEach(func() {
+			opts = &StartEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+	})
+
+	Describe("RestartEnvOpts", func() {
+		var opts *RestartEnvOpts
+
+		BeforeEach(func() {
+			opts = &RestartEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path, s3://BUCKET/KEY or git+REPO#PATH"`,
+			))
+		})
+
+		It("has --skip-drain", func() {
+			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
+				`long:"skip-drain" description:"Skip running drain scripts"`,
+			))
+		})
+	})
+
+	Describe("EnvStateRestoreArgs", func() {
+		var args *EnvStateRestoreArgs
+
+		BeforeEach(func() {
+			args = &EnvStateRestoreArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+
+		Describe("ID", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ID", args)).To(Equal(
+					`positional-arg-name:"ID" description:"Saved state version ID"`,
+				))
+			})
+		})
+	})
+
 	Describe("AliasEnvOpts", func() {
 		var opts *AliasEnvOpts
 
@@ -1198,6 +1592,30 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("FollowAll", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("FollowAll", opts)).To(Equal(
+					`long:"follow-all" description:"Track event logs of all current tasks"`,
+				))
+			})
+		})
+
+		Describe("Export", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Export", opts)).To(Equal(
+					`long:"export" value-name:"DIR" description:"Download event, CPI, debug and result logs with task details into directory"`,
+				))
+			})
+		})
+
+		Describe("FromFile", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("FromFile", opts)).To(Equal(
+					`long:"from-file" value-name:"DIR" description:"Show logs of a task exported with --export instead of fetching them from director"`,
+				))
+			})
+		})
 	})
 
 	Describe("TaskArgs", func() {
@@ -2806,6 +3224,44 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("CPIFlags", func() {
+		var opts *CPIFlags
+
+		BeforeEach(func() {
+			opts = &CPIFlags{}
+		})
+
+		It("CPITimeout contains desired values", func() {
+			Expect(getStructTagForName("CPITimeout", opts)).To(Equal(
+				`long:"cpi-timeout" value-name:"DURATION" description:"Abort CPI calls taking longer than the duration (e.g. 30m)"`,
+			))
+		})
+
+		It("CPIMethodTimeouts contains desired values", func() {
+			Expect(getStructTagForName("CPIMethodTimeouts", opts)).To(Equal(
+				`long:"cpi-method-timeout" value-name:"METHOD=DURATION" description:"Abort calls of the CPI method taking longer than the duration (e.g. create_vm=1h)"`,
+			))
+		})
+
+		It("CPIRetries contains desired values", func() {
+			Expect(getStructTagForName("CPIRetries", opts)).To(Equal(
+				`long:"cpi-retries" value-name:"NUMBER" description:"Retry CPI calls failing with errors the CPI marks as retryable" default:"3"`,
+			))
+		})
+
+		It("RecordCPI contains desired values", func() {
+			Expect(getStructTagForName("RecordCPI", opts)).To(Equal(
+				`long:"record-cpi" value-name:"PATH" description:"Record CPI calls and responses to a JSON lines file, replacing its contents"`,
+			))
+		})
+
+		It("ReplayCPI contains desired values", func() {
+			Expect(getStructTagForName("ReplayCPI", opts)).To(Equal(
+				`long:"replay-cpi" value-name:"PATH" description:"Respond to CPI calls from a file recorded with --record-cpi instead of running the CPI (agents on created VMs must still be reachable)"`,
+			))
+		})
+	})
+
 	Describe("InitReleaseOpts", func() {
 		var opts *InitReleaseOpts
 
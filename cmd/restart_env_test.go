@@ -0,0 +1,96 @@
+package cmd_test
+
+import (
+	"code.cloudfoundry.org/clock"
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("RestartEnvCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		mockAgent     *mock_cmd.MockEnvAgent
+		fakeStage     *fakeui.FakeStage
+		command       *RestartEnvCmd
+		opts          RestartEnvOpts
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		mockAgent = mock_cmd.NewMockEnvAgent(mockCtrl)
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			return mockInspector
+		}
+
+		command = NewRestartEnvCmd(envProvider, clock.NewClock(), boshlog.NewLogger(boshlog.LevelNone))
+
+		opts = RestartEnvOpts{
+			Args: RestartEnvArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+		}
+
+		mockInspector.EXPECT().WithLockedState(gomock.Any()).DoAndReturn(func(fn func() error) error {
+			return fn()
+		})
+
+		mockInspector.EXPECT().DeploymentManifest().Return(bideplmanifest.Manifest{
+			Jobs: []bideplmanifest.Job{{Name: "fake-job"}},
+			Update: bideplmanifest.Update{
+				UpdateWatchTime: bideplmanifest.WatchTime{Start: 0, End: 300000},
+			},
+		}, nil)
+
+		mockInspector.EXPECT().Agent().Return(mockAgent, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("drains, stops and starts jobs and waits for them to be running", func() {
+		gomock.InOrder(
+			mockAgent.EXPECT().Drain("shutdown").Return(int64(0), nil),
+			mockAgent.EXPECT().Stop().Return(nil),
+			mockAgent.EXPECT().Start().Return(nil),
+			mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "running"}, nil),
+		)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+			{Name: "Draining jobs on instance 'fake-job/0'"},
+			{Name: "Stopping jobs on instance 'fake-job/0'"},
+			{Name: "Starting jobs on instance 'fake-job/0'"},
+			{Name: "Waiting for instance 'fake-job/0' to be running"},
+		}))
+	})
+
+	It("skips draining when requested", func() {
+		opts.SkipDrain = true
+
+		gomock.InOrder(
+			mockAgent.EXPECT().Stop().Return(nil),
+			mockAgent.EXPECT().Start().Return(nil),
+			mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "running"}, nil),
+		)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeStage.PerformCalls).To(HaveLen(3))
+	})
+})
@@ -89,6 +89,8 @@ func (c *SessionImpl) Director() (boshdir.Director, error) {
 	}
 
 	dirConfig.CACert = c.context.CACert()
+	dirConfig.TaskPollInterval = c.context.TaskPollInterval()
+	dirConfig.TaskTimeout = c.context.TaskTimeout()
 
 	creds := c.Credentials()
 
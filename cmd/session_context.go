@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
@@ -52,3 +54,11 @@ func (c SessionContextImpl) CACert() string {
 func (c SessionContextImpl) Deployment() string {
 	return c.opts.DeploymentOpt
 }
+
+func (c SessionContextImpl) TaskPollInterval() time.Duration {
+	return c.opts.TaskPollIntervalOpt
+}
+
+func (c SessionContextImpl) TaskTimeout() time.Duration {
+	return c.opts.TaskTimeoutOpt
+}
@@ -1,6 +1,8 @@
 package cmd_test
 
 import (
+	"time"
+
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -118,4 +120,22 @@ var _ = Describe("SessionContextImpl", func() {
 			Expect(build().Deployment()).To(Equal(""))
 		})
 	})
+
+	Describe("TaskPollInterval", func() {
+		It("returns global option", func() {
+			opts.TaskPollIntervalOpt = 2 * time.Second
+			Expect(build().TaskPollInterval()).To(Equal(2 * time.Second))
+		})
+	})
+
+	Describe("TaskTimeout", func() {
+		It("returns global option if provided", func() {
+			opts.TaskTimeoutOpt = time.Hour
+			Expect(build().TaskTimeout()).To(Equal(time.Hour))
+		})
+
+		It("returns zero if global option is not set", func() {
+			Expect(build().TaskTimeout()).To(BeZero())
+		})
+	})
 })
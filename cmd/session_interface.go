@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshuaa "github.com/cloudfoundry/bosh-cli/uaa"
@@ -15,6 +17,9 @@ type SessionContext interface {
 	Credentials() cmdconf.Creds
 
 	Deployment() string
+
+	TaskPollInterval() time.Duration
+	TaskTimeout() time.Duration
 }
 
 //go:generate counterfeiter . Session
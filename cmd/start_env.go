@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StartEnvCmd struct {
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+	timeService clock.Clock
+	logger      boshlog.Logger
+}
+
+func NewStartEnvCmd(envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector, timeService clock.Clock, logger boshlog.Logger) *StartEnvCmd {
+	return &StartEnvCmd{envProvider: envProvider, timeService: timeService, logger: logger}
+}
+
+func (c *StartEnvCmd) Run(stage boshui.Stage, opts StartEnvOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	return inspector.WithLockedState(func() error {
+		jobs, err := newEnvJobs(inspector, c.timeService, c.logger)
+		if err != nil {
+			return err
+		}
+
+		return jobs.Start(stage)
+	})
+}
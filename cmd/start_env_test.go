@@ -0,0 +1,155 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/clock/fakeclock"
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("StartEnvCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		mockAgent     *mock_cmd.MockEnvAgent
+		fakeStage     *fakeui.FakeStage
+		command       *StartEnvCmd
+		opts          StartEnvOpts
+		manifest      bideplmanifest.Manifest
+		envProvider   func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		mockAgent = mock_cmd.NewMockEnvAgent(mockCtrl)
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider = func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			return mockInspector
+		}
+
+		command = NewStartEnvCmd(envProvider, clock.NewClock(), boshlog.NewLogger(boshlog.LevelNone))
+
+		opts = StartEnvOpts{
+			Args: StartEnvArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+		}
+
+		manifest = bideplmanifest.Manifest{
+			Jobs: []bideplmanifest.Job{{Name: "fake-job"}},
+			Update: bideplmanifest.Update{
+				UpdateWatchTime: bideplmanifest.WatchTime{Start: 0, End: 1000},
+			},
+		}
+
+		mockInspector.EXPECT().WithLockedState(gomock.Any()).DoAndReturn(func(fn func() error) error {
+			return fn()
+		})
+	})
+
+	JustBeforeEach(func() {
+		mockInspector.EXPECT().DeploymentManifest().Return(manifest, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when the manifest has jobs", func() {
+		BeforeEach(func() {
+			mockInspector.EXPECT().Agent().Return(mockAgent, nil)
+		})
+
+		It("starts jobs and waits for them to be running", func() {
+			gomock.InOrder(
+				mockAgent.EXPECT().Start().Return(nil),
+				mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "running"}, nil),
+			)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+				{Name: "Starting jobs on instance 'fake-job/0'"},
+				{Name: "Waiting for instance 'fake-job/0' to be running"},
+			}))
+		})
+
+		It("returns an error when jobs are not running within the update watch time", func() {
+			mockAgent.EXPECT().Start().Return(nil)
+			mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "failing"}, nil)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Received non-running job state: 'failing'"))
+		})
+
+		It("does not wait when starting fails", func() {
+			mockAgent.EXPECT().Start().Return(errors.New("fake-start-err"))
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Starting agent"))
+
+			Expect(fakeStage.PerformCalls).To(HaveLen(1))
+		})
+
+		Context("when jobs are running only after a while", func() {
+			var (
+				timeService *fakeclock.FakeClock
+				done        chan error
+			)
+
+			BeforeEach(func() {
+				timeService = fakeclock.NewFakeClock(time.Now())
+				command = NewStartEnvCmd(envProvider, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+				manifest.Update.UpdateWatchTime = bideplmanifest.WatchTime{Start: 1000, End: 3000}
+
+				done = make(chan error, 1)
+			})
+
+			It("waits for the start of the update watch time and retries through the same clock", func() {
+				gomock.InOrder(
+					mockAgent.EXPECT().Start().Return(nil),
+					mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "starting"}, nil),
+					mockAgent.EXPECT().GetState().Return(biagentclient.AgentState{JobState: "running"}, nil),
+				)
+
+				go func() { done <- command.Run(fakeStage, opts) }()
+
+				timeService.WaitForWatcherAndIncrement(time.Second)
+				Consistently(done).ShouldNot(Receive())
+
+				timeService.WaitForWatcherAndIncrement(time.Second)
+				Eventually(done).Should(Receive(BeNil()))
+			})
+		})
+	})
+
+	Context("when the manifest has no jobs", func() {
+		BeforeEach(func() {
+			manifest.Jobs = nil
+		})
+
+		It("returns an error", func() {
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment manifest does not contain any jobs"))
+		})
+	})
+})
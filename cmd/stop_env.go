@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StopEnvCmd struct {
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector
+	timeService clock.Clock
+	logger      boshlog.Logger
+}
+
+func NewStopEnvCmd(envProvider func(string, string, boshtpl.Variables, patch.Op) EnvInspector, timeService clock.Clock, logger boshlog.Logger) *StopEnvCmd {
+	return &StopEnvCmd{envProvider: envProvider, timeService: timeService, logger: logger}
+}
+
+func (c *StopEnvCmd) Run(stage boshui.Stage, opts StopEnvOpts) error {
+	inspector := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	// the state stays locked so that a concurrent create-env does not update jobs while they are stopped
+	return inspector.WithLockedState(func() error {
+		jobs, err := newEnvJobs(inspector, c.timeService, c.logger)
+		if err != nil {
+			return err
+		}
+
+		if !opts.SkipDrain {
+			err = jobs.Drain(stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		return jobs.Stop(stage)
+	})
+}
@@ -0,0 +1,113 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("StopEnvCmd", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockInspector *mock_cmd.MockEnvInspector
+		mockAgent     *mock_cmd.MockEnvAgent
+		fakeStage     *fakeui.FakeStage
+		command       *StopEnvCmd
+		opts          StopEnvOpts
+		locked        bool
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockInspector = mock_cmd.NewMockEnvInspector(mockCtrl)
+		mockAgent = mock_cmd.NewMockEnvAgent(mockCtrl)
+		fakeStage = fakeui.NewFakeStage()
+
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvInspector {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockInspector
+		}
+
+		command = NewStopEnvCmd(envProvider, clock.NewClock(), boshlog.NewLogger(boshlog.LevelNone))
+
+		opts = StopEnvOpts{
+			Args:      StopEnvArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		locked = false
+		mockInspector.EXPECT().WithLockedState(gomock.Any()).DoAndReturn(func(fn func() error) error {
+			locked = true
+			defer func() { locked = false }()
+			return fn()
+		})
+
+		mockInspector.EXPECT().DeploymentManifest().Return(bideplmanifest.Manifest{
+			Jobs: []bideplmanifest.Job{{Name: "fake-job"}},
+		}, nil)
+
+		mockInspector.EXPECT().Agent().Return(mockAgent, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("drains and stops jobs while the state is locked", func() {
+		gomock.InOrder(
+			mockAgent.EXPECT().Drain("shutdown").Return(int64(0), nil),
+			mockAgent.EXPECT().Stop().Do(func() { Expect(locked).To(BeTrue()) }).Return(nil),
+		)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+			{Name: "Draining jobs on instance 'fake-job/0'"},
+			{Name: "Stopping jobs on instance 'fake-job/0'"},
+		}))
+	})
+
+	It("skips draining when requested", func() {
+		opts.SkipDrain = true
+
+		mockAgent.EXPECT().Stop().Return(nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+			{Name: "Stopping jobs on instance 'fake-job/0'"},
+		}))
+	})
+
+	It("does not stop jobs when draining fails", func() {
+		mockAgent.EXPECT().Drain("shutdown").Return(int64(0), errors.New("fake-drain-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-drain-err"))
+	})
+
+	It("returns an error when stopping fails", func() {
+		mockAgent.EXPECT().Drain("shutdown").Return(int64(0), nil)
+		mockAgent.EXPECT().Stop().Return(errors.New("fake-stop-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Stopping agent"))
+		Expect(err.Error()).To(ContainSubstring("fake-stop-err"))
+	})
+})
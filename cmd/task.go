@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"errors"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
@@ -11,21 +15,32 @@ type TaskCmd struct {
 	eventsTaskReporter boshuit.Reporter
 	plainTaskReporter  boshuit.Reporter
 	director           boshdir.Director
+	fs                 boshsys.FileSystem
 }
 
 func NewTaskCmd(
 	eventsTaskReporter boshuit.Reporter,
 	plainTaskReporter boshuit.Reporter,
 	director boshdir.Director,
+	fs boshsys.FileSystem,
 ) TaskCmd {
 	return TaskCmd{
 		eventsTaskReporter: eventsTaskReporter,
 		plainTaskReporter:  plainTaskReporter,
 		director:           director,
+		fs:                 fs,
 	}
 }
 
 func (c TaskCmd) Run(opts TaskOpts) error {
+	if opts.FollowAll {
+		return c.followAll(opts)
+	}
+
+	if len(opts.Export) > 0 && (opts.Event || opts.CPI || opts.Debug || opts.Result) {
+		return errors.New("Task log type cannot be specified together with --export")
+	}
+
 	var task boshdir.Task
 
 	var err error
@@ -52,6 +67,10 @@ func (c TaskCmd) Run(opts TaskOpts) error {
 		}
 	}
 
+	if len(opts.Export) > 0 {
+		return c.export(task, opts.Export)
+	}
+
 	switch {
 	case opts.Event:
 		err = task.EventOutput(c.plainTaskReporter)
@@ -67,3 +86,68 @@ func (c TaskCmd) Run(opts TaskOpts) error {
 
 	return err
 }
+
+// followAll tracks events of all current tasks at the same time
+// and fails if any of them does not succeed
+func (c TaskCmd) followAll(opts TaskOpts) error {
+	if opts.Args.ID != 0 {
+		return errors.New("Task ID cannot be specified together with --follow-all")
+	}
+
+	if len(opts.Export) > 0 {
+		return errors.New("Tasks cannot be exported with --follow-all")
+	}
+
+	if opts.Event || opts.CPI || opts.Debug || opts.Result {
+		return errors.New("Only task events can be tracked with --follow-all")
+	}
+
+	filter := boshdir.TasksFilter{
+		All:        opts.All,
+		Deployment: opts.Deployment,
+	}
+
+	tasks, err := c.director.CurrentTasks(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(tasks) == 0 {
+		return errors.New("No task found")
+	}
+
+	if describer, ok := c.eventsTaskReporter.(boshuit.TaskDescriber); ok {
+		for _, task := range tasks {
+			describer.DescribeTask(task.ID(), task.DeploymentName())
+		}
+	}
+
+	errs := make([]error, len(tasks))
+
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		wg.Add(1)
+
+		go func(i int, task boshdir.Task) {
+			defer wg.Done()
+			errs[i] = task.EventOutput(c.eventsTaskReporter)
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	var taskErrs []error
+
+	for _, err := range errs {
+		if err != nil {
+			taskErrs = append(taskErrs, err)
+		}
+	}
+
+	if len(taskErrs) > 0 {
+		return bosherr.NewMultiError(taskErrs...)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+)
+
+// taskExportDetailsFile holds task details next to the <type>.log files of an exported task
+const taskExportDetailsFile = "task.json"
+
+type taskExportDetails struct {
+	ID    int    `json:"id"`
+	State string `json:"state"`
+
+	Description string `json:"description"`
+	Result      string `json:"result"`
+	User        string `json:"user"`
+	Deployment  string `json:"deployment"`
+	ContextID   string `json:"context_id"`
+
+	StartedAt      time.Time `json:"started_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+func taskExportLogPath(dir, type_ string) string {
+	return filepath.Join(dir, type_+".log")
+}
+
+// export downloads all task logs and task details so that
+// they can be looked at after director no longer keeps them
+func (c TaskCmd) export(task boshdir.Task, dir string) error {
+	err := c.fs.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating export directory '%s'", dir)
+	}
+
+	outputs := []struct {
+		Type  string
+		Fetch func(boshdir.TaskReporter) error
+	}{
+		{"event", task.EventOutput},
+		{"cpi", task.CPIOutput},
+		{"debug", task.DebugOutput},
+		{"result", task.ResultOutput},
+	}
+
+	state := task.State()
+
+	for _, output := range outputs {
+		collector := &taskOutputCollector{}
+
+		// output of running tasks is collected until they finish;
+		// tasks that finished without succeeding are still exported
+		err := output.Fetch(collector)
+		if err != nil && !collector.Finished() {
+			return bosherr.WrapErrorf(err, "Downloading task '%d' %s log", task.ID(), output.Type)
+		}
+
+		if len(collector.state) > 0 {
+			state = collector.state
+		}
+
+		path := taskExportLogPath(dir, output.Type)
+
+		err = c.fs.WriteFile(path, collector.output)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing task log '%s'", path)
+		}
+	}
+
+	details := taskExportDetails{
+		ID:    task.ID(),
+		State: state,
+
+		Description: task.Description(),
+		Result:      task.Result(),
+		User:        task.User(),
+		Deployment:  task.DeploymentName(),
+		ContextID:   task.ContextID(),
+
+		StartedAt:      task.StartedAt(),
+		LastActivityAt: task.LastActivityAt(),
+	}
+
+	detailsBytes, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling task details")
+	}
+
+	path := filepath.Join(dir, taskExportDetailsFile)
+
+	err = c.fs.WriteFile(path, detailsBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing task details '%s'", path)
+	}
+
+	return nil
+}
+
+type taskOutputCollector struct {
+	output []byte
+	state  string
+}
+
+func (c *taskOutputCollector) TaskStarted(int) {}
+
+func (c *taskOutputCollector) TaskFinished(_ int, state string) {
+	c.state = state
+}
+
+func (c *taskOutputCollector) TaskOutputChunk(_ int, chunk []byte) {
+	c.output = append(c.output, chunk...)
+}
+
+func (c *taskOutputCollector) Finished() bool {
+	switch c.state {
+	case "", "queued", "processing", "cancelling":
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
+)
+
+// TaskReplayCmd shows logs of a task exported with 'bosh task --export'
+// the same way they are shown while tracking the task on director
+type TaskReplayCmd struct {
+	eventsTaskReporter boshuit.Reporter
+	plainTaskReporter  boshuit.Reporter
+	fs                 boshsys.FileSystem
+}
+
+func NewTaskReplayCmd(
+	eventsTaskReporter boshuit.Reporter,
+	plainTaskReporter boshuit.Reporter,
+	fs boshsys.FileSystem,
+) TaskReplayCmd {
+	return TaskReplayCmd{
+		eventsTaskReporter: eventsTaskReporter,
+		plainTaskReporter:  plainTaskReporter,
+		fs:                 fs,
+	}
+}
+
+func (c TaskReplayCmd) Run(opts TaskOpts) error {
+	if opts.Args.ID != 0 {
+		return errors.New("Task ID cannot be specified together with --from-file")
+	}
+
+	if opts.FollowAll || len(opts.Export) > 0 {
+		return errors.New("Exported task cannot be tracked with --follow-all or exported again")
+	}
+
+	detailsPath := filepath.Join(opts.FromFile, taskExportDetailsFile)
+
+	detailsBytes, err := c.fs.ReadFile(detailsPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading task details '%s'", detailsPath)
+	}
+
+	var details taskExportDetails
+
+	err = json.Unmarshal(detailsBytes, &details)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling task details '%s'", detailsPath)
+	}
+
+	type_ := "event"
+	reporter := c.plainTaskReporter
+
+	switch {
+	case opts.Event:
+		type_ = "event"
+	case opts.CPI:
+		type_ = "cpi"
+	case opts.Debug:
+		type_ = "debug"
+	case opts.Result:
+		type_ = "result"
+	default:
+		reporter = c.eventsTaskReporter
+	}
+
+	logPath := taskExportLogPath(opts.FromFile, type_)
+
+	output, err := c.fs.ReadFile(logPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading task log '%s'", logPath)
+	}
+
+	reporter.TaskStarted(details.ID)
+
+	if len(output) > 0 {
+		reporter.TaskOutputChunk(details.ID, output)
+	}
+
+	reporter.TaskFinished(details.ID, details.State)
+
+	if details.State != "done" {
+		return bosherr.Errorf("Expected task '%d' to succeed but state is '%s'", details.ID, details.State)
+	}
+
+	return nil
+}
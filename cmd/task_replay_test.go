@@ -0,0 +1,178 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
+)
+
+var _ = Describe("TaskReplayCmd", func() {
+	var (
+		eventsRep *fakedir.FakeTaskReporter
+		plainRep  *fakedir.FakeTaskReporter
+		fs        *fakesys.FakeFileSystem
+		command   TaskReplayCmd
+	)
+
+	BeforeEach(func() {
+		eventsRep = &fakedir.FakeTaskReporter{}
+		plainRep = &fakedir.FakeTaskReporter{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewTaskReplayCmd(eventsRep, plainRep, fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts TaskOpts
+		)
+
+		BeforeEach(func() {
+			opts = TaskOpts{FromFile: "/export-dir"}
+
+			fs.WriteFileString("/export-dir/task.json", `{"id": 123, "state": "done"}`)
+			fs.WriteFileString("/export-dir/event.log", "event-output")
+			fs.WriteFileString("/export-dir/cpi.log", "cpi-output")
+			fs.WriteFileString("/export-dir/debug.log", "debug-output")
+			fs.WriteFileString("/export-dir/result.log", "result-output")
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("shows exported 'event' output", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(eventsRep.TaskStartedCallCount()).To(Equal(1))
+			Expect(eventsRep.TaskStartedArgsForCall(0)).To(Equal(123))
+
+			Expect(eventsRep.TaskOutputChunkCallCount()).To(Equal(1))
+
+			id, chunk := eventsRep.TaskOutputChunkArgsForCall(0)
+			Expect(id).To(Equal(123))
+			Expect(chunk).To(Equal([]byte("event-output")))
+
+			Expect(eventsRep.TaskFinishedCallCount()).To(Equal(1))
+
+			id, state := eventsRep.TaskFinishedArgsForCall(0)
+			Expect(id).To(Equal(123))
+			Expect(state).To(Equal("done"))
+
+			Expect(plainRep.TaskStartedCallCount()).To(Equal(0))
+		})
+
+		It("shows exported 'event' output if requested", func() {
+			opts.Event = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, chunk := plainRep.TaskOutputChunkArgsForCall(0)
+			Expect(chunk).To(Equal([]byte("event-output")))
+			Expect(eventsRep.TaskStartedCallCount()).To(Equal(0))
+		})
+
+		It("shows exported 'cpi' output if requested", func() {
+			opts.CPI = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, chunk := plainRep.TaskOutputChunkArgsForCall(0)
+			Expect(chunk).To(Equal([]byte("cpi-output")))
+		})
+
+		It("shows exported 'debug' output if requested", func() {
+			opts.Debug = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, chunk := plainRep.TaskOutputChunkArgsForCall(0)
+			Expect(chunk).To(Equal([]byte("debug-output")))
+		})
+
+		It("shows exported 'result' output if requested", func() {
+			opts.Result = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, chunk := plainRep.TaskOutputChunkArgsForCall(0)
+			Expect(chunk).To(Equal([]byte("result-output")))
+		})
+
+		It("renders events the same way as while tracking the task", func() {
+			ui := &fakeui.FakeUI{}
+			command = NewTaskReplayCmd(boshuit.NewReporter(ui, true), plainRep, fs)
+
+			fs.WriteFileString("/export-dir/event.log",
+				`{"time":1, "stage":"Preparing deployment", "state":"started", "tags":[], "total":1, "task":"Binding deployment", "index":1, "progress":0}`+"\n"+
+					`{"time":2, "stage":"Preparing deployment", "state":"finished", "tags":[], "total":1, "task":"Binding deployment", "index":1, "progress":100}`+"\n")
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("Task 123"))
+			Expect(ui.Blocks).To(ContainElement(ContainSubstring("Preparing deployment: Binding deployment")))
+		})
+
+		It("returns error if exported task did not succeed", func() {
+			fs.WriteFileString("/export-dir/task.json", `{"id": 123, "state": "error"}`)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected task '123' to succeed but state is 'error'"))
+
+			Expect(eventsRep.TaskOutputChunkCallCount()).To(Equal(1))
+			Expect(eventsRep.TaskFinishedCallCount()).To(Equal(1))
+		})
+
+		It("returns error if task details cannot be read", func() {
+			fs.ReadFileError = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading task details '/export-dir/task.json'"))
+		})
+
+		It("returns error if task details cannot be parsed", func() {
+			fs.WriteFileString("/export-dir/task.json", "invalid-json")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling task details '/export-dir/task.json'"))
+		})
+
+		It("returns error if task log was not exported", func() {
+			fs.RemoveAll("/export-dir/event.log")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading task log '/export-dir/event.log'"))
+			Expect(eventsRep.TaskStartedCallCount()).To(Equal(0))
+		})
+
+		It("returns error if task id is specified", func() {
+			opts.Args.ID = 5
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Task ID cannot be specified together with --from-file"))
+		})
+
+		It("returns error if following all tasks", func() {
+			opts.FollowAll = true
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Exported task cannot be tracked with --follow-all or exported again"))
+		})
+	})
+})
@@ -2,13 +2,17 @@ package cmd_test
 
 import (
 	"errors"
+	"time"
 
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 )
 
 var _ = Describe("TaskCmd", func() {
@@ -16,6 +20,7 @@ var _ = Describe("TaskCmd", func() {
 		eventsRep *fakedir.FakeTaskReporter
 		plainRep  *fakedir.FakeTaskReporter
 		director  *fakedir.FakeDirector
+		fs        *fakesys.FakeFileSystem
 		command   TaskCmd
 	)
 
@@ -23,7 +28,8 @@ var _ = Describe("TaskCmd", func() {
 		eventsRep = &fakedir.FakeTaskReporter{}
 		plainRep = &fakedir.FakeTaskReporter{}
 		director = &fakedir.FakeDirector{}
-		command = NewTaskCmd(eventsRep, plainRep, director)
+		fs = fakesys.NewFakeFileSystem()
+		command = NewTaskCmd(eventsRep, plainRep, director, fs)
 	})
 
 	Describe("Run", func() {
@@ -240,5 +246,237 @@ var _ = Describe("TaskCmd", func() {
 				Expect(err.Error()).To(ContainSubstring("fake-err"))
 			})
 		})
+
+		Context("when following all tasks", func() {
+			var (
+				otherTask *fakedir.FakeTask
+			)
+
+			BeforeEach(func() {
+				opts.FollowAll = true
+
+				task.IDStub = func() int { return 5 }
+				task.DeploymentNameReturns("fake-deployment")
+
+				otherTask = &fakedir.FakeTask{}
+				otherTask.IDStub = func() int { return 4 }
+				otherTask.DeploymentNameReturns("fake-other-deployment")
+
+				director.CurrentTasksReturns([]boshdir.Task{task, otherTask}, nil)
+			})
+
+			It("shows 'event' output of all current tasks", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(task.EventOutputCallCount()).To(Equal(1))
+				Expect(task.EventOutputArgsForCall(0)).To(Equal(eventsRep))
+
+				Expect(otherTask.EventOutputCallCount()).To(Equal(1))
+				Expect(otherTask.EventOutputArgsForCall(0)).To(Equal(eventsRep))
+			})
+
+			It("follows tasks at the same time", func() {
+				otherTaskStarted := make(chan struct{})
+
+				task.EventOutputStub = func(boshdir.TaskReporter) error {
+					<-otherTaskStarted
+					return nil
+				}
+
+				otherTask.EventOutputStub = func(boshdir.TaskReporter) error {
+					close(otherTaskStarted)
+					return nil
+				}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("describes tasks with their deployment names when reporter supports it", func() {
+				ui := &fakeui.FakeUI{}
+				eventsReporter := boshuit.NewReporter(ui, true)
+
+				task.EventOutputStub = func(reporter boshdir.TaskReporter) error {
+					reporter.TaskStarted(5)
+					reporter.TaskOutputChunk(5, []byte(`{"time":1454193505,"error":{"code":100,"message":"err-msg"}}`+"\n"))
+					return nil
+				}
+
+				err := NewTaskCmd(eventsReporter, plainRep, director, fs).Run(opts)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.Blocks).To(ContainElement("\nTask 5 | fake-deployment | 22:38:25 | "))
+			})
+
+			It("filters tasks based on 'all' and 'deployment' option", func() {
+				opts.All = true
+				opts.Deployment = "deployment-name"
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(director.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{All: true, Deployment: "deployment-name"}))
+			})
+
+			It("returns error if any task does not succeed after all tasks finish", func() {
+				otherTask.EventOutputReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(task.EventOutputCallCount()).To(Equal(1))
+			})
+
+			It("returns error if there are no current tasks", func() {
+				director.CurrentTasksReturns(nil, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No task found"))
+			})
+
+			It("returns error if task id is specified", func() {
+				opts.Args.ID = 5
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Task ID cannot be specified together with --follow-all"))
+				Expect(director.CurrentTasksCallCount()).To(Equal(0))
+			})
+
+			It("returns error if other than event output is requested", func() {
+				opts.CPI = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Only task events can be tracked with --follow-all"))
+				Expect(director.CurrentTasksCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when exporting task", func() {
+			reportOutput := func(state string, output string) func(boshdir.TaskReporter) error {
+				return func(reporter boshdir.TaskReporter) error {
+					reporter.TaskStarted(123)
+					reporter.TaskOutputChunk(123, []byte(output))
+					reporter.TaskFinished(123, state)
+					return nil
+				}
+			}
+
+			BeforeEach(func() {
+				opts.Args.ID = 123
+				opts.Export = "/export-dir"
+
+				task.IDReturns(123)
+				task.StateReturns("processing")
+				task.DescriptionReturns("create deployment")
+				task.ResultReturns("result")
+				task.UserReturns("admin")
+				task.DeploymentNameReturns("dep")
+				task.ContextIDReturns("context")
+				task.StartedAtReturns(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+				task.LastActivityAtReturns(time.Date(2017, time.March, 1, 10, 5, 0, 0, time.UTC))
+
+				task.EventOutputStub = reportOutput("done", "event-output")
+				task.CPIOutputStub = reportOutput("done", "cpi-output")
+				task.DebugOutputStub = reportOutput("done", "debug-output")
+				task.ResultOutputStub = reportOutput("done", "result-output")
+			})
+
+			It("writes all task logs and task details into directory", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/export-dir")).To(BeTrue())
+				Expect(fs.ReadFileString("/export-dir/event.log")).To(Equal("event-output"))
+				Expect(fs.ReadFileString("/export-dir/cpi.log")).To(Equal("cpi-output"))
+				Expect(fs.ReadFileString("/export-dir/debug.log")).To(Equal("debug-output"))
+				Expect(fs.ReadFileString("/export-dir/result.log")).To(Equal("result-output"))
+
+				Expect(fs.ReadFileString("/export-dir/task.json")).To(MatchJSON(`{
+					"id": 123,
+					"state": "done",
+					"description": "create deployment",
+					"result": "result",
+					"user": "admin",
+					"deployment": "dep",
+					"context_id": "context",
+					"started_at": "2017-03-01T10:00:00Z",
+					"last_activity_at": "2017-03-01T10:05:00Z"
+				}`))
+
+				Expect(eventsRep.TaskStartedCallCount()).To(Equal(0))
+				Expect(plainRep.TaskStartedCallCount()).To(Equal(0))
+			})
+
+			It("exports task that did not succeed", func() {
+				failedOutput := func(output string) func(boshdir.TaskReporter) error {
+					return func(reporter boshdir.TaskReporter) error {
+						reportOutput("error", output)(reporter)
+						return errors.New("fake-err")
+					}
+				}
+
+				task.EventOutputStub = failedOutput("event-output")
+				task.CPIOutputStub = failedOutput("cpi-output")
+				task.DebugOutputStub = failedOutput("debug-output")
+				task.ResultOutputStub = failedOutput("result-output")
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.ReadFileString("/export-dir/event.log")).To(Equal("event-output"))
+				Expect(fs.ReadFileString("/export-dir/task.json")).To(ContainSubstring(`"state": "error"`))
+			})
+
+			It("returns error if task log cannot be downloaded", func() {
+				task.CPIOutputStub = func(reporter boshdir.TaskReporter) error {
+					reporter.TaskStarted(123)
+					reporter.TaskFinished(123, "")
+					return errors.New("fake-err")
+				}
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Downloading task '123' cpi log"))
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+				Expect(fs.FileExists("/export-dir/task.json")).To(BeFalse())
+			})
+
+			It("returns error if directory cannot be created", func() {
+				fs.MkdirAllError = errors.New("fake-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Creating export directory '/export-dir'"))
+			})
+
+			It("returns error if task log cannot be written", func() {
+				fs.WriteFileErrors["/export-dir/debug.log"] = errors.New("fake-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Writing task log '/export-dir/debug.log'"))
+			})
+
+			It("returns error if task log type is specified", func() {
+				opts.Debug = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Task log type cannot be specified together with --export"))
+				Expect(director.FindTaskCallCount()).To(Equal(0))
+			})
+
+			It("returns error if following all tasks", func() {
+				opts.Args.ID = 0
+				opts.FollowAll = true
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Tasks cannot be exported with --follow-all"))
+			})
+		})
 	})
 })
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type ValidateEnvCmd struct {
+	ui          boshui.UI
+	envProvider EnvProviderFunction
+}
+
+func NewValidateEnvCmd(ui boshui.UI, envProvider EnvProviderFunction) *ValidateEnvCmd {
+	return &ValidateEnvCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *ValidateEnvCmd) Run(stage boshui.Stage, opts ValidateEnvOpts) error {
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
+
+	depPreparer := c.envProvider(opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	return depPreparer.ValidateDeployment(stage)
+}
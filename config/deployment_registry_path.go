@@ -0,0 +1,18 @@
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DeploymentRegistryPath is where the registry persists instance settings of the deployment,
+// next to local state files or in the workspace for remote ones
+func DeploymentRegistryPath(deploymentStatePath string, workspaceRootPath string) string {
+	if strings.HasPrefix(deploymentStatePath, s3StatePrefix) || strings.HasPrefix(deploymentStatePath, gitStatePrefix) {
+		return filepath.Join(workspaceRootPath, "registry", fmt.Sprintf("%x.json", sha1.Sum([]byte(deploymentStatePath))))
+	}
+
+	return strings.TrimSuffix(deploymentStatePath, filepath.Ext(deploymentStatePath)) + "-registry.json"
+}
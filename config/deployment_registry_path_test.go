@@ -0,0 +1,19 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeploymentRegistryPath", func() {
+	It("keeps registry settings of local state next to the state file", func() {
+		Expect(DeploymentRegistryPath("/path/to/state.json", "/workspace")).To(Equal("/path/to/state-registry.json"))
+	})
+
+	It("keeps registry settings of remote state in the workspace", func() {
+		Expect(DeploymentRegistryPath("s3://bucket/state.json", "/workspace")).To(HavePrefix("/workspace/registry/"))
+		Expect(DeploymentRegistryPath("git+repo#state.json", "/workspace")).To(HavePrefix("/workspace/registry/"))
+		Expect(DeploymentRegistryPath("s3://bucket/state.json", "/workspace")).ToNot(Equal(DeploymentRegistryPath("git+repo#state.json", "/workspace")))
+	})
+})
@@ -7,6 +7,7 @@ import (
 type DeploymentRepo interface {
 	UpdateCurrent(manifestSHA string) error
 	FindCurrent() (manifestSHA string, found bool, err error)
+	UpdateCurrentManifest(manifest string) error
 }
 
 type deploymentRepo struct {
@@ -47,3 +48,18 @@ func (r deploymentRepo) UpdateCurrent(manifestSHA string) error {
 	}
 	return nil
 }
+
+func (r deploymentRepo) UpdateCurrentManifest(manifest string) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	deploymentState.CurrentManifest = manifest
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
@@ -64,4 +64,15 @@ var _ = Describe("DeploymentRepo", func() {
 			})
 		})
 	})
+
+	Describe("UpdateCurrentManifest", func() {
+		It("updates the deployed manifest", func() {
+			err := repo.UpdateCurrentManifest("name: fake-deployment-name\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentManifest).To(Equal("name: fake-deployment-name\n"))
+		})
+	})
 })
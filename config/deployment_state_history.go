@@ -0,0 +1,253 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const maxDeploymentStateSnapshots = 100
+
+type DeploymentStateSnapshot struct {
+	ID        int             `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	State     DeploymentState `json:"state"`
+}
+
+func (s DeploymentStateSnapshot) StemcellCID() string {
+	for _, stemcell := range s.State.Stemcells {
+		if stemcell.ID == s.State.CurrentStemcellID {
+			return stemcell.CID
+		}
+	}
+	return ""
+}
+
+type DeploymentStateHistory interface {
+	Record(DeploymentState) error
+	List() ([]DeploymentStateSnapshot, error)
+	Find(id int) (DeploymentStateSnapshot, bool, error)
+}
+
+type fileSystemDeploymentStateHistory struct {
+	historyPath string
+	fs          boshsys.FileSystem
+	clock       clock.Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewFileSystemDeploymentStateHistory(historyPath string, fs boshsys.FileSystem, clock clock.Clock, logger boshlog.Logger) DeploymentStateHistory {
+	return fileSystemDeploymentStateHistory{
+		historyPath: historyPath,
+		fs:          fs,
+		clock:       clock,
+		logger:      logger,
+		logTag:      "deploymentStateHistory",
+	}
+}
+
+// DeploymentStateHistoryPath keeps the history of local state files next to them.
+// History of remote states is kept in the local workspace and is not shared
+// with other machines using the same remote state.
+func DeploymentStateHistoryPath(deploymentStatePath string, workspaceRootPath string) string {
+	if strings.HasPrefix(deploymentStatePath, s3StatePrefix) || strings.HasPrefix(deploymentStatePath, gitStatePrefix) {
+		return filepath.Join(workspaceRootPath, "state-history", fmt.Sprintf("%x", sha1.Sum([]byte(deploymentStatePath))))
+	}
+
+	return deploymentStatePath + ".history"
+}
+
+func (h fileSystemDeploymentStateHistory) Record(deploymentState DeploymentState) error {
+	ids, err := h.ids()
+	if err != nil {
+		return err
+	}
+
+	nextID := 1
+	if len(ids) > 0 {
+		nextID = ids[len(ids)-1] + 1
+	}
+
+	snapshot := DeploymentStateSnapshot{
+		ID:        nextID,
+		Timestamp: h.clock.Now().UTC(),
+		State:     deploymentState,
+	}
+
+	jsonContent, err := json.MarshalIndent(snapshot, "", "    ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state snapshot into JSON")
+	}
+
+	err = h.fs.WriteFile(h.snapshotPath(nextID), jsonContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state snapshot '%s'", h.snapshotPath(nextID))
+	}
+
+	ids = append(ids, nextID)
+
+	for len(ids) > maxDeploymentStateSnapshots {
+		err = h.fs.RemoveAll(h.snapshotPath(ids[0]))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing deployment state snapshot '%s'", h.snapshotPath(ids[0]))
+		}
+		ids = ids[1:]
+	}
+
+	return nil
+}
+
+func (h fileSystemDeploymentStateHistory) List() ([]DeploymentStateSnapshot, error) {
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []DeploymentStateSnapshot
+
+	for _, id := range ids {
+		snapshot, err := h.read(id)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (h fileSystemDeploymentStateHistory) Find(id int) (DeploymentStateSnapshot, bool, error) {
+	if !h.fs.FileExists(h.snapshotPath(id)) {
+		return DeploymentStateSnapshot{}, false, nil
+	}
+
+	snapshot, err := h.read(id)
+	if err != nil {
+		return DeploymentStateSnapshot{}, false, err
+	}
+
+	return snapshot, true, nil
+}
+
+func (h fileSystemDeploymentStateHistory) ids() ([]int, error) {
+	paths, err := h.fs.Glob(filepath.Join(h.historyPath, "*.json"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing deployment state snapshots in '%s'", h.historyPath)
+	}
+
+	var ids []int
+
+	for _, path := range paths {
+		id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			h.logger.Debug(h.logTag, "Ignoring unexpected file in deployment state history: %s", path)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+func (h fileSystemDeploymentStateHistory) read(id int) (DeploymentStateSnapshot, error) {
+	contents, err := h.fs.ReadFile(h.snapshotPath(id))
+	if err != nil {
+		return DeploymentStateSnapshot{}, bosherr.WrapErrorf(err, "Reading deployment state snapshot '%s'", h.snapshotPath(id))
+	}
+
+	var snapshot DeploymentStateSnapshot
+
+	err = json.Unmarshal(contents, &snapshot)
+	if err != nil {
+		return DeploymentStateSnapshot{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state snapshot '%s'", h.snapshotPath(id))
+	}
+
+	return snapshot, nil
+}
+
+func (h fileSystemDeploymentStateHistory) snapshotPath(id int) string {
+	return filepath.Join(h.historyPath, fmt.Sprintf("%d.json", id))
+}
+
+// historyDeploymentStateService records a snapshot of the last state saved
+// by each operation holding the lock, e.g. a create-env or delete-env,
+// when it unlocks the state. States saved without the lock are recorded right away.
+type historyDeploymentStateService struct {
+	DeploymentStateService
+
+	history DeploymentStateHistory
+	logger  boshlog.Logger
+	logTag  string
+
+	locked     bool
+	savedState *DeploymentState
+}
+
+func NewHistoryDeploymentStateService(deploymentStateService DeploymentStateService, history DeploymentStateHistory, logger boshlog.Logger) DeploymentStateService {
+	return &historyDeploymentStateService{
+		DeploymentStateService: deploymentStateService,
+
+		history: history,
+		logger:  logger,
+		logTag:  "historyDeploymentStateService",
+	}
+}
+
+func (s *historyDeploymentStateService) Save(deploymentState DeploymentState) error {
+	err := s.DeploymentStateService.Save(deploymentState)
+	if err != nil {
+		return err
+	}
+
+	if s.locked {
+		s.savedState = &deploymentState
+		return nil
+	}
+
+	s.record(deploymentState)
+
+	return nil
+}
+
+func (s *historyDeploymentStateService) Lock() error {
+	err := s.DeploymentStateService.Lock()
+	if err != nil {
+		return err
+	}
+
+	s.locked = true
+
+	return nil
+}
+
+func (s *historyDeploymentStateService) Unlock() error {
+	if s.savedState != nil {
+		s.record(*s.savedState)
+	}
+
+	s.locked = false
+	s.savedState = nil
+
+	return s.DeploymentStateService.Unlock()
+}
+
+func (s *historyDeploymentStateService) record(deploymentState DeploymentState) {
+	// Failing to keep history should not fail the deployment
+	err := s.history.Record(deploymentState)
+	if err != nil {
+		s.logger.Warn(s.logTag, "Recording deployment state history: %s", err.Error())
+	}
+}
@@ -0,0 +1,210 @@
+package config_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("DeploymentStateHistory", func() {
+	var (
+		historyPath string
+		fs          boshsys.FileSystem
+		timeService *fakeclock.FakeClock
+		history     DeploymentStateHistory
+		logger      boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		historyPath, err = ioutil.TempDir("", "deployment-state-history")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+		history = NewFileSystemDeploymentStateHistory(historyPath, fs, timeService, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(historyPath)
+	})
+
+	Describe("DeploymentStateHistoryPath", func() {
+		It("keeps history of local state next to the state file", func() {
+			Expect(DeploymentStateHistoryPath("/path/to/state.json", "/workspace")).To(Equal("/path/to/state.json.history"))
+		})
+
+		It("keeps history of remote state in the workspace", func() {
+			Expect(DeploymentStateHistoryPath("s3://bucket/state.json", "/workspace")).To(HavePrefix("/workspace/state-history/"))
+			Expect(DeploymentStateHistoryPath("git+repo#state.json", "/workspace")).To(HavePrefix("/workspace/state-history/"))
+			Expect(DeploymentStateHistoryPath("s3://bucket/state.json", "/workspace")).ToNot(Equal(DeploymentStateHistoryPath("git+repo#state.json", "/workspace")))
+		})
+	})
+
+	Describe("Record", func() {
+		It("saves numbered snapshots with the current time", func() {
+			Expect(history.Record(DeploymentState{CurrentVMCID: "fake-vm-cid-1"})).To(Succeed())
+
+			timeService.Increment(time.Hour)
+			Expect(history.Record(DeploymentState{CurrentVMCID: "fake-vm-cid-2"})).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]DeploymentStateSnapshot{
+				{
+					ID:        1,
+					Timestamp: time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC),
+					State:     DeploymentState{CurrentVMCID: "fake-vm-cid-1"},
+				},
+				{
+					ID:        2,
+					Timestamp: time.Date(2017, time.March, 1, 11, 0, 0, 0, time.UTC),
+					State:     DeploymentState{CurrentVMCID: "fake-vm-cid-2"},
+				},
+			}))
+		})
+
+		It("removes the oldest snapshots when there are more than 100", func() {
+			for i := 0; i < 102; i++ {
+				Expect(history.Record(DeploymentState{})).To(Succeed())
+			}
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(100))
+			Expect(snapshots[0].ID).To(Equal(3))
+			Expect(snapshots[99].ID).To(Equal(102))
+		})
+
+		It("ignores unexpected files", func() {
+			Expect(fs.WriteFileString(filepath.Join(historyPath, "notes.json"), "{}")).To(Succeed())
+
+			Expect(history.Record(DeploymentState{})).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].ID).To(Equal(1))
+		})
+	})
+
+	Describe("Find", func() {
+		BeforeEach(func() {
+			Expect(history.Record(DeploymentState{CurrentVMCID: "fake-vm-cid"})).To(Succeed())
+		})
+
+		It("returns the snapshot with the given id", func() {
+			snapshot, found, err := history.Find(1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(snapshot.State.CurrentVMCID).To(Equal("fake-vm-cid"))
+		})
+
+		It("returns false when the snapshot does not exist", func() {
+			_, found, err := history.Find(2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error when the snapshot cannot be parsed", func() {
+			Expect(fs.WriteFileString(filepath.Join(historyPath, "1.json"), "not-json")).To(Succeed())
+
+			_, _, err := history.Find(1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state snapshot"))
+		})
+	})
+
+	Describe("DeploymentStateSnapshot", func() {
+		It("returns the CID of the current stemcell", func() {
+			snapshot := DeploymentStateSnapshot{
+				State: DeploymentState{
+					CurrentStemcellID: "stemcell-1",
+					Stemcells:         []StemcellRecord{{ID: "stemcell-2", CID: "fake-other-stemcell-cid"}, {ID: "stemcell-1", CID: "fake-stemcell-cid"}},
+				},
+			}
+
+			Expect(snapshot.StemcellCID()).To(Equal("fake-stemcell-cid"))
+		})
+	})
+
+	Describe("NewHistoryDeploymentStateService", func() {
+		var (
+			fakeFS  *fakesys.FakeFileSystem
+			service DeploymentStateService
+		)
+
+		BeforeEach(func() {
+			fakeFS = fakesys.NewFakeFileSystem()
+			service = NewHistoryDeploymentStateService(
+				NewFileSystemDeploymentStateService(fakeFS, fakeuuid.NewFakeGenerator(), logger, "/path/to/state.json"),
+				history,
+				logger,
+			)
+		})
+
+		It("records states saved without the lock right away", func() {
+			Expect(service.Save(DeploymentState{CurrentVMCID: "fake-vm-cid"})).To(Succeed())
+
+			snapshot, found, err := history.Find(1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(snapshot.State.CurrentVMCID).To(Equal("fake-vm-cid"))
+		})
+
+		It("records the last state saved while locked once when unlocking", func() {
+			Expect(service.Lock()).To(Succeed())
+			Expect(service.Save(DeploymentState{CurrentVMCID: "fake-vm-cid-1"})).To(Succeed())
+			Expect(service.Save(DeploymentState{CurrentVMCID: "fake-vm-cid-2"})).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+
+			Expect(service.Unlock()).To(Succeed())
+
+			snapshots, err = history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].State.CurrentVMCID).To(Equal("fake-vm-cid-2"))
+			Expect(fakeFS.FileExists("/path/to/state.json.lock")).To(BeFalse())
+		})
+
+		It("does not record anything when nothing was saved while locked", func() {
+			Expect(service.Lock()).To(Succeed())
+			Expect(service.Unlock()).To(Succeed())
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+		})
+
+		It("does not record state that failed to save", func() {
+			fakeFS.WriteFileError = errors.New("fake-write-error")
+
+			err := service.Save(DeploymentState{CurrentVMCID: "fake-vm-cid"})
+			Expect(err).To(HaveOccurred())
+
+			snapshots, err := history.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+		})
+
+		It("delegates to the wrapped service", func() {
+			Expect(service.Path()).To(Equal("/path/to/state.json"))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
@@ -12,9 +14,38 @@ type DeploymentState struct {
 	CurrentDiskID      string           `json:"current_disk_id"`
 	CurrentReleaseIDs  []string         `json:"current_release_ids"`
 	CurrentManifestSHA string           `json:"current_manifest_sha"`
+	CurrentManifest    string           `json:"current_manifest,omitempty"`
 	Disks              []DiskRecord     `json:"disks"`
 	Stemcells          []StemcellRecord `json:"stemcells"`
 	Releases           []ReleaseRecord  `json:"releases"`
+
+	// Snapshots of disks taken before migrating their content to a new disk
+	Snapshots []SnapshotRecord `json:"snapshots,omitempty"`
+
+	// Current named persistent disks of the first instance of the first job by disk name
+	CurrentNamedDiskIDs map[string]string `json:"current_named_disk_ids,omitempty"`
+
+	// Instances other than the first instance of the first job,
+	// whose VM and disk are tracked by CurrentVMCID and CurrentDiskID
+	Instances []InstanceRecord `json:"instances,omitempty"`
+
+	// Version is incremented by remote state services on every save
+	// and used to detect concurrent modifications
+	Version int `json:"version,omitempty"`
+}
+
+type InstanceRecord struct {
+	Name    string `json:"name"`
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+	VMCID   string `json:"vm_cid"`
+	DiskID  string `json:"disk_id"`
+
+	// VMConfigDigest identifies the stemcell, resource pool and networks the VM was created with,
+	// the VM is recreated when they change
+	VMConfigDigest string `json:"vm_config_digest,omitempty"`
+
+	NamedDiskIDs map[string]string `json:"named_disk_ids,omitempty"`
 }
 
 type StemcellRecord struct {
@@ -30,6 +61,21 @@ type DiskRecord struct {
 	CID             string         `json:"cid"`
 	Size            int            `json:"size"`
 	CloudProperties biproperty.Map `json:"cloud_properties"`
+
+	// Name is empty for the disk mounted by the agent
+	// and set for named persistent disks
+	Name string `json:"name,omitempty"`
+
+	// OrphanedUntil is set for disks replaced by a migration,
+	// which are kept until then instead of being deleted as unused
+	OrphanedUntil *time.Time `json:"orphaned_until,omitempty"`
+}
+
+type SnapshotRecord struct {
+	ID        string    `json:"id"`
+	CID       string    `json:"cid"`
+	DiskCID   string    `json:"disk_cid"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type ReleaseRecord struct {
@@ -40,8 +86,15 @@ type ReleaseRecord struct {
 
 type DeploymentStateService interface {
 	Path() string
-	Exists() bool
+	Exists() (bool, error)
 	Load() (DeploymentState, error)
+
+	// Read returns the stored state without generating and saving missing IDs like Load
+	Read() (DeploymentState, error)
+
 	Save(DeploymentState) error
 	Cleanup() error
+
+	Lock() error
+	Unlock() error
 }
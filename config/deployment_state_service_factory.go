@@ -0,0 +1,95 @@
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+const (
+	s3StatePrefix  = "s3://"
+	gitStatePrefix = "git+"
+
+	defaultS3Region = "us-east-1"
+)
+
+// NewDeploymentStateService picks a state service based on the state path.
+// s3://bucket/path/state.json[?region=...&endpoint=...] stores state in an S3 compatible bucket,
+// git+<repo-url>#path/state.json stores state in a git repository,
+// anything else is a path on the local file system.
+func NewDeploymentStateService(
+	fs boshsys.FileSystem,
+	uuidGenerator boshuuid.Generator,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	deploymentStatePath string,
+	workspaceRootPath string,
+) DeploymentStateService {
+	if strings.HasPrefix(deploymentStatePath, s3StatePrefix) {
+		return newS3DeploymentStateServiceFromURL(deploymentStatePath, uuidGenerator, logger)
+	}
+
+	if strings.HasPrefix(deploymentStatePath, gitStatePrefix) {
+		repoURL, filePath := parseGitStatePath(deploymentStatePath)
+		checkoutPath := filepath.Join(workspaceRootPath, "state-repos", fmt.Sprintf("%x", sha1.Sum([]byte(repoURL))))
+
+		return NewGitDeploymentStateService(repoURL, filePath, checkoutPath, fs, runner, uuidGenerator, logger)
+	}
+
+	return NewFileSystemDeploymentStateService(fs, uuidGenerator, logger, deploymentStatePath)
+}
+
+func newS3DeploymentStateServiceFromURL(deploymentStatePath string, uuidGenerator boshuuid.Generator, logger boshlog.Logger) DeploymentStateService {
+	bucket, key, query := parseS3StatePath(deploymentStatePath)
+
+	awsConfig := aws.NewConfig().WithRegion(defaultS3Region)
+	if region := query.Get("region"); region != "" {
+		awsConfig = awsConfig.WithRegion(region)
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	// Credentials are taken from the environment or the shared AWS configuration;
+	// session errors surface on the first request.
+	client := s3.New(session.New(awsConfig))
+
+	return NewS3DeploymentStateService(client, bucket, key, uuidGenerator, logger)
+}
+
+func parseS3StatePath(deploymentStatePath string) (string, string, url.Values) {
+	path := strings.TrimPrefix(deploymentStatePath, s3StatePrefix)
+
+	var query url.Values
+	if i := strings.Index(path, "?"); i >= 0 {
+		query, _ = url.ParseQuery(path[i+1:])
+		path = path[:i]
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) < 2 {
+		return parts[0], "", query
+	}
+
+	return parts[0], parts[1], query
+}
+
+func parseGitStatePath(deploymentStatePath string) (string, string) {
+	path := strings.TrimPrefix(deploymentStatePath, gitStatePrefix)
+
+	i := strings.LastIndex(path, "#")
+	if i < 0 {
+		return path, "state.json"
+	}
+
+	return path[:i], path[i+1:]
+}
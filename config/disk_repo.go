@@ -1,6 +1,9 @@
 package config
 
 import (
+	"sort"
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -9,9 +12,14 @@ import (
 type DiskRepo interface {
 	UpdateCurrent(diskID string) error
 	FindCurrent() (DiskRecord, bool, error)
+	FindAllCurrent() ([]DiskRecord, error)
+	CurrentDiskNames() ([]string, error)
+	Named(diskName string) DiskRepo
 	ClearCurrent() error
 	Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
 	Find(cid string) (DiskRecord, bool, error)
+	UpdateSize(cid string, size int) error
+	Orphan(cid string, until time.Time) error
 	All() ([]DiskRecord, error)
 	Delete(DiskRecord) error
 }
@@ -19,6 +27,12 @@ type DiskRepo interface {
 type diskRepo struct {
 	deploymentStateService DeploymentStateService
 	uuidGenerator          boshuuid.Generator
+
+	// instance is nil for the first instance of the first job
+	instance *instanceKey
+
+	// diskName is empty for the disk mounted by the agent
+	diskName string
 }
 
 func NewDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator boshuuid.Generator) DiskRepo {
@@ -28,6 +42,21 @@ func NewDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator bo
 	}
 }
 
+// NewInstanceDiskRepo tracks the current disk of an additional instance
+func NewInstanceDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator boshuuid.Generator, name string, index int) DiskRepo {
+	return diskRepo{
+		deploymentStateService: deploymentStateService,
+		uuidGenerator:          uuidGenerator,
+		instance:               &instanceKey{name: name, index: index},
+	}
+}
+
+// Named returns a repo that tracks the named persistent disk of the same instance
+func (r diskRepo) Named(diskName string) DiskRepo {
+	r.diskName = diskName
+	return r
+}
+
 func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
 	config, records, err := r.load()
 	if err != nil {
@@ -43,6 +72,7 @@ func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (Di
 		CID:             cid,
 		Size:            size,
 		CloudProperties: cloudProperties,
+		Name:            r.diskName,
 	}
 	newRecord.ID, err = r.uuidGenerator.Generate()
 	if err != nil {
@@ -65,7 +95,7 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 		return DiskRecord{}, false, bosherr.WrapError(err, "Loading existing config")
 	}
 
-	currentDiskID := deploymentState.CurrentDiskID
+	currentDiskID := r.currentDiskID(deploymentState)
 	if currentDiskID == "" {
 		return DiskRecord{}, false, nil
 	}
@@ -79,6 +109,50 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 	return DiskRecord{}, false, nil
 }
 
+// FindAllCurrent returns the current disks of all instances
+func (r diskRepo) FindAllCurrent() ([]DiskRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []DiskRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	currentDiskIDs := map[string]bool{deploymentState.CurrentDiskID: true}
+	for _, diskID := range deploymentState.CurrentNamedDiskIDs {
+		currentDiskIDs[diskID] = true
+	}
+	for _, instance := range deploymentState.Instances {
+		currentDiskIDs[instance.DiskID] = true
+		for _, diskID := range instance.NamedDiskIDs {
+			currentDiskIDs[diskID] = true
+		}
+	}
+
+	records := []DiskRecord{}
+	for _, record := range deploymentState.Disks {
+		if currentDiskIDs[record.ID] {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// CurrentDiskNames returns the names of the current named persistent disks of the instance
+func (r diskRepo) CurrentDiskNames() ([]string, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []string{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	diskNames := []string{}
+	for diskName := range r.namedDiskIDs(deploymentState) {
+		diskNames = append(diskNames, diskName)
+	}
+	sort.Strings(diskNames)
+
+	return diskNames, nil
+}
+
 func (r diskRepo) UpdateCurrent(diskID string) error {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -95,7 +169,7 @@ func (r diskRepo) UpdateCurrent(diskID string) error {
 		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
 	}
 
-	deploymentState.CurrentDiskID = diskID
+	r.setCurrentDiskID(&deploymentState, diskID)
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -114,6 +188,65 @@ func (r diskRepo) Find(cid string) (DiskRecord, bool, error) {
 	return foundRecord, found, nil
 }
 
+// UpdateSize records the size of a disk that was resized in place
+func (r diskRepo) UpdateSize(cid string, size int) error {
+	config, records, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range records {
+		if records[i].CID == cid {
+			records[i].Size = size
+			found = true
+		}
+	}
+
+	if !found {
+		return bosherr.Errorf("Failed to update size of disk cid '%s', no existing record found", cid)
+	}
+
+	config.Disks = records
+
+	err = r.deploymentStateService.Save(config)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+// Orphan keeps an unused disk until the given time instead of deleting it right away
+func (r diskRepo) Orphan(cid string, until time.Time) error {
+	config, records, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range records {
+		if records[i].CID == cid {
+			orphanedUntil := until
+			records[i].OrphanedUntil = &orphanedUntil
+			found = true
+		}
+	}
+
+	if !found {
+		return bosherr.Errorf("Failed to orphan disk cid '%s', no existing record found", cid)
+	}
+
+	config.Disks = records
+
+	err = r.deploymentStateService.Save(config)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
 func (r diskRepo) All() ([]DiskRecord, error) {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -142,6 +275,15 @@ func (r diskRepo) Delete(diskRecord DiskRecord) error {
 		config.CurrentDiskID = ""
 	}
 
+	deleteNamedDiskID(config.CurrentNamedDiskIDs, diskRecord.ID)
+
+	for i := range config.Instances {
+		if config.Instances[i].DiskID == diskRecord.ID {
+			config.Instances[i].DiskID = ""
+		}
+		deleteNamedDiskID(config.Instances[i].NamedDiskIDs, diskRecord.ID)
+	}
+
 	err = r.deploymentStateService.Save(config)
 	if err != nil {
 		return bosherr.WrapError(err, "Saving new config")
@@ -156,7 +298,7 @@ func (r diskRepo) ClearCurrent() error {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
-	deploymentState.CurrentDiskID = ""
+	r.setCurrentDiskID(&deploymentState, "")
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -187,3 +329,72 @@ func (r diskRepo) find(records []DiskRecord, cid string) (DiskRecord, bool) {
 	}
 	return DiskRecord{}, false
 }
+
+func (r diskRepo) currentDiskID(deploymentState DeploymentState) string {
+	if r.diskName != "" {
+		return r.namedDiskIDs(deploymentState)[r.diskName]
+	}
+
+	if r.instance == nil {
+		return deploymentState.CurrentDiskID
+	}
+
+	record, _ := r.instance.find(deploymentState)
+	return record.DiskID
+}
+
+func (r diskRepo) setCurrentDiskID(deploymentState *DeploymentState, diskID string) {
+	if r.diskName != "" {
+		r.setNamedDiskID(deploymentState, diskID)
+		return
+	}
+
+	if r.instance == nil {
+		deploymentState.CurrentDiskID = diskID
+		return
+	}
+
+	r.instance.update(deploymentState, func(record *InstanceRecord) { record.DiskID = diskID })
+}
+
+func (r diskRepo) namedDiskIDs(deploymentState DeploymentState) map[string]string {
+	if r.instance == nil {
+		return deploymentState.CurrentNamedDiskIDs
+	}
+
+	record, _ := r.instance.find(deploymentState)
+	return record.NamedDiskIDs
+}
+
+func (r diskRepo) setNamedDiskID(deploymentState *DeploymentState, diskID string) {
+	update := func(namedDiskIDs map[string]string) map[string]string {
+		if diskID == "" {
+			delete(namedDiskIDs, r.diskName)
+			if len(namedDiskIDs) == 0 {
+				return nil
+			}
+			return namedDiskIDs
+		}
+
+		if namedDiskIDs == nil {
+			namedDiskIDs = map[string]string{}
+		}
+		namedDiskIDs[r.diskName] = diskID
+		return namedDiskIDs
+	}
+
+	if r.instance == nil {
+		deploymentState.CurrentNamedDiskIDs = update(deploymentState.CurrentNamedDiskIDs)
+		return
+	}
+
+	r.instance.update(deploymentState, func(record *InstanceRecord) { record.NamedDiskIDs = update(record.NamedDiskIDs) })
+}
+
+func deleteNamedDiskID(namedDiskIDs map[string]string, diskID string) {
+	for diskName, namedDiskID := range namedDiskIDs {
+		if namedDiskID == diskID {
+			delete(namedDiskIDs, diskName)
+		}
+	}
+}
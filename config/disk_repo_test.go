@@ -1,6 +1,8 @@
 package config_test
 
 import (
+	"time"
+
 	. "github.com/cloudfoundry/bosh-cli/config"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -81,6 +83,50 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("UpdateSize", func() {
+		It("updates the size of the disk record", func() {
+			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateSize("fake-cid", 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundRecord, found, err := repo.Find("fake-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundRecord.ID).To(Equal(savedRecord.ID))
+			Expect(foundRecord.Size).To(Equal(2048))
+		})
+
+		It("returns an error when the disk is not in the records", func() {
+			err := repo.UpdateSize("fake-cid", 2048)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no existing record found"))
+		})
+	})
+
+	Describe("Orphan", func() {
+		It("records until when the disk is kept", func() {
+			_, err := repo.Save("fake-cid", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			until := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+			err = repo.Orphan("fake-cid", until)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundRecord, found, err := repo.Find("fake-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundRecord.OrphanedUntil).To(Equal(&until))
+		})
+
+		It("returns an error when the disk is not in the records", func() {
+			err := repo.Orphan("fake-cid", time.Now())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no existing record found"))
+		})
+	})
+
 	Describe("UpdateCurrent", func() {
 		Context("when a disk record exists with the same ID", func() {
 			var (
@@ -4,6 +4,9 @@ type FakeDeploymentRepo struct {
 	UpdateCurrentManifestSHA string
 	UpdateCurrentErr         error
 
+	UpdateCurrentManifestManifest string
+	UpdateCurrentManifestErr      error
+
 	findCurrentOutput deploymentRepoFindCurrentOutput
 }
 
@@ -33,3 +36,8 @@ func (r *FakeDeploymentRepo) SetFindCurrentBehavior(manifestSHA string, found bo
 		err:         err,
 	}
 }
+
+func (r *FakeDeploymentRepo) UpdateCurrentManifest(manifest string) error {
+	r.UpdateCurrentManifestManifest = manifest
+	return r.UpdateCurrentManifestErr
+}
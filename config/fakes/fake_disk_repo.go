@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
@@ -19,7 +21,19 @@ type FakeDiskRepo struct {
 	DeleteInputs []DiskRepoDeleteInput
 	DeleteErr    error
 
+	UpdateSizeInputs []DiskRepoUpdateSizeInput
+	UpdateSizeErr    error
+
+	OrphanInputs []DiskRepoOrphanInput
+	OrphanErr    error
+
 	allOutput diskRepoAllOutput
+
+	CurrentDiskNamesNames []string
+	CurrentDiskNamesErr   error
+
+	NamedInputs []string
+	NamedRepos  map[string]biconfig.DiskRepo
 }
 
 type DiskRepoUpdateCurrentInput struct {
@@ -47,6 +61,16 @@ type DiskRepoDeleteInput struct {
 	DiskRecord biconfig.DiskRecord
 }
 
+type DiskRepoUpdateSizeInput struct {
+	CID  string
+	Size int
+}
+
+type DiskRepoOrphanInput struct {
+	CID   string
+	Until time.Time
+}
+
 type diskRepoFindOutput struct {
 	diskRecord biconfig.DiskRecord
 	found      bool
@@ -78,6 +102,25 @@ func (r *FakeDiskRepo) FindCurrent() (biconfig.DiskRecord, bool, error) {
 	return r.findCurrentOutput.diskRecord, r.findCurrentOutput.found, r.findCurrentOutput.err
 }
 
+func (r *FakeDiskRepo) FindAllCurrent() ([]biconfig.DiskRecord, error) {
+	if !r.findCurrentOutput.found {
+		return []biconfig.DiskRecord{}, r.findCurrentOutput.err
+	}
+	return []biconfig.DiskRecord{r.findCurrentOutput.diskRecord}, r.findCurrentOutput.err
+}
+
+func (r *FakeDiskRepo) CurrentDiskNames() ([]string, error) {
+	return r.CurrentDiskNamesNames, r.CurrentDiskNamesErr
+}
+
+func (r *FakeDiskRepo) Named(diskName string) biconfig.DiskRepo {
+	r.NamedInputs = append(r.NamedInputs, diskName)
+	if namedRepo, found := r.NamedRepos[diskName]; found {
+		return namedRepo
+	}
+	return r
+}
+
 func (r *FakeDiskRepo) ClearCurrent() error {
 	return nil
 }
@@ -96,6 +139,22 @@ func (r *FakeDiskRepo) Find(cid string) (biconfig.DiskRecord, bool, error) {
 	return r.findOutput[cid].diskRecord, r.findOutput[cid].found, r.findOutput[cid].err
 }
 
+func (r *FakeDiskRepo) UpdateSize(cid string, size int) error {
+	r.UpdateSizeInputs = append(r.UpdateSizeInputs, DiskRepoUpdateSizeInput{
+		CID:  cid,
+		Size: size,
+	})
+	return r.UpdateSizeErr
+}
+
+func (r *FakeDiskRepo) Orphan(cid string, until time.Time) error {
+	r.OrphanInputs = append(r.OrphanInputs, DiskRepoOrphanInput{
+		CID:   cid,
+		Until: until,
+	})
+	return r.OrphanErr
+}
+
 func (r *FakeDiskRepo) All() ([]biconfig.DiskRecord, error) {
 	return r.allOutput.diskRecords, r.allOutput.err
 }
@@ -0,0 +1,120 @@
+package fakes
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// FakeS3Server is an in-memory S3 compatible server that supports
+// path style GET, PUT, HEAD and DELETE requests on objects
+// and conditional PUT requests with 'If-None-Match: *' and 'If-Match'.
+type FakeS3Server struct {
+	URL string
+
+	server  *httptest.Server
+	objects map[string][]byte
+	lock    sync.Mutex
+
+	PutCount int
+
+	// DenyAccess fails all requests as if credentials were invalid
+	DenyAccess bool
+
+	// BeforePut is called with the bucket and key of every PUT request before it is handled
+	BeforePut func(bucket, key string)
+}
+
+func NewFakeS3Server() *FakeS3Server {
+	s := &FakeS3Server{objects: map[string][]byte{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+	return s
+}
+
+func (s *FakeS3Server) Close() {
+	s.server.Close()
+}
+
+func (s *FakeS3Server) Object(bucket, key string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	contents, found := s.objects[bucket+"/"+key]
+	return contents, found
+}
+
+func (s *FakeS3Server) SetObject(bucket, key string, contents []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.objects[bucket+"/"+key] = contents
+}
+
+func (s *FakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	objectPath := strings.TrimPrefix(r.URL.Path, "/")
+
+	if r.Method == "PUT" && s.BeforePut != nil {
+		parts := strings.SplitN(objectPath, "/", 2)
+		s.BeforePut(parts[0], parts[1])
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.DenyAccess {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+		return
+	}
+
+	switch r.Method {
+	case "GET", "HEAD":
+		contents, found := s.objects[objectPath]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == "GET" {
+				w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			}
+			return
+		}
+		w.Header().Set("ETag", etag(contents))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "GET" {
+			w.Write(contents)
+		}
+
+	case "PUT":
+		contents, found := s.objects[objectPath]
+		ifMatch := r.Header.Get("If-Match")
+		if (found && r.Header.Get("If-None-Match") == "*") || (ifMatch != "" && (!found || ifMatch != etag(contents))) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`))
+			return
+		}
+
+		contents, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[objectPath] = contents
+		s.PutCount++
+		w.WriteHeader(http.StatusOK)
+
+	case "DELETE":
+		delete(s.objects, objectPath)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func etag(contents []byte) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum(contents))
+}
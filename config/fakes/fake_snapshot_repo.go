@@ -0,0 +1,47 @@
+package fakes
+
+import (
+	"time"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+type FakeSnapshotRepo struct {
+	SaveInputs []SnapshotRepoSaveInput
+	SaveRecord biconfig.SnapshotRecord
+	SaveErr    error
+
+	AllRecords []biconfig.SnapshotRecord
+	AllErr     error
+
+	DeleteInputs []biconfig.SnapshotRecord
+	DeleteErr    error
+}
+
+type SnapshotRepoSaveInput struct {
+	CID       string
+	DiskCID   string
+	CreatedAt time.Time
+}
+
+func NewFakeSnapshotRepo() *FakeSnapshotRepo {
+	return &FakeSnapshotRepo{}
+}
+
+func (r *FakeSnapshotRepo) Save(cid, diskCID string, createdAt time.Time) (biconfig.SnapshotRecord, error) {
+	r.SaveInputs = append(r.SaveInputs, SnapshotRepoSaveInput{
+		CID:       cid,
+		DiskCID:   diskCID,
+		CreatedAt: createdAt,
+	})
+	return r.SaveRecord, r.SaveErr
+}
+
+func (r *FakeSnapshotRepo) All() ([]biconfig.SnapshotRecord, error) {
+	return r.AllRecords, r.AllErr
+}
+
+func (r *FakeSnapshotRepo) Delete(snapshotRecord biconfig.SnapshotRecord) error {
+	r.DeleteInputs = append(r.DeleteInputs, snapshotRecord)
+	return r.DeleteErr
+}
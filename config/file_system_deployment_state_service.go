@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -43,8 +44,8 @@ func (s *fileSystemDeploymentStateService) Path() string {
 	return s.configPath
 }
 
-func (s *fileSystemDeploymentStateService) Exists() bool {
-	return s.fs.FileExists(s.configPath)
+func (s *fileSystemDeploymentStateService) Exists() (bool, error) {
+	return s.fs.FileExists(s.configPath), nil
 }
 
 func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
@@ -54,7 +55,25 @@ func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
 
 	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.configPath)
 
-	deploymentState := &DeploymentState{}
+	deploymentState, err := s.Read()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	err = s.initDefaults(&deploymentState)
+	if err != nil {
+		return DeploymentState{}, bosherr.WrapErrorf(err, "Initializing deployment state defaults")
+	}
+
+	return deploymentState, nil
+}
+
+func (s *fileSystemDeploymentStateService) Read() (DeploymentState, error) {
+	if s.configPath == "" {
+		panic("configPath not yet set!")
+	}
+
+	deploymentState := DeploymentState{}
 
 	if s.fs.FileExists(s.configPath) {
 		deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
@@ -63,18 +82,13 @@ func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
 		}
 		s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
 
-		err = json.Unmarshal(deploymentStateFileContents, deploymentState)
+		err = json.Unmarshal(deploymentStateFileContents, &deploymentState)
 		if err != nil {
 			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.configPath)
 		}
 	}
 
-	err := s.initDefaults(deploymentState)
-	if err != nil {
-		return DeploymentState{}, bosherr.WrapErrorf(err, "Initializing deployment state defaults")
-	}
-
-	return *deploymentState, nil
+	return deploymentState, nil
 }
 
 func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState) error {
@@ -121,3 +135,69 @@ func (s *fileSystemDeploymentStateService) Cleanup() error {
 	}
 	return nil
 }
+
+// Lock takes an advisory lock by exclusively creating a lock file next to the state file
+func (s *fileSystemDeploymentStateService) Lock() error {
+	lockPath := s.lockPath()
+
+	if s.fs.FileExists(lockPath) {
+		return s.lockedError(lockPath)
+	}
+
+	err := s.fs.MkdirAll(filepath.Dir(lockPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating deployment state lock file directory")
+	}
+
+	lockFile, err := s.fs.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return s.lockedError(lockPath)
+		}
+		return bosherr.WrapErrorf(err, "Creating deployment state lock file '%s'", lockPath)
+	}
+
+	defer lockFile.Close()
+
+	_, err = lockFile.Write([]byte(lockOwner()))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state lock file '%s'", lockPath)
+	}
+
+	s.logger.Debug(s.logTag, "Locked deployment state: %s", s.configPath)
+
+	return nil
+}
+
+func (s *fileSystemDeploymentStateService) Unlock() error {
+	err := s.fs.RemoveAll(s.lockPath())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing deployment state lock file '%s'", s.lockPath())
+	}
+
+	s.logger.Debug(s.logTag, "Unlocked deployment state: %s", s.configPath)
+
+	return nil
+}
+
+func (s *fileSystemDeploymentStateService) lockPath() string {
+	return s.configPath + ".lock"
+}
+
+func (s *fileSystemDeploymentStateService) lockedError(lockPath string) error {
+	owner, err := s.fs.ReadFileString(lockPath)
+	if err != nil || owner == "" {
+		owner = "unknown"
+	}
+
+	return bosherr.Errorf("Deployment state '%s' is locked by '%s'. Remove '%s' if no other process is using it", s.configPath, owner, lockPath)
+}
+
+func lockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%d@%s", os.Getpid(), hostname)
+}
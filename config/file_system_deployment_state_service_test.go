@@ -139,6 +139,26 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 		})
 	})
 
+	Describe("Read", func() {
+		It("reads the given config file without generating defaults", func() {
+			fakeFs.WriteFileString(deploymentStatePath, `{"current_vm_cid":"fake-vm-cid"}`)
+
+			deploymentState, err := service.Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentState).To(Equal(DeploymentState{CurrentVMCID: "fake-vm-cid"}))
+
+			Expect(fakeFs.ReadFileString(deploymentStatePath)).To(Equal(`{"current_vm_cid":"fake-vm-cid"}`))
+		})
+
+		It("returns an empty DeploymentState without creating the config when it does not exist", func() {
+			deploymentState, err := service.Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deploymentState).To(Equal(DeploymentState{}))
+
+			Expect(fakeFs.FileExists(deploymentStatePath)).To(BeFalse())
+		})
+	})
+
 	Describe("Save", func() {
 		It("writes the deployment state to the deployment file", func() {
 			config := DeploymentState{
@@ -231,4 +251,46 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 			Expect(err.Error()).To(ContainSubstring("Could not do that Dave"))
 		})
 	})
+
+	Describe("Lock", func() {
+		It("creates a lock file next to the state file", func() {
+			err := service.Lock()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFs.FileExists("/some/deployment.json.lock")).To(BeTrue())
+			owner, err := fakeFs.ReadFileString("/some/deployment.json.lock")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(owner).To(MatchRegexp(`^\d+@`))
+		})
+
+		It("returns an error when the state is already locked", func() {
+			fakeFs.WriteFileString("/some/deployment.json.lock", "123@other-host")
+
+			err := service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deployment state '/some/deployment.json' is locked by '123@other-host'"))
+		})
+
+		It("returns an error when the lock file cannot be created", func() {
+			fakeFs.OpenFileErr = errors.New("fake-open-file-error")
+
+			err := service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-open-file-error"))
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes the lock file", func() {
+			err := service.Lock()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = service.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeFs.FileExists("/some/deployment.json.lock")).To(BeFalse())
+
+			err = service.Lock()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 })
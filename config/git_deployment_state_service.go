@@ -0,0 +1,327 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// gitEmptyTree is the ID of the empty tree object, which exists in every repository
+const gitEmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// gitDeploymentStateService keeps the deployment state as a file in a git repository.
+// Lock pushes a lock ref that must not exist yet, so only one process holds it.
+// Saves are committed and pushed; a rejected push or a version mismatch
+// means that somebody else has modified the state in the meantime.
+type gitDeploymentStateService struct {
+	repoURL       string
+	filePath      string
+	checkoutPath  string
+	fs            boshsys.FileSystem
+	runner        boshsys.CmdRunner
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+	logTag        string
+
+	// lockCommit is set while the lock is held
+	lockCommit string
+}
+
+func NewGitDeploymentStateService(
+	repoURL string,
+	filePath string,
+	checkoutPath string,
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) DeploymentStateService {
+	return &gitDeploymentStateService{
+		repoURL:       repoURL,
+		filePath:      filePath,
+		checkoutPath:  checkoutPath,
+		fs:            fs,
+		runner:        runner,
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		logTag:        "gitDeploymentStateService",
+	}
+}
+
+func (s *gitDeploymentStateService) Path() string {
+	return fmt.Sprintf("git+%s#%s", s.repoURL, s.filePath)
+}
+
+func (s *gitDeploymentStateService) Exists() (bool, error) {
+	err := s.sync()
+	if err != nil {
+		return false, err
+	}
+
+	return s.fs.FileExists(s.statePath()), nil
+}
+
+func (s *gitDeploymentStateService) Load() (DeploymentState, error) {
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.Path())
+
+	err := s.sync()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	deploymentState, err := s.read()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	if deploymentState.DirectorID == "" {
+		deploymentState.DirectorID, err = s.uuidGenerator.Generate()
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Generating DirectorID")
+		}
+
+		err = s.save(&deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Saving deployment state")
+		}
+	}
+
+	return deploymentState, nil
+}
+
+func (s *gitDeploymentStateService) Read() (DeploymentState, error) {
+	err := s.sync()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	return s.read()
+}
+
+func (s *gitDeploymentStateService) Save(deploymentState DeploymentState) error {
+	err := s.sync()
+	if err != nil {
+		return err
+	}
+
+	return s.save(&deploymentState)
+}
+
+// save expects the checkout to be in sync with the remote repository
+func (s *gitDeploymentStateService) save(deploymentState *DeploymentState) error {
+	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+
+	storedState, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	err = checkDeploymentStateVersion(s.Path(), storedState, *deploymentState)
+	if err != nil {
+		return err
+	}
+
+	deploymentState.Version++
+
+	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
+	}
+
+	err = s.fs.WriteFile(s.statePath(), jsonContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state file '%s'", s.statePath())
+	}
+
+	return s.commitAndPush(fmt.Sprintf("Update deployment state to version %d", deploymentState.Version), "add", s.filePath)
+}
+
+func (s *gitDeploymentStateService) Cleanup() error {
+	err := s.sync()
+	if err != nil {
+		return err
+	}
+
+	if !s.fs.FileExists(s.statePath()) {
+		return nil
+	}
+
+	err = s.commitAndPush("Delete deployment state", "rm", s.filePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Could not delete deployment state %s", s.Path())
+	}
+
+	return nil
+}
+
+func (s *gitDeploymentStateService) Lock() error {
+	err := s.sync()
+	if err != nil {
+		return err
+	}
+
+	// lock commit only carries the owner, it uses the empty tree
+	lockCommit, _, _, err := s.git("commit-tree", gitEmptyTree, "-m", lockOwner())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating deployment state lock commit")
+	}
+
+	lockCommit = strings.TrimSpace(lockCommit)
+
+	// empty expected value makes the push fail if the lock ref already exists
+	_, _, _, err = s.git("push", "--quiet", "--force-with-lease="+s.lockRef()+":", "origin", lockCommit+":"+s.lockRef())
+	if err != nil {
+		return s.lockedError(err)
+	}
+
+	s.lockCommit = lockCommit
+
+	s.logger.Debug(s.logTag, "Locked deployment state: %s", s.Path())
+
+	return nil
+}
+
+func (s *gitDeploymentStateService) Unlock() error {
+	if len(s.lockCommit) == 0 {
+		return nil
+	}
+
+	// only delete the lock ref if it is still the one pushed by Lock
+	_, _, _, err := s.git("push", "--quiet", "--force-with-lease="+s.lockRef()+":"+s.lockCommit, "origin", ":"+s.lockRef())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing deployment state lock '%s' from '%s'", s.lockRef(), s.repoURL)
+	}
+
+	s.lockCommit = ""
+
+	s.logger.Debug(s.logTag, "Unlocked deployment state: %s", s.Path())
+
+	return nil
+}
+
+// lockRef is specific to the state file so that one repository can keep states of several environments
+func (s *gitDeploymentStateService) lockRef() string {
+	return fmt.Sprintf("refs/bosh-state-locks/%x", sha1.Sum([]byte(s.filePath)))
+}
+
+func (s *gitDeploymentStateService) lockedError(pushErr error) error {
+	_, _, _, err := s.git("fetch", "--quiet", "origin", s.lockRef())
+	if err != nil {
+		// lock ref does not exist, so the push failed for another reason
+		return bosherr.WrapErrorf(pushErr, "Pushing deployment state lock '%s' to '%s'", s.lockRef(), s.repoURL)
+	}
+
+	owner, _, _, err := s.git("log", "-1", "--format=%s", "FETCH_HEAD")
+	if err != nil || len(strings.TrimSpace(owner)) == 0 {
+		owner = "unknown"
+	}
+
+	return bosherr.Errorf(
+		"Deployment state '%s' is locked by '%s'. Delete ref '%s' in '%s' if no other process is using it",
+		s.Path(), strings.TrimSpace(owner), s.lockRef(), s.repoURL)
+}
+
+func (s *gitDeploymentStateService) sync() error {
+	if !s.fs.FileExists(filepath.Join(s.checkoutPath, ".git")) {
+		err := s.fs.MkdirAll(filepath.Dir(s.checkoutPath), 0700)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating deployment state checkout directory")
+		}
+
+		_, _, _, err = s.runner.RunCommand("git", "clone", "--quiet", s.repoURL, s.checkoutPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Cloning deployment state repository '%s'", s.repoURL)
+		}
+
+		return nil
+	}
+
+	_, _, _, err := s.git("fetch", "--quiet", "origin")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching deployment state repository '%s'", s.repoURL)
+	}
+
+	// a freshly created repository has no branch until the first state is pushed
+	_, _, _, err = s.git("rev-parse", "--quiet", "--verify", "@{upstream}")
+	if err != nil {
+		return s.resetToEmptyTree()
+	}
+
+	_, _, _, err = s.git("reset", "--quiet", "--hard", "@{upstream}")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resetting deployment state checkout '%s'", s.checkoutPath)
+	}
+
+	return nil
+}
+
+// resetToEmptyTree drops commits and files that have not been pushed,
+// so that the next commit starts the history of the upstream branch
+func (s *gitDeploymentStateService) resetToEmptyTree() error {
+	for _, args := range [][]string{
+		{"update-ref", "-d", "HEAD"},
+		{"read-tree", "--empty"},
+		{"clean", "--quiet", "--force", "-d"},
+	} {
+		_, _, _, err := s.git(args...)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Resetting deployment state checkout '%s' to an empty tree", s.checkoutPath)
+		}
+	}
+
+	return nil
+}
+
+func (s *gitDeploymentStateService) read() (DeploymentState, error) {
+	var deploymentState DeploymentState
+
+	if !s.fs.FileExists(s.statePath()) {
+		return deploymentState, nil
+	}
+
+	contents, err := s.fs.ReadFile(s.statePath())
+	if err != nil {
+		return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.statePath())
+	}
+
+	err = json.Unmarshal(contents, &deploymentState)
+	if err != nil {
+		return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.statePath())
+	}
+
+	return deploymentState, nil
+}
+
+func (s *gitDeploymentStateService) commitAndPush(message string, args ...string) error {
+	_, _, _, err := s.git(args...)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Staging deployment state")
+	}
+
+	_, _, _, err = s.git("commit", "--quiet", "-m", message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Committing deployment state")
+	}
+
+	_, _, _, err = s.git("push", "--quiet", "origin", "HEAD")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Pushing deployment state to '%s', it may have been modified concurrently", s.repoURL)
+	}
+
+	return nil
+}
+
+func (s *gitDeploymentStateService) git(args ...string) (string, string, int, error) {
+	return s.runner.RunCommand("git", append([]string{"-C", s.checkoutPath}, args...)...)
+}
+
+func (s *gitDeploymentStateService) statePath() string {
+	return filepath.Join(s.checkoutPath, s.filePath)
+}
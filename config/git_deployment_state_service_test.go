@@ -0,0 +1,309 @@
+package config_test
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("gitDeploymentStateService", func() {
+	var (
+		fs                *fakesys.FakeFileSystem
+		runner            *fakesys.FakeCmdRunner
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		service           DeploymentStateService
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		service = NewGitDeploymentStateService(
+			"git@example.com:org/state.git",
+			"envs/state.json",
+			"/fake-checkout",
+			fs,
+			runner,
+			fakeUUIDGenerator,
+			logger,
+		)
+	})
+
+	storedState := func() DeploymentState {
+		contents, err := fs.ReadFile("/fake-checkout/envs/state.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var deploymentState DeploymentState
+		Expect(json.Unmarshal(contents, &deploymentState)).To(Succeed())
+		return deploymentState
+	}
+
+	It("has a path pointing to the repository and file", func() {
+		Expect(service.Path()).To(Equal("git+git@example.com:org/state.git#envs/state.json"))
+	})
+
+	Context("when the repository has not been cloned yet", func() {
+		It("clones the repository", func() {
+			Expect(service.Exists()).To(BeFalse())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"git", "clone", "--quiet", "git@example.com:org/state.git", "/fake-checkout"},
+			}))
+		})
+
+		It("returns an error when cloning fails", func() {
+			runner.AddCmdResult("git clone --quiet git@example.com:org/state.git /fake-checkout", fakesys.FakeCmdResult{
+				Error: errors.New("fake-clone-error"),
+			})
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-clone-error"))
+		})
+	})
+
+	Context("when the repository has been cloned", func() {
+		BeforeEach(func() {
+			fs.MkdirAll("/fake-checkout/.git", 0700)
+		})
+
+		It("fetches and resets to the upstream branch", func() {
+			service.Exists()
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"git", "-C", "/fake-checkout", "fetch", "--quiet", "origin"},
+				{"git", "-C", "/fake-checkout", "rev-parse", "--quiet", "--verify", "@{upstream}"},
+				{"git", "-C", "/fake-checkout", "reset", "--quiet", "--hard", "@{upstream}"},
+			}))
+		})
+
+		It("resets to an empty tree when the upstream branch does not exist yet", func() {
+			runner.AddCmdResult("git -C /fake-checkout rev-parse --quiet --verify @{upstream}", fakesys.FakeCmdResult{
+				Error: errors.New("fake-missing-upstream"),
+			})
+
+			_, err := service.Exists()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands[2:]).To(Equal([][]string{
+				{"git", "-C", "/fake-checkout", "update-ref", "-d", "HEAD"},
+				{"git", "-C", "/fake-checkout", "read-tree", "--empty"},
+				{"git", "-C", "/fake-checkout", "clean", "--quiet", "--force", "-d"},
+			}))
+		})
+
+		It("returns an error when fetching fails", func() {
+			runner.AddCmdResult("git -C /fake-checkout fetch --quiet origin", fakesys.FakeCmdResult{
+				Error: errors.New("fake-fetch-error"),
+			})
+
+			_, err := service.Exists()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-fetch-error"))
+		})
+
+		It("loads the state from the checkout", func() {
+			fs.WriteFileString("/fake-checkout/envs/state.json", `{"director_id":"fake-director-id","version":2}`)
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState).To(Equal(DeploymentState{DirectorID: "fake-director-id", Version: 2}))
+		})
+
+		It("commits and pushes the saved state with an incremented version", func() {
+			fs.WriteFileString("/fake-checkout/envs/state.json", `{"director_id":"fake-director-id","version":2}`)
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id", CurrentVMCID: "fake-vm-cid", Version: 2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(storedState()).To(Equal(DeploymentState{DirectorID: "fake-director-id", CurrentVMCID: "fake-vm-cid", Version: 3}))
+			Expect(runner.RunCommands[3:]).To(Equal([][]string{
+				{"git", "-C", "/fake-checkout", "add", "envs/state.json"},
+				{"git", "-C", "/fake-checkout", "commit", "--quiet", "-m", "Update deployment state to version 3"},
+				{"git", "-C", "/fake-checkout", "push", "--quiet", "origin", "HEAD"},
+			}))
+		})
+
+		It("refuses to save when the stored version is newer", func() {
+			fs.WriteFileString("/fake-checkout/envs/state.json", `{"director_id":"fake-director-id","version":4}`)
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id", Version: 2})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("was modified concurrently: expected version 2 but found version 4"))
+			Expect(runner.RunCommands).To(HaveLen(3))
+		})
+
+		It("returns an error when pushing is rejected", func() {
+			runner.AddCmdResult("git -C /fake-checkout push --quiet origin HEAD", fakesys.FakeCmdResult{
+				Error: errors.New("fake-push-rejected"),
+			})
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("it may have been modified concurrently"))
+			Expect(err.Error()).To(ContainSubstring("fake-push-rejected"))
+		})
+
+		Describe("Lock", func() {
+			lockRef := "refs/bosh-state-locks/" + fmt.Sprintf("%x", sha1.Sum([]byte("envs/state.json")))
+
+			BeforeEach(func() {
+				runner.AddCmdResult("git -C /fake-checkout commit-tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904 -m "+lockOwner(), fakesys.FakeCmdResult{
+					Stdout: "fake-lock-commit\n",
+				})
+			})
+
+			It("pushes a lock ref that must not exist yet and deletes it when unlocked", func() {
+				err := service.Lock()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = service.Unlock()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(runner.RunCommands[3:]).To(Equal([][]string{
+					{"git", "-C", "/fake-checkout", "commit-tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", "-m", lockOwner()},
+					{"git", "-C", "/fake-checkout", "push", "--quiet", "--force-with-lease=" + lockRef + ":", "origin", "fake-lock-commit:" + lockRef},
+					{"git", "-C", "/fake-checkout", "push", "--quiet", "--force-with-lease=" + lockRef + ":fake-lock-commit", "origin", ":" + lockRef},
+				}))
+			})
+
+			It("returns an error when the state is locked by another process", func() {
+				runner.AddCmdResult("git -C /fake-checkout push --quiet --force-with-lease="+lockRef+": origin fake-lock-commit:"+lockRef, fakesys.FakeCmdResult{
+					Error: errors.New("fake-push-rejected"),
+				})
+				runner.AddCmdResult("git -C /fake-checkout log -1 --format=%s FETCH_HEAD", fakesys.FakeCmdResult{
+					Stdout: "123@other-host\n",
+				})
+
+				err := service.Lock()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(
+					"Deployment state 'git+git@example.com:org/state.git#envs/state.json' is locked by '123@other-host'. " +
+						"Delete ref '" + lockRef + "' in 'git@example.com:org/state.git' if no other process is using it"))
+
+				Expect(service.Unlock()).To(Succeed())
+				Expect(runner.RunCommands).To(HaveLen(7))
+			})
+
+			It("returns the push error when the lock ref does not exist", func() {
+				runner.AddCmdResult("git -C /fake-checkout push --quiet --force-with-lease="+lockRef+": origin fake-lock-commit:"+lockRef, fakesys.FakeCmdResult{
+					Error: errors.New("fake-push-error"),
+				})
+				runner.AddCmdResult("git -C /fake-checkout fetch --quiet origin "+lockRef, fakesys.FakeCmdResult{
+					Error: errors.New("fake-missing-ref"),
+				})
+
+				err := service.Lock()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Pushing deployment state lock"))
+				Expect(err.Error()).To(ContainSubstring("fake-push-error"))
+			})
+		})
+
+		It("removes the state file from the repository on cleanup", func() {
+			fs.WriteFileString("/fake-checkout/envs/state.json", `{}`)
+
+			err := service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands[3:]).To(Equal([][]string{
+				{"git", "-C", "/fake-checkout", "rm", "envs/state.json"},
+				{"git", "-C", "/fake-checkout", "commit", "--quiet", "-m", "Delete deployment state"},
+				{"git", "-C", "/fake-checkout", "push", "--quiet", "origin", "HEAD"},
+			}))
+		})
+	})
+})
+
+var _ = Describe("gitDeploymentStateService with a real repository", func() {
+	var (
+		tmpDir      string
+		remoteURL   string
+		newService  func(checkout string) DeploymentStateService
+		previousEnv map[string]string
+	)
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not installed")
+		}
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "git-deployment-state")
+		Expect(err).ToNot(HaveOccurred())
+
+		// commits need an identity, which may not be configured where the tests run
+		previousEnv = map[string]string{}
+		for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+			previousEnv[name] = os.Getenv(name)
+			os.Setenv(name, "bosh@example.com")
+		}
+
+		remoteURL = filepath.Join(tmpDir, "remote.git")
+		output, err := exec.Command("git", "init", "--quiet", "--bare", remoteURL).CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(output))
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+		runner := boshsys.NewExecCmdRunner(logger)
+
+		newService = func(checkout string) DeploymentStateService {
+			return NewGitDeploymentStateService(
+				remoteURL,
+				"envs/state.json",
+				filepath.Join(tmpDir, checkout),
+				fs,
+				runner,
+				fakeuuid.NewFakeGenerator(),
+				logger,
+			)
+		}
+	})
+
+	AfterEach(func() {
+		for name, value := range previousEnv {
+			os.Setenv(name, value)
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	It("syncs a checkout of an empty repository and pushes the first state", func() {
+		service := newService("checkout")
+
+		Expect(service.Exists()).To(BeFalse())
+		Expect(service.Exists()).To(BeFalse())
+
+		Expect(service.Lock()).To(Succeed())
+		Expect(service.Save(DeploymentState{DirectorID: "fake-director-id"})).To(Succeed())
+		Expect(service.Unlock()).To(Succeed())
+
+		deploymentState, err := newService("other-checkout").Read()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState).To(Equal(DeploymentState{DirectorID: "fake-director-id", Version: 1}))
+	})
+})
+
+func lockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%d@%s", os.Getpid(), hostname)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type InstanceRepo interface {
+	All() ([]InstanceRecord, error)
+	Delete(name string, index int) error
+	UpdateVMConfigDigest(name string, index int, digest string) error
+}
+
+type instanceRepo struct {
+	deploymentStateService DeploymentStateService
+}
+
+func NewInstanceRepo(deploymentStateService DeploymentStateService) InstanceRepo {
+	return instanceRepo{
+		deploymentStateService: deploymentStateService,
+	}
+}
+
+func (r instanceRepo) All() ([]InstanceRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []InstanceRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Instances == nil {
+		return []InstanceRecord{}, nil
+	}
+
+	return deploymentState.Instances, nil
+}
+
+func (r instanceRepo) Delete(name string, index int) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	newRecords := []InstanceRecord{}
+	for _, record := range deploymentState.Instances {
+		if record.Name != name || record.Index != index {
+			newRecords = append(newRecords, record)
+		}
+	}
+
+	deploymentState.Instances = newRecords
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+func (r instanceRepo) UpdateVMConfigDigest(name string, index int, digest string) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	for i := range deploymentState.Instances {
+		record := &deploymentState.Instances[i]
+		if record.Name == name && record.Index == index {
+			record.VMConfigDigest = digest
+		}
+	}
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+// instanceKey identifies an instance that is tracked in DeploymentState.Instances
+type instanceKey struct {
+	name    string
+	index   int
+	address string
+}
+
+func (k instanceKey) find(deploymentState DeploymentState) (InstanceRecord, bool) {
+	for _, record := range deploymentState.Instances {
+		if record.Name == k.name && record.Index == k.index {
+			return record, true
+		}
+	}
+
+	return InstanceRecord{}, false
+}
+
+func (k instanceKey) update(deploymentState *DeploymentState, updateFunc func(*InstanceRecord)) {
+	for i := range deploymentState.Instances {
+		record := &deploymentState.Instances[i]
+		if record.Name == k.name && record.Index == k.index {
+			updateFunc(record)
+			if k.address != "" {
+				record.Address = k.address
+			}
+			return
+		}
+	}
+
+	record := InstanceRecord{Name: k.name, Index: k.index, Address: k.address}
+	updateFunc(&record)
+	deploymentState.Instances = append(deploymentState.Instances, record)
+}
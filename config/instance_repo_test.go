@@ -0,0 +1,208 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InstanceRepo", func() {
+	var (
+		repo                   InstanceRepo
+		deploymentStateService DeploymentStateService
+		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		repo = NewInstanceRepo(deploymentStateService)
+	})
+
+	Describe("All", func() {
+		It("returns no instances when there are none", func() {
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		It("returns instances recorded by instance vm repos", func() {
+			err := NewInstanceVMRepo(deploymentStateService, "fake-job", 1, "10.0.0.2").UpdateCurrent("fake-vm-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = NewInstanceVMRepo(deploymentStateService, "other-job", 0, "10.0.0.3").UpdateCurrent("fake-vm-cid-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{Name: "fake-job", Index: 1, Address: "10.0.0.2", VMCID: "fake-vm-cid-1"},
+				{Name: "other-job", Index: 0, Address: "10.0.0.3", VMCID: "fake-vm-cid-2"},
+			}))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes only the matching instance", func() {
+			err := NewInstanceVMRepo(deploymentStateService, "fake-job", 1, "10.0.0.2").UpdateCurrent("fake-vm-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = NewInstanceVMRepo(deploymentStateService, "fake-job", 2, "10.0.0.3").UpdateCurrent("fake-vm-cid-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Delete("fake-job", 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{Name: "fake-job", Index: 2, Address: "10.0.0.3", VMCID: "fake-vm-cid-2"},
+			}))
+		})
+	})
+
+	Describe("UpdateVMConfigDigest", func() {
+		It("records the digest until the vm of the instance changes", func() {
+			vmRepo := NewInstanceVMRepo(deploymentStateService, "fake-job", 1, "10.0.0.2")
+			err := vmRepo.UpdateCurrent("fake-vm-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateVMConfigDigest("fake-job", 1, "fake-digest")
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{Name: "fake-job", Index: 1, Address: "10.0.0.2", VMCID: "fake-vm-cid-1", VMConfigDigest: "fake-digest"},
+			}))
+
+			err = vmRepo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err = repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{Name: "fake-job", Index: 1, Address: "10.0.0.2"},
+			}))
+		})
+	})
+
+	Describe("instance vm repo", func() {
+		It("does not change the vm of the first instance", func() {
+			err := NewVMRepo(deploymentStateService).UpdateCurrent("fake-vm-cid-0")
+			Expect(err).ToNot(HaveOccurred())
+
+			vmRepo := NewInstanceVMRepo(deploymentStateService, "fake-job", 1, "10.0.0.2")
+
+			_, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			err = vmRepo.UpdateCurrent("fake-vm-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			cid, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cid).To(Equal("fake-vm-cid-1"))
+
+			cid, found, err = NewVMRepo(deploymentStateService).FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cid).To(Equal("fake-vm-cid-0"))
+		})
+	})
+
+	Describe("instance disk repo", func() {
+		It("tracks the current disk of the instance separately", func() {
+			firstDiskRepo := NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+			firstDisk, err := firstDiskRepo.Save("fake-disk-cid-0", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(firstDiskRepo.UpdateCurrent(firstDisk.ID)).To(Succeed())
+
+			instanceDiskRepo := NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1)
+			instanceDisk, err := instanceDiskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceDiskRepo.UpdateCurrent(instanceDisk.ID)).To(Succeed())
+
+			record, found, err := instanceDiskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(instanceDisk))
+
+			record, found, err = firstDiskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(firstDisk))
+
+			records, err := firstDiskRepo.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{firstDisk, instanceDisk}))
+		})
+
+		It("clears the current disk of the instance when the disk is deleted", func() {
+			instanceDiskRepo := NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1)
+			instanceDisk, err := instanceDiskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceDiskRepo.UpdateCurrent(instanceDisk.ID)).To(Succeed())
+
+			Expect(instanceDiskRepo.Delete(instanceDisk)).To(Succeed())
+
+			_, found, err := instanceDiskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("named disk repo", func() {
+		It("tracks named disks separately from the disk mounted by the agent", func() {
+			diskRepo := NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+			disk, err := diskRepo.Save("fake-disk-cid-0", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskRepo.UpdateCurrent(disk.ID)).To(Succeed())
+
+			namedDiskRepo := diskRepo.Named("fake-data")
+			namedDisk, err := namedDiskRepo.Save("fake-disk-cid-1", 2048, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namedDisk.Name).To(Equal("fake-data"))
+			Expect(namedDiskRepo.UpdateCurrent(namedDisk.ID)).To(Succeed())
+
+			record, found, err := namedDiskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(namedDisk))
+
+			record, found, err = diskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(disk))
+
+			names, err := diskRepo.CurrentDiskNames()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"fake-data"}))
+
+			records, err := diskRepo.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(ConsistOf(disk, namedDisk))
+		})
+
+		It("forgets the name when the named disk is deleted", func() {
+			namedDiskRepo := NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1).Named("fake-data")
+			namedDisk, err := namedDiskRepo.Save("fake-disk-cid-1", 2048, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namedDiskRepo.UpdateCurrent(namedDisk.ID)).To(Succeed())
+
+			Expect(namedDiskRepo.Delete(namedDisk)).To(Succeed())
+
+			names, err := namedDiskRepo.CurrentDiskNames()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// ManifestRedactionKeyStore keeps the keys that sensitive values of the manifest
+// stored in a deployment state are digested with. Keys are kept in the workspace
+// and never in the deployment state, so that the digests cannot be checked
+// against guessed values by anybody who can read the state.
+type ManifestRedactionKeyStore interface {
+	Find(directorID string) ([]byte, bool, error)
+	Create(directorID string) ([]byte, error)
+}
+
+type fileSystemManifestRedactionKeyStore struct {
+	keysPath      string
+	fs            boshsys.FileSystem
+	uuidGenerator boshuuid.Generator
+}
+
+func NewFileSystemManifestRedactionKeyStore(keysPath string, fs boshsys.FileSystem, uuidGenerator boshuuid.Generator) ManifestRedactionKeyStore {
+	return fileSystemManifestRedactionKeyStore{
+		keysPath:      keysPath,
+		fs:            fs,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// ManifestRedactionKeysPath is where keys of all deployment states are kept
+func ManifestRedactionKeysPath(workspaceRootPath string) string {
+	return filepath.Join(workspaceRootPath, "manifest-keys")
+}
+
+func (s fileSystemManifestRedactionKeyStore) Find(directorID string) ([]byte, bool, error) {
+	if !s.fs.FileExists(s.keyPath(directorID)) {
+		return nil, false, nil
+	}
+
+	key, err := s.fs.ReadFile(s.keyPath(directorID))
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Reading manifest redaction key '%s'", s.keyPath(directorID))
+	}
+
+	return key, true, nil
+}
+
+func (s fileSystemManifestRedactionKeyStore) Create(directorID string) ([]byte, error) {
+	key, err := s.uuidGenerator.Generate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating manifest redaction key")
+	}
+
+	err = s.fs.MkdirAll(s.keysPath, 0700)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating manifest redaction keys directory '%s'", s.keysPath)
+	}
+
+	err = s.fs.WriteFileString(s.keyPath(directorID), key)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Writing manifest redaction key '%s'", s.keyPath(directorID))
+	}
+
+	err = s.fs.Chmod(s.keyPath(directorID), 0600)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Setting permissions of manifest redaction key '%s'", s.keyPath(directorID))
+	}
+
+	return []byte(key), nil
+}
+
+func (s fileSystemManifestRedactionKeyStore) keyPath(directorID string) string {
+	return filepath.Join(s.keysPath, directorID)
+}
@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"errors"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("fileSystemManifestRedactionKeyStore", func() {
+	var (
+		fs                *fakesys.FakeFileSystem
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		keyStore          ManifestRedactionKeyStore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		fakeUUIDGenerator.GeneratedUUID = "fake-key"
+
+		keyStore = NewFileSystemManifestRedactionKeyStore("/fake-workspace/manifest-keys", fs, fakeUUIDGenerator)
+	})
+
+	It("keeps keys in the workspace", func() {
+		Expect(ManifestRedactionKeysPath("/fake-workspace")).To(Equal("/fake-workspace/manifest-keys"))
+	})
+
+	It("does not find keys that have not been created", func() {
+		_, found, err := keyStore.Find("fake-director-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("finds created keys of the director", func() {
+		key, err := keyStore.Create("fake-director-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).To(Equal([]byte("fake-key")))
+
+		stat, err := fs.Stat("/fake-workspace/manifest-keys/fake-director-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(BeEquivalentTo(0600))
+
+		foundKey, found, err := keyStore.Find("fake-director-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(foundKey).To(Equal(key))
+
+		_, found, err = keyStore.Find("fake-other-director-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns an error when the key cannot be written", func() {
+		fs.WriteFileError = errors.New("fake-write-error")
+
+		_, err := keyStore.Create("fake-director-id")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+	})
+})
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// s3DeploymentStateService keeps the deployment state as an object in an S3 compatible bucket.
+// Lock creates a lock object next to the state with a conditional put that fails if it already
+// exists; the store has to support 'If-None-Match' and 'If-Match' on PUT (AWS S3 does). In addition every save
+// compares the version of the stored state with the loaded version and refuses to overwrite newer state,
+// the state is only written if it has not been changed since it was read.
+type s3DeploymentStateService struct {
+	client        s3iface.S3API
+	bucket        string
+	key           string
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+	logTag        string
+}
+
+func NewS3DeploymentStateService(client s3iface.S3API, bucket string, key string, uuidGenerator boshuuid.Generator, logger boshlog.Logger) DeploymentStateService {
+	return &s3DeploymentStateService{
+		client:        client,
+		bucket:        bucket,
+		key:           key,
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		logTag:        "s3DeploymentStateService",
+	}
+}
+
+func (s *s3DeploymentStateService) Path() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.key)
+}
+
+func (s *s3DeploymentStateService) Exists() (bool, error) {
+	_, _, found, err := s.fetch()
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+func (s *s3DeploymentStateService) Load() (DeploymentState, error) {
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.Path())
+
+	deploymentState, _, _, err := s.fetch()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	if deploymentState.DirectorID == "" {
+		deploymentState.DirectorID, err = s.uuidGenerator.Generate()
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Generating DirectorID")
+		}
+
+		err = s.save(&deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Saving deployment state")
+		}
+	}
+
+	return deploymentState, nil
+}
+
+func (s *s3DeploymentStateService) Read() (DeploymentState, error) {
+	deploymentState, _, _, err := s.fetch()
+	return deploymentState, err
+}
+
+func (s *s3DeploymentStateService) Save(deploymentState DeploymentState) error {
+	return s.save(&deploymentState)
+}
+
+func (s *s3DeploymentStateService) save(deploymentState *DeploymentState) error {
+	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+
+	storedState, etag, found, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	err = checkDeploymentStateVersion(s.Path(), storedState, *deploymentState)
+	if err != nil {
+		return err
+	}
+
+	deploymentState.Version++
+
+	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
+	}
+
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+		Body:   bytes.NewReader(jsonContent),
+	})
+
+	// only overwrite the state that was checked above
+	if found {
+		req.HTTPRequest.Header.Set("If-Match", etag)
+	} else {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	}
+
+	err = req.Send()
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusPreconditionFailed {
+			return bosherr.Errorf("Deployment state '%s' was modified concurrently", s.Path())
+		}
+		return bosherr.WrapErrorf(err, "Writing deployment state '%s'", s.Path())
+	}
+
+	return nil
+}
+
+func (s *s3DeploymentStateService) Cleanup() error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Could not delete deployment state %s", s.Path())
+	}
+
+	return nil
+}
+
+func (s *s3DeploymentStateService) Lock() error {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.lockKey()),
+		Body:   bytes.NewReader([]byte(lockOwner())),
+	})
+
+	// only create the lock object if nobody else holds the lock
+	req.HTTPRequest.Header.Set("If-None-Match", "*")
+
+	err := req.Send()
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusPreconditionFailed {
+			return s.lockedError()
+		}
+		return bosherr.WrapErrorf(err, "Creating deployment state lock 's3://%s/%s'", s.bucket, s.lockKey())
+	}
+
+	s.logger.Debug(s.logTag, "Locked deployment state: %s", s.Path())
+
+	return nil
+}
+
+func (s *s3DeploymentStateService) Unlock() error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.lockKey()),
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing deployment state lock 's3://%s/%s'", s.bucket, s.lockKey())
+	}
+
+	s.logger.Debug(s.logTag, "Unlocked deployment state: %s", s.Path())
+
+	return nil
+}
+
+func (s *s3DeploymentStateService) lockKey() string {
+	return s.key + ".lock"
+}
+
+func (s *s3DeploymentStateService) lockedError() error {
+	owner := "unknown"
+
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.lockKey()),
+	})
+	if err == nil {
+		defer output.Body.Close()
+
+		contents, err := ioutil.ReadAll(output.Body)
+		if err == nil && len(contents) > 0 {
+			owner = string(contents)
+		}
+	}
+
+	return bosherr.Errorf(
+		"Deployment state '%s' is locked by '%s'. Remove 's3://%s/%s' if no other process is using it",
+		s.Path(), owner, s.bucket, s.lockKey())
+}
+
+// fetch returns the stored state together with the ETag of the state object
+func (s *s3DeploymentStateService) fetch() (DeploymentState, string, bool, error) {
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return DeploymentState{}, "", false, nil
+		}
+		return DeploymentState{}, "", false, bosherr.WrapErrorf(err, "Reading deployment state '%s'", s.Path())
+	}
+
+	defer output.Body.Close()
+
+	contents, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return DeploymentState{}, "", false, bosherr.WrapErrorf(err, "Reading deployment state '%s'", s.Path())
+	}
+
+	var deploymentState DeploymentState
+
+	err = json.Unmarshal(contents, &deploymentState)
+	if err != nil {
+		return DeploymentState{}, "", false, bosherr.WrapErrorf(err, "Unmarshalling deployment state '%s'", s.Path())
+	}
+
+	return deploymentState, aws.StringValue(output.ETag), true, nil
+}
+
+func checkDeploymentStateVersion(path string, storedState DeploymentState, deploymentState DeploymentState) error {
+	if storedState.Version != deploymentState.Version {
+		return bosherr.Errorf(
+			"Deployment state '%s' was modified concurrently: expected version %d but found version %d",
+			path, deploymentState.Version, storedState.Version)
+	}
+
+	return nil
+}
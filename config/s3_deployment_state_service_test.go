@@ -0,0 +1,217 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("s3DeploymentStateService", func() {
+	var (
+		server            *fakebiconfig.FakeS3Server
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		service           DeploymentStateService
+	)
+
+	BeforeEach(func() {
+		os.Setenv("AWS_ACCESS_KEY_ID", "fake-access-key-id")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "fake-secret-access-key")
+
+		server = fakebiconfig.NewFakeS3Server()
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		service = NewDeploymentStateService(
+			fakesys.NewFakeFileSystem(),
+			fakeUUIDGenerator,
+			fakesys.NewFakeCmdRunner(),
+			logger,
+			"s3://fake-bucket/path/to/state.json?endpoint="+server.URL,
+			"/fake-workspace",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	})
+
+	storedState := func() DeploymentState {
+		contents, found := server.Object("fake-bucket", "path/to/state.json")
+		Expect(found).To(BeTrue())
+
+		var deploymentState DeploymentState
+		Expect(json.Unmarshal(contents, &deploymentState)).To(Succeed())
+		return deploymentState
+	}
+
+	It("has a path pointing to the bucket and key", func() {
+		Expect(service.Path()).To(Equal("s3://fake-bucket/path/to/state.json"))
+	})
+
+	Describe("Exists", func() {
+		It("returns false when the state object does not exist", func() {
+			Expect(service.Exists()).To(BeFalse())
+		})
+
+		It("returns true when the state object exists", func() {
+			server.SetObject("fake-bucket", "path/to/state.json", []byte("{}"))
+			Expect(service.Exists()).To(BeTrue())
+		})
+
+		It("returns an error when the state object cannot be read", func() {
+			server.DenyAccess = true
+
+			_, err := service.Exists()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading deployment state 's3://fake-bucket/path/to/state.json'"))
+			Expect(err.Error()).To(ContainSubstring("AccessDenied"))
+		})
+	})
+
+	Describe("Lock", func() {
+		It("creates a lock object next to the state until unlocked", func() {
+			err := service.Lock()
+			Expect(err).ToNot(HaveOccurred())
+
+			owner, found := server.Object("fake-bucket", "path/to/state.json.lock")
+			Expect(found).To(BeTrue())
+			Expect(string(owner)).To(ContainSubstring("@"))
+
+			err = service.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found = server.Object("fake-bucket", "path/to/state.json.lock")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error when the state is locked by another process", func() {
+			server.SetObject("fake-bucket", "path/to/state.json.lock", []byte("123@other-host"))
+
+			err := service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Deployment state 's3://fake-bucket/path/to/state.json' is locked by '123@other-host'. " +
+					"Remove 's3://fake-bucket/path/to/state.json.lock' if no other process is using it"))
+
+			owner, _ := server.Object("fake-bucket", "path/to/state.json.lock")
+			Expect(string(owner)).To(Equal("123@other-host"))
+		})
+
+		It("returns an error when the lock object cannot be created", func() {
+			server.DenyAccess = true
+
+			err := service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating deployment state lock 's3://fake-bucket/path/to/state.json.lock'"))
+		})
+	})
+
+	Describe("Load", func() {
+		It("generates and saves a director id when there is no state", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-director-id"
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+			Expect(deploymentState.Version).To(Equal(1))
+
+			Expect(storedState().DirectorID).To(Equal("fake-director-id"))
+		})
+
+		It("returns the stored state", func() {
+			server.SetObject("fake-bucket", "path/to/state.json", []byte(`{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid","version":3}`))
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState).To(Equal(DeploymentState{
+				DirectorID:   "fake-director-id",
+				CurrentVMCID: "fake-vm-cid",
+				Version:      3,
+			}))
+		})
+
+		It("returns an error when the stored state cannot be parsed", func() {
+			server.SetObject("fake-bucket", "path/to/state.json", []byte("not-json"))
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state 's3://fake-bucket/path/to/state.json'"))
+		})
+	})
+
+	Describe("Save", func() {
+		It("increments the version of the stored state", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState.CurrentVMCID = "fake-vm-cid"
+
+			err = service.Save(deploymentState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(storedState().CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(storedState().Version).To(Equal(2))
+		})
+
+		It("refuses to overwrite state that was modified concurrently", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			server.SetObject("fake-bucket", "path/to/state.json", []byte(`{"director_id":"fake-director-id","version":5}`))
+
+			err = service.Save(deploymentState)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("was modified concurrently: expected version 1 but found version 5"))
+			Expect(storedState().Version).To(Equal(5))
+		})
+
+		It("refuses to overwrite state that is modified while saving", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			server.BeforePut = func(bucket, key string) {
+				server.BeforePut = nil
+				server.SetObject(bucket, key, []byte(`{"director_id":"other-director-id","version":1}`))
+			}
+
+			err = service.Save(deploymentState)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state 's3://fake-bucket/path/to/state.json' was modified concurrently"))
+			Expect(storedState().DirectorID).To(Equal("other-director-id"))
+		})
+
+		It("refuses to overwrite state that is created while saving", func() {
+			server.BeforePut = func(bucket, key string) {
+				server.BeforePut = nil
+				server.SetObject(bucket, key, []byte(`{"director_id":"other-director-id"}`))
+			}
+
+			err := service.Save(DeploymentState{DirectorID: "fake-director-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state 's3://fake-bucket/path/to/state.json' was modified concurrently"))
+			Expect(storedState().DirectorID).To(Equal("other-director-id"))
+		})
+	})
+
+	Describe("Cleanup", func() {
+		It("deletes the state object", func() {
+			server.SetObject("fake-bucket", "path/to/state.json", []byte("{}"))
+
+			err := service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found := server.Object("fake-bucket", "path/to/state.json")
+			Expect(found).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// SnapshotRepo persists the snapshots taken of disks before they are migrated
+type SnapshotRepo interface {
+	Save(cid, diskCID string, createdAt time.Time) (SnapshotRecord, error)
+	All() ([]SnapshotRecord, error)
+	Delete(SnapshotRecord) error
+}
+
+type snapshotRepo struct {
+	deploymentStateService DeploymentStateService
+	uuidGenerator          boshuuid.Generator
+}
+
+func NewSnapshotRepo(deploymentStateService DeploymentStateService, uuidGenerator boshuuid.Generator) SnapshotRepo {
+	return snapshotRepo{
+		deploymentStateService: deploymentStateService,
+		uuidGenerator:          uuidGenerator,
+	}
+}
+
+func (r snapshotRepo) Save(cid, diskCID string, createdAt time.Time) (SnapshotRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return SnapshotRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	newRecord := SnapshotRecord{
+		CID:       cid,
+		DiskCID:   diskCID,
+		CreatedAt: createdAt,
+	}
+
+	newRecord.ID, err = r.uuidGenerator.Generate()
+	if err != nil {
+		return newRecord, bosherr.WrapError(err, "Generating snapshot id")
+	}
+
+	deploymentState.Snapshots = append(deploymentState.Snapshots, newRecord)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return newRecord, bosherr.WrapError(err, "Saving new config")
+	}
+
+	return newRecord, nil
+}
+
+func (r snapshotRepo) All() ([]SnapshotRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []SnapshotRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Snapshots == nil {
+		return []SnapshotRecord{}, nil
+	}
+
+	return deploymentState.Snapshots, nil
+}
+
+func (r snapshotRepo) Delete(snapshotRecord SnapshotRecord) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	newRecords := []SnapshotRecord{}
+	for _, record := range deploymentState.Snapshots {
+		if record.ID != snapshotRecord.ID {
+			newRecords = append(newRecords, record)
+		}
+	}
+
+	deploymentState.Snapshots = newRecords
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
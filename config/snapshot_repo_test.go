@@ -0,0 +1,84 @@
+package config_test
+
+import (
+	"time"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("SnapshotRepo", func() {
+	var (
+		deploymentStateService DeploymentStateService
+		repo                   SnapshotRepo
+		createdAt              time.Time
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator := &fakeuuid.FakeGenerator{}
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		repo = NewSnapshotRepo(deploymentStateService, fakeUUIDGenerator)
+		createdAt = time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	Describe("Save", func() {
+		It("saves the snapshot record in the deployment state", func() {
+			record, err := repo.Save("fake-snapshot-cid", "fake-disk-cid", createdAt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record).To(Equal(SnapshotRecord{
+				ID:        "fake-uuid-1",
+				CID:       "fake-snapshot-cid",
+				DiskCID:   "fake-disk-cid",
+				CreatedAt: createdAt,
+			}))
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Snapshots).To(Equal([]SnapshotRecord{record}))
+		})
+	})
+
+	Describe("All", func() {
+		It("returns no records when no snapshots were taken", func() {
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		It("returns all snapshot records", func() {
+			firstRecord, err := repo.Save("fake-snapshot-cid-1", "fake-disk-cid", createdAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			secondRecord, err := repo.Save("fake-snapshot-cid-2", "fake-disk-cid", createdAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]SnapshotRecord{firstRecord, secondRecord}))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the snapshot record", func() {
+			firstRecord, err := repo.Save("fake-snapshot-cid-1", "fake-disk-cid", createdAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			secondRecord, err := repo.Save("fake-snapshot-cid-2", "fake-disk-cid", createdAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Delete(firstRecord)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]SnapshotRecord{secondRecord}))
+		})
+	})
+})
@@ -12,6 +12,9 @@ type VMRepo interface {
 
 type vMRepo struct {
 	deploymentStateService DeploymentStateService
+
+	// instance is nil for the first instance of the first job
+	instance *instanceKey
 }
 
 func NewVMRepo(deploymentStateService DeploymentStateService) VMRepo {
@@ -20,6 +23,14 @@ func NewVMRepo(deploymentStateService DeploymentStateService) VMRepo {
 	}
 }
 
+// NewInstanceVMRepo tracks the VM of an additional instance reachable at address
+func NewInstanceVMRepo(deploymentStateService DeploymentStateService, name string, index int, address string) VMRepo {
+	return vMRepo{
+		deploymentStateService: deploymentStateService,
+		instance:               &instanceKey{name: name, index: index, address: address},
+	}
+}
+
 func (r vMRepo) FindCurrent() (string, bool, error) {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -27,6 +38,11 @@ func (r vMRepo) FindCurrent() (string, bool, error) {
 	}
 
 	currentVMCID := deploymentState.CurrentVMCID
+	if r.instance != nil {
+		record, _ := r.instance.find(deploymentState)
+		currentVMCID = record.VMCID
+	}
+
 	if currentVMCID != "" {
 		return currentVMCID, true, nil
 	}
@@ -40,7 +56,7 @@ func (r vMRepo) UpdateCurrent(cid string) error {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
-	deploymentState.CurrentVMCID = cid
+	r.setCurrent(&deploymentState, cid)
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -55,7 +71,7 @@ func (r vMRepo) ClearCurrent() error {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
-	deploymentState.CurrentVMCID = ""
+	r.setCurrent(&deploymentState, "")
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -63,3 +79,15 @@ func (r vMRepo) ClearCurrent() error {
 	}
 	return nil
 }
+
+func (r vMRepo) setCurrent(deploymentState *DeploymentState, cid string) {
+	if r.instance == nil {
+		deploymentState.CurrentVMCID = cid
+		return
+	}
+
+	r.instance.update(deploymentState, func(record *InstanceRecord) {
+		record.VMCID = cid
+		record.VMConfigDigest = ""
+	})
+}
@@ -1,10 +1,14 @@
 package deployment
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -24,31 +28,38 @@ type Deployer interface {
 		biinstallmanifest.Registry,
 		bivm.Manager,
 		biblobstore.Blobstore,
+		InstanceClientFactory,
 		bool,
 		biui.Stage,
 	) (Deployment, error)
 }
 
 type deployer struct {
-	vmManagerFactory       bivm.ManagerFactory
-	instanceManagerFactory biinstance.ManagerFactory
-	deploymentFactory      Factory
-	logger                 boshlog.Logger
-	logTag                 string
+	vmManagerFactory         bivm.ManagerFactory
+	instanceVMManagerFactory bivm.InstanceManagerFactory
+	instanceManagerFactory   biinstance.ManagerFactory
+	instanceRepo             biconfig.InstanceRepo
+	deploymentFactory        Factory
+	logger                   boshlog.Logger
+	logTag                   string
 }
 
 func NewDeployer(
 	vmManagerFactory bivm.ManagerFactory,
+	instanceVMManagerFactory bivm.InstanceManagerFactory,
 	instanceManagerFactory biinstance.ManagerFactory,
+	instanceRepo biconfig.InstanceRepo,
 	deploymentFactory Factory,
 	logger boshlog.Logger,
 ) Deployer {
 	return &deployer{
-		vmManagerFactory:       vmManagerFactory,
-		instanceManagerFactory: instanceManagerFactory,
-		deploymentFactory:      deploymentFactory,
-		logger:                 logger,
-		logTag:                 "deployer",
+		vmManagerFactory:         vmManagerFactory,
+		instanceVMManagerFactory: instanceVMManagerFactory,
+		instanceManagerFactory:   instanceManagerFactory,
+		instanceRepo:             instanceRepo,
+		deploymentFactory:        deploymentFactory,
+		logger:                   logger,
+		logTag:                   "deployer",
 	}
 }
 
@@ -59,6 +70,7 @@ func (d *deployer) Deploy(
 	registryConfig biinstallmanifest.Registry,
 	vmManager bivm.Manager,
 	blobstore biblobstore.Blobstore,
+	instanceClientFactory InstanceClientFactory,
 	skipDrain bool,
 	deployStage biui.Stage,
 ) (Deployment, error) {
@@ -70,7 +82,12 @@ func (d *deployer) Deploy(
 		return nil, err
 	}
 
-	instances, disks, err := d.createAllInstances(deploymentManifest, instanceManager, cloudStemcell, registryConfig, deployStage)
+	keptInstances, err := d.deleteAdditionalInstances(cloud, deploymentManifest, cloudStemcell, instanceClientFactory, pingTimeout, pingDelay, skipDrain, deployStage)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, disks, err := d.createAllInstances(deploymentManifest, cloud, instanceManager, instanceClientFactory, cloudStemcell, registryConfig, keptInstances, deployStage)
 	if err != nil {
 		return nil, err
 	}
@@ -79,29 +96,125 @@ func (d *deployer) Deploy(
 	return d.deploymentFactory.NewDeployment(instances, disks, stemcells), nil
 }
 
+// deleteAdditionalInstances deletes the VMs of additional instances that are no longer part of the manifest
+// or were created with a different stemcell, resource pool or networks, and forgets the instances
+// that are no longer part of the manifest, so that their disks are deleted as unused.
+// It returns the instances whose VMs are kept.
+func (d *deployer) deleteAdditionalInstances(
+	cloud bicloud.Cloud,
+	deploymentManifest bideplmanifest.Manifest,
+	cloudStemcell bistemcell.CloudStemcell,
+	instanceClientFactory InstanceClientFactory,
+	pingTimeout time.Duration,
+	pingDelay time.Duration,
+	skipDrain bool,
+	deployStage biui.Stage,
+) (map[string]bool, error) {
+	keptInstances := map[string]bool{}
+
+	instanceRecords, err := d.instanceRepo.All()
+	if err != nil {
+		return keptInstances, bosherr.WrapError(err, "Finding additional instances")
+	}
+
+	for _, instanceRecord := range instanceRecords {
+		isAdditionalInstance := isAdditionalInstance(deploymentManifest, instanceRecord.Name, instanceRecord.Index)
+
+		if instanceRecord.VMCID != "" {
+			if isAdditionalInstance {
+				digest, err := vmConfigDigest(deploymentManifest, cloudStemcell.CID(), instanceRecord.Name, instanceRecord.Index)
+				if err != nil {
+					return keptInstances, err
+				}
+
+				if digest == instanceRecord.VMConfigDigest {
+					d.logger.Debug(d.logTag, "Keeping VM '%s' of instance '%s/%d'", instanceRecord.VMCID, instanceRecord.Name, instanceRecord.Index)
+					keptInstances[instanceName(instanceRecord.Name, instanceRecord.Index)] = true
+					continue
+				}
+			}
+
+			instanceManager, err := d.newAdditionalInstanceManager(cloud, instanceClientFactory, instanceRecord.Name, instanceRecord.Index, instanceRecord.Address)
+			if err != nil {
+				return keptInstances, err
+			}
+
+			if err := instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, deployStage); err != nil {
+				return keptInstances, err
+			}
+		}
+
+		if !isAdditionalInstance {
+			if err := d.instanceRepo.Delete(instanceRecord.Name, instanceRecord.Index); err != nil {
+				return keptInstances, bosherr.WrapErrorf(err, "Removing instance '%s/%d'", instanceRecord.Name, instanceRecord.Index)
+			}
+		}
+	}
+
+	return keptInstances, nil
+}
+
 func (d *deployer) createAllInstances(
 	deploymentManifest bideplmanifest.Manifest,
+	cloud bicloud.Cloud,
 	instanceManager biinstance.Manager,
+	instanceClientFactory InstanceClientFactory,
 	cloudStemcell bistemcell.CloudStemcell,
 	registryConfig biinstallmanifest.Registry,
+	keptInstances map[string]bool,
 	deployStage biui.Stage,
 ) ([]biinstance.Instance, []bidisk.Disk, error) {
 	instances := []biinstance.Instance{}
 	disks := []bidisk.Disk{}
 
-	if len(deploymentManifest.Jobs) != 1 {
-		return instances, disks, bosherr.Errorf("There must only be one job, found %d", len(deploymentManifest.Jobs))
+	if len(deploymentManifest.Jobs) == 0 {
+		return instances, disks, bosherr.Error("There must be at least one job")
+	}
+
+	if deploymentManifest.Jobs[0].Instances < 1 {
+		return instances, disks, bosherr.Errorf("Job '%s' must have at least one instance, found %d", deploymentManifest.Jobs[0].Name, deploymentManifest.Jobs[0].Instances)
 	}
 
 	for _, jobSpec := range deploymentManifest.Jobs {
-		if jobSpec.Instances != 1 {
-			return instances, disks, bosherr.Errorf("Job '%s' must have only one instance, found %d", jobSpec.Name, jobSpec.Instances)
-		}
 		for instanceID := 0; instanceID < jobSpec.Instances; instanceID++ {
-			instance, instanceDisks, err := instanceManager.Create(jobSpec.Name, instanceID, deploymentManifest, cloudStemcell, registryConfig, deployStage)
-			if err != nil {
-				return instances, disks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobSpec.Name, instanceID)
+			jobInstanceManager := instanceManager
+			instanceRegistryConfig := registryConfig
+
+			var instance biinstance.Instance
+			var instanceDisks []bidisk.Disk
+			var err error
+
+			if isAdditionalInstance(deploymentManifest, jobSpec.Name, instanceID) {
+				address, _ := deploymentManifest.InstanceAddress(jobSpec.Name, instanceID)
+
+				jobInstanceManager, err = d.newAdditionalInstanceManager(cloud, instanceClientFactory, jobSpec.Name, instanceID, address)
+				if err != nil {
+					return instances, disks, err
+				}
+
+				if !instanceRegistryConfig.IsEmpty() {
+					instanceRegistryConfig.SSHTunnel.Host = address
+				}
 			}
+
+			if keptInstances[instanceName(jobSpec.Name, instanceID)] {
+				instance, instanceDisks, err = jobInstanceManager.Update(jobSpec.Name, instanceID, deploymentManifest, deployStage)
+				if err != nil {
+					return instances, disks, bosherr.WrapErrorf(err, "Updating instance '%s/%d'", jobSpec.Name, instanceID)
+				}
+			} else {
+				instance, instanceDisks, err = jobInstanceManager.Create(jobSpec.Name, instanceID, deploymentManifest, cloudStemcell, instanceRegistryConfig, deployStage)
+				if err != nil {
+					return instances, disks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobSpec.Name, instanceID)
+				}
+
+				if isAdditionalInstance(deploymentManifest, jobSpec.Name, instanceID) {
+					if err := d.recordVMConfigDigest(deploymentManifest, cloudStemcell, jobSpec.Name, instanceID); err != nil {
+						return instances, disks, err
+					}
+				}
+			}
+
 			instances = append(instances, instance)
 			disks = append(disks, instanceDisks...)
 
@@ -114,3 +227,66 @@ func (d *deployer) createAllInstances(
 
 	return instances, disks, nil
 }
+
+// isAdditionalInstance is true for instances in the manifest other than the first instance of the first job
+func isAdditionalInstance(deploymentManifest bideplmanifest.Manifest, jobName string, instanceID int) bool {
+	if len(deploymentManifest.Jobs) > 0 && deploymentManifest.JobName() == jobName && instanceID == 0 {
+		return false
+	}
+
+	job, found := deploymentManifest.FindJobByName(jobName)
+	return found && instanceID < job.Instances
+}
+
+func (d *deployer) newAdditionalInstanceManager(
+	cloud bicloud.Cloud,
+	instanceClientFactory InstanceClientFactory,
+	jobName string,
+	instanceID int,
+	address string,
+) (biinstance.Manager, error) {
+	return newAdditionalInstanceManager(cloud, instanceClientFactory, d.instanceVMManagerFactory, d.instanceManagerFactory, jobName, instanceID, address)
+}
+
+func (d *deployer) recordVMConfigDigest(deploymentManifest bideplmanifest.Manifest, cloudStemcell bistemcell.CloudStemcell, jobName string, instanceID int) error {
+	digest, err := vmConfigDigest(deploymentManifest, cloudStemcell.CID(), jobName, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if err := d.instanceRepo.UpdateVMConfigDigest(jobName, instanceID, digest); err != nil {
+		return bosherr.WrapErrorf(err, "Recording VM of instance '%s/%d'", jobName, instanceID)
+	}
+
+	return nil
+}
+
+// vmConfigDigest identifies everything the VM of an instance is created with,
+// the VM does not have to be recreated as long as it does not change
+func vmConfigDigest(deploymentManifest bideplmanifest.Manifest, stemcellCID string, jobName string, instanceID int) (string, error) {
+	resourcePool, err := deploymentManifest.ResourcePool(jobName)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Getting resource pool for job '%s'", jobName)
+	}
+
+	networkInterfaces, err := deploymentManifest.InstanceNetworkInterfaces(jobName, instanceID)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting network spec")
+	}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"stemcell_cid":     stemcellCID,
+		"cloud_properties": resourcePool.CloudProperties,
+		"env":              resourcePool.Env,
+		"networks":         networkInterfaces,
+	})
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Marshalling VM config of instance '%s/%d'", jobName, instanceID)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(config)), nil
+}
+
+func instanceName(jobName string, instanceID int) string {
+	return fmt.Sprintf("%s/%d", jobName, instanceID)
+}
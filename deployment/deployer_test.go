@@ -2,6 +2,7 @@ package deployment_test
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	. "github.com/cloudfoundry/bosh-cli/deployment"
@@ -10,6 +11,7 @@ import (
 	mock_agentclient "github.com/cloudfoundry/bosh-cli/agentclient/mocks"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/deployment/instance/state/mocks"
+	mock_deployment "github.com/cloudfoundry/bosh-cli/deployment/mocks"
 	mock_vm "github.com/cloudfoundry/bosh-cli/deployment/vm/mocks"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -32,6 +34,8 @@ import (
 	fakebisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel/fakes"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
 
 var _ = Describe("Deployer", func() {
@@ -70,6 +74,10 @@ var _ = Describe("Deployer", func() {
 		mockState               *mock_instance_state.MockState
 
 		mockBlobstore *mock_blobstore.MockBlobstore
+
+		mockInstanceVMManagerFactory *mock_vm.MockInstanceManagerFactory
+		mockInstanceClientFactory    *mock_deployment.MockInstanceClientFactory
+		deploymentStateService       biconfig.DeploymentStateService
 	)
 
 	BeforeEach(func() {
@@ -157,9 +165,18 @@ var _ = Describe("Deployer", func() {
 		pingDelay := 500 * time.Millisecond
 		deploymentFactory := NewFactory(pingTimeout, pingDelay)
 
+		mockInstanceVMManagerFactory = mock_vm.NewMockInstanceManagerFactory(mockCtrl)
+		mockInstanceClientFactory = mock_deployment.NewMockInstanceClientFactory(mockCtrl)
+
+		fakeFS := fakesys.NewFakeFileSystem()
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fakeFS, fakeuuid.NewFakeGenerator(), logger, "/deployment.json")
+		instanceRepo := biconfig.NewInstanceRepo(deploymentStateService)
+
 		deployer = NewDeployer(
 			mockVMManagerFactory,
+			mockInstanceVMManagerFactory,
 			instanceManagerFactory,
+			instanceRepo,
 			deploymentFactory,
 			logger,
 		)
@@ -193,7 +210,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("deletes existing vm", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
@@ -209,7 +226,7 @@ var _ = Describe("Deployer", func() {
 		Context("when skip-drain is specified", func() {
 			It("skips draining", func() {
 				skipDrain = true
-				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
@@ -224,7 +241,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("creates a vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
@@ -251,7 +268,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("starts the SSH tunnel", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeSSHTunnel.Started).To(BeTrue())
 			Expect(fakeSSHTunnelFactory.NewSSHTunnelOptions).To(Equal(bisshtunnel.Options{
@@ -271,7 +288,7 @@ var _ = Describe("Deployer", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-ssh-tunnel-start-error"))
 			})
@@ -279,7 +296,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("waits for the vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(fakeVM.WaitUntilReadyInputs).To(ContainElement(fakebivm.WaitUntilReadyInput{
 			Timeout: 10 * time.Minute,
@@ -288,7 +305,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("logs start and stop events to the eventLogger", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeStage.PerformCalls[1]).To(Equal(&fakebiui.PerformCall{
@@ -306,7 +323,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-wait-error"))
 
@@ -318,7 +335,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("updates the vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.ApplyInputs).To(Equal([]fakebivm.ApplyInput{
@@ -328,14 +345,14 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("starts the agent", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.StartCalled).To(Equal(1))
 	})
 
 	It("waits until agent reports state as running", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.WaitToBeRunningInputs).To(ContainElement(fakebivm.WaitInput{
@@ -350,13 +367,13 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("returns an error", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).To(HaveOccurred())
 		})
 	})
 
 	It("logs instance update ui stages", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeStage.PerformCalls[2:4]).To(Equal([]*fakebiui.PerformCall{
@@ -371,7 +388,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("fails with descriptive error", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("Applying the initial agent state: fake-apply-error"))
 		})
@@ -383,7 +400,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
 
@@ -403,7 +420,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-wait-running-error"))
 
@@ -413,4 +430,144 @@ var _ = Describe("Deployer", func() {
 			}))
 		})
 	})
+
+	Context("when the deployment has additional instances", func() {
+		var (
+			fakeInstanceVMManager *fakebivm.FakeManager
+			fakeInstanceVM        *fakebivm.FakeVM
+		)
+
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].Instances = 2
+			deploymentManifest.Jobs[0].ResourcePool = "fake-resource-pool-name"
+			deploymentManifest.Jobs[0].Networks = []bideplmanifest.JobNetwork{
+				{Name: "fake-network-name", StaticIPs: []string{"10.0.0.1", "10.0.0.2"}},
+			}
+			deploymentManifest.ResourcePools = []bideplmanifest.ResourcePool{
+				{Name: "fake-resource-pool-name", Network: "fake-network-name"},
+			}
+			deploymentManifest.Networks = []bideplmanifest.Network{
+				{Name: "fake-network-name", Type: bideplmanifest.Dynamic},
+			}
+
+			fakeInstanceVMManager = fakebivm.NewFakeManager()
+			fakeInstanceVM = fakebivm.NewFakeVM("fake-instance-vm-cid")
+			fakeInstanceVM.AgentClientReturn = mockAgentClient
+			fakeInstanceVMManager.CreateVM = fakeInstanceVM
+
+			mockInstanceClientFactory.EXPECT().NewAgentClient("10.0.0.2").Return(mockAgentClient, nil).AnyTimes()
+			mockInstanceClientFactory.EXPECT().NewBlobstore("10.0.0.2").Return(mockBlobstore, nil).AnyTimes()
+			mockInstanceVMManagerFactory.EXPECT().NewManager(cloud, mockAgentClient, "fake-job-name", 1, "10.0.0.2").Return(fakeInstanceVMManager).AnyTimes()
+		})
+
+		JustBeforeEach(func() {
+			mockStateBuilder.EXPECT().Build("fake-job-name", 1, deploymentManifest, fakeStage, gomock.Any()).Return(mockState, nil).AnyTimes()
+			mockStateBuilder.EXPECT().BuildInitialState("fake-job-name", 1, deploymentManifest).Return(mockState, nil).AnyTimes()
+		})
+
+		It("creates a vm for every instance", func() {
+			deployment, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVMManager.CreateInput.Manifest).To(Equal(deploymentManifest))
+			Expect(fakeInstanceVMManager.CreateInput.Manifest).To(Equal(deploymentManifest))
+			Expect(fakeInstanceVM.StartCalled).To(Equal(1))
+
+			Expect(deployment).ToNot(BeNil())
+		})
+
+		It("updates every instance in order", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			var updates []string
+			for _, call := range fakeStage.PerformCalls {
+				if strings.HasPrefix(call.Name, "Updating instance") {
+					updates = append(updates, call.Name)
+				}
+			}
+			Expect(updates).To(Equal([]string{
+				"Updating instance 'fake-job-name/0'",
+				"Updating instance 'fake-job-name/1'",
+			}))
+		})
+
+		Context("when the instance was deployed before", func() {
+			BeforeEach(func() {
+				// the fake vm manager does not record the vm it creates
+				err := deploymentStateService.Save(biconfig.DeploymentState{
+					Instances: []biconfig.InstanceRecord{
+						{Name: "fake-job-name", Index: 1, Address: "10.0.0.2", VMCID: "fake-instance-vm-cid"},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.Instances).To(HaveLen(1))
+				Expect(deploymentState.Instances[0].VMConfigDigest).ToNot(BeEmpty())
+
+				fakeInstanceVMManager.SetFindCurrentBehavior(fakeInstanceVM, true, nil)
+				fakeInstanceVMManager.CreateInput = fakebivm.CreateInput{}
+				fakeInstanceVM.UpdateDisksInputs = nil
+			})
+
+			It("keeps the vm and updates the instance on it when the vm config has not changed", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeInstanceVM.DeleteCalled).To(Equal(0))
+				Expect(fakeInstanceVMManager.CreateInput).To(Equal(fakebivm.CreateInput{}))
+				Expect(fakeInstanceVM.UpdateDisksInputs).To(HaveLen(1))
+				Expect(fakeInstanceVM.StartCalled).To(Equal(2))
+			})
+
+			It("recreates the vm when the stemcell has changed", func() {
+				fakeStemcellRepo := fakebiconfig.NewFakeStemcellRepo()
+				stemcellRecord := biconfig.StemcellRecord{
+					ID:      "fake-new-stemcell-id",
+					Name:    "fake-stemcell-name",
+					Version: "fake-stemcell-version",
+					CID:     "fake-new-stemcell-cid",
+				}
+				err := fakeStemcellRepo.SetFindBehavior("fake-stemcell-name", "fake-stemcell-version", stemcellRecord, true, nil)
+				Expect(err).ToNot(HaveOccurred())
+				newCloudStemcell := bistemcell.NewCloudStemcell(stemcellRecord, fakeStemcellRepo, cloud)
+
+				_, err = deployer.Deploy(cloud, deploymentManifest, newCloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeInstanceVM.DeleteCalled).To(Equal(1))
+				Expect(fakeInstanceVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+					Stemcell: newCloudStemcell,
+					Manifest: deploymentManifest,
+				}))
+			})
+		})
+
+		Context("when an instance is no longer in the manifest", func() {
+			BeforeEach(func() {
+				err := deploymentStateService.Save(biconfig.DeploymentState{
+					Instances: []biconfig.InstanceRecord{
+						{Name: "removed-job-name", Index: 0, Address: "10.0.0.9"},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("forgets the instance", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, mockInstanceClientFactory, skipDrain, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.Instances).To(BeEmpty())
+			})
+		})
+	})
 })
@@ -12,8 +12,10 @@ import (
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/deployment/instance/state/mocks"
+	mock_deployment "github.com/cloudfoundry/bosh-cli/deployment/mocks"
 	"github.com/golang/mock/gomock"
 
+	"code.cloudfoundry.org/clock"
 	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -151,10 +153,13 @@ var _ = Describe("Deployment", func() {
 
 		JustBeforeEach(func() {
 			// all these local factories & managers are just used to construct a Deployment based on the deployment state
-			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
-			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
+			snapshotRepo := biconfig.NewSnapshotRepo(deploymentStateService, fakeRepoUUIDGenerator)
+			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, snapshotRepo, clock.NewClock(), logger)
+			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, snapshotRepo, clock.NewClock(), logger, bivm.DiskDeployerOptions{})
 
-			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			instanceVMManagerFactory := bivm.NewInstanceManagerFactory(deploymentStateService, stemcellRepo, fakeUUIDGenerator, fs, logger, bivm.DiskDeployerOptions{})
+			instanceRepo := biconfig.NewInstanceRepo(deploymentStateService)
 			sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
@@ -167,8 +172,8 @@ var _ = Describe("Deployment", func() {
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
-			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, deploymentFactory)
-			deploymentManager := deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceVMManagerFactory, instanceManagerFactory, instanceRepo, diskManagerFactory, stemcellManagerFactory, deploymentFactory)
+			deploymentManager := deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore, mock_deployment.NewMockInstanceClientFactory(mockCtrl))
 
 			allowApplySpecToBeCreated()
 
@@ -386,6 +391,24 @@ var _ = Describe("Deployment", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			It("deletes the snapshots of the disk before the disk", func() {
+				snapshotRepo := biconfig.NewSnapshotRepo(deploymentStateService, fakeRepoUUIDGenerator)
+				_, err := snapshotRepo.Save("fake-snapshot-cid", "fake-disk-cid", time.Now())
+				Expect(err).ToNot(HaveOccurred())
+
+				gomock.InOrder(
+					mockCloud.EXPECT().DeleteSnapshot("fake-snapshot-cid"),
+					mockCloud.EXPECT().DeleteDisk("fake-disk-cid"),
+				)
+
+				err = deployment.Delete(skipDrain, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				snapshotRecords, err := snapshotRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshotRecords).To(BeEmpty())
+			})
+
 			Context("when current disk has been deleted manually (outside of bosh)", func() {
 				It("deletes the disk (to ensure related resources are released by the CPI)", func() {
 					mockCloud.EXPECT().DeleteDisk("fake-disk-cid")
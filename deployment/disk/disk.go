@@ -11,30 +11,39 @@ import (
 
 type Disk interface {
 	CID() string
+	Name() string
 	NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool
+	CanResize(newSize int, newCloudProperties biproperty.Map) bool
+	Resize(newSize int) error
+	Snapshot() (snapshotCID string, err error)
 	Delete() error
 }
 
 type disk struct {
 	cid             string
+	name            string
 	size            int
 	cloudProperties biproperty.Map
 
-	cloud bicloud.Cloud
-	repo  biconfig.DiskRepo
+	cloud        bicloud.Cloud
+	repo         biconfig.DiskRepo
+	snapshotRepo biconfig.SnapshotRepo
 }
 
 func NewDisk(
 	diskRecord biconfig.DiskRecord,
 	cloud bicloud.Cloud,
 	repo biconfig.DiskRepo,
+	snapshotRepo biconfig.SnapshotRepo,
 ) Disk {
 	return &disk{
 		cid:             diskRecord.CID,
+		name:            diskRecord.Name,
 		size:            diskRecord.Size,
 		cloudProperties: diskRecord.CloudProperties,
 		cloud:           cloud,
 		repo:            repo,
+		snapshotRepo:    snapshotRepo,
 	}
 }
 
@@ -42,11 +51,57 @@ func (d *disk) CID() string {
 	return d.cid
 }
 
+// Name is empty for the disk mounted by the agent
+func (d *disk) Name() string {
+	return d.name
+}
+
 func (d *disk) NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool {
 	return d.size != newSize || !reflect.DeepEqual(d.cloudProperties, newCloudProperties)
 }
 
+// CanResize is true when only the size of the disk grows, which CPIs may support without migrating the disk
+func (d *disk) CanResize(newSize int, newCloudProperties biproperty.Map) bool {
+	return newSize > d.size && reflect.DeepEqual(d.cloudProperties, newCloudProperties)
+}
+
+// Resize returns a bicloud.Error with NotImplementedError type when the CPI does not support resizing disks
+func (d *disk) Resize(newSize int) error {
+	err := d.cloud.ResizeDisk(d.cid, newSize)
+	if err != nil {
+		cloudErr, ok := err.(bicloud.Error)
+		if ok && cloudErr.Type() == bicloud.NotImplementedError {
+			return cloudErr
+		}
+		return bosherr.WrapError(err, "Resizing disk in the cloud")
+	}
+
+	err = d.repo.UpdateSize(d.cid, newSize)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Updating disk record size (cid=%s)", d.cid)
+	}
+
+	d.size = newSize
+
+	return nil
+}
+
+func (d *disk) Snapshot() (string, error) {
+	snapshotCID, err := d.cloud.SnapshotDisk(d.cid, bicloud.DiskMetadata{})
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Snapshotting disk '%s' in the cloud", d.cid)
+	}
+
+	return snapshotCID, nil
+}
+
+// Delete also deletes the snapshots taken of the disk before it was migrated
 func (d *disk) Delete() error {
+	err := d.deleteSnapshots()
+	if err != nil {
+		return err
+	}
+
 	deleteErr := d.cloud.DeleteDisk(d.cid)
 	if deleteErr != nil {
 		// allow DiskNotFoundError for idempotency
@@ -73,3 +128,28 @@ func (d *disk) Delete() error {
 	// returns bicloud.Error only if it is a DiskNotFoundError
 	return deleteErr
 }
+
+func (d *disk) deleteSnapshots() error {
+	snapshotRecords, err := d.snapshotRepo.All()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding snapshot records")
+	}
+
+	for _, snapshotRecord := range snapshotRecords {
+		if snapshotRecord.DiskCID != d.cid {
+			continue
+		}
+
+		err = d.cloud.DeleteSnapshot(snapshotRecord.CID)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting snapshot '%s' of disk '%s' in the cloud", snapshotRecord.CID, d.cid)
+		}
+
+		err = d.snapshotRepo.Delete(snapshotRecord)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting snapshot record (cid=%s)", snapshotRecord.CID)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package disk_test
 
 import (
 	"errors"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -23,6 +25,7 @@ var _ = Describe("Disk", func() {
 		diskCloudProperties biproperty.Map
 		fakeCloud           *fakebicloud.FakeCloud
 		diskRepo            biconfig.DiskRepo
+		snapshotRepo        biconfig.SnapshotRepo
 		fakeUUIDGenerator   *fakeuuid.FakeGenerator
 	)
 
@@ -44,8 +47,9 @@ var _ = Describe("Disk", func() {
 		//		todo: come back to this?
 		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
 		diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+		snapshotRepo = biconfig.NewSnapshotRepo(deploymentStateService, fakeUUIDGenerator)
 
-		disk = NewDisk(diskRecord, fakeCloud, diskRepo)
+		disk = NewDisk(diskRecord, fakeCloud, diskRepo, snapshotRepo)
 	})
 
 	Describe("NeedsMigration", func() {
@@ -82,6 +86,86 @@ var _ = Describe("Disk", func() {
 		})
 	})
 
+	Describe("CanResize", func() {
+		It("returns true when only the size grows", func() {
+			Expect(disk.CanResize(2048, diskCloudProperties)).To(BeTrue())
+		})
+
+		It("returns false when the size shrinks", func() {
+			Expect(disk.CanResize(512, diskCloudProperties)).To(BeFalse())
+		})
+
+		It("returns false when cloud properties are different", func() {
+			Expect(disk.CanResize(2048, biproperty.Map{"fake-cloud-property-key": "new-value"})).To(BeFalse())
+		})
+	})
+
+	Describe("Resize", func() {
+		BeforeEach(func() {
+			_, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("resizes the disk in the cloud and records the new size", func() {
+			err := disk.Resize(2048)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeCloud.ResizeDiskInputs).To(Equal([]fakebicloud.ResizeDiskInput{
+				{DiskCID: "fake-disk-cid", NewSize: 2048},
+			}))
+
+			diskRecord, found, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskRecord.Size).To(Equal(2048))
+
+			Expect(disk.NeedsMigration(2048, diskCloudProperties)).To(BeFalse())
+		})
+
+		It("returns the cloud error when the CPI does not support resizing", func() {
+			fakeCloud.ResizeDiskErr = bicloud.NewCPIError("resize_disk", bicloud.CmdError{Type: bicloud.NotImplementedError})
+
+			err := disk.Resize(2048)
+			Expect(err).To(HaveOccurred())
+
+			cloudErr, ok := err.(bicloud.Error)
+			Expect(ok).To(BeTrue())
+			Expect(cloudErr.Type()).To(Equal(bicloud.NotImplementedError))
+		})
+
+		It("returns an error when resizing in the cloud fails", func() {
+			fakeCloud.ResizeDiskErr = errors.New("fake-resize-error")
+
+			err := disk.Resize(2048)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-resize-error"))
+
+			diskRecord, _, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskRecord.Size).To(Equal(1024))
+		})
+	})
+
+	Describe("Snapshot", func() {
+		It("snapshots the disk in the cloud", func() {
+			fakeCloud.SnapshotDiskSnapshotCID = "fake-snapshot-cid"
+
+			snapshotCID, err := disk.Snapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshotCID).To(Equal("fake-snapshot-cid"))
+			Expect(fakeCloud.SnapshotDiskInputs).To(Equal([]fakebicloud.SnapshotDiskInput{
+				{DiskCID: "fake-disk-cid", Metadata: bicloud.DiskMetadata{}},
+			}))
+		})
+
+		It("returns an error when snapshotting fails", func() {
+			fakeCloud.SnapshotDiskErr = errors.New("fake-snapshot-error")
+
+			_, err := disk.Snapshot()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-snapshot-error"))
+		})
+	})
+
 	Describe("Delete", func() {
 		It("deletes disk from cloud", func() {
 			err := disk.Delete()
@@ -103,6 +187,50 @@ var _ = Describe("Disk", func() {
 			Expect(diskRecords).To(BeEmpty())
 		})
 
+		Context("when the disk has snapshots", func() {
+			BeforeEach(func() {
+				fakeUUIDGenerator.GeneratedUUID = "fake-snapshot-id-1"
+				_, err := snapshotRepo.Save("fake-snapshot-cid-1", "fake-disk-cid", time.Now())
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeUUIDGenerator.GeneratedUUID = "fake-snapshot-id-2"
+				_, err = snapshotRepo.Save("fake-snapshot-cid-2", "fake-other-disk-cid", time.Now())
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("deletes the snapshots of the disk before the disk", func() {
+				err := disk.Delete()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.DeleteSnapshotInputs).To(Equal([]fakebicloud.DeleteSnapshotInput{
+					{SnapshotCID: "fake-snapshot-cid-1"},
+				}))
+
+				snapshotRecords, err := snapshotRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshotRecords).To(HaveLen(1))
+				Expect(snapshotRecords[0].CID).To(Equal("fake-snapshot-cid-2"))
+			})
+
+			Context("when deleting a snapshot in the cloud fails", func() {
+				BeforeEach(func() {
+					fakeCloud.DeleteSnapshotErr = errors.New("fake-delete-snapshot-error")
+				})
+
+				It("keeps the disk and the snapshot record", func() {
+					err := disk.Delete()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-delete-snapshot-error"))
+
+					Expect(fakeCloud.DeleteDiskInputs).To(BeEmpty())
+
+					snapshotRecords, err := snapshotRepo.All()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(snapshotRecords).To(HaveLen(2))
+				})
+			})
+		})
+
 		Context("when deleted disk is the current disk", func() {
 			BeforeEach(func() {
 				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
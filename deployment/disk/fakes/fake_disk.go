@@ -7,9 +7,21 @@ import (
 type FakeDisk struct {
 	cid string
 
+	NameReturn string
+
 	NeedsMigrationInputs []NeedsMigrationInput
 	needsMigrationOutput needsMigrationOutput
 
+	CanResizeInputs []NeedsMigrationInput
+	CanResizeReturn bool
+
+	ResizeInputs []int
+	ResizeErr    error
+
+	SnapshotCalledTimes int
+	SnapshotCID         string
+	SnapshotErr         error
+
 	DeleteCalledTimes int
 	deleteErr         error
 }
@@ -34,6 +46,10 @@ func (d *FakeDisk) CID() string {
 	return d.cid
 }
 
+func (d *FakeDisk) Name() string {
+	return d.NameReturn
+}
+
 func (d *FakeDisk) NeedsMigration(size int, cloudProperties biproperty.Map) bool {
 	d.NeedsMigrationInputs = append(d.NeedsMigrationInputs, NeedsMigrationInput{
 		Size:            size,
@@ -43,6 +59,25 @@ func (d *FakeDisk) NeedsMigration(size int, cloudProperties biproperty.Map) bool
 	return d.needsMigrationOutput.needsMigration
 }
 
+func (d *FakeDisk) CanResize(size int, cloudProperties biproperty.Map) bool {
+	d.CanResizeInputs = append(d.CanResizeInputs, NeedsMigrationInput{
+		Size:            size,
+		CloudProperties: cloudProperties,
+	})
+
+	return d.CanResizeReturn
+}
+
+func (d *FakeDisk) Resize(size int) error {
+	d.ResizeInputs = append(d.ResizeInputs, size)
+	return d.ResizeErr
+}
+
+func (d *FakeDisk) Snapshot() (string, error) {
+	d.SnapshotCalledTimes++
+	return d.SnapshotCID, d.SnapshotErr
+}
+
 func (d *FakeDisk) Delete() error {
 	d.DeleteCalledTimes++
 	return d.deleteErr
@@ -16,6 +16,9 @@ type FakeManager struct {
 	DeleteUnusedCalledTimes int
 	DeleteUnusedErr         error
 
+	DeleteAllUnusedCalledTimes int
+	DeleteAllUnusedErr         error
+
 	findUnusedOutput findUnusedOutput
 }
 
@@ -52,6 +55,10 @@ func (m *FakeManager) FindCurrent() ([]bidisk.Disk, error) {
 	return m.findCurrentOutput.Disks, m.findCurrentOutput.Err
 }
 
+func (m *FakeManager) FindAllCurrent() ([]bidisk.Disk, error) {
+	return m.findCurrentOutput.Disks, m.findCurrentOutput.Err
+}
+
 func (m *FakeManager) FindUnused() ([]bidisk.Disk, error) {
 	return m.findUnusedOutput.disks, m.findUnusedOutput.err
 }
@@ -61,6 +68,11 @@ func (m *FakeManager) DeleteUnused(eventLogStage biui.Stage) error {
 	return m.DeleteUnusedErr
 }
 
+func (m *FakeManager) DeleteAllUnused(eventLogStage biui.Stage) error {
+	m.DeleteAllUnusedCalledTimes++
+	return m.DeleteAllUnusedErr
+}
+
 func (m *FakeManager) SetFindCurrentBehavior(disks []bidisk.Disk, err error) {
 	m.findCurrentOutput = findCurrentOutput{
 		Disks: disks,
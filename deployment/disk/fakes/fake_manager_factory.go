@@ -9,14 +9,24 @@ type NewManagerInput struct {
 	Cloud bicloud.Cloud
 }
 
+type NewNamedManagerInput struct {
+	Cloud    bicloud.Cloud
+	DiskName string
+}
+
 type FakeManagerFactory struct {
 	NewManagerInputs  []NewManagerInput
 	NewManagerManager bidisk.Manager
+
+	NewNamedManagerInputs   []NewNamedManagerInput
+	NewNamedManagerManagers map[string]bidisk.Manager
 }
 
 func NewFakeManagerFactory() *FakeManagerFactory {
 	return &FakeManagerFactory{
-		NewManagerInputs: []NewManagerInput{},
+		NewManagerInputs:        []NewManagerInput{},
+		NewNamedManagerInputs:   []NewNamedManagerInput{},
+		NewNamedManagerManagers: map[string]bidisk.Manager{},
 	}
 }
 
@@ -28,3 +38,12 @@ func (f *FakeManagerFactory) NewManager(cloud bicloud.Cloud) bidisk.Manager {
 
 	return f.NewManagerManager
 }
+
+func (f *FakeManagerFactory) NewNamedManager(cloud bicloud.Cloud, diskName string) bidisk.Manager {
+	f.NewNamedManagerInputs = append(f.NewNamedManagerInputs, NewNamedManagerInput{
+		Cloud:    cloud,
+		DiskName: diskName,
+	})
+
+	return f.NewNamedManagerManagers[diskName]
+}
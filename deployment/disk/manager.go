@@ -2,7 +2,9 @@ package disk
 
 import (
 	"fmt"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -13,29 +15,37 @@ import (
 
 type Manager interface {
 	FindCurrent() ([]Disk, error)
+	FindAllCurrent() ([]Disk, error)
 	Create(bideplmanifest.DiskPool, string) (Disk, error)
 	FindUnused() ([]Disk, error)
 	DeleteUnused(biui.Stage) error
+	DeleteAllUnused(biui.Stage) error
 }
 
 func NewManager(
 	cloud bicloud.Cloud,
 	diskRepo biconfig.DiskRepo,
+	snapshotRepo biconfig.SnapshotRepo,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) Manager {
 	return &manager{
-		cloud:    cloud,
-		diskRepo: diskRepo,
-		logger:   logger,
-		logTag:   "diskManager",
+		cloud:        cloud,
+		diskRepo:     diskRepo,
+		snapshotRepo: snapshotRepo,
+		timeService:  timeService,
+		logger:       logger,
+		logTag:       "diskManager",
 	}
 }
 
 type manager struct {
-	cloud    bicloud.Cloud
-	diskRepo biconfig.DiskRepo
-	logger   boshlog.Logger
-	logTag   string
+	cloud        bicloud.Cloud
+	diskRepo     biconfig.DiskRepo
+	snapshotRepo biconfig.SnapshotRepo
+	timeService  clock.Clock
+	logger       boshlog.Logger
+	logTag       string
 }
 
 func (m *manager) FindCurrent() ([]Disk, error) {
@@ -47,13 +57,29 @@ func (m *manager) FindCurrent() ([]Disk, error) {
 	}
 
 	if found {
-		disk := NewDisk(diskRecord, m.cloud, m.diskRepo)
+		disk := NewDisk(diskRecord, m.cloud, m.diskRepo, m.snapshotRepo)
 		disks = append(disks, disk)
 	}
 
 	return disks, nil
 }
 
+// FindAllCurrent returns the current disks of all instances
+func (m *manager) FindAllCurrent() ([]Disk, error) {
+	disks := []Disk{}
+
+	diskRecords, err := m.diskRepo.FindAllCurrent()
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Reading disk records")
+	}
+
+	for _, diskRecord := range diskRecords {
+		disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo, m.snapshotRepo))
+	}
+
+	return disks, nil
+}
+
 func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk, error) {
 	diskCloudProperties := diskPool.CloudProperties
 
@@ -72,7 +98,7 @@ func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk,
 		return nil, bosherr.WrapError(err, "Saving deployment disk record")
 	}
 
-	disk := NewDisk(diskRecord, m.cloud, m.diskRepo)
+	disk := NewDisk(diskRecord, m.cloud, m.diskRepo, m.snapshotRepo)
 
 	return disk, nil
 }
@@ -80,34 +106,47 @@ func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk,
 func (m *manager) FindUnused() ([]Disk, error) {
 	disks := []Disk{}
 
-	diskRecords, err := m.diskRepo.All()
-	if err != nil {
-		return disks, bosherr.WrapError(err, "Getting all disk records")
-	}
-
-	currentDiskRecord, found, err := m.diskRepo.FindCurrent()
+	diskRecords, err := m.findUnusedRecords()
 	if err != nil {
-		return disks, bosherr.WrapError(err, "Finding current disk record")
+		return disks, err
 	}
 
 	for _, diskRecord := range diskRecords {
-		if !found || diskRecord.ID != currentDiskRecord.ID {
-			disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo))
-		}
+		disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo, m.snapshotRepo))
 	}
 
 	return disks, nil
 }
 
+// DeleteUnused keeps orphaned disks and their snapshots until their retention expires
 func (m *manager) DeleteUnused(eventLoggerStage biui.Stage) error {
-	disks, err := m.FindUnused()
+	return m.deleteUnused(eventLoggerStage, true)
+}
+
+// DeleteAllUnused also deletes orphaned disks whose retention has not expired yet
+func (m *manager) DeleteAllUnused(eventLoggerStage biui.Stage) error {
+	return m.deleteUnused(eventLoggerStage, false)
+}
+
+func (m *manager) deleteUnused(eventLoggerStage biui.Stage, keepOrphaned bool) error {
+	diskRecords, err := m.findUnusedRecords()
 	if err != nil {
 		return bosherr.WrapError(err, "Finding unused disks")
 	}
 
-	for _, disk := range disks {
+	for _, diskRecord := range diskRecords {
+		disk := NewDisk(diskRecord, m.cloud, m.diskRepo, m.snapshotRepo)
+		orphanedUntil := diskRecord.OrphanedUntil
+
 		stepName := fmt.Sprintf("Deleting unused disk '%s'", disk.CID())
 		err = eventLoggerStage.Perform(stepName, func() error {
+			if keepOrphaned && orphanedUntil != nil && m.timeService.Now().Before(*orphanedUntil) {
+				return biui.NewSkipStageError(
+					bosherr.Errorf("Disk '%s' is orphaned until %s", disk.CID(), orphanedUntil.Format(time.RFC3339)),
+					fmt.Sprintf("Orphaned until %s", orphanedUntil.Format(time.RFC3339)),
+				)
+			}
+
 			err := disk.Delete()
 			cloudErr, ok := err.(bicloud.Error)
 			if ok && cloudErr.Type() == bicloud.DiskNotFoundError {
@@ -122,3 +161,31 @@ func (m *manager) DeleteUnused(eventLoggerStage biui.Stage) error {
 
 	return nil
 }
+
+func (m *manager) findUnusedRecords() ([]biconfig.DiskRecord, error) {
+	unusedDiskRecords := []biconfig.DiskRecord{}
+
+	diskRecords, err := m.diskRepo.All()
+	if err != nil {
+		return unusedDiskRecords, bosherr.WrapError(err, "Getting all disk records")
+	}
+
+	// Disks that are current for any instance are in use
+	currentDiskRecords, err := m.diskRepo.FindAllCurrent()
+	if err != nil {
+		return unusedDiskRecords, bosherr.WrapError(err, "Finding current disk records")
+	}
+
+	currentDiskIDs := map[string]bool{}
+	for _, currentDiskRecord := range currentDiskRecords {
+		currentDiskIDs[currentDiskRecord.ID] = true
+	}
+
+	for _, diskRecord := range diskRecords {
+		if !currentDiskIDs[diskRecord.ID] {
+			unusedDiskRecords = append(unusedDiskRecords, diskRecord)
+		}
+	}
+
+	return unusedDiskRecords, nil
+}
@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"code.cloudfoundry.org/clock"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -8,23 +9,35 @@ import (
 
 type ManagerFactory interface {
 	NewManager(bicloud.Cloud) Manager
+	NewNamedManager(cloud bicloud.Cloud, diskName string) Manager
 }
 
 type managerFactory struct {
-	diskRepo biconfig.DiskRepo
-	logger   boshlog.Logger
+	diskRepo     biconfig.DiskRepo
+	snapshotRepo biconfig.SnapshotRepo
+	timeService  clock.Clock
+	logger       boshlog.Logger
 }
 
 func NewManagerFactory(
 	diskRepo biconfig.DiskRepo,
+	snapshotRepo biconfig.SnapshotRepo,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
-		diskRepo: diskRepo,
-		logger:   logger,
+		diskRepo:     diskRepo,
+		snapshotRepo: snapshotRepo,
+		timeService:  timeService,
+		logger:       logger,
 	}
 }
 
 func (f *managerFactory) NewManager(cloud bicloud.Cloud) Manager {
-	return NewManager(cloud, f.diskRepo, f.logger)
+	return NewManager(cloud, f.diskRepo, f.snapshotRepo, f.timeService, f.logger)
+}
+
+// NewNamedManager creates a manager for the named persistent disk of the same instance
+func (f *managerFactory) NewNamedManager(cloud bicloud.Cloud, diskName string) Manager {
+	return NewManager(cloud, f.diskRepo.Named(diskName), f.snapshotRepo, f.timeService, f.logger)
 }
@@ -2,12 +2,16 @@ package disk_test
 
 import (
 	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	. "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -24,6 +28,8 @@ var _ = Describe("Manager", func() {
 		fakeFs            *fakesys.FakeFileSystem
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
 		diskRepo          biconfig.DiskRepo
+		snapshotRepo      biconfig.SnapshotRepo
+		timeService       *fakeclock.FakeClock
 	)
 
 	BeforeEach(func() {
@@ -32,7 +38,9 @@ var _ = Describe("Manager", func() {
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
 		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/fake/path")
 		diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
-		managerFactory := NewManagerFactory(diskRepo, logger)
+		snapshotRepo = biconfig.NewSnapshotRepo(deploymentStateService, fakeUUIDGenerator)
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC))
+		managerFactory := NewManagerFactory(diskRepo, snapshotRepo, timeService, logger)
 		fakeCloud = fakebicloud.NewFakeCloud()
 		manager = managerFactory.NewManager(fakeCloud)
 		fakeUUIDGenerator.GeneratedUUID = "fake-uuid"
@@ -159,7 +167,7 @@ var _ = Describe("Manager", func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
 			firstDiskRecord, err := diskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
 			Expect(err).ToNot(HaveOccurred())
-			firstDisk = NewDisk(firstDiskRecord, fakeCloud, diskRepo)
+			firstDisk = NewDisk(firstDiskRecord, fakeCloud, diskRepo, snapshotRepo)
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
 			_, err = diskRepo.Save("fake-disk-cid-2", 1024, biproperty.Map{})
@@ -170,7 +178,7 @@ var _ = Describe("Manager", func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-3"
 			thirdDiskRecord, err := diskRepo.Save("fake-disk-cid-3", 1024, biproperty.Map{})
 			Expect(err).ToNot(HaveOccurred())
-			thirdDisk = NewDisk(thirdDiskRecord, fakeCloud, diskRepo)
+			thirdDisk = NewDisk(thirdDiskRecord, fakeCloud, diskRepo, snapshotRepo)
 		})
 
 		It("returns unused disks from repo", func() {
@@ -232,5 +240,72 @@ var _ = Describe("Manager", func() {
 				secondDiskRecord,
 			}))
 		})
+
+		Context("when an unused disk is orphaned", func() {
+			BeforeEach(func() {
+				err := diskRepo.Orphan("fake-disk-cid-3", timeService.Now().Add(time.Hour))
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeUUIDGenerator.GeneratedUUID = "fake-snapshot-id"
+				_, err = snapshotRepo.Save("fake-snapshot-cid", "fake-disk-cid-3", timeService.Now())
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("keeps the disk until its retention expires", func() {
+				err := manager.DeleteUnused(fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.DeleteDiskInputs).To(Equal([]fakebicloud.DeleteDiskInput{
+					{DiskCID: "fake-disk-cid-1"},
+				}))
+				Expect(fakeStage.PerformCalls[1].Name).To(Equal("Deleting unused disk 'fake-disk-cid-3'"))
+				Expect(fakeStage.PerformCalls[1].SkipError.(biui.SkipStageError).SkipMessage()).To(Equal("Orphaned until 2017-03-01T13:00:00Z"))
+
+				_, found, err := diskRepo.Find("fake-disk-cid-3")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+
+				Expect(fakeCloud.DeleteSnapshotInputs).To(BeEmpty())
+				snapshotRecords, err := snapshotRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshotRecords).To(HaveLen(1))
+			})
+
+			It("deletes the disk once its retention expired", func() {
+				timeService.Increment(time.Hour)
+
+				err := manager.DeleteUnused(fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.DeleteDiskInputs).To(Equal([]fakebicloud.DeleteDiskInput{
+					{DiskCID: "fake-disk-cid-1"},
+					{DiskCID: "fake-disk-cid-3"},
+				}))
+
+				Expect(fakeCloud.DeleteSnapshotInputs).To(Equal([]fakebicloud.DeleteSnapshotInput{
+					{SnapshotCID: "fake-snapshot-cid"},
+				}))
+				snapshotRecords, err := snapshotRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshotRecords).To(BeEmpty())
+			})
+
+			It("deletes the disk with DeleteAllUnused", func() {
+				err := manager.DeleteAllUnused(fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.DeleteDiskInputs).To(Equal([]fakebicloud.DeleteDiskInput{
+					{DiskCID: "fake-disk-cid-1"},
+					{DiskCID: "fake-disk-cid-3"},
+				}))
+
+				Expect(fakeCloud.DeleteSnapshotInputs).To(Equal([]fakebicloud.DeleteSnapshotInput{
+					{SnapshotCID: "fake-snapshot-cid"},
+				}))
+				snapshotRecords, err := snapshotRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshotRecords).To(BeEmpty())
+			})
+		})
 	})
 })
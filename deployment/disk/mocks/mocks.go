@@ -48,6 +48,18 @@ func (mr *MockDiskMockRecorder) CID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CID", reflect.TypeOf((*MockDisk)(nil).CID))
 }
 
+// CanResize mocks base method
+func (m *MockDisk) CanResize(arg0 int, arg1 property.Map) bool {
+	ret := m.ctrl.Call(m, "CanResize", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CanResize indicates an expected call of CanResize
+func (mr *MockDiskMockRecorder) CanResize(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanResize", reflect.TypeOf((*MockDisk)(nil).CanResize), arg0, arg1)
+}
+
 // Delete mocks base method
 func (m *MockDisk) Delete() error {
 	ret := m.ctrl.Call(m, "Delete")
@@ -60,6 +72,18 @@ func (mr *MockDiskMockRecorder) Delete() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDisk)(nil).Delete))
 }
 
+// Name mocks base method
+func (m *MockDisk) Name() string {
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name
+func (mr *MockDiskMockRecorder) Name() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockDisk)(nil).Name))
+}
+
 // NeedsMigration mocks base method
 func (m *MockDisk) NeedsMigration(arg0 int, arg1 property.Map) bool {
 	ret := m.ctrl.Call(m, "NeedsMigration", arg0, arg1)
@@ -72,6 +96,31 @@ func (mr *MockDiskMockRecorder) NeedsMigration(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsMigration", reflect.TypeOf((*MockDisk)(nil).NeedsMigration), arg0, arg1)
 }
 
+// Resize mocks base method
+func (m *MockDisk) Resize(arg0 int) error {
+	ret := m.ctrl.Call(m, "Resize", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resize indicates an expected call of Resize
+func (mr *MockDiskMockRecorder) Resize(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resize", reflect.TypeOf((*MockDisk)(nil).Resize), arg0)
+}
+
+// Snapshot mocks base method
+func (m *MockDisk) Snapshot() (string, error) {
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockDiskMockRecorder) Snapshot() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockDisk)(nil).Snapshot))
+}
+
 // MockManager is a mock of Manager interface
 type MockManager struct {
 	ctrl     *gomock.Controller
@@ -108,6 +157,18 @@ func (mr *MockManagerMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockManager)(nil).Create), arg0, arg1)
 }
 
+// DeleteAllUnused mocks base method
+func (m *MockManager) DeleteAllUnused(arg0 ui.Stage) error {
+	ret := m.ctrl.Call(m, "DeleteAllUnused", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAllUnused indicates an expected call of DeleteAllUnused
+func (mr *MockManagerMockRecorder) DeleteAllUnused(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllUnused", reflect.TypeOf((*MockManager)(nil).DeleteAllUnused), arg0)
+}
+
 // DeleteUnused mocks base method
 func (m *MockManager) DeleteUnused(arg0 ui.Stage) error {
 	ret := m.ctrl.Call(m, "DeleteUnused", arg0)
@@ -133,6 +194,19 @@ func (mr *MockManagerMockRecorder) FindCurrent() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCurrent", reflect.TypeOf((*MockManager)(nil).FindCurrent))
 }
 
+// FindAllCurrent mocks base method
+func (m *MockManager) FindAllCurrent() ([]disk.Disk, error) {
+	ret := m.ctrl.Call(m, "FindAllCurrent")
+	ret0, _ := ret[0].([]disk.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllCurrent indicates an expected call of FindAllCurrent
+func (mr *MockManagerMockRecorder) FindAllCurrent() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllCurrent", reflect.TypeOf((*MockManager)(nil).FindAllCurrent))
+}
+
 // FindUnused mocks base method
 func (m *MockManager) FindUnused() ([]disk.Disk, error) {
 	ret := m.ctrl.Call(m, "FindUnused")
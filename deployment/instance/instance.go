@@ -90,13 +90,27 @@ func (i *instance) WaitUntilReady(
 			sshErrCh := make(chan error)
 
 			sshTunnelOptions := bisshtunnel.Options{
-				Host:              registryConfig.SSHTunnel.Host,
-				Port:              registryConfig.SSHTunnel.Port,
-				User:              registryConfig.SSHTunnel.User,
-				Password:          registryConfig.SSHTunnel.Password,
-				PrivateKey:        registryConfig.SSHTunnel.PrivateKey,
-				LocalForwardPort:  registryConfig.Port,
-				RemoteForwardPort: registryConfig.Port,
+				Host:               registryConfig.SSHTunnel.Host,
+				Port:               registryConfig.SSHTunnel.Port,
+				User:               registryConfig.SSHTunnel.User,
+				Password:           registryConfig.SSHTunnel.Password,
+				PrivateKey:         registryConfig.SSHTunnel.PrivateKey,
+				UseAgent:           registryConfig.SSHTunnel.UseAgent,
+				KnownHosts:         registryConfig.SSHTunnel.KnownHosts,
+				HostKeyFingerprint: registryConfig.SSHTunnel.HostKeyFingerprint,
+				LocalForwardPort:   registryConfig.Port,
+				RemoteForwardPort:  registryConfig.Port,
+			}
+
+			for _, jumpHost := range registryConfig.SSHTunnel.JumpHosts {
+				sshTunnelOptions.JumpHosts = append(sshTunnelOptions.JumpHosts, bisshtunnel.JumpHostOptions{
+					Host:               jumpHost.Host,
+					Port:               jumpHost.Port,
+					User:               jumpHost.User,
+					Password:           jumpHost.Password,
+					PrivateKey:         jumpHost.PrivateKey,
+					HostKeyFingerprint: jumpHost.HostKeyFingerprint,
+				})
 			}
 			sshTunnel := i.sshTunnelFactory.NewSSHTunnel(sshTunnelOptions)
 			go sshTunnel.Start(sshReadyErrCh, sshErrCh)
@@ -125,11 +139,22 @@ func (i *instance) UpdateDisks(deploymentManifest bideplmanifest.Manifest, stage
 		return []bidisk.Disk{}, bosherr.WrapError(err, "Getting disk pool")
 	}
 
+	namedDiskPools, err := deploymentManifest.NamedDiskPools(i.jobName)
+	if err != nil {
+		return []bidisk.Disk{}, bosherr.WrapError(err, "Getting named disk pools")
+	}
+
 	disks, err := i.vm.UpdateDisks(diskPool, stage)
 	if err != nil {
 		return disks, bosherr.WrapError(err, "Updating disks")
 	}
 
+	namedDisks, err := i.vm.UpdateNamedDisks(namedDiskPools, stage)
+	disks = append(disks, namedDisks...)
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Updating named disks")
+	}
+
 	return disks, nil
 }
 
@@ -550,6 +550,49 @@ var _ = Describe("Instance", func() {
 				})
 			})
 
+			Context("when jump hosts and host key verification are configured", func() {
+				BeforeEach(func() {
+					registryConfig.SSHTunnel.UseAgent = true
+					registryConfig.SSHTunnel.KnownHosts = "fake-known-hosts"
+					registryConfig.SSHTunnel.HostKeyFingerprint = "SHA256:fake-fingerprint"
+					registryConfig.SSHTunnel.JumpHosts = []biinstallmanifest.SSHJumpHost{
+						{
+							Host:               "fake-bastion-host",
+							Port:               22,
+							User:               "fake-bastion-username",
+							PrivateKey:         "fake-bastion-private-key",
+							HostKeyFingerprint: "SHA256:fake-bastion-fingerprint",
+						},
+					}
+				})
+
+				It("passes them to the SSH tunnel", func() {
+					err := instance.WaitUntilReady(registryConfig, fakeStage)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeSSHTunnelFactory.NewSSHTunnelOptions).To(Equal(bisshtunnel.Options{
+						User:               "fake-ssh-username",
+						PrivateKey:         "--BEGIN PRIVATE KEY-- asdf --END PRIVATE KEY--",
+						Password:           "fake-password",
+						Host:               "fake-ssh-host",
+						Port:               124,
+						UseAgent:           true,
+						KnownHosts:         "fake-known-hosts",
+						HostKeyFingerprint: "SHA256:fake-fingerprint",
+						JumpHosts: []bisshtunnel.JumpHostOptions{
+							{
+								Host:               "fake-bastion-host",
+								Port:               22,
+								User:               "fake-bastion-username",
+								PrivateKey:         "fake-bastion-private-key",
+								HostKeyFingerprint: "SHA256:fake-bastion-fingerprint",
+							},
+						},
+						LocalForwardPort:  125,
+						RemoteForwardPort: 125,
+					}))
+				})
+			})
+
 			Context("when starting SSH tunnel fails", func() {
 				BeforeEach(func() {
 					fakeSSHTunnel.SetStartBehavior(bosherr.Error("fake-ssh-tunnel-start-error"), nil)
@@ -27,6 +27,12 @@ type Manager interface {
 		registryConfig biinstallmanifest.Registry,
 		eventLoggerStage biui.Stage,
 	) (Instance, []bidisk.Disk, error)
+	Update(
+		jobName string,
+		id int,
+		deploymentManifest bideplmanifest.Manifest,
+		eventLoggerStage biui.Stage,
+	) (Instance, []bidisk.Disk, error)
 	DeleteAll(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
@@ -134,6 +140,33 @@ func (m *manager) Create(
 	return instance, disks, err
 }
 
+// Update updates the disks of the instance running on the current VM,
+// which is kept instead of being recreated
+func (m *manager) Update(
+	jobName string,
+	id int,
+	deploymentManifest bideplmanifest.Manifest,
+	eventLoggerStage biui.Stage,
+) (Instance, []bidisk.Disk, error) {
+	vm, found, err := m.vmManager.FindCurrent()
+	if err != nil {
+		return nil, []bidisk.Disk{}, bosherr.WrapErrorf(err, "Finding VM of instance '%s/%d'", jobName, id)
+	}
+
+	if !found {
+		return nil, []bidisk.Disk{}, bosherr.Errorf("Expected VM of instance '%s/%d' to exist", jobName, id)
+	}
+
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
+
+	disks, err := instance.UpdateDisks(deploymentManifest, eventLoggerStage)
+	if err != nil {
+		return instance, disks, bosherr.WrapError(err, "Updating instance disks")
+	}
+
+	return instance, disks, nil
+}
+
 func (m *manager) DeleteAll(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
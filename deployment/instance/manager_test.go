@@ -396,4 +396,73 @@ var _ = Describe("Manager", func() {
 			})
 		})
 	})
+
+	Describe("Update", func() {
+		var (
+			fakeVM             *fakebivm.FakeVM
+			diskPool           bideplmanifest.DiskPool
+			deploymentManifest bideplmanifest.Manifest
+			expectedDisk       *fakebidisk.FakeDisk
+		)
+
+		BeforeEach(func() {
+			diskPool = bideplmanifest.DiskPool{
+				Name:     "fake-persistent-disk-pool-name",
+				DiskSize: 1024,
+			}
+
+			deploymentManifest = bideplmanifest.Manifest{
+				DiskPools: []bideplmanifest.DiskPool{diskPool},
+				Jobs: []bideplmanifest.Job{
+					{
+						Name:               "fake-job-name",
+						PersistentDiskPool: "fake-persistent-disk-pool-name",
+						Instances:          2,
+					},
+				},
+			}
+
+			mockAgentClient := mock_agentclient.NewMockAgentClient(mockCtrl)
+			fakeVM = fakebivm.NewFakeVM("fake-vm-cid")
+			fakeVM.AgentClientReturn = mockAgentClient
+			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, mockAgentClient).Return(mockStateBuilder).AnyTimes()
+
+			expectedDisk = fakebidisk.NewFakeDisk("fake-disk-cid")
+			fakeVM.UpdateDisksDisks = []bidisk.Disk{expectedDisk}
+		})
+
+		Context("when the VM exists", func() {
+			BeforeEach(func() {
+				fakeVMManager.SetFindCurrentBehavior(fakeVM, true, nil)
+			})
+
+			It("updates the disks of the instance on the current VM without creating a VM", func() {
+				instance, disks, err := manager.Update("fake-job-name", 1, deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(instance.JobName()).To(Equal("fake-job-name"))
+				Expect(instance.ID()).To(Equal(1))
+				Expect(disks).To(Equal([]bidisk.Disk{expectedDisk}))
+
+				Expect(fakeVM.UpdateDisksInputs).To(Equal([]fakebivm.UpdateDisksInput{
+					{
+						DiskPool: diskPool,
+						Stage:    fakeStage,
+					},
+				}))
+				Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{}))
+			})
+		})
+
+		Context("when the VM does not exist", func() {
+			BeforeEach(func() {
+				fakeVMManager.SetFindCurrentBehavior(nil, false, nil)
+			})
+
+			It("returns an error", func() {
+				_, _, err := manager.Update("fake-job-name", 1, deploymentManifest, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected VM of instance 'fake-job-name/1' to exist"))
+			})
+		})
+	})
 })
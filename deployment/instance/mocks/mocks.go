@@ -174,6 +174,20 @@ func (mr *MockManagerMockRecorder) DeleteAll(arg0, arg1, arg2, arg3 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockManager)(nil).DeleteAll), arg0, arg1, arg2, arg3)
 }
 
+// Update mocks base method
+func (m *MockManager) Update(arg0 string, arg1 int, arg2 manifest.Manifest, arg3 ui.Stage) (instance.Instance, []disk.Disk, error) {
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(instance.Instance)
+	ret1, _ := ret[1].([]disk.Disk)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Update indicates an expected call of Update
+func (mr *MockManagerMockRecorder) Update(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockManager)(nil).Update), arg0, arg1, arg2, arg3)
+}
+
 // FindCurrent mocks base method
 func (m *MockManager) FindCurrent() ([]instance.Instance, error) {
 	ret := m.ctrl.Call(m, "FindCurrent")
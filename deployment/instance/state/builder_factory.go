@@ -4,6 +4,7 @@ import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
+	biindex "github.com/cloudfoundry/bosh-cli/index"
 	bistatejob "github.com/cloudfoundry/bosh-cli/state/job"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
@@ -16,6 +17,8 @@ type BuilderFactory interface {
 
 type builderFactory struct {
 	packageRepo               bistatepkg.CompiledPackageRepo
+	packageBlobstore          biblobstore.Blobstore
+	blobstorePackageRepos     map[biblobstore.Blobstore]bistatepkg.CompiledPackageRepo
 	releaseJobResolver        bideplrel.JobResolver
 	jobRenderer               bitemplate.JobListRenderer
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor
@@ -31,6 +34,7 @@ func NewBuilderFactory(
 ) BuilderFactory {
 	return &builderFactory{
 		packageRepo:               packageRepo,
+		blobstorePackageRepos:     map[biblobstore.Blobstore]bistatepkg.CompiledPackageRepo{},
 		releaseJobResolver:        releaseJobResolver,
 		jobRenderer:               jobRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
@@ -39,8 +43,14 @@ func NewBuilderFactory(
 }
 
 func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient biagentclient.AgentClient) Builder {
-	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo)
-	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.logger)
+	packageRepo := f.compiledPackageRepo(blobstore)
+	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, packageRepo)
+	if blobstore != f.packageBlobstore {
+		packageCompiler = NewCopyingPackageCompiler(f.packageBlobstore, f.packageRepo, blobstore, packageRepo, packageCompiler)
+	}
+	// the agent reinstalls the dependencies of every package it compiles into the same directory,
+	// so packages are compiled one at a time
+	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, 1, f.logger)
 
 	return NewBuilder(
 		f.releaseJobResolver,
@@ -51,3 +61,21 @@ func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient
 		f.logger,
 	)
 }
+
+// compiledPackageRepo keeps compiled packages separate for every blobstore,
+// so that instances with their own agent blobstore get copies of the packages
+// compiled into the blobstore of the first instance
+func (f *builderFactory) compiledPackageRepo(blobstore biblobstore.Blobstore) bistatepkg.CompiledPackageRepo {
+	if len(f.blobstorePackageRepos) == 0 {
+		f.packageBlobstore = blobstore
+		f.blobstorePackageRepos[blobstore] = f.packageRepo
+	}
+
+	packageRepo, found := f.blobstorePackageRepos[blobstore]
+	if !found {
+		packageRepo = bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex())
+		f.blobstorePackageRepos[blobstore] = packageRepo
+	}
+
+	return packageRepo
+}
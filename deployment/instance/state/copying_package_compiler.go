@@ -0,0 +1,74 @@
+package state
+
+import (
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// copyingPackageCompiler copies packages that were already compiled into another blobstore,
+// verifying them against their digest, and compiles all other packages with the given compiler
+type copyingPackageCompiler struct {
+	sourceBlobstore   biblobstore.Blobstore
+	sourcePackageRepo bistatepkg.CompiledPackageRepo
+	blobstore         biblobstore.Blobstore
+	packageRepo       bistatepkg.CompiledPackageRepo
+	packageCompiler   bistatepkg.Compiler
+}
+
+func NewCopyingPackageCompiler(
+	sourceBlobstore biblobstore.Blobstore,
+	sourcePackageRepo bistatepkg.CompiledPackageRepo,
+	blobstore biblobstore.Blobstore,
+	packageRepo bistatepkg.CompiledPackageRepo,
+	packageCompiler bistatepkg.Compiler,
+) bistatepkg.Compiler {
+	return &copyingPackageCompiler{
+		sourceBlobstore:   sourceBlobstore,
+		sourcePackageRepo: sourcePackageRepo,
+		blobstore:         blobstore,
+		packageRepo:       packageRepo,
+		packageCompiler:   packageCompiler,
+	}
+}
+
+func (c *copyingPackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
+	sourceRecord, found, err := c.sourcePackageRepo.Find(pkg)
+	if err != nil {
+		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Finding compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	}
+
+	if !found {
+		return c.packageCompiler.Compile(pkg)
+	}
+
+	digest, err := boshcrypto.ParseMultipleDigest(sourceRecord.BlobSHA1)
+	if err != nil {
+		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Parsing digest of compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	}
+
+	localBlob, err := c.sourceBlobstore.Get(sourceRecord.BlobID, digest)
+	if err != nil {
+		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Fetching compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	}
+	defer localBlob.DeleteSilently()
+
+	blobID, err := c.blobstore.Add(localBlob.Path())
+	if err != nil {
+		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Adding compiled package '%s/%s' to blobstore", pkg.Name(), pkg.Fingerprint())
+	}
+
+	record := bistatepkg.CompiledPackageRecord{
+		BlobID:   blobID,
+		BlobSHA1: sourceRecord.BlobSHA1,
+	}
+
+	err = c.packageRepo.Save(pkg, record)
+	if err != nil {
+		return record, true, bosherr.WrapErrorf(err, "Saving compiled package record '%#v' of package '%#v'", record, pkg)
+	}
+
+	return record, true, nil
+}
@@ -0,0 +1,129 @@
+package state_test
+
+import (
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
+	. "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	mock_state_package "github.com/cloudfoundry/bosh-cli/state/pkg/mocks"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("CopyingPackageCompiler", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	var (
+		fs                  *fakesys.FakeFileSystem
+		mockSourceBlobstore *mock_blobstore.MockBlobstore
+		mockBlobstore       *mock_blobstore.MockBlobstore
+		mockCompiler        *mock_state_package.MockCompiler
+		sourcePackageRepo   bistatepkg.CompiledPackageRepo
+		packageRepo         bistatepkg.CompiledPackageRepo
+
+		pkg *boshpkg.Package
+
+		compiler bistatepkg.Compiler
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		mockSourceBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
+		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
+		mockCompiler = mock_state_package.NewMockCompiler(mockCtrl)
+		sourcePackageRepo = bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex())
+		packageRepo = bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex())
+
+		pkg = boshpkg.NewPackage(NewResourceWithBuiltArchive(
+			"fake-package-name", "fake-package-fingerprint", "fake-archive-path", "fake-source-package-sha1"), nil)
+
+		compiler = NewCopyingPackageCompiler(mockSourceBlobstore, sourcePackageRepo, mockBlobstore, packageRepo, mockCompiler)
+	})
+
+	Context("when the package was compiled into the source blobstore", func() {
+		var localBlob biblobstore.LocalBlob
+
+		BeforeEach(func() {
+			err := sourcePackageRepo.Save(pkg, bistatepkg.CompiledPackageRecord{
+				BlobID:   "fake-source-blob-id",
+				BlobSHA1: "sha1:07e1306432667f916639d47481edc4f2ca456454",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("fake-local-blob-path", "fake-compiled-package")
+			Expect(err).ToNot(HaveOccurred())
+			localBlob = biblobstore.NewLocalBlob("fake-local-blob-path", fs, boshlog.NewLogger(boshlog.LevelNone))
+		})
+
+		It("copies the package after verifying its digest instead of compiling it", func() {
+			digest := boshcrypto.MustParseMultipleDigest("sha1:07e1306432667f916639d47481edc4f2ca456454")
+			gomock.InOrder(
+				mockSourceBlobstore.EXPECT().Get("fake-source-blob-id", digest).Return(localBlob, nil),
+				mockBlobstore.EXPECT().Add("fake-local-blob-path").Return("fake-blob-id", nil),
+			)
+
+			record, isAlreadyCompiled, err := compiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isAlreadyCompiled).To(BeTrue())
+			Expect(record).To(Equal(bistatepkg.CompiledPackageRecord{
+				BlobID:   "fake-blob-id",
+				BlobSHA1: "sha1:07e1306432667f916639d47481edc4f2ca456454",
+			}))
+
+			savedRecord, found, err := packageRepo.Find(pkg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(savedRecord).To(Equal(record))
+
+			Expect(fs.FileExists("fake-local-blob-path")).To(BeFalse())
+		})
+
+		It("returns an error when fetching the package fails", func() {
+			mockSourceBlobstore.EXPECT().Get("fake-source-blob-id", gomock.Any()).Return(nil, errors.New("fake-digest-mismatch"))
+
+			_, _, err := compiler.Compile(pkg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Fetching compiled package 'fake-package-name/fake-package-fingerprint'"))
+			Expect(err.Error()).To(ContainSubstring("fake-digest-mismatch"))
+		})
+
+		It("returns an error when adding the package fails", func() {
+			mockSourceBlobstore.EXPECT().Get("fake-source-blob-id", gomock.Any()).Return(localBlob, nil)
+			mockBlobstore.EXPECT().Add("fake-local-blob-path").Return("", errors.New("fake-add-error"))
+
+			_, _, err := compiler.Compile(pkg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-add-error"))
+		})
+	})
+
+	Context("when the package was not compiled into the source blobstore", func() {
+		It("compiles the package", func() {
+			record := bistatepkg.CompiledPackageRecord{BlobID: "fake-blob-id", BlobSHA1: "fake-sha1"}
+			mockCompiler.EXPECT().Compile(pkg).Return(record, false, nil)
+
+			compiledRecord, isAlreadyCompiled, err := compiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isAlreadyCompiled).To(BeFalse())
+			Expect(compiledRecord).To(Equal(record))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package deployment
+
+import (
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// InstanceClientFactory creates clients for instances other than the first instance of the first job,
+// whose agent is not reachable through the installation mbus URL but through its own address.
+type InstanceClientFactory interface {
+	NewAgentClient(address string) (biagentclient.AgentClient, error)
+	NewBlobstore(address string) (biblobstore.Blobstore, error)
+}
+
+func newAdditionalInstanceManager(
+	cloud bicloud.Cloud,
+	instanceClientFactory InstanceClientFactory,
+	instanceVMManagerFactory bivm.InstanceManagerFactory,
+	instanceManagerFactory biinstance.ManagerFactory,
+	jobName string,
+	instanceID int,
+	address string,
+) (biinstance.Manager, error) {
+	agentClient, err := instanceClientFactory.NewAgentClient(address)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating agent client for instance '%s/%d'", jobName, instanceID)
+	}
+
+	blobstore, err := instanceClientFactory.NewBlobstore(address)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating blobstore client for instance '%s/%d'", jobName, instanceID)
+	}
+
+	vmManager := instanceVMManagerFactory.NewManager(cloud, agentClient, jobName, instanceID, address)
+
+	return instanceManagerFactory.NewManager(cloud, vmManager, blobstore), nil
+}
@@ -18,6 +18,8 @@ type manager struct {
 	diskManager       bidisk.Manager
 	stemcellManager   bistemcell.Manager
 	deploymentFactory Factory
+
+	additionalInstanceManagers func() ([]biinstance.Manager, error)
 }
 
 func NewManager(
@@ -31,6 +33,8 @@ func NewManager(
 		diskManager:       diskManager,
 		stemcellManager:   stemcellManager,
 		deploymentFactory: deploymentFactory,
+
+		additionalInstanceManagers: func() ([]biinstance.Manager, error) { return nil, nil },
 	}
 }
 
@@ -40,7 +44,20 @@ func (m *manager) FindCurrent() (deployment Deployment, found bool, err error) {
 		return nil, false, bosherr.WrapError(err, "Finding current deployment instances")
 	}
 
-	disks, err := m.diskManager.FindCurrent()
+	additionalInstanceManagers, err := m.additionalInstanceManagers()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding additional deployment instances")
+	}
+
+	for _, instanceManager := range additionalInstanceManagers {
+		additionalInstances, err := instanceManager.FindCurrent()
+		if err != nil {
+			return nil, false, bosherr.WrapError(err, "Finding current deployment instances")
+		}
+		instances = append(instances, additionalInstances...)
+	}
+
+	disks, err := m.diskManager.FindAllCurrent()
 	if err != nil {
 		return nil, false, bosherr.WrapError(err, "Finding current deployment disks")
 	}
@@ -57,8 +74,9 @@ func (m *manager) FindCurrent() (deployment Deployment, found bool, err error) {
 	return m.deploymentFactory.NewDeployment(instances, disks, stemcells), true, nil
 }
 
+// Cleanup deletes orphaned disks regardless of their retention, as it runs when the environment is deleted
 func (m *manager) Cleanup(stage biui.Stage) error {
-	if err := m.diskManager.DeleteUnused(stage); err != nil {
+	if err := m.diskManager.DeleteAllUnused(stage); err != nil {
 		return err
 	}
 
@@ -4,6 +4,7 @@ import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
@@ -11,38 +12,75 @@ import (
 )
 
 type ManagerFactory interface {
-	NewManager(bicloud.Cloud, biagentclient.AgentClient, biblobstore.Blobstore) Manager
+	NewManager(bicloud.Cloud, biagentclient.AgentClient, biblobstore.Blobstore, InstanceClientFactory) Manager
 }
 
 type managerFactory struct {
-	vmManagerFactory       bivm.ManagerFactory
-	instanceManagerFactory biinstance.ManagerFactory
-	diskManagerFactory     bidisk.ManagerFactory
-	stemcellManagerFactory bistemcell.ManagerFactory
-	deploymentFactory      Factory
+	vmManagerFactory         bivm.ManagerFactory
+	instanceVMManagerFactory bivm.InstanceManagerFactory
+	instanceManagerFactory   biinstance.ManagerFactory
+	instanceRepo             biconfig.InstanceRepo
+	diskManagerFactory       bidisk.ManagerFactory
+	stemcellManagerFactory   bistemcell.ManagerFactory
+	deploymentFactory        Factory
 }
 
 func NewManagerFactory(
 	vmManagerFactory bivm.ManagerFactory,
+	instanceVMManagerFactory bivm.InstanceManagerFactory,
 	instanceManagerFactory biinstance.ManagerFactory,
+	instanceRepo biconfig.InstanceRepo,
 	diskManagerFactory bidisk.ManagerFactory,
 	stemcellManagerFactory bistemcell.ManagerFactory,
 	deploymentFactory Factory,
 ) ManagerFactory {
 	return &managerFactory{
-		vmManagerFactory:       vmManagerFactory,
-		instanceManagerFactory: instanceManagerFactory,
-		diskManagerFactory:     diskManagerFactory,
-		stemcellManagerFactory: stemcellManagerFactory,
-		deploymentFactory:      deploymentFactory,
+		vmManagerFactory:         vmManagerFactory,
+		instanceVMManagerFactory: instanceVMManagerFactory,
+		instanceManagerFactory:   instanceManagerFactory,
+		instanceRepo:             instanceRepo,
+		diskManagerFactory:       diskManagerFactory,
+		stemcellManagerFactory:   stemcellManagerFactory,
+		deploymentFactory:        deploymentFactory,
 	}
 }
 
-func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, blobstore biblobstore.Blobstore) Manager {
+func (f *managerFactory) NewManager(
+	cloud bicloud.Cloud,
+	agentClient biagentclient.AgentClient,
+	blobstore biblobstore.Blobstore,
+	instanceClientFactory InstanceClientFactory,
+) Manager {
 	vmManager := f.vmManagerFactory.NewManager(cloud, agentClient)
 	instanceManager := f.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
 	diskManager := f.diskManagerFactory.NewManager(cloud)
 	stemcellManager := f.stemcellManagerFactory.NewManager(cloud)
 
-	return NewManager(instanceManager, diskManager, stemcellManager, f.deploymentFactory)
+	m := NewManager(instanceManager, diskManager, stemcellManager, f.deploymentFactory).(*manager)
+
+	m.additionalInstanceManagers = func() ([]biinstance.Manager, error) {
+		instanceRecords, err := f.instanceRepo.All()
+		if err != nil {
+			return nil, err
+		}
+
+		instanceManagers := []biinstance.Manager{}
+		for _, instanceRecord := range instanceRecords {
+			if instanceRecord.VMCID == "" {
+				continue
+			}
+
+			instanceManager, err := newAdditionalInstanceManager(
+				cloud, instanceClientFactory, f.instanceVMManagerFactory, f.instanceManagerFactory,
+				instanceRecord.Name, instanceRecord.Index, instanceRecord.Address)
+			if err != nil {
+				return nil, err
+			}
+			instanceManagers = append(instanceManagers, instanceManager)
+		}
+
+		return instanceManagers, nil
+	}
+
+	return m
 }
@@ -15,6 +15,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"code.cloudfoundry.org/clock"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
@@ -72,7 +73,7 @@ var _ = Describe("Manager", func() {
 
 		JustBeforeEach(func() {
 			mockInstanceManager.EXPECT().FindCurrent().Return(expectedInstances, nil)
-			mockDiskManager.EXPECT().FindCurrent().Return(expectedDisks, nil)
+			mockDiskManager.EXPECT().FindAllCurrent().Return(expectedDisks, nil)
 			mockStemcellManager.EXPECT().FindCurrent().Return(expectedStemcells, nil)
 
 			expectNewDeployment = mockDeploymentFactory.EXPECT().NewDeployment(expectedInstances, expectedDisks, expectedStemcells).Return(mockDeployment).AnyTimes()
@@ -185,10 +186,13 @@ var _ = Describe("Manager", func() {
 		})
 
 		JustBeforeEach(func() {
-			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
-			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
+			snapshotRepo := biconfig.NewSnapshotRepo(deploymentStateService, fakeRepoUUIDGenerator)
+			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, snapshotRepo, clock.NewClock(), logger)
+			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, snapshotRepo, clock.NewClock(), logger, bivm.DiskDeployerOptions{})
 
-			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			instanceVMManagerFactory := bivm.NewInstanceManagerFactory(deploymentStateService, stemcellRepo, fakeUUIDGenerator, fs, logger, bivm.DiskDeployerOptions{})
+			instanceRepo := biconfig.NewInstanceRepo(deploymentStateService)
 			sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
@@ -199,8 +203,8 @@ var _ = Describe("Manager", func() {
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
-			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, mockDeploymentFactory)
-			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceVMManagerFactory, instanceManagerFactory, instanceRepo, diskManagerFactory, stemcellManagerFactory, mockDeploymentFactory)
+			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore, mock_deployment.NewMockInstanceClientFactory(mockCtrl))
 		})
 
 		Context("no orphan disk or stemcell records exist", func() {
@@ -0,0 +1,280 @@
+package manifest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+	"gopkg.in/yaml.v2"
+)
+
+const redactedValue = "<redacted>"
+
+// Values nested under these keys may contain credentials
+var redactedKeys = map[string]bool{
+	"properties":  true,
+	"env":         true,
+	"mbus":        true,
+	"cert":        true,
+	"ssh_tunnel":  true,
+	"registry":    true,
+	"password":    true,
+	"private_key": true,
+}
+
+var redactedDigestPattern = regexp.MustCompile(`<redacted:[0-9a-f]{64}>`)
+
+// RedactManifest replaces every value nested under a sensitive key with a digest
+// of the value keyed with key, keeping the structure of the manifest intact,
+// so that changes of sensitive values are detected without storing them.
+func RedactManifest(manifestBytes []byte, key []byte) ([]byte, error) {
+	manifest := yaml.MapSlice{}
+
+	err := yaml.Unmarshal(manifestBytes, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling manifest")
+	}
+
+	r := redactor{key: key, values: map[string]interface{}{}}
+
+	bytes, err := yaml.Marshal(r.redact(manifest, false))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling redacted manifest")
+	}
+
+	return bytes, nil
+}
+
+// DiffManifests compares a manifest stored with RedactManifest with the current one
+// and returns lines in the same format as the diffs returned by the director.
+// Sensitive values are shown as redacted unless redactCurrent is false,
+// in which case added lines show the current values as they are.
+// A nil key means that the key of the previous manifest is not known,
+// changes of sensitive values cannot be detected then.
+func DiffManifests(previousManifestBytes, currentManifestBytes []byte, key []byte, redactCurrent bool) ([][]interface{}, error) {
+	if key == nil {
+		previousManifestBytes = redactedDigestPattern.ReplaceAll(previousManifestBytes, []byte(redactedValue))
+	}
+
+	var previousManifest interface{}
+
+	err := yaml.Unmarshal(previousManifestBytes, &previousManifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling previous manifest")
+	}
+
+	var currentManifest interface{}
+
+	err = yaml.Unmarshal(currentManifestBytes, &currentManifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling current manifest")
+	}
+
+	r := redactor{key: key, values: map[string]interface{}{}}
+	currentManifest = r.redact(currentManifest, false)
+
+	d := differ{previous: previousManifest, current: currentManifest}
+
+	ops := patch.Diff{Left: previousManifest, Right: currentManifest}.Calculate()
+
+	// every change is described by a test op checking the previous value followed by the change
+	for i := 0; i+1 < len(ops); i += 2 {
+		testOp, ok := ops[i].(patch.TestOp)
+		if !ok {
+			return nil, bosherr.Errorf("Expected test op but found '%T'", ops[i])
+		}
+
+		tokens := testOp.Path.Tokens()
+
+		switch op := ops[i+1].(type) {
+		case patch.RemoveOp:
+			err = d.addLines(tokens, testOp.Value, "removed")
+		case patch.ReplaceOp:
+			if !testOp.Absent {
+				err = d.addLines(tokens, testOp.Value, "removed")
+			}
+			if err == nil {
+				err = d.addLines(tokens, op.Value, "added")
+			}
+		default:
+			err = bosherr.Errorf("Unexpected diff op '%T'", op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, line := range d.result {
+		line[0] = redactedDigestPattern.ReplaceAllStringFunc(line[0].(string), func(digest string) string {
+			if line[1] == "added" && !redactCurrent {
+				return r.display(digest)
+			}
+			return redactedValue
+		})
+	}
+
+	return d.result, nil
+}
+
+// differ renders changes as lines, each preceded by the keys enclosing it
+// in its manifest unless they were already shown for the line before
+type differ struct {
+	previous interface{}
+	current  interface{}
+
+	shown  []string
+	result [][]interface{}
+}
+
+func (d *differ) addLines(tokens []patch.Token, value interface{}, state string) error {
+	node := d.current
+	if state == "removed" {
+		node = d.previous
+	}
+
+	if len(tokens) < 2 {
+		return bosherr.Error("Comparing manifests that are not maps")
+	}
+
+	var parents []string
+	indent := ""
+
+	// the first token is the root and the last one is the changed value
+	for _, token := range tokens[1 : len(tokens)-1] {
+		switch typedToken := token.(type) {
+		case patch.KeyToken:
+			parents = append(parents, indent+typedToken.Key+":")
+			node = d.child(node, typedToken.Key)
+			if _, isMap := node.(map[interface{}]interface{}); isMap {
+				indent += "  "
+			}
+
+		case patch.IndexToken:
+			items, _ := node.([]interface{})
+			if typedToken.Index >= len(items) {
+				return bosherr.Errorf("Finding item %d of manifest list", typedToken.Index)
+			}
+			node = items[typedToken.Index]
+			parents = append(parents, indent+"- "+d.label(node))
+			indent += "  "
+
+		default:
+			return bosherr.Errorf("Unexpected manifest path token '%T'", token)
+		}
+	}
+
+	var changed interface{}
+	if keyToken, isKey := tokens[len(tokens)-1].(patch.KeyToken); isKey {
+		changed = yaml.MapSlice{{Key: keyToken.Key, Value: value}}
+	} else {
+		changed = []interface{}{value}
+	}
+
+	bytes, err := yaml.Marshal(changed)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling manifest")
+	}
+
+	common := 0
+	for common < len(d.shown) && common < len(parents) && d.shown[common] == parents[common] {
+		common++
+	}
+
+	for _, parent := range parents[common:] {
+		d.result = append(d.result, []interface{}{parent, ""})
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(bytes), "\n"), "\n") {
+		d.result = append(d.result, []interface{}{indent + line, state})
+	}
+
+	d.shown = parents
+
+	return nil
+}
+
+func (d *differ) child(node interface{}, key string) interface{} {
+	typedNode, _ := node.(map[interface{}]interface{})
+	for k, v := range typedNode {
+		if fmt.Sprintf("%s", k) == key {
+			return v
+		}
+	}
+	return nil
+}
+
+// label identifies list items by their name like the director does
+func (d *differ) label(item interface{}) string {
+	if name := d.child(item, "name"); name != nil {
+		return fmt.Sprintf("name: %v", name)
+	}
+	return ""
+}
+
+type redactor struct {
+	key    []byte
+	values map[string]interface{}
+}
+
+func (r redactor) redact(node interface{}, sensitive bool) interface{} {
+	switch typedNode := node.(type) {
+	case yaml.MapSlice:
+		result := yaml.MapSlice{}
+		for _, item := range typedNode {
+			key, _ := item.Key.(string)
+			result = append(result, yaml.MapItem{
+				Key:   item.Key,
+				Value: r.redact(item.Value, sensitive || redactedKeys[key]),
+			})
+		}
+		return result
+
+	case map[interface{}]interface{}:
+		result := map[interface{}]interface{}{}
+		for itemKey, itemValue := range typedNode {
+			key, _ := itemKey.(string)
+			result[itemKey] = r.redact(itemValue, sensitive || redactedKeys[key])
+		}
+		return result
+
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range typedNode {
+			result = append(result, r.redact(item, sensitive))
+		}
+		return result
+
+	default:
+		if sensitive && node != nil {
+			if r.key == nil {
+				return redactedValue
+			}
+
+			mac := hmac.New(sha256.New, r.key)
+			fmt.Fprintf(mac, "%T\x00%v", node, node)
+
+			digest := fmt.Sprintf("<redacted:%x>", mac.Sum(nil))
+			r.values[digest] = node
+			return digest
+		}
+		return node
+	}
+}
+
+func (r redactor) display(digest string) string {
+	value, found := r.values[digest]
+	if !found {
+		return redactedValue
+	}
+
+	if str, ok := value.(string); ok && strings.Contains(str, "\n") {
+		return strconv.Quote(str)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
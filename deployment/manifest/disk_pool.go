@@ -9,3 +9,9 @@ type DiskPool struct {
 	DiskSize        int
 	CloudProperties biproperty.Map
 }
+
+// NamedDiskPool is the disk pool of a named persistent disk
+type NamedDiskPool struct {
+	DiskName string
+	DiskPool DiskPool
+}
@@ -12,10 +12,19 @@ type Job struct {
 	Networks           []JobNetwork
 	PersistentDisk     int
 	PersistentDiskPool string
+	PersistentDisks    []PersistentDisk
 	ResourcePool       string
 	Properties         biproperty.Map
 }
 
+// PersistentDisk is a named persistent disk of a job. Unlike the disk configured
+// with persistent_disk or persistent_disk_pool it is attached but not mounted by the agent.
+type PersistentDisk struct {
+	Name     string
+	DiskPool string
+	DiskSize int
+}
+
 type JobLifecycle string
 
 const (
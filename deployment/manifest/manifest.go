@@ -16,6 +16,18 @@ type Manifest struct {
 	Tags          map[string]string
 }
 
+// WithAgentBlobstore returns a copy of the manifest whose resource pools configure the agent
+// to use the given blobstore, see ResourcePool.WithAgentBlobstore
+func (d Manifest) WithAgentBlobstore(provider string, options map[string]interface{}) Manifest {
+	resourcePools := make([]ResourcePool, len(d.ResourcePools))
+	for i, resourcePool := range d.ResourcePools {
+		resourcePools[i] = resourcePool.WithAgentBlobstore(provider, options)
+	}
+
+	d.ResourcePools = resourcePools
+	return d
+}
+
 type Update struct {
 	UpdateWatchTime WatchTime
 }
@@ -24,6 +36,12 @@ type Update struct {
 // We can't use map[string]NetworkInterface, because it's impossible to down-cast to what the cloud client requires.
 //TODO: refactor to NetworkInterfaces(Job) and use FindJobByName before using (then remove error)
 func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map, error) {
+	return d.InstanceNetworkInterfaces(jobName, 0)
+}
+
+// InstanceNetworkInterfaces returns the network interfaces of the instance with the given index,
+// which uses the static IP with the same index on every network.
+func (d Manifest) InstanceNetworkInterfaces(jobName string, index int) (map[string]biproperty.Map, error) {
 	job, found := d.FindJobByName(jobName)
 	if !found {
 		return map[string]biproperty.Map{}, bosherr.Errorf("Could not find job with name: %s", jobName)
@@ -35,7 +53,11 @@ func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map,
 	var err error
 	for _, jobNetwork := range job.Networks {
 		network := networkMap[jobNetwork.Name]
-		ifaceMap[jobNetwork.Name], err = network.Interface(jobNetwork.StaticIPs, jobNetwork.Defaults)
+		var staticIPs []string
+		if index < len(jobNetwork.StaticIPs) {
+			staticIPs = jobNetwork.StaticIPs[index:]
+		}
+		ifaceMap[jobNetwork.Name], err = network.Interface(staticIPs, jobNetwork.Defaults)
 		if err != nil {
 			return map[string]biproperty.Map{}, bosherr.WrapError(err, "Building network interface")
 		}
@@ -47,11 +69,28 @@ func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map,
 	return ifaceMap, nil
 }
 
+// JobName returns the name of the first job. Its first instance runs the agent
+// reachable through the installation mbus URL.
 func (d Manifest) JobName() string {
-	// Currently we deploy only one job
 	return d.Jobs[0].Name
 }
 
+// InstanceAddress returns the static IP of the instance with the given index on the first job network that has one
+func (d Manifest) InstanceAddress(jobName string, index int) (string, bool) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return "", false
+	}
+
+	for _, jobNetwork := range job.Networks {
+		if index < len(jobNetwork.StaticIPs) {
+			return jobNetwork.StaticIPs[index], true
+		}
+	}
+
+	return "", false
+}
+
 func (d Manifest) Stemcell(jobName string) (StemcellRef, error) {
 	resourcePool, err := d.ResourcePool(jobName)
 	if err != nil {
@@ -102,6 +141,42 @@ func (d Manifest) DiskPool(jobName string) (DiskPool, error) {
 	return DiskPool{}, nil
 }
 
+// NamedDiskPools returns the disk pools of the named persistent disks of the job
+func (d Manifest) NamedDiskPools(jobName string) ([]NamedDiskPool, error) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return []NamedDiskPool{}, bosherr.Errorf("Could not find job with name: %s", jobName)
+	}
+
+	namedDiskPools := []NamedDiskPool{}
+
+	for _, persistentDisk := range job.PersistentDisks {
+		diskPool := DiskPool{
+			DiskSize:        persistentDisk.DiskSize,
+			CloudProperties: biproperty.Map{},
+		}
+
+		if persistentDisk.DiskPool != "" {
+			found := false
+			for _, pool := range d.DiskPools {
+				if pool.Name == persistentDisk.DiskPool {
+					diskPool = pool
+					found = true
+					break
+				}
+			}
+			if !found {
+				err := bosherr.Errorf("Could not find disk pool '%s' for persistent disk '%s' of job '%s'", persistentDisk.DiskPool, persistentDisk.Name, jobName)
+				return []NamedDiskPool{}, err
+			}
+		}
+
+		namedDiskPools = append(namedDiskPools, NamedDiskPool{DiskName: persistentDisk.Name, DiskPool: diskPool})
+	}
+
+	return namedDiskPools, nil
+}
+
 func (d Manifest) networkMap() map[string]Network {
 	result := map[string]Network{}
 	for _, network := range d.Networks {
@@ -186,6 +186,131 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("InstanceNetworkInterfaces", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Networks: []Network{
+					{
+						Name:            "fake-network-name",
+						Type:            "dynamic",
+						CloudProperties: biproperty.Map{},
+					},
+				},
+				Jobs: []Job{
+					{
+						Name:      "fake-job-name",
+						Instances: 2,
+						Networks: []JobNetwork{
+							{
+								Name:      "fake-network-name",
+								StaticIPs: []string{"5.6.7.8", "5.6.7.9"},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("uses the static IP with the index of the instance", func() {
+			Expect(deploymentManifest.InstanceNetworkInterfaces("fake-job-name", 1)).To(Equal(map[string]biproperty.Map{
+				"fake-network-name": biproperty.Map{
+					"type":             "dynamic",
+					"ip":               "5.6.7.9",
+					"cloud_properties": biproperty.Map{},
+					"default":          []NetworkDefault{"dns", "gateway"},
+				},
+			}))
+		})
+	})
+
+	Describe("NamedDiskPools", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				DiskPools: []DiskPool{
+					{
+						Name:            "fake-disk-pool-name",
+						DiskSize:        1024,
+						CloudProperties: biproperty.Map{"fake-key": "fake-value"},
+					},
+				},
+				Jobs: []Job{
+					{
+						Name: "fake-job-name",
+						PersistentDisks: []PersistentDisk{
+							{Name: "blobstore", DiskPool: "fake-disk-pool-name"},
+							{Name: "database", DiskSize: 2048},
+						},
+					},
+					{
+						Name: "other-job-name",
+						PersistentDisks: []PersistentDisk{
+							{Name: "blobstore", DiskPool: "non-existent-disk-pool"},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns the disk pool of every named disk in order", func() {
+			Expect(deploymentManifest.NamedDiskPools("fake-job-name")).To(Equal([]NamedDiskPool{
+				{
+					DiskName: "blobstore",
+					DiskPool: DiskPool{
+						Name:            "fake-disk-pool-name",
+						DiskSize:        1024,
+						CloudProperties: biproperty.Map{"fake-key": "fake-value"},
+					},
+				},
+				{
+					DiskName: "database",
+					DiskPool: DiskPool{
+						DiskSize:        2048,
+						CloudProperties: biproperty.Map{},
+					},
+				},
+			}))
+		})
+
+		It("returns an error when the disk pool does not exist", func() {
+			_, err := deploymentManifest.NamedDiskPools("other-job-name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Could not find disk pool 'non-existent-disk-pool' for persistent disk 'blobstore' of job 'other-job-name'"))
+		})
+	})
+
+	Describe("InstanceAddress", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Jobs: []Job{
+					{
+						Name:      "fake-job-name",
+						Instances: 2,
+						Networks: []JobNetwork{
+							{Name: "fake-network-name"},
+							{Name: "other-network-name", StaticIPs: []string{"10.0.0.1", "10.0.0.2"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns the static IP of the instance on the first network that has one", func() {
+			address, found := deploymentManifest.InstanceAddress("fake-job-name", 1)
+			Expect(found).To(BeTrue())
+			Expect(address).To(Equal("10.0.0.2"))
+		})
+
+		It("returns false when the instance does not have a static IP", func() {
+			_, found := deploymentManifest.InstanceAddress("fake-job-name", 2)
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns false when the job does not exist", func() {
+			_, found := deploymentManifest.InstanceAddress("non-existent-job", 0)
+			Expect(found).To(BeFalse())
+		})
+	})
+
 	Describe("ResourcePool", func() {
 		BeforeEach(func() {
 			deploymentManifest = Manifest{
@@ -228,6 +353,69 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("WithAgentBlobstore", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				ResourcePools: []ResourcePool{
+					{
+						Name: "fake-resource-pool-name-1",
+						Env: biproperty.Map{
+							"bosh": biproperty.Map{
+								"password": "fake-password",
+							},
+						},
+					},
+					{
+						Name: "fake-resource-pool-name-2",
+					},
+				},
+			}
+		})
+
+		It("adds the blobstore to the bosh env of every resource pool", func() {
+			options := map[string]interface{}{"bucket_name": "fake-bucket-name"}
+
+			manifest := deploymentManifest.WithAgentBlobstore("s3", options)
+
+			blobstores := []interface{}{
+				biproperty.Map{
+					"provider": "s3",
+					"options":  biproperty.Map{"bucket_name": "fake-bucket-name"},
+				},
+			}
+			Expect(manifest.ResourcePools).To(Equal([]ResourcePool{
+				{
+					Name: "fake-resource-pool-name-1",
+					Env: biproperty.Map{
+						"bosh": biproperty.Map{
+							"password":   "fake-password",
+							"blobstores": blobstores,
+						},
+					},
+				},
+				{
+					Name: "fake-resource-pool-name-2",
+					Env: biproperty.Map{
+						"bosh": biproperty.Map{
+							"blobstores": blobstores,
+						},
+					},
+				},
+			}))
+		})
+
+		It("does not modify the original manifest", func() {
+			deploymentManifest.WithAgentBlobstore("s3", map[string]interface{}{})
+
+			Expect(deploymentManifest.ResourcePools[0].Env).To(Equal(biproperty.Map{
+				"bosh": biproperty.Map{
+					"password": "fake-password",
+				},
+			}))
+			Expect(deploymentManifest.ResourcePools[1].Env).To(BeNil())
+		})
+	})
+
 	Describe("DiskPool", func() {
 		Context("when the deployment has disk_pools", func() {
 			BeforeEach(func() {
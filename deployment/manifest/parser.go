@@ -75,12 +75,19 @@ type job struct {
 	Templates          []releaseJobRef
 	Jobs               []releaseJobRef `yaml:"jobs"`
 	Networks           []jobNetwork
-	PersistentDisk     int    `yaml:"persistent_disk"`
-	PersistentDiskPool string `yaml:"persistent_disk_pool"`
-	ResourcePool       string `yaml:"resource_pool"`
+	PersistentDisk     int              `yaml:"persistent_disk"`
+	PersistentDiskPool string           `yaml:"persistent_disk_pool"`
+	PersistentDisks    []persistentDisk `yaml:"persistent_disks"`
+	ResourcePool       string           `yaml:"resource_pool"`
 	Properties         map[interface{}]interface{}
 }
 
+type persistentDisk struct {
+	Name     string `yaml:"name"`
+	DiskPool string `yaml:"disk_pool"`
+	DiskSize int    `yaml:"disk_size"`
+}
+
 type releaseJobRef struct {
 	Name    string
 	Release string
@@ -208,6 +215,14 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 			ResourcePool:       rawJob.ResourcePool,
 		}
 
+		for _, rawPersistentDisk := range rawJob.PersistentDisks {
+			job.PersistentDisks = append(job.PersistentDisks, PersistentDisk{
+				Name:     rawPersistentDisk.Name,
+				DiskPool: rawPersistentDisk.DiskPool,
+				DiskSize: rawPersistentDisk.DiskSize,
+			})
+		}
+
 		if len(rawJob.Templates) > 0 && len(rawJob.Jobs) > 0 {
 			return jobs, bosherr.Error("Deployment specifies both templates and jobs keys for instance_group " + job.Name + ", only one is allowed")
 		}
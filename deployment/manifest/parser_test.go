@@ -469,6 +469,31 @@ instance_groups:
 				Expect(deploymentManifest.Jobs[0].Name).To(Equal("jobby"))
 			})
 		})
+		Context("when a job has named persistent disks", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  persistent_disks:
+  - name: blobstore
+    disk_pool: fake-disk-pool-name
+  - name: database
+    disk_size: 2048
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses the persistent disks", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentManifest.Jobs[0].PersistentDisks).To(Equal([]PersistentDisk{
+					{Name: "blobstore", DiskPool: "fake-disk-pool-name"},
+					{Name: "database", DiskSize: 2048},
+				}))
+			})
+		})
+
 		Context("when jobs is defined inside an instance_group, treats it as templates", func() {
 			BeforeEach(func() {
 				contents := `
@@ -12,6 +12,53 @@ type ResourcePool struct {
 	Stemcell        StemcellRef
 }
 
+// WithAgentBlobstore returns a copy of the resource pool whose env configures the agent
+// to use the given blobstore instead of its embedded DAV blobstore
+func (r ResourcePool) WithAgentBlobstore(provider string, options map[string]interface{}) ResourcePool {
+	env := biproperty.Map{}
+	for key, value := range r.Env {
+		env[key] = value
+	}
+
+	boshEnv := biproperty.Map{}
+	if existingBoshEnv, ok := env["bosh"].(biproperty.Map); ok {
+		for key, value := range existingBoshEnv {
+			boshEnv[key] = value
+		}
+	}
+
+	blobstoreOptions := biproperty.Map{}
+	for key, value := range options {
+		blobstoreOptions[key] = value
+	}
+
+	boshEnv["blobstores"] = []interface{}{
+		biproperty.Map{
+			"provider": provider,
+			"options":  blobstoreOptions,
+		},
+	}
+	env["bosh"] = boshEnv
+
+	r.Env = env
+	return r
+}
+
+// MbusCertificate returns the certificate the agent serves its mbus with when it is set in env.bosh.mbus.cert
+func (r ResourcePool) MbusCertificate() (string, bool) {
+	value := biproperty.Property(r.Env)
+	for _, key := range []string{"bosh", "mbus", "cert", "certificate"} {
+		propertyMap, ok := value.(biproperty.Map)
+		if !ok {
+			return "", false
+		}
+		value = propertyMap[key]
+	}
+
+	certificate, ok := value.(string)
+	return certificate, ok && certificate != ""
+}
+
 type StemcellRef struct {
 	URL  string
 	SHA1 string
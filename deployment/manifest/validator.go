@@ -1,6 +1,8 @@
 package manifest
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"net"
 	"regexp"
 	"strings"
@@ -70,14 +72,15 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 	}
 
-	if len(deploymentManifest.Jobs) > 1 {
-		errs = append(errs, bosherr.Error("jobs must be of size 1"))
-	}
+	jobNames := map[string]struct{}{}
 
 	for idx, job := range deploymentManifest.Jobs {
 		if v.isBlank(job.Name) {
 			errs = append(errs, bosherr.Errorf("jobs[%d].name must be provided", idx))
+		} else if _, found := jobNames[job.Name]; found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].name '%s' must be unique", idx, job.Name))
 		}
+		jobNames[job.Name] = struct{}{}
 		if job.PersistentDisk < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk must be >= 0", idx))
 		}
@@ -86,6 +89,7 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk_pool must be the name of a disk pool", idx))
 			}
 		}
+		errs = append(errs, v.validatePersistentDisks(deploymentManifest, job, idx)...)
 		if job.Instances < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].instances must be >= 0", idx))
 		}
@@ -101,6 +105,7 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 
 		errs = append(errs, v.validateJobNetworks(job.Networks, deploymentManifest.Networks, idx)...)
+		errs = append(errs, v.validateAdditionalInstances(deploymentManifest, job, idx)...)
 
 		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService {
 			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' ('%s' not supported)", idx, job.Lifecycle))
@@ -178,6 +183,81 @@ func (v *validator) diskPoolNames(deploymentManifest Manifest) map[string]struct
 	return names
 }
 
+// validateAdditionalInstances checks that every instance but the first instance of the first job
+// can be reached through its own static IP, which its mbus certificate is valid for,
+// and uses the same stemcell as the first job.
+func (v *validator) validateAdditionalInstances(deploymentManifest Manifest, job Job, jobIdx int) []error {
+	errs := []error{}
+
+	firstAdditionalInstance := 0
+	if jobIdx == 0 {
+		firstAdditionalInstance = 1
+	}
+
+	var mbusCertificate *x509.Certificate
+	var resourcePoolIdx int
+	if firstAdditionalInstance < job.Instances {
+		var err error
+		mbusCertificate, resourcePoolIdx, err = v.mbusCertificate(deploymentManifest, job)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for instanceIdx := firstAdditionalInstance; instanceIdx < job.Instances; instanceIdx++ {
+		address, found := deploymentManifest.InstanceAddress(job.Name, instanceIdx)
+		if !found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].networks must have a static IP for instance %d", jobIdx, instanceIdx))
+			continue
+		}
+
+		// the agent of every instance serves the mbus certificate of its resource pool,
+		// which is verified against the address the instance is reached through
+		if mbusCertificate != nil && mbusCertificate.VerifyHostname(address) != nil {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].env.bosh.mbus.cert.certificate must include the static IP '%s' of jobs[%d] instance %d in its alternative names", resourcePoolIdx, address, jobIdx, instanceIdx))
+		}
+	}
+
+	if jobIdx > 0 && job.Instances > 0 {
+		stemcell, err := deploymentManifest.Stemcell(job.Name)
+		if err == nil {
+			firstStemcell, err := deploymentManifest.Stemcell(deploymentManifest.JobName())
+			if err == nil && stemcell != firstStemcell {
+				errs = append(errs, bosherr.Errorf("jobs[%d].resource_pool must use the same stemcell as jobs[0]", jobIdx))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) mbusCertificate(deploymentManifest Manifest, job Job) (*x509.Certificate, int, error) {
+	for idx, resourcePool := range deploymentManifest.ResourcePools {
+		if resourcePool.Name != job.ResourcePool {
+			continue
+		}
+
+		certificatePEM, found := resourcePool.MbusCertificate()
+		if !found {
+			return nil, idx, nil
+		}
+
+		block, _ := pem.Decode([]byte(certificatePEM))
+		if block == nil {
+			return nil, idx, bosherr.Errorf("resource_pools[%d].env.bosh.mbus.cert.certificate must be a PEM encoded certificate", idx)
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, idx, bosherr.Errorf("resource_pools[%d].env.bosh.mbus.cert.certificate must be a valid certificate", idx)
+		}
+
+		return certificate, idx, nil
+	}
+
+	return nil, -1, nil
+}
+
 func (v *validator) resourcePoolNames(deploymentManifest Manifest) map[string]struct{} {
 	names := make(map[string]struct{})
 	for _, resourcePool := range deploymentManifest.ResourcePools {
@@ -372,3 +452,30 @@ func (v *validator) validateGateway(idx int, gateway string, ipNet maybeIPNet) [
 
 	return errors
 }
+
+func (v *validator) validatePersistentDisks(deploymentManifest Manifest, job Job, jobIdx int) []error {
+	errs := []error{}
+
+	diskNames := map[string]struct{}{}
+
+	for idx, persistentDisk := range job.PersistentDisks {
+		if v.isBlank(persistentDisk.Name) {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].name must be provided", jobIdx, idx))
+		} else if _, found := diskNames[persistentDisk.Name]; found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].name '%s' must be unique", jobIdx, idx, persistentDisk.Name))
+		}
+		diskNames[persistentDisk.Name] = struct{}{}
+
+		if persistentDisk.DiskPool != "" && persistentDisk.DiskSize != 0 {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d] must specify either disk_pool or disk_size", jobIdx, idx))
+		} else if persistentDisk.DiskPool != "" {
+			if _, ok := v.diskPoolNames(deploymentManifest)[persistentDisk.DiskPool]; !ok {
+				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].disk_pool must be the name of a disk pool", jobIdx, idx))
+			}
+		} else if persistentDisk.DiskSize <= 0 {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].disk_size must be > 0", jobIdx, idx))
+		}
+	}
+
+	return errs
+}
@@ -1,6 +1,15 @@
 package manifest_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	. "github.com/onsi/ginkgo"
@@ -572,17 +581,96 @@ var _ = Describe("Validator", func() {
 			})
 		})
 
-		It("validates that there is only one job", func() {
+		It("validates that job names are unique", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{
-					{},
-					{},
+					{Name: "fake-job-name"},
+					{Name: "fake-job-name"},
 				},
 			}
 
 			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("jobs must be of size 1"))
+			Expect(err.Error()).To(ContainSubstring("jobs[1].name 'fake-job-name' must be unique"))
+		})
+
+		Context("when deploying multiple instances", func() {
+			BeforeEach(func() {
+				validManifest.Networks[0].Type = "manual"
+				validManifest.Networks[0].Subnets = []Subnet{{Range: "10.10.0.0/24", Gateway: "10.10.0.1"}}
+
+				validManifest.Jobs[0].Instances = 2
+				validManifest.Jobs[0].Networks[0].StaticIPs = []string{"10.10.0.10", "10.10.0.11"}
+
+				otherJob := validManifest.Jobs[0]
+				otherJob.Name = "fake-other-job-name"
+				otherJob.Instances = 1
+				otherJob.Networks = []JobNetwork{{Name: "fake-network-name", StaticIPs: []string{"10.10.0.12"}}}
+				validManifest.Jobs = append(validManifest.Jobs, otherJob)
+			})
+
+			It("does not error when every additional instance has a static IP", func() {
+				err := validator.Validate(validManifest, validReleaseSetManifest)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("validates that every additional instance has a static IP", func() {
+				validManifest.Jobs[0].Networks[0].StaticIPs = []string{"10.10.0.10"}
+				validManifest.Jobs[1].Networks[0].StaticIPs = nil
+
+				err := validator.Validate(validManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[0].networks must have a static IP for instance 1"))
+				Expect(err.Error()).To(ContainSubstring("jobs[1].networks must have a static IP for instance 0"))
+			})
+
+			Context("when the resource pool sets an mbus certificate", func() {
+				var mbusEnv = func(certificate string) biproperty.Map {
+					return biproperty.Map{
+						"bosh": biproperty.Map{
+							"mbus": biproperty.Map{
+								"cert": biproperty.Map{"certificate": certificate},
+							},
+						},
+					}
+				}
+
+				It("does not error when the certificate includes the static IPs of all instances", func() {
+					validManifest.ResourcePools[0].Env = mbusEnv(generateCertificate("10.10.0.10", "10.10.0.11", "10.10.0.12"))
+
+					err := validator.Validate(validManifest, validReleaseSetManifest)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("validates that the certificate includes the static IP of every additional instance", func() {
+					validManifest.ResourcePools[0].Env = mbusEnv(generateCertificate("10.10.0.10"))
+
+					err := validator.Validate(validManifest, validReleaseSetManifest)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("resource_pools[0].env.bosh.mbus.cert.certificate must include the static IP '10.10.0.11' of jobs[0] instance 1 in its alternative names"))
+					Expect(err.Error()).To(ContainSubstring("resource_pools[0].env.bosh.mbus.cert.certificate must include the static IP '10.10.0.12' of jobs[1] instance 0 in its alternative names"))
+				})
+
+				It("validates that the certificate can be parsed", func() {
+					validManifest.ResourcePools[0].Env = mbusEnv("fake-certificate")
+
+					err := validator.Validate(validManifest, validReleaseSetManifest)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("resource_pools[0].env.bosh.mbus.cert.certificate must be a PEM encoded certificate"))
+				})
+			})
+
+			It("validates that all jobs use the same stemcell", func() {
+				otherResourcePool := validManifest.ResourcePools[0]
+				otherResourcePool.Name = "fake-other-resource-pool-name"
+				otherResourcePool.Stemcell = StemcellRef{URL: "file://fake-other-stemcell-url"}
+				validManifest.ResourcePools = append(validManifest.ResourcePools, otherResourcePool)
+				validManifest.Jobs[1].ResourcePool = "fake-other-resource-pool-name"
+
+				err := validator.Validate(validManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("jobs[1].resource_pool must use the same stemcell as jobs[0]"))
+			})
 		})
 
 		It("validates job name", func() {
@@ -647,6 +735,35 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disk_pool must be the name of a disk pool"))
 		})
 
+		It("validates named persistent disks", func() {
+			deploymentManifest := Manifest{
+				Jobs: []Job{
+					{
+						PersistentDisks: []PersistentDisk{
+							{DiskSize: 1024},
+							{Name: "fake-disk", DiskPool: "non-existent-disk-pool"},
+							{Name: "fake-disk", DiskSize: 1024},
+							{Name: "other-disk", DiskSize: 1024, DiskPool: "fake-disk-pool"},
+							{Name: "sizeless-disk"},
+						},
+					},
+				},
+				DiskPools: []DiskPool{
+					{
+						Name: "fake-disk-pool",
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[0].name must be provided"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[1].disk_pool must be the name of a disk pool"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[2].name 'fake-disk' must be unique"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[3] must specify either disk_pool or disk_size"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[4].disk_size must be > 0"))
+		})
+
 		It("validates job resource pool is provided", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{{}},
@@ -1036,3 +1153,23 @@ var _ = Describe("Validator", func() {
 		})
 	})
 })
+
+func generateCertificate(ips ...string) string {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake-mbus"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	for _, ip := range ips {
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP(ip))
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	Expect(err).ToNot(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}))
+}
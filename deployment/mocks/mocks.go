@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cloudfoundry/bosh-cli/deployment (interfaces: Deployment,Factory,Deployer,Manager,ManagerFactory)
+// Source: github.com/cloudfoundry/bosh-cli/deployment (interfaces: Deployment,Factory,Deployer,Manager,ManagerFactory,InstanceClientFactory)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -114,16 +114,16 @@ func (m *MockDeployer) EXPECT() *MockDeployerMockRecorder {
 }
 
 // Deploy mocks base method
-func (m *MockDeployer) Deploy(arg0 cloud.Cloud, arg1 manifest.Manifest, arg2 stemcell.CloudStemcell, arg3 manifest0.Registry, arg4 vm.Manager, arg5 blobstore.Blobstore, arg6 bool, arg7 ui.Stage) (deployment.Deployment, error) {
-	ret := m.ctrl.Call(m, "Deploy", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+func (m *MockDeployer) Deploy(arg0 cloud.Cloud, arg1 manifest.Manifest, arg2 stemcell.CloudStemcell, arg3 manifest0.Registry, arg4 vm.Manager, arg5 blobstore.Blobstore, arg6 deployment.InstanceClientFactory, arg7 bool, arg8 ui.Stage) (deployment.Deployment, error) {
+	ret := m.ctrl.Call(m, "Deploy", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 	ret0, _ := ret[0].(deployment.Deployment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Deploy indicates an expected call of Deploy
-func (mr *MockDeployerMockRecorder) Deploy(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deploy", reflect.TypeOf((*MockDeployer)(nil).Deploy), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+func (mr *MockDeployerMockRecorder) Deploy(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deploy", reflect.TypeOf((*MockDeployer)(nil).Deploy), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 }
 
 // MockManager is a mock of Manager interface
@@ -199,13 +199,62 @@ func (m *MockManagerFactory) EXPECT() *MockManagerFactoryMockRecorder {
 }
 
 // NewManager mocks base method
-func (m *MockManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.AgentClient, arg2 blobstore.Blobstore) deployment.Manager {
-	ret := m.ctrl.Call(m, "NewManager", arg0, arg1, arg2)
+func (m *MockManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.AgentClient, arg2 blobstore.Blobstore, arg3 deployment.InstanceClientFactory) deployment.Manager {
+	ret := m.ctrl.Call(m, "NewManager", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(deployment.Manager)
 	return ret0
 }
 
 // NewManager indicates an expected call of NewManager
-func (mr *MockManagerFactoryMockRecorder) NewManager(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManager", reflect.TypeOf((*MockManagerFactory)(nil).NewManager), arg0, arg1, arg2)
+func (mr *MockManagerFactoryMockRecorder) NewManager(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManager", reflect.TypeOf((*MockManagerFactory)(nil).NewManager), arg0, arg1, arg2, arg3)
+}
+
+// MockInstanceClientFactory is a mock of InstanceClientFactory interface
+type MockInstanceClientFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockInstanceClientFactoryMockRecorder
+}
+
+// MockInstanceClientFactoryMockRecorder is the mock recorder for MockInstanceClientFactory
+type MockInstanceClientFactoryMockRecorder struct {
+	mock *MockInstanceClientFactory
+}
+
+// NewMockInstanceClientFactory creates a new mock instance
+func NewMockInstanceClientFactory(ctrl *gomock.Controller) *MockInstanceClientFactory {
+	mock := &MockInstanceClientFactory{ctrl: ctrl}
+	mock.recorder = &MockInstanceClientFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockInstanceClientFactory) EXPECT() *MockInstanceClientFactoryMockRecorder {
+	return m.recorder
+}
+
+// NewAgentClient mocks base method
+func (m *MockInstanceClientFactory) NewAgentClient(arg0 string) (agentclient.AgentClient, error) {
+	ret := m.ctrl.Call(m, "NewAgentClient", arg0)
+	ret0, _ := ret[0].(agentclient.AgentClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewAgentClient indicates an expected call of NewAgentClient
+func (mr *MockInstanceClientFactoryMockRecorder) NewAgentClient(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAgentClient", reflect.TypeOf((*MockInstanceClientFactory)(nil).NewAgentClient), arg0)
+}
+
+// NewBlobstore mocks base method
+func (m *MockInstanceClientFactory) NewBlobstore(arg0 string) (blobstore.Blobstore, error) {
+	ret := m.ctrl.Call(m, "NewBlobstore", arg0)
+	ret0, _ := ret[0].(blobstore.Blobstore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewBlobstore indicates an expected call of NewBlobstore
+func (mr *MockInstanceClientFactoryMockRecorder) NewBlobstore(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewBlobstore", reflect.TypeOf((*MockInstanceClientFactory)(nil).NewBlobstore), arg0)
 }
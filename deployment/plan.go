@@ -0,0 +1,424 @@
+package deployment
+
+import (
+	"fmt"
+	"reflect"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	birel "github.com/cloudfoundry/bosh-cli/release"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+// Plan describes the changes create-env would make to an environment
+// without having talked to the CPI.
+type Plan struct {
+	CreateVM   bool
+	RecreateVM bool
+
+	UploadStemcell bool
+	Stemcell       string
+
+	CreateDisk       bool
+	MigrateDisk      bool
+	DiskSize         int
+	PreviousDiskSize int
+
+	NamedDisks []NamedDiskPlan
+
+	// Instances other than the first instance of the first job
+	Instances []InstancePlan
+
+	PackagesToCompile []string
+}
+
+// InstancePlan describes the changes to an instance other than the first instance of the first job
+type InstancePlan struct {
+	Name       string
+	Index      int
+	CreateVM   bool
+	RecreateVM bool
+	DeleteVM   bool
+
+	CreateDisk       bool
+	MigrateDisk      bool
+	DiskSize         int
+	PreviousDiskSize int
+
+	NamedDisks []NamedDiskPlan
+}
+
+func (p InstancePlan) HasChanges() bool {
+	for _, namedDisk := range p.NamedDisks {
+		if namedDisk.CreateDisk || namedDisk.MigrateDisk {
+			return true
+		}
+	}
+
+	return p.CreateVM || p.RecreateVM || p.DeleteVM || p.CreateDisk || p.MigrateDisk
+}
+
+// NamedDiskPlan describes the changes to a named persistent disk
+type NamedDiskPlan struct {
+	Name             string
+	CreateDisk       bool
+	MigrateDisk      bool
+	DiskSize         int
+	PreviousDiskSize int
+}
+
+func (p Plan) HasChanges() bool {
+	for _, namedDisk := range p.NamedDisks {
+		if namedDisk.CreateDisk || namedDisk.MigrateDisk {
+			return true
+		}
+	}
+
+	for _, instance := range p.Instances {
+		if instance.HasChanges() {
+			return true
+		}
+	}
+
+	return p.CreateVM || p.RecreateVM || p.UploadStemcell || p.CreateDisk || p.MigrateDisk
+}
+
+type Planner interface {
+	Plan(
+		deploymentState biconfig.DeploymentState,
+		deploymentManifest bideplmanifest.Manifest,
+		manifestSHA string,
+		releases []birel.Release,
+		stemcell bistemcell.ExtractedStemcell,
+		recreate bool,
+		recreatePersistentDisks bool,
+	) (Plan, error)
+}
+
+type planner struct{}
+
+func NewPlanner() Planner {
+	return planner{}
+}
+
+func (p planner) Plan(
+	deploymentState biconfig.DeploymentState,
+	deploymentManifest bideplmanifest.Manifest,
+	manifestSHA string,
+	releases []birel.Release,
+	stemcell bistemcell.ExtractedStemcell,
+	recreate bool,
+	recreatePersistentDisks bool,
+) (Plan, error) {
+	plan := Plan{
+		Stemcell: fmt.Sprintf("%s/%s", stemcell.Manifest().Name, stemcell.Manifest().Version),
+	}
+
+	stemcellRecord, stemcellFound := p.findStemcell(deploymentState, func(record biconfig.StemcellRecord) bool {
+		return record.Name == stemcell.Manifest().Name && record.Version == stemcell.Manifest().Version
+	})
+	plan.UploadStemcell = !stemcellFound
+
+	if deploymentState.CurrentVMCID == "" {
+		plan.CreateVM = true
+	} else if recreate || !p.isDeployed(deploymentState, manifestSHA, releases, stemcell) {
+		plan.RecreateVM = true
+	}
+
+	diskPlan, err := p.planDisk(deploymentState, deploymentManifest, deploymentManifest.JobName(), deploymentState.CurrentDiskID, recreatePersistentDisks)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan.CreateDisk = diskPlan.CreateDisk
+	plan.MigrateDisk = diskPlan.MigrateDisk
+	plan.DiskSize = diskPlan.DiskSize
+	plan.PreviousDiskSize = diskPlan.PreviousDiskSize
+
+	plan.NamedDisks, err = p.planNamedDisks(deploymentState, deploymentManifest, deploymentManifest.JobName(), deploymentState.CurrentNamedDiskIDs, recreatePersistentDisks)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	// other instances are only touched when the deployment is not skipped
+	if plan.CreateVM || plan.RecreateVM {
+		plan.Instances, err = p.planInstances(deploymentState, deploymentManifest, stemcellRecord.CID, recreatePersistentDisks)
+		if err != nil {
+			return Plan{}, err
+		}
+	}
+
+	if plan.CreateVM || plan.RecreateVM {
+		plan.PackagesToCompile, err = p.packagesToCompile(deploymentManifest, releases)
+		if err != nil {
+			return Plan{}, err
+		}
+	}
+
+	return plan, nil
+}
+
+// planInstances makes the same decisions about the VMs of other instances as the deployer,
+// which keeps VMs whose stemcell, resource pool and networks have not changed
+func (p planner) planInstances(
+	deploymentState biconfig.DeploymentState,
+	deploymentManifest bideplmanifest.Manifest,
+	stemcellCID string,
+	recreatePersistentDisks bool,
+) ([]InstancePlan, error) {
+	instancePlans := []InstancePlan{}
+
+	for _, record := range deploymentState.Instances {
+		if !isAdditionalInstance(deploymentManifest, record.Name, record.Index) && record.VMCID != "" {
+			instancePlans = append(instancePlans, InstancePlan{Name: record.Name, Index: record.Index, DeleteVM: true})
+		}
+	}
+
+	for _, job := range deploymentManifest.Jobs {
+		for index := 0; index < job.Instances; index++ {
+			if !isAdditionalInstance(deploymentManifest, job.Name, index) {
+				continue
+			}
+
+			instancePlan := InstancePlan{Name: job.Name, Index: index}
+
+			record, found := p.findInstance(deploymentState, job.Name, index)
+			if !found || record.VMCID == "" {
+				instancePlan.CreateVM = true
+			} else {
+				// a stemcell that has not been uploaded yet gets a new CID
+				digest, err := vmConfigDigest(deploymentManifest, stemcellCID, job.Name, index)
+				if err != nil {
+					return nil, err
+				}
+				instancePlan.RecreateVM = digest != record.VMConfigDigest
+			}
+
+			diskPlan, err := p.planDisk(deploymentState, deploymentManifest, job.Name, record.DiskID, recreatePersistentDisks)
+			if err != nil {
+				return nil, err
+			}
+			instancePlan.CreateDisk = diskPlan.CreateDisk
+			instancePlan.MigrateDisk = diskPlan.MigrateDisk
+			instancePlan.DiskSize = diskPlan.DiskSize
+			instancePlan.PreviousDiskSize = diskPlan.PreviousDiskSize
+
+			instancePlan.NamedDisks, err = p.planNamedDisks(deploymentState, deploymentManifest, job.Name, record.NamedDiskIDs, recreatePersistentDisks)
+			if err != nil {
+				return nil, err
+			}
+
+			instancePlans = append(instancePlans, instancePlan)
+		}
+	}
+
+	return instancePlans, nil
+}
+
+// planDisk uses NamedDiskPlan for the unnamed persistent disk of a job as well, its Name is empty
+func (p planner) planDisk(
+	deploymentState biconfig.DeploymentState,
+	deploymentManifest bideplmanifest.Manifest,
+	jobName string,
+	diskID string,
+	recreatePersistentDisks bool,
+) (NamedDiskPlan, error) {
+	diskPool, err := deploymentManifest.DiskPool(jobName)
+	if err != nil {
+		return NamedDiskPlan{}, bosherr.WrapError(err, "Finding disk pool")
+	}
+
+	if diskPool.DiskSize <= 0 {
+		return NamedDiskPlan{}, nil
+	}
+
+	return p.planDiskPool(deploymentState, diskPool, diskID, recreatePersistentDisks), nil
+}
+
+func (p planner) planNamedDisks(
+	deploymentState biconfig.DeploymentState,
+	deploymentManifest bideplmanifest.Manifest,
+	jobName string,
+	namedDiskIDs map[string]string,
+	recreatePersistentDisks bool,
+) ([]NamedDiskPlan, error) {
+	namedDiskPools, err := deploymentManifest.NamedDiskPools(jobName)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding named disk pools")
+	}
+
+	var namedDiskPlans []NamedDiskPlan
+
+	for _, namedDiskPool := range namedDiskPools {
+		namedDiskPlan := p.planDiskPool(deploymentState, namedDiskPool.DiskPool, namedDiskIDs[namedDiskPool.DiskName], recreatePersistentDisks)
+		namedDiskPlan.Name = namedDiskPool.DiskName
+
+		namedDiskPlans = append(namedDiskPlans, namedDiskPlan)
+	}
+
+	return namedDiskPlans, nil
+}
+
+func (p planner) planDiskPool(deploymentState biconfig.DeploymentState, diskPool bideplmanifest.DiskPool, diskID string, recreatePersistentDisks bool) NamedDiskPlan {
+	diskPlan := NamedDiskPlan{DiskSize: diskPool.DiskSize}
+
+	currentDisk, found := p.findDisk(deploymentState, diskID)
+	if !found {
+		diskPlan.CreateDisk = true
+	} else if recreatePersistentDisks || p.diskNeedsMigration(currentDisk, diskPool) {
+		diskPlan.MigrateDisk = true
+		diskPlan.PreviousDiskSize = currentDisk.Size
+	}
+
+	return diskPlan
+}
+
+func (p planner) findInstance(deploymentState biconfig.DeploymentState, name string, index int) (biconfig.InstanceRecord, bool) {
+	for _, record := range deploymentState.Instances {
+		if record.Name == name && record.Index == index {
+			return record, true
+		}
+	}
+	return biconfig.InstanceRecord{}, false
+}
+
+func (p planner) isDeployed(deploymentState biconfig.DeploymentState, manifestSHA string, releases []birel.Release, stemcell bistemcell.ExtractedStemcell) bool {
+	if deploymentState.CurrentManifestSHA == "" || deploymentState.CurrentManifestSHA != manifestSHA {
+		return false
+	}
+
+	currentStemcell, found := p.findStemcell(deploymentState, func(record biconfig.StemcellRecord) bool {
+		return record.ID == deploymentState.CurrentStemcellID
+	})
+	if !found || currentStemcell.Name != stemcell.Manifest().Name || currentStemcell.Version != stemcell.Manifest().Version {
+		return false
+	}
+
+	if len(deploymentState.CurrentReleaseIDs) == 0 || len(deploymentState.CurrentReleaseIDs) != len(releases) {
+		return false
+	}
+
+	for _, release := range releases {
+		found := false
+		for _, releaseRecord := range deploymentState.Releases {
+			if !p.isCurrentRelease(deploymentState, releaseRecord.ID) {
+				continue
+			}
+			if releaseRecord.Name == release.Name() && releaseRecord.Version == release.Version() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p planner) isCurrentRelease(deploymentState biconfig.DeploymentState, releaseID string) bool {
+	for _, id := range deploymentState.CurrentReleaseIDs {
+		if id == releaseID {
+			return true
+		}
+	}
+	return false
+}
+
+func (p planner) findStemcell(deploymentState biconfig.DeploymentState, matches func(biconfig.StemcellRecord) bool) (biconfig.StemcellRecord, bool) {
+	for _, record := range deploymentState.Stemcells {
+		if matches(record) {
+			return record, true
+		}
+	}
+	return biconfig.StemcellRecord{}, false
+}
+
+func (p planner) findDisk(deploymentState biconfig.DeploymentState, diskID string) (biconfig.DiskRecord, bool) {
+	if diskID == "" {
+		return biconfig.DiskRecord{}, false
+	}
+
+	for _, record := range deploymentState.Disks {
+		if record.ID == diskID {
+			return record, true
+		}
+	}
+	return biconfig.DiskRecord{}, false
+}
+
+func (p planner) diskNeedsMigration(diskRecord biconfig.DiskRecord, diskPool bideplmanifest.DiskPool) bool {
+	cloudProperties := diskPool.CloudProperties
+	if cloudProperties == nil {
+		cloudProperties = biproperty.Map{}
+	}
+	recordedCloudProperties := diskRecord.CloudProperties
+	if recordedCloudProperties == nil {
+		recordedCloudProperties = biproperty.Map{}
+	}
+
+	return diskRecord.Size != diskPool.DiskSize || !reflect.DeepEqual(recordedCloudProperties, cloudProperties)
+}
+
+func (p planner) packagesToCompile(deploymentManifest bideplmanifest.Manifest, releases []birel.Release) ([]string, error) {
+	job, _ := deploymentManifest.FindJobByName(deploymentManifest.JobName())
+
+	seen := map[birelpkg.Compilable]bool{}
+	var packages []birelpkg.Compilable
+
+	var visit func(pkg birelpkg.Compilable)
+	visit = func(pkg birelpkg.Compilable) {
+		if seen[pkg] {
+			return
+		}
+		seen[pkg] = true
+		packages = append(packages, pkg)
+		for _, dep := range pkg.Deps() {
+			visit(dep)
+		}
+	}
+
+	for _, jobRef := range job.Templates {
+		release, found := p.findRelease(releases, jobRef.Release)
+		if !found {
+			return nil, bosherr.Errorf("Finding release '%s' for job '%s'", jobRef.Release, jobRef.Name)
+		}
+
+		releaseJob, found := release.FindJobByName(jobRef.Name)
+		if !found {
+			return nil, bosherr.Errorf("Finding job '%s' in release '%s'", jobRef.Name, jobRef.Release)
+		}
+
+		for _, pkg := range releaseJob.Packages {
+			visit(pkg)
+		}
+	}
+
+	sortedPackages, err := birelpkg.Sort(packages)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Sorting packages")
+	}
+
+	names := []string{}
+	for _, pkg := range sortedPackages {
+		if pkg.IsCompiled() {
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s/%s", pkg.Name(), pkg.Fingerprint()))
+	}
+
+	return names, nil
+}
+
+func (p planner) findRelease(releases []birel.Release, name string) (birel.Release, bool) {
+	for _, release := range releases {
+		if release.Name() == name {
+			return release, true
+		}
+	}
+	return nil, false
+}
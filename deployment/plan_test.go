@@ -0,0 +1,324 @@
+package deployment_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/cloudfoundry/bosh-cli/deployment"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+var _ = Describe("Planner", func() {
+	var (
+		release            *fakerel.FakeRelease
+		releases           []boshrel.Release
+		stemcell           bistemcell.ExtractedStemcell
+		deploymentManifest bideplmanifest.Manifest
+		deploymentState    biconfig.DeploymentState
+		planner            Planner
+	)
+
+	BeforeEach(func() {
+		pkg1 := birelpkg.NewPackage(NewResource("fake-package-1", "fake-package-1-fp", nil), nil)
+		pkg2 := birelpkg.NewPackage(NewResource("fake-package-2", "fake-package-2-fp", nil), []string{"fake-package-1"})
+		err := pkg2.AttachDependencies([]*birelpkg.Package{pkg1})
+		Expect(err).ToNot(HaveOccurred())
+
+		job := bireljob.NewJob(NewResource("fake-job", "fake-job-fp", nil))
+		job.PackageNames = []string{"fake-package-2"}
+		err = job.AttachPackages([]*birelpkg.Package{pkg2})
+		Expect(err).ToNot(HaveOccurred())
+
+		release = &fakerel.FakeRelease{
+			NameStub:    func() string { return "fake-release-name" },
+			VersionStub: func() string { return "fake-release-version" },
+		}
+		release.FindJobByNameReturns(*job, true)
+		releases = []boshrel.Release{release}
+
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{
+				Name:    "fake-stemcell-name",
+				Version: "fake-stemcell-version",
+			},
+			"fake-extracted-path",
+			nil,
+			fakesys.NewFakeFileSystem(),
+		)
+
+		deploymentManifest = bideplmanifest.Manifest{
+			Name: "fake-deployment-name",
+			Jobs: []bideplmanifest.Job{
+				{
+					Name:               "fake-instance-group",
+					PersistentDiskPool: "fake-disk-pool",
+					Templates: []bideplmanifest.ReleaseJobRef{
+						{Name: "fake-job", Release: "fake-release-name"},
+					},
+				},
+			},
+			DiskPools: []bideplmanifest.DiskPool{
+				{
+					Name:            "fake-disk-pool",
+					DiskSize:        2048,
+					CloudProperties: biproperty.Map{"fake-key": "fake-value"},
+				},
+			},
+		}
+
+		deploymentState = biconfig.DeploymentState{
+			CurrentVMCID:       "fake-vm-cid",
+			CurrentManifestSHA: "fake-manifest-sha",
+			CurrentStemcellID:  "fake-stemcell-id",
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell-name", Version: "fake-stemcell-version"},
+			},
+			CurrentReleaseIDs: []string{"fake-release-id"},
+			Releases: []biconfig.ReleaseRecord{
+				{ID: "fake-release-id", Name: "fake-release-name", Version: "fake-release-version"},
+			},
+			CurrentDiskID: "fake-disk-id",
+			Disks: []biconfig.DiskRecord{
+				{
+					ID:              "fake-disk-id",
+					CID:             "fake-disk-cid",
+					Size:            2048,
+					CloudProperties: biproperty.Map{"fake-key": "fake-value"},
+				},
+			},
+		}
+
+		planner = NewPlanner()
+	})
+
+	It("plans no changes when nothing has changed", func() {
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.HasChanges()).To(BeFalse())
+		Expect(plan.PackagesToCompile).To(BeEmpty())
+	})
+
+	Context("when nothing has been deployed", func() {
+		BeforeEach(func() {
+			deploymentState = biconfig.DeploymentState{}
+		})
+
+		It("plans to upload the stemcell, create the VM and the disk", func() {
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.UploadStemcell).To(BeTrue())
+			Expect(plan.Stemcell).To(Equal("fake-stemcell-name/fake-stemcell-version"))
+			Expect(plan.CreateVM).To(BeTrue())
+			Expect(plan.RecreateVM).To(BeFalse())
+			Expect(plan.CreateDisk).To(BeTrue())
+			Expect(plan.DiskSize).To(Equal(2048))
+		})
+
+		It("plans to compile the job packages in dependency order", func() {
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.PackagesToCompile).To(Equal([]string{
+				"fake-package-1/fake-package-1-fp",
+				"fake-package-2/fake-package-2-fp",
+			}))
+		})
+	})
+
+	It("plans to recreate the VM when the manifest has changed", func() {
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-other-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.RecreateVM).To(BeTrue())
+		Expect(plan.UploadStemcell).To(BeFalse())
+		Expect(plan.PackagesToCompile).To(HaveLen(2))
+	})
+
+	It("plans to recreate the VM when a release has changed", func() {
+		release.VersionStub = func() string { return "fake-other-release-version" }
+
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.RecreateVM).To(BeTrue())
+	})
+
+	It("plans to upload the stemcell and recreate the VM when the stemcell has changed", func() {
+		deploymentState.Stemcells[0].Version = "fake-old-stemcell-version"
+
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.UploadStemcell).To(BeTrue())
+		Expect(plan.RecreateVM).To(BeTrue())
+	})
+
+	It("plans to recreate the VM when recreate is requested", func() {
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, true, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.RecreateVM).To(BeTrue())
+	})
+
+	It("plans to migrate the disk when its size has changed", func() {
+		deploymentManifest.DiskPools[0].DiskSize = 4096
+
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.MigrateDisk).To(BeTrue())
+		Expect(plan.PreviousDiskSize).To(Equal(2048))
+		Expect(plan.DiskSize).To(Equal(4096))
+		Expect(plan.RecreateVM).To(BeFalse())
+	})
+
+	It("plans to migrate the disk when its cloud properties have changed", func() {
+		deploymentManifest.DiskPools[0].CloudProperties = biproperty.Map{"fake-key": "fake-other-value"}
+
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.MigrateDisk).To(BeTrue())
+	})
+
+	It("plans to migrate the disk when recreating persistent disks is requested", func() {
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.MigrateDisk).To(BeTrue())
+	})
+
+	It("plans to create and replace named persistent disks", func() {
+		deploymentManifest.Jobs[0].PersistentDisks = []bideplmanifest.PersistentDisk{
+			{Name: "fake-data", DiskSize: 1024},
+			{Name: "fake-logs", DiskPool: "fake-disk-pool"},
+		}
+		deploymentState.CurrentNamedDiskIDs = map[string]string{"fake-data": "fake-data-disk-id"}
+		deploymentState.Disks = append(deploymentState.Disks, biconfig.DiskRecord{
+			ID:              "fake-data-disk-id",
+			CID:             "fake-data-disk-cid",
+			Size:            512,
+			CloudProperties: biproperty.Map{},
+			Name:            "fake-data",
+		})
+
+		plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.HasChanges()).To(BeTrue())
+		Expect(plan.NamedDisks).To(Equal([]NamedDiskPlan{
+			{Name: "fake-data", MigrateDisk: true, DiskSize: 1024, PreviousDiskSize: 512},
+			{Name: "fake-logs", CreateDisk: true, DiskSize: 2048},
+		}))
+	})
+
+	Context("when the manifest has other instances", func() {
+		// vmConfigDigest computes the digest the deployer records for the VM of an instance
+		vmConfigDigest := func(stemcellCID string, index int) string {
+			resourcePool, err := deploymentManifest.ResourcePool("fake-instance-group")
+			Expect(err).ToNot(HaveOccurred())
+
+			networkInterfaces, err := deploymentManifest.InstanceNetworkInterfaces("fake-instance-group", index)
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := json.Marshal(map[string]interface{}{
+				"stemcell_cid":     stemcellCID,
+				"cloud_properties": resourcePool.CloudProperties,
+				"env":              resourcePool.Env,
+				"networks":         networkInterfaces,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			return fmt.Sprintf("%x", sha256.Sum256(config))
+		}
+
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].Instances = 3
+			deploymentManifest.Jobs[0].ResourcePool = "fake-resource-pool"
+			deploymentManifest.Jobs[0].Networks = []bideplmanifest.JobNetwork{{Name: "fake-network"}}
+			deploymentManifest.ResourcePools = []bideplmanifest.ResourcePool{
+				{Name: "fake-resource-pool", Network: "fake-network", CloudProperties: biproperty.Map{"fake-key": "fake-value"}},
+			}
+			deploymentManifest.Networks = []bideplmanifest.Network{
+				{Name: "fake-network", Type: bideplmanifest.Dynamic},
+			}
+
+			deploymentState.Stemcells[0].CID = "fake-stemcell-cid"
+			deploymentState.Disks = append(deploymentState.Disks, biconfig.DiskRecord{
+				ID:              "fake-instance-disk-id",
+				CID:             "fake-instance-disk-cid",
+				Size:            2048,
+				CloudProperties: biproperty.Map{"fake-key": "fake-value"},
+			})
+			deploymentState.Instances = []biconfig.InstanceRecord{
+				{
+					Name:           "fake-instance-group",
+					Index:          1,
+					VMCID:          "fake-instance-vm-cid",
+					DiskID:         "fake-instance-disk-id",
+					VMConfigDigest: vmConfigDigest("fake-stemcell-cid", 1),
+				},
+				{Name: "fake-removed-instance-group", Index: 1, VMCID: "fake-removed-vm-cid"},
+			}
+		})
+
+		It("keeps unchanged VMs, creates new VMs and deletes removed VMs", func() {
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-other-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Instances).To(Equal([]InstancePlan{
+				{Name: "fake-removed-instance-group", Index: 1, DeleteVM: true},
+				{Name: "fake-instance-group", Index: 1, DiskSize: 2048},
+				{Name: "fake-instance-group", Index: 2, CreateVM: true, CreateDisk: true, DiskSize: 2048},
+			}))
+		})
+
+		It("plans to recreate VMs whose resource pool has changed", func() {
+			deploymentManifest.ResourcePools[0].CloudProperties = biproperty.Map{"fake-key": "fake-other-value"}
+
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-other-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Instances[1].RecreateVM).To(BeTrue())
+		})
+
+		It("plans to recreate VMs when the stemcell has not been uploaded yet", func() {
+			deploymentState.Stemcells[0].Version = "fake-old-stemcell-version"
+
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Instances[1].RecreateVM).To(BeTrue())
+		})
+
+		It("plans to migrate disks of other instances", func() {
+			deploymentManifest.DiskPools[0].DiskSize = 4096
+
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-other-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Instances[1]).To(Equal(InstancePlan{
+				Name:             "fake-instance-group",
+				Index:            1,
+				MigrateDisk:      true,
+				DiskSize:         4096,
+				PreviousDiskSize: 2048,
+			}))
+		})
+
+		It("does not plan changes to other instances when the deploy is skipped", func() {
+			plan, err := planner.Plan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Instances).To(BeEmpty())
+			Expect(plan.HasChanges()).To(BeFalse())
+		})
+	})
+
+	It("returns an error when a job's release cannot be found", func() {
+		deploymentManifest.Jobs[0].Templates[0].Release = "fake-missing-release"
+
+		_, err := planner.Plan(deploymentState, deploymentManifest, "fake-other-manifest-sha", releases, stemcell, false, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Finding release 'fake-missing-release' for job 'fake-job'"))
+	})
+})
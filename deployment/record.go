@@ -11,6 +11,7 @@ type Record interface {
 	IsDeployed(manifestSHA string, releases []birel.Release, stemcell bistemcell.ExtractedStemcell) (bool, error)
 	Clear() error
 	Update(manifestSHA string, releases []birel.Release) error
+	UpdateManifest(redactedManifest string) error
 }
 
 type deploymentRecord struct {
@@ -114,3 +115,12 @@ func (v *deploymentRecord) Update(manifestSHA string, releases []birel.Release)
 
 	return nil
 }
+
+func (v *deploymentRecord) UpdateManifest(redactedManifest string) error {
+	err := v.deploymentRepo.UpdateCurrentManifest(redactedManifest)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving deployed manifest")
+	}
+
+	return nil
+}
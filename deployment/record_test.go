@@ -361,4 +361,20 @@ var _ = Describe("Record", func() {
 			})
 		})
 	})
+
+	Describe("UpdateManifest", func() {
+		It("saves the deployed manifest", func() {
+			err := deploymentRecord.UpdateManifest("name: fake-deployment-name\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentRepo.UpdateCurrentManifestManifest).To(Equal("name: fake-deployment-name\n"))
+		})
+
+		It("returns an error when saving the manifest fails", func() {
+			deploymentRepo.UpdateCurrentManifestErr = errors.New("fake-update-error")
+
+			err := deploymentRecord.UpdateManifest("name: fake-deployment-name\n")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-update-error"))
+		})
+	})
 })
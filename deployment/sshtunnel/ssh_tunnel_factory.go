@@ -1,6 +1,8 @@
 package sshtunnel
 
 import (
+	"reflect"
+
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -13,12 +15,29 @@ type Options struct {
 	Password   string
 	PrivateKey string
 
+	UseAgent           bool
+	KnownHosts         string
+	HostKeyFingerprint string
+
+	JumpHosts []JumpHostOptions
+
 	LocalForwardPort  int
 	RemoteForwardPort int
 }
 
+type JumpHostOptions struct {
+	Host string
+	Port int
+
+	User       string
+	Password   string
+	PrivateKey string
+
+	HostKeyFingerprint string
+}
+
 func (o Options) IsEmpty() bool {
-	return o == Options{}
+	return reflect.DeepEqual(o, Options{})
 }
 
 type Factory interface {
@@ -43,6 +62,23 @@ func (f *factory) NewSSHTunnel(opts Options) SSHTunnel {
 		User:       opts.User,
 		Password:   opts.Password,
 		PrivateKey: opts.PrivateKey,
+
+		UseAgent:           opts.UseAgent,
+		KnownHosts:         opts.KnownHosts,
+		HostKeyFingerprint: opts.HostKeyFingerprint,
+	}
+
+	for _, jumpHost := range opts.JumpHosts {
+		clientOpts.JumpHosts = append(clientOpts.JumpHosts, boshssh.ClientOpts{
+			Host: jumpHost.Host,
+			Port: jumpHost.Port,
+
+			User:       jumpHost.User,
+			Password:   jumpHost.Password,
+			PrivateKey: jumpHost.PrivateKey,
+
+			HostKeyFingerprint: jumpHost.HostKeyFingerprint,
+		})
 	}
 
 	tunnel := &sshTunnel{
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"time"
 
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -15,24 +16,57 @@ import (
 // DiskDeployer is in the vm package to avoid a [disk -> vm -> disk] dependency cycle
 type DiskDeployer interface {
 	Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cloud, vm VM, eventLoggerStage biui.Stage) ([]bidisk.Disk, error)
+	DeployNamed(namedDiskPools []bideplmanifest.NamedDiskPool, cloud bicloud.Cloud, vm VM, eventLoggerStage biui.Stage) ([]bidisk.Disk, error)
+}
+
+// DiskDeployerOptions control how existing persistent disks are migrated
+type DiskDeployerOptions struct {
+	// RecreatePersistentDisks migrates disks even when their size and cloud properties did not change
+	RecreatePersistentDisks bool
+
+	// SnapshotBeforeMigration takes a snapshot of a disk before its content is migrated to a new disk
+	SnapshotBeforeMigration bool
+
+	// OrphanedDiskRetention keeps disks replaced by a migration for that long instead of deleting them right away
+	OrphanedDiskRetention time.Duration
+
+	// ReplaceNamedDisks replaces named disks that need migration with empty disks.
+	// The agent only migrates the content of the disk it mounts, so replaced named disks
+	// are kept as orphaned disks for OrphanedDiskRetention, which must be set.
+	ReplaceNamedDisks bool
 }
 
 type diskDeployer struct {
-	diskRepo               biconfig.DiskRepo
-	diskManagerFactory     bidisk.ManagerFactory
-	diskManager            bidisk.Manager
-	logger                 boshlog.Logger
-	logTag                 string
-	recreatePersistentDisk bool
+	cloud              bicloud.Cloud
+	diskRepo           biconfig.DiskRepo
+	snapshotRepo       biconfig.SnapshotRepo
+	diskManagerFactory bidisk.ManagerFactory
+	diskManager        bidisk.Manager
+	timeService        Clock
+	logger             boshlog.Logger
+	logTag             string
+	options            DiskDeployerOptions
+
+	// diskName is empty for the disk mounted by the agent
+	diskName string
 }
 
-func NewDiskDeployer(diskManagerFactory bidisk.ManagerFactory, diskRepo biconfig.DiskRepo, logger boshlog.Logger, recreatePersistentDisk bool) DiskDeployer {
+func NewDiskDeployer(
+	diskManagerFactory bidisk.ManagerFactory,
+	diskRepo biconfig.DiskRepo,
+	snapshotRepo biconfig.SnapshotRepo,
+	timeService Clock,
+	logger boshlog.Logger,
+	options DiskDeployerOptions,
+) DiskDeployer {
 	return &diskDeployer{
-		diskManagerFactory:     diskManagerFactory,
-		diskRepo:               diskRepo,
-		logger:                 logger,
-		logTag:                 "diskDeployer",
-		recreatePersistentDisk: recreatePersistentDisk,
+		diskManagerFactory: diskManagerFactory,
+		diskRepo:           diskRepo,
+		snapshotRepo:       snapshotRepo,
+		timeService:        timeService,
+		logger:             logger,
+		logTag:             "diskDeployer",
+		options:            options,
 	}
 }
 
@@ -41,7 +75,85 @@ func (d *diskDeployer) Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cl
 		return []bidisk.Disk{}, nil
 	}
 
+	d.cloud = cloud
+	d.diskManager = d.diskManagerFactory.NewManager(cloud)
+
+	disks, err := d.deployDisk(diskPool, vm, stage)
+	if err != nil {
+		return disks, err
+	}
+
+	err = d.diskManager.DeleteUnused(stage)
+	if err != nil {
+		return disks, err
+	}
+
+	return disks, nil
+}
+
+// DeployNamed creates, attaches and migrates every named persistent disk independently.
+// Named disks that are no longer part of the manifest are detached and deleted as unused.
+func (d *diskDeployer) DeployNamed(namedDiskPools []bideplmanifest.NamedDiskPool, cloud bicloud.Cloud, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
+	disks := []bidisk.Disk{}
+
+	currentDiskNames, err := d.diskRepo.CurrentDiskNames()
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Finding existing named disks")
+	}
+
+	if len(namedDiskPools) == 0 && len(currentDiskNames) == 0 {
+		return disks, nil
+	}
+
+	deployedDiskNames := map[string]bool{}
+
+	for _, namedDiskPool := range namedDiskPools {
+		deployedDiskNames[namedDiskPool.DiskName] = true
+
+		namedDisks, err := d.named(namedDiskPool.DiskName, cloud).deployDisk(namedDiskPool.DiskPool, vm, stage)
+		disks = append(disks, namedDisks...)
+		if err != nil {
+			return disks, bosherr.WrapErrorf(err, "Deploying disk '%s'", namedDiskPool.DiskName)
+		}
+	}
+
+	for _, diskName := range currentDiskNames {
+		if deployedDiskNames[diskName] {
+			continue
+		}
+
+		err = d.named(diskName, cloud).detachCurrentDisk(vm, stage)
+		if err != nil {
+			return disks, bosherr.WrapErrorf(err, "Detaching disk '%s'", diskName)
+		}
+	}
+
 	d.diskManager = d.diskManagerFactory.NewManager(cloud)
+
+	err = d.diskManager.DeleteUnused(stage)
+	if err != nil {
+		return disks, err
+	}
+
+	return disks, nil
+}
+
+func (d *diskDeployer) named(diskName string, cloud bicloud.Cloud) *diskDeployer {
+	return &diskDeployer{
+		cloud:              cloud,
+		diskRepo:           d.diskRepo.Named(diskName),
+		snapshotRepo:       d.snapshotRepo,
+		diskManagerFactory: d.diskManagerFactory,
+		diskManager:        d.diskManagerFactory.NewNamedManager(cloud, diskName),
+		timeService:        d.timeService,
+		logger:             d.logger,
+		logTag:             d.logTag,
+		options:            d.options,
+		diskName:           diskName,
+	}
+}
+
+func (d *diskDeployer) deployDisk(diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
 	disks, err := d.diskManager.FindCurrent()
 	if err != nil {
 		return disks, bosherr.WrapError(err, "Finding existing disk")
@@ -63,12 +175,32 @@ func (d *diskDeployer) Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cl
 		}
 	}
 
-	err = d.diskManager.DeleteUnused(stage)
+	return disks, nil
+}
+
+// detachCurrentDisk stops tracking the current disk, so that it is deleted as unused
+func (d *diskDeployer) detachCurrentDisk(vm VM, stage biui.Stage) error {
+	disks, err := d.diskManager.FindCurrent()
 	if err != nil {
-		return disks, err
+		return bosherr.WrapError(err, "Finding existing disk")
 	}
 
-	return disks, nil
+	for _, disk := range disks {
+		stageName := fmt.Sprintf("Detaching disk '%s'", disk.CID())
+		err = stage.Perform(stageName, func() error {
+			return vm.DetachDisk(disk)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = d.diskRepo.ClearCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Clearing current disk record")
+	}
+
+	return nil
 }
 
 func (d *diskDeployer) deployExistingDisk(disk bidisk.Disk, diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
@@ -83,7 +215,25 @@ func (d *diskDeployer) deployExistingDisk(disk bidisk.Disk, diskPool bideplmanif
 		return disks, err
 	}
 
-	if d.recreatePersistentDisk || disk.NeedsMigration(diskPool.DiskSize, diskPool.CloudProperties) {
+	if !d.options.RecreatePersistentDisks && disk.CanResize(diskPool.DiskSize, diskPool.CloudProperties) {
+		canResize, err := d.cpiCanResizeDisks()
+		if err != nil {
+			return disks, err
+		}
+
+		if canResize {
+			resized, err := d.resizeDisk(disk, diskPool, vm, stage)
+			if err != nil {
+				return disks, err
+			}
+
+			if resized {
+				return disks, nil
+			}
+		}
+	}
+
+	if d.options.RecreatePersistentDisks || disk.NeedsMigration(diskPool.DiskSize, diskPool.CloudProperties) {
 		disk, err = d.migrateDisk(disk, diskPool, vm, stage)
 		if err != nil {
 			return disks, err
@@ -120,6 +270,67 @@ func (d *diskDeployer) deployNewDisk(diskPool bideplmanifest.DiskPool, vm VM, st
 	return disks, nil
 }
 
+// cpiCanResizeDisks is true for CPIs implementing API version 2, which introduced resize_disk
+func (d *diskDeployer) cpiCanResizeDisks() (bool, error) {
+	cpiInfo, err := d.cloud.Info()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Getting CPI info")
+	}
+
+	return cpiInfo.ApiVersion >= 2, nil
+}
+
+// resizeDisk grows the disk in place the way the director does: unmount, detach, resize, attach and mount.
+// The disk is not resized when the CPI does not implement resize_disk after all.
+func (d *diskDeployer) resizeDisk(disk bidisk.Disk, diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) (resized bool, err error) {
+	d.logger.Debug(d.logTag, "Resizing disk '%s'", disk.CID())
+
+	// named disks are not mounted by the agent
+	if disk.Name() == "" {
+		stageName := fmt.Sprintf("Unmounting disk '%s'", disk.CID())
+		err = stage.Perform(stageName, func() error {
+			return vm.UnmountDisk(disk)
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	stageName := fmt.Sprintf("Detaching disk '%s'", disk.CID())
+	err = stage.Perform(stageName, func() error {
+		return vm.DetachDisk(disk)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	stageName = fmt.Sprintf("Resizing disk '%s' to %d MiB", disk.CID(), diskPool.DiskSize)
+	err = stage.Perform(stageName, func() error {
+		resizeErr := disk.Resize(diskPool.DiskSize)
+		if resizeErr != nil {
+			cloudErr, ok := resizeErr.(bicloud.Error)
+			if ok && cloudErr.Type() == bicloud.NotImplementedError {
+				return biui.NewSkipStageError(cloudErr, "Resizing disks is not supported by the CPI")
+			}
+			return resizeErr
+		}
+
+		resized = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// the disk is attached again whether it was resized or not, so that it can be migrated otherwise
+	err = d.attachDisk(disk, vm, stage)
+	if err != nil {
+		return false, err
+	}
+
+	return resized, nil
+}
+
 func (d *diskDeployer) migrateDisk(
 	originalDisk bidisk.Disk,
 	diskPool bideplmanifest.DiskPool,
@@ -128,6 +339,28 @@ func (d *diskDeployer) migrateDisk(
 ) (newDisk bidisk.Disk, err error) {
 	d.logger.Debug(d.logTag, "Migrating disk '%s'", originalDisk.CID())
 
+	if d.diskName != "" {
+		if !d.options.ReplaceNamedDisks {
+			return newDisk, bosherr.Errorf(
+				"Disk '%s' needs to be migrated, but the agent can only migrate the content of the disk it mounts. "+
+					"Use --replace-named-disks with --orphaned-disk-retention to replace it with an empty disk and keep the existing disk", d.diskName)
+		}
+
+		if d.options.OrphanedDiskRetention <= 0 {
+			return newDisk, bosherr.Errorf("Disk '%s' can only be replaced when replaced disks are kept as orphaned disks", d.diskName)
+		}
+	}
+
+	if d.options.SnapshotBeforeMigration {
+		stageName := fmt.Sprintf("Snapshotting disk '%s'", originalDisk.CID())
+		err = stage.Perform(stageName, func() error {
+			return d.snapshotDisk(originalDisk)
+		})
+		if err != nil {
+			return newDisk, err
+		}
+	}
+
 	err = stage.Perform("Creating disk", func() error {
 		newDisk, err = d.diskManager.Create(diskPool, vm.CID())
 		return err
@@ -144,12 +377,16 @@ func (d *diskDeployer) migrateDisk(
 		return newDisk, err
 	}
 
-	stageName = fmt.Sprintf("Migrating disk content from '%s' to '%s'", originalDisk.CID(), newDisk.CID())
-	err = stage.Perform(stageName, func() error {
-		return vm.MigrateDisk()
-	})
-	if err != nil {
-		return newDisk, err
+	// the agent only migrates the content of the disk it mounts,
+	// named disks are replaced and kept as orphaned disks, jobs are responsible for their content
+	if d.diskName == "" {
+		stageName = fmt.Sprintf("Migrating disk content from '%s' to '%s'", originalDisk.CID(), newDisk.CID())
+		err = stage.Perform(stageName, func() error {
+			return vm.MigrateDisk()
+		})
+		if err != nil {
+			return newDisk, err
+		}
 	}
 
 	err = d.updateCurrentDiskRecord(newDisk)
@@ -165,6 +402,20 @@ func (d *diskDeployer) migrateDisk(
 		return newDisk, err
 	}
 
+	if d.options.OrphanedDiskRetention > 0 {
+		// orphaned disks are deleted as unused once their retention expired
+		orphanedUntil := d.timeService.Now().Add(d.options.OrphanedDiskRetention)
+		stageName = fmt.Sprintf("Orphaning disk '%s' until %s", originalDisk.CID(), orphanedUntil.Format(time.RFC3339))
+		err = stage.Perform(stageName, func() error {
+			return d.diskRepo.Orphan(originalDisk.CID(), orphanedUntil)
+		})
+		if err != nil {
+			return newDisk, err
+		}
+
+		return newDisk, nil
+	}
+
 	stageName = fmt.Sprintf("Deleting disk '%s'", originalDisk.CID())
 	err = stage.Perform(stageName, func() error {
 		return originalDisk.Delete()
@@ -176,6 +427,20 @@ func (d *diskDeployer) migrateDisk(
 	return newDisk, nil
 }
 
+func (d *diskDeployer) snapshotDisk(disk bidisk.Disk) error {
+	snapshotCID, err := disk.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.snapshotRepo.Save(snapshotCID, disk.CID(), d.timeService.Now())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving snapshot record (cid=%s)", snapshotCID)
+	}
+
+	return nil
+}
+
 func (d *diskDeployer) updateCurrentDiskRecord(disk bidisk.Disk) error {
 	savedDiskRecord, found, err := d.diskRepo.Find(disk.CID())
 	if err != nil {
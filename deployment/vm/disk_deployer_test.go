@@ -3,9 +3,14 @@ package vm_test
 import (
 	. "github.com/cloudfoundry/bosh-cli/deployment/vm"
 
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -30,6 +35,8 @@ var _ = Describe("DiskDeployer", func() {
 		fakeDisk               *fakebidisk.FakeDisk
 		fakeDiskRepo           *fakebiconfig.FakeDiskRepo
 		fakeDiskManagerFactory *fakebidisk.FakeManagerFactory
+		fakeSnapshotRepo       *fakebiconfig.FakeSnapshotRepo
+		timeService            *fakeclock.FakeClock
 		logger                 boshlog.Logger
 	)
 
@@ -46,11 +53,15 @@ var _ = Describe("DiskDeployer", func() {
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		fakeStage = fakebiui.NewFakeStage()
 		fakeDiskRepo = fakebiconfig.NewFakeDiskRepo()
+		fakeSnapshotRepo = fakebiconfig.NewFakeSnapshotRepo()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC))
 		diskDeployer = NewDiskDeployer(
 			fakeDiskManagerFactory,
 			fakeDiskRepo,
+			fakeSnapshotRepo,
+			timeService,
 			logger,
-			false,
+			DiskDeployerOptions{},
 		)
 
 		fakeDiskManager.SetFindCurrentBehavior([]bidisk.Disk{}, nil)
@@ -116,8 +127,10 @@ var _ = Describe("DiskDeployer", func() {
 					diskDeployer = NewDiskDeployer(
 						fakeDiskManagerFactory,
 						fakeDiskRepo,
+						fakeSnapshotRepo,
+						timeService,
 						logger,
-						true,
+						DiskDeployerOptions{RecreatePersistentDisks: true},
 					)
 					existingDisk.SetNeedsMigrationBehavior(false)
 
@@ -287,6 +300,134 @@ var _ = Describe("DiskDeployer", func() {
 				})
 			})
 
+			Context("when disk can be resized", func() {
+				BeforeEach(func() {
+					existingDisk.CanResizeReturn = true
+					existingDisk.SetNeedsMigrationBehavior(true)
+					cloud.InfoResult = bicloud.CpiInfo{ApiVersion: 2}
+				})
+
+				It("unmounts, detaches, resizes and reattaches the disk without migrating it", func() {
+					disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{existingDisk}))
+
+					Expect(existingDisk.ResizeInputs).To(Equal([]int{1024}))
+					Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+					Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{
+						{Disk: existingDisk},
+					}))
+					Expect(fakeVM.AttachDiskInputs).To(Equal([]fakebivm.AttachDiskInput{
+						{Disk: existingDisk},
+						{Disk: existingDisk},
+					}))
+
+					Expect(fakeVM.UnmountDiskInputs).To(Equal([]fakebivm.UnmountDiskInput{
+						{Disk: existingDisk},
+					}))
+
+					Expect(fakeStage.PerformCalls[1:5]).To(Equal([]*fakebiui.PerformCall{
+						{Name: "Unmounting disk 'fake-existing-disk-cid'"},
+						{Name: "Detaching disk 'fake-existing-disk-cid'"},
+						{Name: "Resizing disk 'fake-existing-disk-cid' to 1024 MiB"},
+						{Name: "Attaching disk 'fake-existing-disk-cid' to VM 'fake-vm-cid'"},
+					}))
+				})
+
+				Context("when the CPI does not support resizing disks", func() {
+					var (
+						secondaryDisk  *fakebidisk.FakeDisk
+						notImplemented bicloud.Error
+					)
+
+					BeforeEach(func() {
+						notImplemented = bicloud.NewCPIError("resize_disk", bicloud.CmdError{Type: bicloud.NotImplementedError})
+						existingDisk.ResizeErr = notImplemented
+
+						secondaryDisk = fakebidisk.NewFakeDisk("fake-secondary-disk-cid")
+						fakeDiskManager.CreateDisk = secondaryDisk
+						fakeDiskRepo.SetFindBehavior("fake-secondary-disk-cid", biconfig.DiskRecord{ID: "fake-secondary-disk-id"}, true, nil)
+					})
+
+					It("skips resizing and migrates the disk", func() {
+						disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(disks).To(Equal([]bidisk.Disk{secondaryDisk}))
+
+						Expect(fakeStage.PerformCalls[3]).To(Equal(&fakebiui.PerformCall{
+							Name:      "Resizing disk 'fake-existing-disk-cid' to 1024 MiB",
+							Error:     biui.NewSkipStageError(notImplemented, "Resizing disks is not supported by the CPI"),
+							SkipError: biui.NewSkipStageError(notImplemented, "Resizing disks is not supported by the CPI"),
+						}))
+						Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
+						Expect(fakeDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
+							{DiskID: "fake-secondary-disk-id"},
+						}))
+					})
+				})
+
+				Context("when the CPI implements an API version before resize_disk", func() {
+					BeforeEach(func() {
+						cloud.InfoResult = bicloud.CpiInfo{ApiVersion: 1}
+
+						secondaryDisk := fakebidisk.NewFakeDisk("fake-secondary-disk-cid")
+						fakeDiskManager.CreateDisk = secondaryDisk
+						fakeDiskRepo.SetFindBehavior("fake-secondary-disk-cid", biconfig.DiskRecord{ID: "fake-secondary-disk-id"}, true, nil)
+					})
+
+					It("migrates the disk without detaching it first", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(existingDisk.ResizeInputs).To(BeEmpty())
+						Expect(fakeVM.UnmountDiskInputs).To(BeEmpty())
+						Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
+						Expect(fakeStage.PerformCalls[1]).To(Equal(&fakebiui.PerformCall{Name: "Creating disk"}))
+					})
+				})
+
+				Context("when getting the CPI info fails", func() {
+					BeforeEach(func() {
+						cloud.InfoError = bosherr.Error("fake-info-error")
+					})
+
+					It("returns an error", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-info-error"))
+						Expect(fakeVM.DetachDiskInputs).To(BeEmpty())
+					})
+				})
+
+				Context("when resizing the disk fails", func() {
+					BeforeEach(func() {
+						existingDisk.ResizeErr = bosherr.Error("fake-resize-error")
+					})
+
+					It("returns an error without migrating the disk", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("fake-resize-error"))
+						Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+					})
+				})
+
+				Context("when disk is forced to be recreated", func() {
+					BeforeEach(func() {
+						diskDeployer = NewDiskDeployer(fakeDiskManagerFactory, fakeDiskRepo, fakeSnapshotRepo, timeService, logger, DiskDeployerOptions{RecreatePersistentDisks: true})
+						fakeDiskRepo.SetFindBehavior("fake-new-disk-cid", biconfig.DiskRecord{ID: "fake-new-disk-id"}, true, nil)
+					})
+
+					It("does not resize the disk", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(existingDisk.ResizeInputs).To(BeEmpty())
+						Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
+					})
+				})
+			})
+
 			Context("when disk needs migration", func() {
 				var secondaryDisk *fakebidisk.FakeDisk
 
@@ -457,6 +598,120 @@ var _ = Describe("DiskDeployer", func() {
 						}))
 					})
 				})
+
+				It("deletes the primary disk", func() {
+					_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(existingDisk.DeleteCalledTimes).To(Equal(1))
+					Expect(fakeDiskRepo.OrphanInputs).To(BeEmpty())
+				})
+
+				Context("when disks are snapshotted before migration", func() {
+					BeforeEach(func() {
+						diskDeployer = NewDiskDeployer(
+							fakeDiskManagerFactory,
+							fakeDiskRepo,
+							fakeSnapshotRepo,
+							timeService,
+							logger,
+							DiskDeployerOptions{SnapshotBeforeMigration: true},
+						)
+						existingDisk.SnapshotCID = "fake-snapshot-cid"
+					})
+
+					It("snapshots the primary disk before creating the secondary disk", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(existingDisk.SnapshotCalledTimes).To(Equal(1))
+
+						Expect(fakeStage.PerformCalls[1:3]).To(Equal([]*fakebiui.PerformCall{
+							{Name: "Snapshotting disk 'fake-existing-disk-cid'"},
+							{Name: "Creating disk"},
+						}))
+					})
+
+					It("saves the snapshot record", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fakeSnapshotRepo.SaveInputs).To(Equal([]fakebiconfig.SnapshotRepoSaveInput{
+							{
+								CID:       "fake-snapshot-cid",
+								DiskCID:   "fake-existing-disk-cid",
+								CreatedAt: time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC),
+							},
+						}))
+					})
+
+					Context("when snapshotting the disk fails", func() {
+						BeforeEach(func() {
+							existingDisk.SnapshotErr = bosherr.Error("fake-snapshot-error")
+						})
+
+						It("returns an error without migrating the disk", func() {
+							_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-snapshot-error"))
+							Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+							Expect(fakeSnapshotRepo.SaveInputs).To(BeEmpty())
+						})
+					})
+
+					Context("when saving the snapshot record fails", func() {
+						BeforeEach(func() {
+							fakeSnapshotRepo.SaveErr = bosherr.Error("fake-save-error")
+						})
+
+						It("returns an error without migrating the disk", func() {
+							_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Saving snapshot record (cid=fake-snapshot-cid)"))
+							Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+						})
+					})
+				})
+
+				Context("when replaced disks are orphaned", func() {
+					BeforeEach(func() {
+						diskDeployer = NewDiskDeployer(
+							fakeDiskManagerFactory,
+							fakeDiskRepo,
+							fakeSnapshotRepo,
+							timeService,
+							logger,
+							DiskDeployerOptions{OrphanedDiskRetention: 48 * time.Hour},
+						)
+					})
+
+					It("orphans the primary disk instead of deleting it", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(existingDisk.DeleteCalledTimes).To(Equal(0))
+
+						Expect(fakeDiskRepo.OrphanInputs).To(Equal([]fakebiconfig.DiskRepoOrphanInput{
+							{
+								CID:   "fake-existing-disk-cid",
+								Until: time.Date(2017, time.March, 3, 12, 0, 0, 0, time.UTC),
+							},
+						}))
+
+						Expect(fakeStage.PerformCalls[5]).To(Equal(&fakebiui.PerformCall{
+							Name: "Orphaning disk 'fake-existing-disk-cid' until 2017-03-03T12:00:00Z",
+						}))
+					})
+
+					Context("when orphaning the disk fails", func() {
+						BeforeEach(func() {
+							fakeDiskRepo.OrphanErr = bosherr.Error("fake-orphan-error")
+						})
+
+						It("returns an error", func() {
+							_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-orphan-error"))
+						})
+					})
+				})
 			})
 		})
 
@@ -603,4 +858,174 @@ var _ = Describe("DiskDeployer", func() {
 			Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
 		})
 	})
+
+	Describe("DeployNamed", func() {
+		var (
+			namedDiskPools    []bideplmanifest.NamedDiskPool
+			fakeNamedManager  *fakebidisk.FakeManager
+			fakeNamedDiskRepo *fakebiconfig.FakeDiskRepo
+			fakeNamedDisk     *fakebidisk.FakeDisk
+		)
+
+		BeforeEach(func() {
+			namedDiskPools = []bideplmanifest.NamedDiskPool{
+				{
+					DiskName: "fake-disk-name",
+					DiskPool: bideplmanifest.DiskPool{
+						Name:            "fake-disk-pool-name",
+						DiskSize:        2048,
+						CloudProperties: biproperty.Map{},
+					},
+				},
+			}
+
+			fakeNamedManager = fakebidisk.NewFakeManager()
+			fakeNamedManager.SetFindCurrentBehavior([]bidisk.Disk{}, nil)
+			fakeNamedDisk = fakebidisk.NewFakeDisk("fake-named-disk-cid")
+			fakeNamedDisk.NameReturn = "fake-disk-name"
+			fakeNamedManager.CreateDisk = fakeNamedDisk
+			fakeDiskManagerFactory.NewNamedManagerManagers["fake-disk-name"] = fakeNamedManager
+
+			fakeNamedDiskRepo = fakebiconfig.NewFakeDiskRepo()
+			fakeNamedDiskRepo.SetFindBehavior("fake-named-disk-cid", biconfig.DiskRecord{ID: "fake-named-disk-id"}, true, nil)
+			fakeDiskRepo.NamedRepos = map[string]biconfig.DiskRepo{"fake-disk-name": fakeNamedDiskRepo}
+
+			fakeVM.SetAttachDiskBehavior(fakeNamedDisk, nil)
+		})
+
+		It("does nothing when there are no named disks", func() {
+			disks, err := diskDeployer.DeployNamed([]bideplmanifest.NamedDiskPool{}, cloud, fakeVM, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(disks).To(BeEmpty())
+
+			Expect(fakeDiskManagerFactory.NewManagerInputs).To(BeEmpty())
+			Expect(fakeStage.PerformCalls).To(BeEmpty())
+		})
+
+		It("creates and attaches a disk for every named disk pool", func() {
+			disks, err := diskDeployer.DeployNamed(namedDiskPools, cloud, fakeVM, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(disks).To(Equal([]bidisk.Disk{fakeNamedDisk}))
+
+			Expect(fakeNamedManager.CreateInputs).To(Equal([]fakebidisk.CreateInput{
+				{DiskPool: namedDiskPools[0].DiskPool, InstanceID: "fake-vm-cid"},
+			}))
+			Expect(fakeVM.AttachDiskInputs).To(Equal([]fakebivm.AttachDiskInput{
+				{Disk: fakeNamedDisk},
+			}))
+			Expect(fakeNamedDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
+				{DiskID: "fake-named-disk-id"},
+			}))
+			Expect(fakeDiskRepo.UpdateCurrentInputs).To(BeEmpty())
+		})
+
+		It("deletes unused disks", func() {
+			_, err := diskDeployer.DeployNamed(namedDiskPools, cloud, fakeVM, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeDiskManager.DeleteUnusedCalledTimes).To(Equal(1))
+		})
+
+		Context("when the named disk needs migration", func() {
+			var existingDisk *fakebidisk.FakeDisk
+
+			BeforeEach(func() {
+				existingDisk = fakebidisk.NewFakeDisk("fake-existing-named-disk-cid")
+				existingDisk.NameReturn = "fake-disk-name"
+				existingDisk.SetNeedsMigrationBehavior(true)
+				fakeNamedManager.SetFindCurrentBehavior([]bidisk.Disk{existingDisk}, nil)
+				fakeVM.SetAttachDiskBehavior(existingDisk, nil)
+			})
+
+			It("refuses to replace the disk", func() {
+				_, err := diskDeployer.DeployNamed(namedDiskPools, cloud, fakeVM, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Disk 'fake-disk-name' needs to be migrated, but the agent can only migrate the content of the disk it mounts"))
+
+				Expect(fakeNamedManager.CreateInputs).To(BeEmpty())
+				Expect(fakeVM.DetachDiskInputs).To(BeEmpty())
+				Expect(existingDisk.DeleteCalledTimes).To(Equal(0))
+			})
+
+			Context("when replacing named disks is enabled", func() {
+				BeforeEach(func() {
+					diskDeployer = NewDiskDeployer(
+						fakeDiskManagerFactory,
+						fakeDiskRepo,
+						fakeSnapshotRepo,
+						timeService,
+						logger,
+						DiskDeployerOptions{ReplaceNamedDisks: true, OrphanedDiskRetention: 48 * time.Hour},
+					)
+				})
+
+				It("replaces the disk without migrating its content and keeps the existing disk as orphaned", func() {
+					disks, err := diskDeployer.DeployNamed(namedDiskPools, cloud, fakeVM, fakeStage)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{fakeNamedDisk}))
+
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+					Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{
+						{Disk: existingDisk},
+					}))
+					Expect(fakeNamedDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
+						{DiskID: "fake-named-disk-id"},
+					}))
+					Expect(fakeNamedDiskRepo.OrphanInputs).To(Equal([]fakebiconfig.DiskRepoOrphanInput{
+						{CID: "fake-existing-named-disk-cid", Until: timeService.Now().Add(48 * time.Hour)},
+					}))
+					Expect(existingDisk.DeleteCalledTimes).To(Equal(0))
+				})
+			})
+
+			Context("when replacing named disks is enabled without keeping orphaned disks", func() {
+				BeforeEach(func() {
+					diskDeployer = NewDiskDeployer(
+						fakeDiskManagerFactory,
+						fakeDiskRepo,
+						fakeSnapshotRepo,
+						timeService,
+						logger,
+						DiskDeployerOptions{ReplaceNamedDisks: true},
+					)
+				})
+
+				It("refuses to replace the disk", func() {
+					_, err := diskDeployer.DeployNamed(namedDiskPools, cloud, fakeVM, fakeStage)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Disk 'fake-disk-name' can only be replaced when replaced disks are kept as orphaned disks"))
+
+					Expect(fakeNamedManager.CreateInputs).To(BeEmpty())
+					Expect(existingDisk.DeleteCalledTimes).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a named disk is no longer in the manifest", func() {
+			var removedDisk *fakebidisk.FakeDisk
+
+			BeforeEach(func() {
+				fakeDiskRepo.CurrentDiskNamesNames = []string{"removed-disk-name"}
+
+				removedDisk = fakebidisk.NewFakeDisk("fake-removed-disk-cid")
+				removedDiskManager := fakebidisk.NewFakeManager()
+				removedDiskManager.SetFindCurrentBehavior([]bidisk.Disk{removedDisk}, nil)
+				fakeDiskManagerFactory.NewNamedManagerManagers["removed-disk-name"] = removedDiskManager
+			})
+
+			It("detaches the disk and deletes it as unused", func() {
+				_, err := diskDeployer.DeployNamed([]bideplmanifest.NamedDiskPool{}, cloud, fakeVM, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{
+					{Disk: removedDisk},
+				}))
+				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+					{Name: "Detaching disk 'fake-removed-disk-cid'"},
+				}))
+				Expect(fakeDiskRepo.NamedInputs).To(Equal([]string{"removed-disk-name"}))
+				Expect(fakeDiskManager.DeleteUnusedCalledTimes).To(Equal(1))
+			})
+		})
+	})
 })
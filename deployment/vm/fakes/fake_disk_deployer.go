@@ -11,6 +11,17 @@ import (
 type FakeDiskDeployer struct {
 	DeployInputs  []DeployInput
 	deployOutputs deployOutput
+
+	DeployNamedInputs []DeployNamedInput
+	DeployNamedDisks  []bidisk.Disk
+	DeployNamedErr    error
+}
+
+type DeployNamedInput struct {
+	NamedDiskPools   []bideplmanifest.NamedDiskPool
+	Cloud            bicloud.Cloud
+	VM               bivm.VM
+	EventLoggerStage biui.Stage
 }
 
 type DeployInput struct {
@@ -47,6 +58,22 @@ func (d *FakeDiskDeployer) Deploy(
 	return d.deployOutputs.disks, d.deployOutputs.err
 }
 
+func (d *FakeDiskDeployer) DeployNamed(
+	namedDiskPools []bideplmanifest.NamedDiskPool,
+	cloud bicloud.Cloud,
+	vm bivm.VM,
+	eventLoggerStage biui.Stage,
+) ([]bidisk.Disk, error) {
+	d.DeployNamedInputs = append(d.DeployNamedInputs, DeployNamedInput{
+		NamedDiskPools:   namedDiskPools,
+		Cloud:            cloud,
+		VM:               vm,
+		EventLoggerStage: eventLoggerStage,
+	})
+
+	return d.DeployNamedDisks, d.DeployNamedErr
+}
+
 func (d *FakeDiskDeployer) SetDeployBehavior(disks []bidisk.Disk, err error) {
 	d.deployOutputs = deployOutput{
 		disks: disks,
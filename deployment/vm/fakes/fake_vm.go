@@ -23,6 +23,10 @@ type FakeVM struct {
 	UpdateDisksDisks  []bidisk.Disk
 	UpdateDisksErr    error
 
+	UpdateNamedDisksInputs []UpdateNamedDisksInput
+	UpdateNamedDisksDisks  []bidisk.Disk
+	UpdateNamedDisksErr    error
+
 	ApplyInputs []ApplyInput
 	ApplyErr    error
 
@@ -72,6 +76,11 @@ type UpdateDisksInput struct {
 	Stage    biui.Stage
 }
 
+type UpdateNamedDisksInput struct {
+	NamedDiskPools []bideplmanifest.NamedDiskPool
+	Stage          biui.Stage
+}
+
 type ApplyInput struct {
 	ApplySpec bias.ApplySpec
 }
@@ -143,6 +152,14 @@ func (vm *FakeVM) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage
 	return vm.UpdateDisksDisks, vm.UpdateDisksErr
 }
 
+func (vm *FakeVM) UpdateNamedDisks(namedDiskPools []bideplmanifest.NamedDiskPool, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
+	vm.UpdateNamedDisksInputs = append(vm.UpdateNamedDisksInputs, UpdateNamedDisksInput{
+		NamedDiskPools: namedDiskPools,
+		Stage:          eventLoggerStage,
+	})
+	return vm.UpdateNamedDisksDisks, vm.UpdateNamedDisksErr
+}
+
 func (vm *FakeVM) Apply(applySpec bias.ApplySpec) error {
 	vm.ApplyInputs = append(vm.ApplyInputs, ApplyInput{
 		ApplySpec: applySpec,
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -18,26 +19,41 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+// DirectorName is set as the 'director' metadata of VMs and disks
+// created by create-env, together with the name of the deployment
+const DirectorName = "bosh-init"
+
+// DirectorIDKey is the metadata key of VMs and disks created by create-env
+// that holds the director ID of their deployment state. Unlike the deployment name
+// it tells apart environments that use the same manifest.
+const DirectorIDKey = "director_id"
+
 type Manager interface {
 	FindCurrent() (VM, bool, error)
 	Create(bistemcell.CloudStemcell, bideplmanifest.Manifest) (VM, error)
 }
 
 type manager struct {
-	vmRepo             biconfig.VMRepo
-	stemcellRepo       biconfig.StemcellRepo
-	diskDeployer       DiskDeployer
-	agentClient        biagentclient.AgentClient
-	agentClientFactory bihttpagent.AgentClientFactory
-	cloud              bicloud.Cloud
-	uuidGenerator      boshuuid.Generator
-	fs                 boshsys.FileSystem
-	logger             boshlog.Logger
-	logTag             string
-	timeService        Clock
+	deploymentStateService biconfig.DeploymentStateService
+	vmRepo                 biconfig.VMRepo
+	stemcellRepo           biconfig.StemcellRepo
+	diskDeployer           DiskDeployer
+	agentClient            biagentclient.AgentClient
+	agentClientFactory     bihttpagent.AgentClientFactory
+	cloud                  bicloud.Cloud
+	uuidGenerator          boshuuid.Generator
+	fs                     boshsys.FileSystem
+	logger                 boshlog.Logger
+	logTag                 string
+	timeService            Clock
+
+	// jobName is empty for the manager of the first instance of the first job
+	jobName string
+	index   int
 }
 
 func NewManager(
+	deploymentStateService biconfig.DeploymentStateService,
 	vmRepo biconfig.VMRepo,
 	stemcellRepo biconfig.StemcellRepo,
 	diskDeployer DiskDeployer,
@@ -49,19 +65,42 @@ func NewManager(
 	timeService Clock,
 ) Manager {
 	return &manager{
-		cloud:         cloud,
-		agentClient:   agentClient,
-		vmRepo:        vmRepo,
-		stemcellRepo:  stemcellRepo,
-		diskDeployer:  diskDeployer,
-		uuidGenerator: uuidGenerator,
-		fs:            fs,
-		logger:        logger,
-		logTag:        "vmManager",
-		timeService:   timeService,
+		deploymentStateService: deploymentStateService,
+		cloud:                  cloud,
+		agentClient:            agentClient,
+		vmRepo:                 vmRepo,
+		stemcellRepo:           stemcellRepo,
+		diskDeployer:           diskDeployer,
+		uuidGenerator:          uuidGenerator,
+		fs:                     fs,
+		logger:                 logger,
+		logTag:                 "vmManager",
+		timeService:            timeService,
 	}
 }
 
+// NewInstanceManager creates a manager for the VM of an additional instance
+// that is tracked by the given repos and reached through the given agent client
+func NewInstanceManager(
+	jobName string,
+	index int,
+	deploymentStateService biconfig.DeploymentStateService,
+	vmRepo biconfig.VMRepo,
+	stemcellRepo biconfig.StemcellRepo,
+	diskDeployer DiskDeployer,
+	agentClient biagentclient.AgentClient,
+	cloud bicloud.Cloud,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+	timeService Clock,
+) Manager {
+	m := NewManager(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, agentClient, cloud, uuidGenerator, fs, logger, timeService).(*manager)
+	m.jobName = jobName
+	m.index = index
+	return m
+}
+
 func (m *manager) FindCurrent() (VM, bool, error) {
 	vmCID, found, err := m.vmRepo.FindCurrent()
 	if err != nil {
@@ -88,8 +127,12 @@ func (m *manager) FindCurrent() (VM, bool, error) {
 }
 
 func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error) {
-	jobName := deploymentManifest.JobName()
-	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName)
+	jobName := m.jobName
+	if jobName == "" {
+		jobName = deploymentManifest.JobName()
+	}
+
+	networkInterfaces, err := deploymentManifest.InstanceNetworkInterfaces(jobName, m.index)
 	m.logger.Debug(m.logTag, "Creating VM with network interfaces: %#v", networkInterfaces)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Getting network spec")
@@ -100,6 +143,11 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		return nil, bosherr.WrapErrorf(err, "Getting resource pool for job '%s'", jobName)
 	}
 
+	deploymentState, err := m.deploymentStateService.Load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading deployment state")
+	}
+
 	agentID, err := m.uuidGenerator.Generate()
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Generating agent ID")
@@ -112,11 +160,11 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 
 	metadata := bicloud.VMMetadata{
 		"deployment":     deploymentManifest.Name,
-		"job":            deploymentManifest.JobName(),
-		"instance_group": deploymentManifest.JobName(),
-		"index":          "0",
-		"director":       "bosh-init",
-		"name":           fmt.Sprintf("%s/%d", deploymentManifest.JobName(), 0),
+		"job":            jobName,
+		"instance_group": jobName,
+		"index":          strconv.Itoa(m.index),
+		"director":       DirectorName,
+		"name":           fmt.Sprintf("%s/%d", jobName, m.index),
 		"created_at":     m.timeService.Now().Format(time.RFC3339),
 	}
 
@@ -124,6 +172,9 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		metadata[tagKey] = tagValue
 	}
 
+	// set after the tags so that clean-up-env can rely on it
+	metadata[DirectorIDKey] = deploymentState.DirectorID
+
 	err = m.cloud.SetVMMetadata(cid, metadata)
 	if err != nil {
 		cloudErr, ok := err.(bicloud.Error)
@@ -5,6 +5,7 @@ import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -15,15 +16,17 @@ type ManagerFactory interface {
 }
 
 type managerFactory struct {
-	vmRepo        biconfig.VMRepo
-	stemcellRepo  biconfig.StemcellRepo
-	diskDeployer  DiskDeployer
-	uuidGenerator boshuuid.Generator
-	fs            boshsys.FileSystem
-	logger        boshlog.Logger
+	deploymentStateService biconfig.DeploymentStateService
+	vmRepo                 biconfig.VMRepo
+	stemcellRepo           biconfig.StemcellRepo
+	diskDeployer           DiskDeployer
+	uuidGenerator          boshuuid.Generator
+	fs                     boshsys.FileSystem
+	logger                 boshlog.Logger
 }
 
 func NewManagerFactory(
+	deploymentStateService biconfig.DeploymentStateService,
 	vmRepo biconfig.VMRepo,
 	stemcellRepo biconfig.StemcellRepo,
 	diskDeployer DiskDeployer,
@@ -32,17 +35,19 @@ func NewManagerFactory(
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
-		vmRepo:        vmRepo,
-		stemcellRepo:  stemcellRepo,
-		diskDeployer:  diskDeployer,
-		uuidGenerator: uuidGenerator,
-		fs:            fs,
-		logger:        logger,
+		deploymentStateService: deploymentStateService,
+		vmRepo:                 vmRepo,
+		stemcellRepo:           stemcellRepo,
+		diskDeployer:           diskDeployer,
+		uuidGenerator:          uuidGenerator,
+		fs:                     fs,
+		logger:                 logger,
 	}
 }
 
 func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient) Manager {
 	return NewManager(
+		f.deploymentStateService,
 		f.vmRepo,
 		f.stemcellRepo,
 		f.diskDeployer,
@@ -54,3 +59,60 @@ func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclie
 		clock.NewClock(),
 	)
 }
+
+// InstanceManagerFactory creates VM managers for instances other than the first instance of the first job.
+// Each of them tracks its VM and disk separately in the deployment state.
+type InstanceManagerFactory interface {
+	NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, jobName string, index int, address string) Manager
+}
+
+type instanceManagerFactory struct {
+	deploymentStateService biconfig.DeploymentStateService
+	stemcellRepo           biconfig.StemcellRepo
+	uuidGenerator          boshuuid.Generator
+	fs                     boshsys.FileSystem
+	logger                 boshlog.Logger
+	diskDeployerOptions    DiskDeployerOptions
+}
+
+func NewInstanceManagerFactory(
+	deploymentStateService biconfig.DeploymentStateService,
+	stemcellRepo biconfig.StemcellRepo,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+	diskDeployerOptions DiskDeployerOptions,
+) InstanceManagerFactory {
+	return &instanceManagerFactory{
+		deploymentStateService: deploymentStateService,
+		stemcellRepo:           stemcellRepo,
+		uuidGenerator:          uuidGenerator,
+		fs:                     fs,
+		logger:                 logger,
+		diskDeployerOptions:    diskDeployerOptions,
+	}
+}
+
+func (f *instanceManagerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, jobName string, index int, address string) Manager {
+	vmRepo := biconfig.NewInstanceVMRepo(f.deploymentStateService, jobName, index, address)
+	diskRepo := biconfig.NewInstanceDiskRepo(f.deploymentStateService, f.uuidGenerator, jobName, index)
+	snapshotRepo := biconfig.NewSnapshotRepo(f.deploymentStateService, f.uuidGenerator)
+	timeService := clock.NewClock()
+	diskDeployer := NewDiskDeployer(
+		bidisk.NewManagerFactory(diskRepo, snapshotRepo, timeService, f.logger), diskRepo, snapshotRepo, timeService, f.logger, f.diskDeployerOptions)
+
+	return NewInstanceManager(
+		jobName,
+		index,
+		f.deploymentStateService,
+		vmRepo,
+		f.stemcellRepo,
+		diskDeployer,
+		agentClient,
+		cloud,
+		f.uuidGenerator,
+		f.fs,
+		f.logger,
+		timeService,
+	)
+}
@@ -52,12 +52,15 @@ var _ = Describe("Manager", func() {
 		fakeUUIDGenerator := &fakeuuid.FakeGenerator{}
 		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
 		stemcellRepo = biconfig.NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
+		err := deploymentStateService.Save(biconfig.DeploymentState{DirectorID: "fake-director-id"})
+		Expect(err).ToNot(HaveOccurred())
 
 		fakeDiskDeployer = fakebivm.NewFakeDiskDeployer()
 		fakeTime := time.Date(2016, time.November, 10, 23, 0, 0, 0, time.UTC)
 		fakeTimeService = &FakeClock{Times: []time.Time{fakeTime, time.Now().Add(10 * time.Minute)}}
 
 		manager = NewManager(
+			deploymentStateService,
 			fakeVMRepo,
 			stemcellRepo,
 			fakeDiskDeployer,
@@ -142,6 +145,7 @@ var _ = Describe("Manager", func() {
 					"instance_group": "fake-job",
 					"index":          "0",
 					"director":       "bosh-init",
+					"director_id":    "fake-director-id",
 					"name":           "fake-job/0",
 					"created_at":     "2016-11-10T23:00:00Z",
 				},
@@ -170,6 +174,7 @@ var _ = Describe("Manager", func() {
 				"instance_group": "fake-job",
 				"index":          "0",
 				"director":       "bosh-init",
+				"director_id":    "fake-director-id",
 				"name":           "fake-job/0",
 				"created_at":     "2016-11-10T23:00:00Z",
 			}))
@@ -192,6 +197,7 @@ var _ = Describe("Manager", func() {
 					"instance_group": "fake-job",
 					"index":          "0",
 					"director":       "bosh-init",
+					"director_id":    "fake-director-id",
 					"empty1":         "",
 					"key1":           "value1",
 					"created_at":     "2016-11-10T23:00:00Z",
@@ -219,9 +225,21 @@ var _ = Describe("Manager", func() {
 						"instance_group": "manifest-instance-group",
 						"index":          "7",
 						"director":       "manifest-director",
+						"director_id":    "fake-director-id",
 						"created_at":     "2016-11-10T23:00:00Z",
 					}))
 				})
+
+				It("keeps the director id so that the resources of the environment can be listed", func() {
+					deploymentManifest.Tags = map[string]string{
+						"director_id": "manifest-director-id",
+					}
+
+					_, err := manager.Create(stemcell, deploymentManifest)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeCloud.SetVMMetadataMetadata).To(HaveKeyWithValue("director_id", "fake-director-id"))
+				})
 			})
 		})
 
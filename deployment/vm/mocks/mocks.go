@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cloudfoundry/bosh-cli/deployment/vm (interfaces: ManagerFactory)
+// Source: github.com/cloudfoundry/bosh-cli/deployment/vm (interfaces: ManagerFactory,InstanceManagerFactory)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -46,3 +46,38 @@ func (m *MockManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.Agent
 func (mr *MockManagerFactoryMockRecorder) NewManager(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManager", reflect.TypeOf((*MockManagerFactory)(nil).NewManager), arg0, arg1)
 }
+
+// MockInstanceManagerFactory is a mock of InstanceManagerFactory interface
+type MockInstanceManagerFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockInstanceManagerFactoryMockRecorder
+}
+
+// MockInstanceManagerFactoryMockRecorder is the mock recorder for MockInstanceManagerFactory
+type MockInstanceManagerFactoryMockRecorder struct {
+	mock *MockInstanceManagerFactory
+}
+
+// NewMockInstanceManagerFactory creates a new mock instance
+func NewMockInstanceManagerFactory(ctrl *gomock.Controller) *MockInstanceManagerFactory {
+	mock := &MockInstanceManagerFactory{ctrl: ctrl}
+	mock.recorder = &MockInstanceManagerFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockInstanceManagerFactory) EXPECT() *MockInstanceManagerFactoryMockRecorder {
+	return m.recorder
+}
+
+// NewManager mocks base method
+func (m *MockInstanceManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.AgentClient, arg2 string, arg3 int, arg4 string) vm.Manager {
+	ret := m.ctrl.Call(m, "NewManager", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(vm.Manager)
+	return ret0
+}
+
+// NewManager indicates an expected call of NewManager
+func (mr *MockInstanceManagerFactoryMockRecorder) NewManager(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManager", reflect.TypeOf((*MockInstanceManagerFactory)(nil).NewManager), arg0, arg1, arg2, arg3, arg4)
+}
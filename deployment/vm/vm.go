@@ -35,6 +35,7 @@ type VM interface {
 	Drain() error
 	Apply(bias.ApplySpec) error
 	UpdateDisks(bideplmanifest.DiskPool, biui.Stage) ([]bidisk.Disk, error)
+	UpdateNamedDisks([]bideplmanifest.NamedDiskPool, biui.Stage) ([]bidisk.Disk, error)
 	WaitToBeRunning(maxAttempts int, delay time.Duration) error
 	AttachDisk(bidisk.Disk) error
 	DetachDisk(bidisk.Disk) error
@@ -191,6 +192,14 @@ func (vm *vm) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage biu
 	return disks, nil
 }
 
+func (vm *vm) UpdateNamedDisks(namedDiskPools []bideplmanifest.NamedDiskPool, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
+	disks, err := vm.diskDeployer.DeployNamed(namedDiskPools, vm.cloud, vm, eventLoggerStage)
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Deploying named disks")
+	}
+	return disks, nil
+}
+
 func (vm *vm) WaitToBeRunning(maxAttempts int, delay time.Duration) error {
 	agentGetStateRetryable := biagentclient.NewGetStateRetryable(vm.agentClient)
 	agentGetStateRetryStrategy := boshretry.NewAttemptRetryStrategy(maxAttempts, delay, agentGetStateRetryable, vm.logger)
@@ -225,6 +234,11 @@ func (vm *vm) AttachDisk(disk bidisk.Disk) error {
 		}
 	}
 
+	// named disks are only made known to the agent, jobs are responsible for mounting them
+	if disk.Name() != "" {
+		return nil
+	}
+
 	err = vm.agentClient.MountDisk(disk.CID())
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting disk")
@@ -262,7 +276,7 @@ func (vm *vm) Disks() ([]bidisk.Disk, error) {
 	}
 
 	for _, diskCID := range disks {
-		disk := bidisk.NewDisk(biconfig.DiskRecord{CID: diskCID}, nil, nil)
+		disk := bidisk.NewDisk(biconfig.DiskRecord{CID: diskCID}, nil, nil, nil)
 		result = append(result, disk)
 	}
 
@@ -337,6 +337,16 @@ var _ = Describe("VM", func() {
 			Expect(fakeAgentClient.MountDiskArgsForCall(0)).To(Equal("fake-disk-cid"))
 		})
 
+		It("does not mount named disks", func() {
+			disk.NameReturn = "fake-disk-name"
+			fakeCloud.AttachDiskHints = "/dev/sdc"
+
+			err := vm.AttachDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeAgentClient.AddPersistentDiskCallCount()).To(Equal(1))
+			Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(0))
+		})
+
 		Context("when metadata is set", func() {
 			It("sets the metadata to the disk", func() {
 				expectedDiskMetadata := bicloud.DiskMetadata{
@@ -558,8 +568,8 @@ var _ = Describe("VM", func() {
 		It("returns disks that are reported by the agent", func() {
 			disks, err := vm.Disks()
 			Expect(err).ToNot(HaveOccurred())
-			expectedFirstDisk := bidisk.NewDisk(biconfig.DiskRecord{CID: "fake-disk-cid-1"}, nil, nil)
-			expectedSecondDisk := bidisk.NewDisk(biconfig.DiskRecord{CID: "fake-disk-cid-2"}, nil, nil)
+			expectedFirstDisk := bidisk.NewDisk(biconfig.DiskRecord{CID: "fake-disk-cid-1"}, nil, nil, nil)
+			expectedSecondDisk := bidisk.NewDisk(biconfig.DiskRecord{CID: "fake-disk-cid-2"}, nil, nil, nil)
 			Expect(disks).To(Equal([]bidisk.Disk{expectedFirstDisk, expectedSecondDisk}))
 		})
 
@@ -3,6 +3,7 @@ package director
 import (
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -20,10 +21,22 @@ func NewClient(
 	logger boshlog.Logger,
 ) Client {
 	clientRequest := NewClientRequest(endpoint, httpClient, fileReporter, logger)
-	taskClientRequest := NewTaskClientRequest(clientRequest, taskReporter, 500*time.Millisecond)
+	taskClientRequest := NewTaskClientRequest(clientRequest, taskReporter, 500*time.Millisecond, clock.NewClock())
 	return Client{clientRequest, taskClientRequest}
 }
 
+// WithTaskPolling changes initial interval between checks of task state and cancels
+// tasks started by the client that do not finish within timeout unless it is zero
+func (c Client) WithTaskPolling(checkStepDuration time.Duration, timeout time.Duration) Client {
+	if checkStepDuration > 0 {
+		c.taskClientRequest.taskCheckStepDuration = checkStepDuration
+	}
+
+	c.taskClientRequest = c.taskClientRequest.WithTaskTimeout(timeout)
+
+	return c
+}
+
 func (c Client) WithContext(contextId string) Client {
 	clientRequest := c.clientRequest.WithContext(contextId)
 
@@ -91,7 +91,9 @@ func (f Factory) httpClient(factoryConfig FactoryConfig, config cmdconf.Config,
 		Host:   net.JoinHostPort(factoryConfig.Host, fmt.Sprintf("%d", factoryConfig.Port)),
 	}
 
-	return NewClient(endpoint.String(), httpClient, taskReporter, fileReporter, f.logger), nil
+	client := NewClient(endpoint.String(), httpClient, taskReporter, fileReporter, f.logger)
+
+	return client.WithTaskPolling(factoryConfig.TaskPollInterval, factoryConfig.TaskTimeout), nil
 }
 
 func clearBody(req *http.Request) {
@@ -6,6 +6,7 @@ import (
 	gourl "net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -22,6 +23,12 @@ type FactoryConfig struct {
 	ClientSecret string
 
 	TokenFunc func(bool) (string, error)
+
+	// Default task poll interval is used when zero
+	TaskPollInterval time.Duration
+
+	// Tasks started by the director client are not cancelled when zero
+	TaskTimeout time.Duration
 }
 
 func NewConfigFromURL(url string) (FactoryConfig, error) {
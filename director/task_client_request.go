@@ -5,27 +5,46 @@ import (
 	"net/http"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// maxTaskCheckStepDuration limits how far checks of a task without new output back off
+const maxTaskCheckStepDuration = 10 * time.Second
+
 type TaskClientRequest struct {
 	clientRequest         ClientRequest
 	taskReporter          TaskReporter
 	taskCheckStepDuration time.Duration
+	timeService           clock.Clock
+
+	// taskTimeout cancels tasks started through this request that
+	// are still running after it passes; disabled when zero
+	taskTimeout time.Duration
 }
 
 func NewTaskClientRequest(
 	clientRequest ClientRequest,
 	taskReporter TaskReporter,
 	taskCheckStepDuration time.Duration,
+	timeService clock.Clock,
 ) TaskClientRequest {
 	return TaskClientRequest{
 		clientRequest:         clientRequest,
 		taskReporter:          taskReporter,
 		taskCheckStepDuration: taskCheckStepDuration,
+		timeService:           timeService,
 	}
 }
 
+// WithTaskTimeout cancels tasks started through this request (e.g. by PostResult)
+// that do not finish within timeout unless it is zero; tasks that are only
+// tracked with WaitForCompletion are never cancelled
+func (r TaskClientRequest) WithTaskTimeout(timeout time.Duration) TaskClientRequest {
+	r.taskTimeout = timeout
+	return r
+}
+
 type taskShortResp struct {
 	ID    int    // 165
 	State string // e.g. "queued", "processing", "done", "error", "cancelled"
@@ -86,6 +105,11 @@ func (r TaskClientRequest) DeleteResult(path string) ([]byte, error) {
 }
 
 func (r TaskClientRequest) WaitForCompletion(id int, type_ string, taskReporter TaskReporter) error {
+	return r.waitForCompletion(id, type_, taskReporter, 0)
+}
+
+// waitForCompletion cancels the task if it is still running after timeout unless it is zero
+func (r TaskClientRequest) waitForCompletion(id int, type_ string, taskReporter TaskReporter, timeout time.Duration) error {
 	taskReporter.TaskStarted(id)
 
 	var taskResp taskShortResp
@@ -97,6 +121,9 @@ func (r TaskClientRequest) WaitForCompletion(id int, type_ string, taskReporter
 
 	taskPath := fmt.Sprintf("/tasks/%d", id)
 
+	startedAt := r.timeService.Now()
+	checkStepDuration := r.taskCheckStepDuration
+
 	for {
 		err := r.clientRequest.Get(taskPath, &taskResp)
 		if err != nil {
@@ -105,13 +132,34 @@ func (r TaskClientRequest) WaitForCompletion(id int, type_ string, taskReporter
 
 		// retrieve output *after* getting state to make sure
 		// it's complete in case of task being finished
+		lastOutputOffset := outputOffset
+
 		outputOffset, err = r.reportOutputChunk(taskResp.ID, outputOffset, type_, taskReporter)
 		if err != nil {
 			return bosherr.WrapError(err, "Getting task output")
 		}
 
 		if taskResp.IsRunning() {
-			time.Sleep(r.taskCheckStepDuration)
+			if timeout > 0 && r.timeService.Since(startedAt) >= timeout {
+				err := r.cancelTimedOutTask(id)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Task '%d' did not finish within %s", id, timeout)
+				}
+
+				// reported state is the one the task ends up in instead of the last one received
+				taskResp.State = "cancelled"
+
+				return bosherr.Errorf("Task '%d' did not finish within %s and was cancelled", id, timeout)
+			}
+
+			// check often while output is flowing and back off while task is quiet
+			if outputOffset > lastOutputOffset {
+				checkStepDuration = r.taskCheckStepDuration
+			} else {
+				checkStepDuration = r.nextCheckStepDuration(checkStepDuration)
+			}
+
+			r.timeService.Sleep(checkStepDuration)
 			continue
 		}
 
@@ -125,8 +173,29 @@ func (r TaskClientRequest) WaitForCompletion(id int, type_ string, taskReporter
 	}
 }
 
+func (r TaskClientRequest) nextCheckStepDuration(current time.Duration) time.Duration {
+	maxStepDuration := maxTaskCheckStepDuration
+	if r.taskCheckStepDuration > maxStepDuration {
+		maxStepDuration = r.taskCheckStepDuration
+	}
+
+	next := current * 2
+	if next > maxStepDuration {
+		next = maxStepDuration
+	}
+
+	return next
+}
+
+func (r TaskClientRequest) cancelTimedOutTask(id int) error {
+	task := TaskImpl{client: Client{clientRequest: r.clientRequest, taskClientRequest: r}, id: id}
+
+	return task.Cancel()
+}
+
 func (r TaskClientRequest) waitForResult(taskResp taskShortResp) ([]byte, error) {
-	err := r.WaitForCompletion(taskResp.ID, "event", r.taskReporter)
+	// only tasks started by this request are cancelled after timing out
+	err := r.waitForCompletion(taskResp.ID, "event", r.taskReporter, r.taskTimeout)
 	if err != nil {
 		return nil, err
 	}
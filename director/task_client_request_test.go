@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	boshhttp "github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	. "github.com/onsi/ginkgo"
@@ -19,14 +20,18 @@ var _ = Describe("TaskClientRequest", func() {
 	var (
 		server *ghttp.Server
 
-		buildReq func(TaskReporter) TaskClientRequest
+		timeService *sleepRecordingClock
+
+		buildReq func(TaskReporter, time.Duration) TaskClientRequest
 		req      TaskClientRequest
 	)
 
 	BeforeEach(func() {
 		_, server = BuildServer()
 
-		buildReq = func(taskReporter TaskReporter) TaskClientRequest {
+		timeService = &sleepRecordingClock{FakeClock: fakeclock.NewFakeClock(time.Now())}
+
+		buildReq = func(taskReporter TaskReporter, taskCheckStepDuration time.Duration) TaskClientRequest {
 			httpTransport := &http.Transport{
 				TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
 				TLSHandshakeTimeout: 10 * time.Second,
@@ -37,10 +42,10 @@ var _ = Describe("TaskClientRequest", func() {
 			httpClient := boshhttp.NewHTTPClient(rawClient, logger)
 			fileReporter := NewNoopFileReporter()
 			clientReq := NewClientRequest(server.URL(), httpClient, fileReporter, logger)
-			return NewTaskClientRequest(clientReq, taskReporter, 0*time.Second)
+			return NewTaskClientRequest(clientReq, taskReporter, taskCheckStepDuration, timeService)
 		}
 
-		req = buildReq(NewNoopTaskReporter())
+		req = buildReq(NewNoopTaskReporter(), 0*time.Second)
 	})
 
 	AfterEach(func() {
@@ -102,6 +107,84 @@ var _ = Describe("TaskClientRequest", func() {
 			return req.PostResult("/path", []byte("req-body"), setHeaders)
 		}
 
+		Context("when started task does not finish within task timeout", func() {
+			var (
+				taskReporter *fakedir.FakeTaskReporter
+			)
+
+			BeforeEach(func() {
+				taskReporter = &fakedir.FakeTaskReporter{}
+				req = buildReq(taskReporter, 20*time.Millisecond).WithTaskTimeout(30 * time.Millisecond)
+
+				redirectHeader := http.Header{}
+				redirectHeader.Add("Location", "/tasks/123")
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/path"),
+						ghttp.RespondWith(http.StatusFound, nil, redirectHeader),
+					),
+					// followed redirect
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/tasks/123"),
+						ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"processing"}`),
+					),
+				)
+
+				for i := 0; i < 2; i++ {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/tasks/123"),
+							ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"processing"}`),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/tasks/123/output", "type=event"),
+							ghttp.RespondWith(http.StatusOK, ""),
+						),
+					)
+				}
+			})
+
+			It("cancels the task and returns an error", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/task/123"),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+				)
+
+				_, err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Task '123' did not finish within 30ms and was cancelled"))
+
+				Expect(server.ReceivedRequests()).To(HaveLen(7))
+				Expect(timeService.sleeps).To(Equal([]time.Duration{40 * time.Millisecond}))
+
+				Expect(taskReporter.TaskFinishedCallCount()).To(Equal(1))
+
+				id, state := taskReporter.TaskFinishedArgsForCall(0)
+				Expect(id).To(Equal(123))
+				Expect(state).To(Equal("cancelled"))
+			})
+
+			It("returns an error if cancelling the task fails", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/task/123"),
+						ghttp.RespondWith(http.StatusInternalServerError, ""),
+					),
+				)
+
+				_, err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Task '123' did not finish within 30ms"))
+				Expect(err.Error()).To(ContainSubstring("Cancelling task '123'"))
+
+				_, state := taskReporter.TaskFinishedArgsForCall(0)
+				Expect(state).To(Equal("processing"))
+			})
+		})
+
 		It("waits for task to finish", func() {
 			redirectHeader := http.Header{}
 			redirectHeader.Add("Location", "/tasks/123")
@@ -454,5 +537,98 @@ var _ = Describe("TaskClientRequest", func() {
 			Expect(taskReporter.TaskStartedCallCount()).To(Equal(1))
 			Expect(taskReporter.TaskFinishedCallCount()).To(Equal(1))
 		})
+
+		It("does not cancel tasks it only tracks when task timeout passes", func() {
+			req = buildReq(NewNoopTaskReporter(), 20*time.Millisecond).WithTaskTimeout(30 * time.Millisecond)
+
+			for _, state := range []string{"processing", "processing", "done"} {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/tasks/123"),
+						ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"`+state+`"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/tasks/123/output", "type=event"),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+				)
+			}
+
+			Expect(act()).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(6))
+		})
+
+		Describe("checking task state", func() {
+			appendRunningTaskHandlers := func(outputs ...string) {
+				for _, output := range outputs {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/tasks/123"),
+							ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"processing"}`),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/tasks/123/output", "type=event"),
+							ghttp.RespondWith(http.StatusOK, output),
+						),
+					)
+				}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/tasks/123"),
+						ghttp.RespondWith(http.StatusOK, `{"id":123, "state":"done"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/tasks/123/output", "type=event"),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+				)
+			}
+
+			It("backs off while task has no new output", func() {
+				req = buildReq(NewNoopTaskReporter(), 20*time.Millisecond)
+
+				appendRunningTaskHandlers("", "", "", "")
+
+				Expect(act()).ToNot(HaveOccurred())
+				Expect(timeService.sleeps).To(Equal([]time.Duration{
+					40 * time.Millisecond, 80 * time.Millisecond, 160 * time.Millisecond, 320 * time.Millisecond,
+				}))
+			})
+
+			It("does not back off further than 10 seconds", func() {
+				req = buildReq(NewNoopTaskReporter(), 4*time.Second)
+
+				appendRunningTaskHandlers("", "", "")
+
+				Expect(act()).ToNot(HaveOccurred())
+				Expect(timeService.sleeps).To(Equal([]time.Duration{
+					8 * time.Second, 10 * time.Second, 10 * time.Second,
+				}))
+			})
+
+			It("checks at initial interval while output is flowing", func() {
+				req = buildReq(NewNoopTaskReporter(), 20*time.Millisecond)
+
+				appendRunningTaskHandlers("chunk1", "", "chunk2", "chunk3")
+
+				Expect(act()).ToNot(HaveOccurred())
+				Expect(timeService.sleeps).To(Equal([]time.Duration{
+					20 * time.Millisecond, 40 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond,
+				}))
+				Expect(taskReporter.TaskOutputChunkCallCount()).To(Equal(3))
+			})
+		})
 	})
 })
+
+// sleepRecordingClock moves fake time forward by slept durations instead of blocking
+type sleepRecordingClock struct {
+	*fakeclock.FakeClock
+	sleeps []time.Duration
+}
+
+func (c *sleepRecordingClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.Increment(d)
+}
@@ -54,8 +54,21 @@ func (i *installation) WithRunningRegistry(logger boshlog.Logger, stage biui.Sta
 	if err != nil {
 		return err
 	}
-	defer i.stopRegistryNice(logger, stage)
-	return fn()
+
+	err = fn()
+	i.stopRegistryNice(logger, stage)
+	if err != nil {
+		return err
+	}
+
+	// Agents fetched their settings once the deployment was created or deleted,
+	// persisted settings are only kept to resume after a failure and contain credentials
+	err = i.registryServerManager.Cleanup()
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting persisted registry settings")
+	}
+
+	return nil
 }
 
 func (i *installation) stopRegistryNice(logger boshlog.Logger, stage biui.Stage) {
@@ -73,7 +86,15 @@ func (i *installation) StartRegistry() error {
 			return bosherr.Error("Registry already started")
 		}
 		config := i.manifest.Registry
-		registryServer, err := i.registryServerManager.Start(config.Username, config.Password, config.Host, config.Port)
+		registryServer, err := i.registryServerManager.Start(biregistry.ServerConfig{
+			Username:    config.Username,
+			Password:    config.Password,
+			Host:        config.Host,
+			Port:        config.Port,
+			Certificate: config.TLS.Certificate,
+			PrivateKey:  config.TLS.PrivateKey,
+			Persist:     config.Persist,
+		})
 		if err != nil {
 			return bosherr.WrapError(err, "Starting registry")
 		}
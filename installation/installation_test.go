@@ -7,6 +7,7 @@ import (
 	. "github.com/cloudfoundry/bosh-cli/installation"
 
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	mock_registry "github.com/cloudfoundry/bosh-cli/registry/mocks"
 	test_support_mocks "github.com/cloudfoundry/bosh-cli/test_support/mocks"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
@@ -54,11 +55,11 @@ var _ = Describe("Installation", func() {
 
 	Describe("WithRunningRegistry", func() {
 		var (
-			startCall, spyCall, stopCall *gomock.Call
-			spy                          *test_support_mocks.MockSpy
-			logBuffer                    *gbytes.Buffer
-			logger                       boshlog.Logger
-			fakeStage                    *fakebiui.FakeStage
+			startCall, spyCall, stopCall, cleanupCall *gomock.Call
+			spy                                       *test_support_mocks.MockSpy
+			logBuffer                                 *gbytes.Buffer
+			logger                                    boshlog.Logger
+			fakeStage                                 *fakebiui.FakeStage
 		)
 
 		BeforeEach(func() {
@@ -70,9 +71,10 @@ var _ = Describe("Installation", func() {
 			}
 
 			spy = test_support_mocks.NewMockSpy(mockCtrl)
-			startCall = mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+			startCall = mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(mockRegistryServer, nil)
 			spyCall = spy.EXPECT().Record()
 			stopCall = mockRegistryServer.EXPECT().Stop().Return(nil)
+			cleanupCall = mockRegistryServerManager.EXPECT().Cleanup().Return(nil)
 
 			logBuffer = gbytes.NewBuffer()
 			goLogger := log.New(logBuffer, "", log.LstdFlags)
@@ -106,8 +108,9 @@ var _ = Describe("Installation", func() {
 		})
 
 		Context("the function succeeds", func() {
-			It("stops the registry and returns nil", func() {
+			It("stops the registry, deletes persisted settings and returns nil", func() {
 				stopCall.After(spyCall)
+				cleanupCall.After(stopCall)
 
 				err := newInstalation().WithRunningRegistry(logger, fakeStage, func() error {
 					spy.Record()
@@ -115,11 +118,23 @@ var _ = Describe("Installation", func() {
 				})
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			It("returns an error when deleting persisted settings fails", func() {
+				cleanupCall.Return(errors.New("fake-cleanup-error"))
+
+				err := newInstalation().WithRunningRegistry(logger, fakeStage, func() error {
+					spy.Record()
+					return nil
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Deleting persisted registry settings: fake-cleanup-error"))
+			})
 		})
 
 		Context("the function fails", func() {
-			It("stops the registry and returns the error", func() {
+			It("stops the registry, keeps persisted settings and returns the error", func() {
 				stopCall.After(spyCall)
+				cleanupCall.Times(0)
 				err := newInstalation().WithRunningRegistry(logger, fakeStage, func() error {
 					spy.Record()
 					return errors.New("blarg!")
@@ -133,6 +148,7 @@ var _ = Describe("Installation", func() {
 				startCall.Return(mockRegistryServer, errors.New("registry-start-error"))
 				spyCall.Times(0)
 				stopCall.Times(0)
+				cleanupCall.Times(0)
 
 				err := newInstalation().WithRunningRegistry(logger, fakeStage, func() error {
 					spy.Record()
@@ -148,6 +164,7 @@ var _ = Describe("Installation", func() {
 			Context("when the function fails", func() {
 				It("logs a warning and returns the function error", func() {
 					stopCall.Return(errors.New("registry-stop-error"))
+					cleanupCall.Times(0)
 
 					err := newInstalation().WithRunningRegistry(logger, fakeStage, func() error {
 						spy.Record()
@@ -184,7 +201,7 @@ var _ = Describe("Installation", func() {
 			})
 
 			It("starts the registry", func() {
-				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+				mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(mockRegistryServer, nil)
 
 				err := newInstalation().StartRegistry()
 				Expect(err).NotTo(HaveOccurred())
@@ -192,7 +209,7 @@ var _ = Describe("Installation", func() {
 
 			Context("when starting registry fails", func() {
 				BeforeEach(func() {
-					mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(nil, errors.New("fake-registry-start-error"))
+					mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(nil, errors.New("fake-registry-start-error"))
 				})
 
 				It("returns an error", func() {
@@ -203,6 +220,38 @@ var _ = Describe("Installation", func() {
 			})
 		})
 
+		Context("when registry tls and persistence are configured", func() {
+			BeforeEach(func() {
+				manifest.Registry = biinstallmanifest.Registry{
+					Username: "fake-username",
+					Password: "fake-password",
+					Host:     "fake-host",
+					Port:     123,
+					TLS: biinstallmanifest.RegistryTLS{
+						Certificate: "fake-certificate",
+						PrivateKey:  "fake-private-key",
+						CA:          "fake-ca",
+					},
+					Persist: true,
+				}
+			})
+
+			It("starts the registry with them", func() {
+				mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{
+					Username:    "fake-username",
+					Password:    "fake-password",
+					Host:        "fake-host",
+					Port:        123,
+					Certificate: "fake-certificate",
+					PrivateKey:  "fake-private-key",
+					Persist:     true,
+				}).Return(mockRegistryServer, nil)
+
+				err := newInstalation().StartRegistry()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		Context("when registry config is empty", func() {
 			BeforeEach(func() {
 				manifest.Registry = biinstallmanifest.Registry{}
@@ -229,7 +278,7 @@ var _ = Describe("Installation", func() {
 
 				installation = newInstalation()
 
-				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+				mockRegistryServerManager.EXPECT().Start(biregistry.ServerConfig{Username: "fake-username", Password: "fake-password", Host: "fake-host", Port: 123}).Return(mockRegistryServer, nil)
 				err := installation.StartRegistry()
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -1,6 +1,8 @@
 package installation
 
 import (
+	"runtime"
+
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
@@ -34,6 +36,8 @@ type installerFactory struct {
 	logTag                 string
 	fs                     boshsys.FileSystem
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	parallel               int
+	compiledPackageCache   bistatepkg.CompiledPackageCache
 }
 
 func NewInstallerFactory(
@@ -46,6 +50,8 @@ func NewInstallerFactory(
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
+	parallel int,
+	compiledPackageCache bistatepkg.CompiledPackageCache,
 ) InstallerFactory {
 	return &installerFactory{
 		ui:                     ui,
@@ -58,6 +64,8 @@ func NewInstallerFactory(
 		logTag:                 "installer",
 		fs:                     fs,
 		digestCreateAlgorithms: digestCreateAlgorithms,
+		parallel:               parallel,
+		compiledPackageCache:   compiledPackageCache,
 	}
 }
 
@@ -71,6 +79,8 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 		releaseJobResolver:     f.releaseJobResolver,
 		fs:                     f.fs,
 		digestCreateAlgorithms: f.digestCreateAlgorithms,
+		parallel:               f.parallel,
+		compiledPackageCache:   f.compiledPackageCache,
 	}
 
 	return NewInstaller(
@@ -99,6 +109,8 @@ type installerFactoryContext struct {
 	blobExtractor          blobextract.Extractor
 	compiledPackageRepo    bistatepkg.CompiledPackageRepo
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	parallel               int
+	compiledPackageCache   bistatepkg.CompiledPackageCache
 }
 
 func (c *installerFactoryContext) JobRenderer() JobRenderer {
@@ -132,6 +144,7 @@ func (c *installerFactoryContext) JobDependencyCompiler() bistatejob.DependencyC
 
 	c.jobDependencyCompiler = bistatejob.NewDependencyCompiler(
 		c.InstallationStatePackageCompiler(),
+		c.parallel,
 		c.logger,
 	)
 
@@ -154,6 +167,19 @@ func (c *installerFactoryContext) InstallationStatePackageCompiler() bistatepkg.
 		c.logger,
 	)
 
+	if c.compiledPackageCache != nil {
+		// packages are compiled on this machine, so they are cached for its platform
+		c.packageCompiler = bistatepkg.NewCachingCompiler(
+			c.packageCompiler,
+			c.compiledPackageCache,
+			bistatepkg.CompilationPlatform(c.fs, runtime.GOOS, runtime.GOARCH),
+			c.CompiledPackageRepo(),
+			c.Blobstore(),
+			c.fs,
+			c.logger,
+		)
+	}
+
 	return c.packageCompiler
 }
 
@@ -1,6 +1,9 @@
 package manifest
 
 import (
+	"fmt"
+	"reflect"
+
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
@@ -11,6 +14,7 @@ type Manifest struct {
 	Mbus       string
 	Cert       Certificate
 	Registry   Registry
+	Blobstore  Blobstore
 }
 
 type Certificate struct {
@@ -28,18 +32,81 @@ type Registry struct {
 	Host      string
 	Port      int
 	SSHTunnel SSHTunnel
+	TLS       RegistryTLS
+
+	// Persist keeps instance settings on disk so that an interrupted create-env
+	// can resume against a VM that has not yet fetched them
+	Persist bool
+}
+
+// RegistryTLS makes the registry serve HTTPS with a certificate that is
+// either provided in the manifest or generated for the registry host
+type RegistryTLS struct {
+	Certificate string
+	PrivateKey  string
+	CA          string
+}
+
+func (t RegistryTLS) IsEnabled() bool {
+	return t.Certificate != ""
 }
 
 func (r Registry) IsEmpty() bool {
-	return r == Registry{}
+	return reflect.DeepEqual(r, Registry{})
+}
+
+// Blobstore selects the blobstore the CLI uses to exchange rendered jobs and
+// compiled packages with the agent. An empty provider (or "dav") uses the
+// agent's embedded DAV blobstore reachable through the mbus URL. Other providers
+// are passed to the agent through env.bosh.blobstores of the resource pools.
+type Blobstore struct {
+	Provider string
+	Options  map[string]interface{}
+}
+
+func (b Blobstore) IsDAV() bool {
+	return b.Provider == "" || b.Provider == BlobstoreProviderDAV
 }
 
+const (
+	BlobstoreProviderDAV   = "dav"
+	BlobstoreProviderS3    = "s3"
+	BlobstoreProviderGCS   = "gcs"
+	BlobstoreProviderLocal = "local"
+)
+
 type SSHTunnel struct {
 	User       string
 	Host       string
 	Port       int
 	Password   string
 	PrivateKey string `yaml:"private_key"`
+
+	// UseAgent authenticates to all hosts with keys of the ssh-agent
+	UseAgent bool `yaml:"use_agent"`
+
+	// KnownHosts is a path to a known_hosts file in the manifest
+	// and its contents once parsed; it verifies keys of all hosts
+	// unless a host has a pinned HostKeyFingerprint
+	KnownHosts         string `yaml:"known_hosts"`
+	HostKeyFingerprint string `yaml:"host_key_fingerprint"`
+
+	// JumpHosts are connected through in order to reach Host
+	JumpHosts []SSHJumpHost `yaml:"jump_hosts"`
+}
+
+type SSHJumpHost struct {
+	User       string
+	Host       string
+	Port       int
+	Password   string
+	PrivateKey string `yaml:"private_key"`
+
+	HostKeyFingerprint string `yaml:"host_key_fingerprint"`
+}
+
+func (t SSHTunnel) IsEmpty() bool {
+	return reflect.DeepEqual(t, SSHTunnel{})
 }
 
 func (m *Manifest) PopulateRegistry(username string, password string, host string, port int, sshTunnel SSHTunnel) {
@@ -57,3 +124,13 @@ func (m *Manifest) PopulateRegistry(username string, password string, host strin
 		SSHTunnel: sshTunnel,
 	}
 }
+
+// PopulateRegistryTLS exposes the HTTPS endpoint of the registry and the CA
+// to trust it with to the CPI
+func (m *Manifest) PopulateRegistryTLS(tls RegistryTLS) {
+	m.Registry.TLS = tls
+
+	registryProperties := m.Properties["registry"].(biproperty.Map)
+	registryProperties["endpoint"] = fmt.Sprintf("https://%s:%d", m.Registry.Host, m.Registry.Port)
+	registryProperties["ca_cert"] = tls.CA
+}
@@ -1,8 +1,12 @@
 package manifest
 
 import (
+	cryptotls "crypto/tls"
+	"io"
 	"strings"
 
+	"golang.org/x/crypto/ssh"
+
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -40,6 +44,50 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 		errs = append(errs, bosherr.Errorf("cloud_provider.template.release '%s' must refer to a release in releases", cpiReleaseName))
 	}
 
+	switch manifest.Blobstore.Provider {
+	case "":
+		if len(manifest.Blobstore.Options) > 0 {
+			errs = append(errs, bosherr.Error("cloud_provider.blobstore.provider must be provided when cloud_provider.blobstore.options are set"))
+		}
+	case BlobstoreProviderDAV, BlobstoreProviderS3, BlobstoreProviderGCS:
+	case BlobstoreProviderLocal:
+		if _, found := manifest.Blobstore.Options["blobstore_path"]; !found {
+			errs = append(errs, bosherr.Error("cloud_provider.blobstore.options.blobstore_path must be provided when using the local provider"))
+		}
+	default:
+		errs = append(errs, bosherr.Errorf("cloud_provider.blobstore.provider '%s' must be one of: dav, s3, gcs, local", manifest.Blobstore.Provider))
+	}
+
+	if tls := manifest.Registry.TLS; tls.IsEnabled() || tls.PrivateKey != "" {
+		if _, err := cryptotls.X509KeyPair([]byte(tls.Certificate), []byte(tls.PrivateKey)); err != nil {
+			errs = append(errs, bosherr.Error("cloud_provider.registry.tls must contain a matching certificate and private_key"))
+		}
+	}
+
+	sshTunnel := manifest.Registry.SSHTunnel
+
+	if !v.isValidKnownHosts(sshTunnel.KnownHosts) {
+		errs = append(errs, bosherr.Error("cloud_provider.ssh_tunnel.known_hosts must be in known_hosts format"))
+	}
+
+	if !v.isValidFingerprint(sshTunnel.HostKeyFingerprint) {
+		errs = append(errs, bosherr.Errorf("cloud_provider.ssh_tunnel.host_key_fingerprint '%s' must start with SHA256: or MD5:", sshTunnel.HostKeyFingerprint))
+	}
+
+	for i, jumpHost := range sshTunnel.JumpHosts {
+		if v.isBlank(jumpHost.Host) {
+			errs = append(errs, bosherr.Errorf("cloud_provider.ssh_tunnel.jump_hosts[%d].host must be provided", i))
+		}
+
+		if jumpHost.Port <= 0 {
+			errs = append(errs, bosherr.Errorf("cloud_provider.ssh_tunnel.jump_hosts[%d].port must be provided", i))
+		}
+
+		if !v.isValidFingerprint(jumpHost.HostKeyFingerprint) {
+			errs = append(errs, bosherr.Errorf("cloud_provider.ssh_tunnel.jump_hosts[%d].host_key_fingerprint '%s' must start with SHA256: or MD5:", i, jumpHost.HostKeyFingerprint))
+		}
+	}
+
 	if len(errs) > 0 {
 		return bosherr.NewMultiError(errs...)
 	}
@@ -50,3 +98,22 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
+
+func (v *validator) isValidFingerprint(fingerprint string) bool {
+	return fingerprint == "" || strings.HasPrefix(fingerprint, "SHA256:") || strings.HasPrefix(fingerprint, "MD5:")
+}
+
+func (v *validator) isValidKnownHosts(knownHosts string) bool {
+	rest := []byte(knownHosts)
+
+	for {
+		_, _, _, _, next, err := ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			return true
+		} else if err != nil {
+			return false
+		}
+
+		rest = next
+	}
+}
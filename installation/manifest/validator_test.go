@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -101,5 +102,90 @@ var _ = Describe("Validator", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cloud_provider.template.release 'not-provided-valid-release-name' must refer to a release in releases"))
 		})
+
+		It("validates the blobstore provider is supported", func() {
+			manifest := validManifest
+			manifest.Blobstore = Blobstore{Provider: "fake-provider"}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.blobstore.provider 'fake-provider' must be one of: dav, s3, gcs, local"))
+		})
+
+		It("validates the blobstore provider is provided when blobstore options are set", func() {
+			manifest := validManifest
+			manifest.Blobstore = Blobstore{Options: map[string]interface{}{"bucket_name": "fake-bucket-name"}}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.blobstore.provider must be provided when cloud_provider.blobstore.options are set"))
+		})
+
+		It("validates the local blobstore provider has a blobstore_path", func() {
+			manifest := validManifest
+			manifest.Blobstore = Blobstore{Provider: "local", Options: map[string]interface{}{}}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.blobstore.options.blobstore_path must be provided"))
+
+			manifest.Blobstore.Options["blobstore_path"] = "/fake-path"
+			err = validator.Validate(manifest, releaseSetManifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates the ssh tunnel known_hosts and fingerprints", func() {
+			manifest := validManifest
+			manifest.Registry.SSHTunnel = SSHTunnel{
+				Host:               "fake-host",
+				Port:               22,
+				KnownHosts:         "fake-host invalid-known-hosts",
+				HostKeyFingerprint: "fake-fingerprint",
+			}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel.known_hosts must be in known_hosts format"))
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel.host_key_fingerprint 'fake-fingerprint' must start with SHA256: or MD5:"))
+
+			manifest.Registry.SSHTunnel.KnownHosts = "# no hosts yet\n"
+			manifest.Registry.SSHTunnel.HostKeyFingerprint = "SHA256:fake-fingerprint"
+			err = validator.Validate(manifest, releaseSetManifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates the ssh tunnel jump hosts", func() {
+			manifest := validManifest
+			manifest.Registry.SSHTunnel = SSHTunnel{
+				Host: "fake-host",
+				Port: 22,
+				JumpHosts: []SSHJumpHost{
+					{Host: "fake-bastion-host", Port: 22, HostKeyFingerprint: "MD5:fake-fingerprint"},
+					{HostKeyFingerprint: "fake-fingerprint"},
+				},
+			}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).ToNot(ContainSubstring("jump_hosts[0]"))
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel.jump_hosts[1].host must be provided"))
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel.jump_hosts[1].port must be provided"))
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.ssh_tunnel.jump_hosts[1].host_key_fingerprint 'fake-fingerprint' must start with SHA256: or MD5:"))
+		})
+
+		It("validates the registry tls certificate matches its private key", func() {
+			certificate, _, err := biregistry.GenerateCertificate("127.0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, otherPrivateKey, err := biregistry.GenerateCertificate("127.0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest := validManifest
+			manifest.Registry.TLS = RegistryTLS{Certificate: certificate, PrivateKey: otherPrivateKey}
+
+			err = validator.Validate(manifest, releaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cloud_provider.registry.tls must contain a matching certificate and private_key"))
+		})
 	})
 })
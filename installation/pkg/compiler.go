@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -24,6 +25,12 @@ type compiler struct {
 	blobExtractor       blobextract.Extractor
 	logger              boshlog.Logger
 	logTag              string
+
+	// packagesDir is shared by packages compiled in parallel,
+	// it is cleaned up once no package is being compiled
+	packagesLock      sync.Mutex
+	installedPackages map[string]bool
+	compiling         int
 }
 
 func NewPackageCompiler(
@@ -46,6 +53,8 @@ func NewPackageCompiler(
 		blobExtractor:       blobExtractor,
 		logger:              logger,
 		logTag:              "packageCompiler",
+
+		installedPackages: map[string]bool{},
 	}
 }
 
@@ -64,6 +73,9 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, nil
 	}
 
+	c.beginCompile()
+	defer c.endCompile()
+
 	c.logger.Debug(c.logTag, "Installing dependencies of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	err = c.installPackages(pkg.Deps())
@@ -71,12 +83,6 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Installing dependencies of package '%s'", pkg.Name())
 	}
 
-	defer func() {
-		if err = c.fileSystem.RemoveAll(c.packagesDir); err != nil {
-			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
-		}
-	}()
-
 	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	installDir := filepath.Join(c.packagesDir, pkg.Name())
@@ -138,9 +144,37 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.WrapError(err, "Saving compiled package")
 	}
 
+	// the install dir already holds the compiled package for packages depending on it
+	c.packagesLock.Lock()
+	c.installedPackages[pkg.Name()] = true
+	c.packagesLock.Unlock()
+
 	return record, isCompiledPackage, nil
 }
 
+func (c *compiler) beginCompile() {
+	c.packagesLock.Lock()
+	defer c.packagesLock.Unlock()
+
+	c.compiling++
+}
+
+func (c *compiler) endCompile() {
+	c.packagesLock.Lock()
+	defer c.packagesLock.Unlock()
+
+	c.compiling--
+	if c.compiling > 0 {
+		return
+	}
+
+	c.installedPackages = map[string]bool{}
+
+	if err := c.fileSystem.RemoveAll(c.packagesDir); err != nil {
+		c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
+	}
+}
+
 func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
 	for _, pkg := range packages {
 		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
@@ -152,13 +186,31 @@ func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
 			return bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
 		}
 
-		c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
-
-		err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
+		err = c.installPackage(pkg, record)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
+			return err
 		}
 	}
 
 	return nil
 }
+
+func (c *compiler) installPackage(pkg birelpkg.Compilable, record bistatepkg.CompiledPackageRecord) error {
+	c.packagesLock.Lock()
+	defer c.packagesLock.Unlock()
+
+	if c.installedPackages[pkg.Name()] {
+		return nil
+	}
+
+	c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	err := c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
+	}
+
+	c.installedPackages[pkg.Name()] = true
+
+	return nil
+}
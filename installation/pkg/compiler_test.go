@@ -326,5 +326,60 @@ var _ = Describe("PackageCompiler", func() {
 				Expect(err.Error()).To(ContainSubstring("fake-error"))
 			})
 		})
+
+		Context("when packages sharing a dependency are compiled in parallel", func() {
+			var (
+				otherPkg   *birelpkg.Package
+				compiledCh chan string
+			)
+
+			BeforeEach(func() {
+				otherPkg = birelpkg.NewExtractedPackage(NewResource("pkg2-name", "", nil), []string{"pkg-dep1-name"}, "/pkg2-dir", fs)
+				otherPkg.AttachDependencies([]*birelpkg.Package{dependency1})
+
+				// both packages are being compiled once their blobs are created
+				compiledCh = make(chan string, 2)
+				blobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+					compiledCh <- "compiled"
+					Eventually(func() int { return len(compiledCh) }).Should(Equal(2))
+					return "fake-blob-id", boshcrypto.MustParseMultipleDigest("fakefingerprint"), nil
+				}
+			})
+
+			JustBeforeEach(func() {
+				mockCompiledPackageRepo.EXPECT().Find(otherPkg).Return(bistatepkg.CompiledPackageRecord{}, false, nil)
+				mockCompiledPackageRepo.EXPECT().Save(otherPkg, gomock.Any())
+
+				fs.WriteFileString("/pkg2-dir/packaging", "")
+			})
+
+			It("installs the shared dependency once and cleans up the packages dir after both are compiled", func() {
+				errCh := make(chan error, 2)
+				for _, p := range []*birelpkg.Package{pkg, otherPkg} {
+					go func(p *birelpkg.Package) {
+						defer GinkgoRecover()
+						_, _, err := compiler.Compile(p)
+						errCh <- err
+					}(p)
+				}
+
+				Expect(<-errCh).ToNot(HaveOccurred())
+				Expect(<-errCh).ToNot(HaveOccurred())
+
+				Expect(runner.RunComplexCommands).To(HaveLen(2))
+
+				extractedDirs := []string{}
+				for i := 0; i < fakeExtractor.ExtractCallCount(); i++ {
+					_, _, targetDir := fakeExtractor.ExtractArgsForCall(i)
+					extractedDirs = append(extractedDirs, targetDir)
+				}
+				Expect(extractedDirs).To(ConsistOf(
+					filepath.Join(packagesDir, "pkg-dep1-name"),
+					filepath.Join(packagesDir, "pkg-dep2-name"),
+				))
+
+				Expect(fs.FileExists(packagesDir)).To(BeFalse())
+			})
+		})
 	})
 })
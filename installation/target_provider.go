@@ -10,6 +10,7 @@ import (
 
 type TargetProvider interface {
 	NewTarget() (Target, error)
+	NewTemporaryTarget() (Target, error)
 }
 
 type targetProvider struct {
@@ -52,3 +53,14 @@ func (p *targetProvider) NewTarget() (Target, error) {
 
 	return NewTarget(filepath.Join(p.installationsRootPath, installationID)), nil
 }
+
+// NewTemporaryTarget returns a target for a new installation without recording it
+// in the deployment state; callers are expected to uninstall it when done.
+func (p *targetProvider) NewTemporaryTarget() (Target, error) {
+	installationID, err := p.uuidGenerator.Generate()
+	if err != nil {
+		return Target{}, bosherr.WrapError(err, "Generating installation ID")
+	}
+
+	return NewTarget(filepath.Join(p.installationsRootPath, installationID)), nil
+}
@@ -104,4 +104,14 @@ var _ = Describe("TargetProvider", func() {
 			Expect(deploymentState.InstallationID).To(Equal("fake-uuid-1"))
 		})
 	})
+
+	Describe("NewTemporaryTarget", func() {
+		It("returns a target for a new installation_id without saving it", func() {
+			target, err := targetProvider.NewTemporaryTarget()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Path()).To(Equal(filepath.Join("/", ".bosh", "installations", "fake-uuid-0")))
+
+			Expect(fakeFS.FileExists(configPath)).To(BeFalse())
+		})
+	})
 })
@@ -7,6 +7,7 @@ import (
 	"text/template"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -387,7 +388,7 @@ cloud_provider:
 			fakeRegistryUUIDGenerator = fakeuuid.NewFakeGenerator()
 			fakeRegistryUUIDGenerator.GeneratedUUID = "registry-password"
 			installationValidator := biinstallmanifest.NewValidator(logger)
-			installationParser := biinstallmanifest.NewParser(fs, fakeRegistryUUIDGenerator, logger, installationValidator)
+			installationParser := biinstallmanifest.NewParser(fs, fakeRegistryUUIDGenerator, biregistry.NewCertificateProvider(fs, "/fake-registry.json"), logger, installationValidator)
 
 			deploymentValidator := bideplmanifest.NewValidator(logger)
 
@@ -411,12 +412,15 @@ cloud_provider:
 				legacyDeploymentStateMigrator = biconfig.NewLegacyDeploymentStateMigrator(deploymentStateService, fs, fakeUUIDGenerator, logger)
 				deploymentRecord := bidepl.NewRecord(deploymentRepo, releaseRepo, stemcellRepo)
 				stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
-				diskManagerFactory = bidisk.NewManagerFactory(diskRepo, logger)
-				diskDeployer = bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
-				vmManagerFactory = bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeAgentIDGenerator, fs, logger)
+				snapshotRepo := biconfig.NewSnapshotRepo(deploymentStateService, fakeRepoUUIDGenerator)
+				diskManagerFactory = bidisk.NewManagerFactory(diskRepo, snapshotRepo, clock.NewClock(), logger)
+				diskDeployer = bivm.NewDiskDeployer(diskManagerFactory, diskRepo, snapshotRepo, clock.NewClock(), logger, bivm.DiskDeployerOptions{})
+				vmManagerFactory = bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, fakeAgentIDGenerator, fs, logger)
 				deployer := bidepl.NewDeployer(
 					vmManagerFactory,
+					bivm.NewInstanceManagerFactory(deploymentStateService, stemcellRepo, fakeAgentIDGenerator, fs, logger, bivm.DiskDeployerOptions{}),
 					instanceManagerFactory,
+					biconfig.NewInstanceRepo(deploymentStateService),
 					deploymentFactory,
 					logger,
 				)
@@ -479,6 +483,9 @@ cloud_provider:
 					deploymentManifestParser,
 					tempRootConfigurator,
 					targetProvider,
+					biinstall.NewUninstaller(fs, logger),
+					bidepl.NewPlanner(),
+					biconfig.NewFileSystemManifestRedactionKeyStore("/fake-manifest-keys", fs, fakeuuid.NewFakeGenerator()),
 				)
 			}
 
@@ -518,6 +525,60 @@ cloud_provider:
 			)
 		}
 
+		var expectDeployWithDiskResize = func() {
+			agentID := "fake-uuid-1"
+			oldVMCID := "fake-vm-cid-1"
+			newVMCID := "fake-vm-cid-2"
+			diskCID := "fake-disk-cid-1"
+			newDiskSize := 2048
+
+			gomock.InOrder(
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: cpiApiVersion}, nil),
+				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
+
+				// shutdown old vm
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().Drain("shutdown"),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{diskCID}, nil),
+				mockAgentClient.EXPECT().UnmountDisk(diskCID),
+				mockCloud.EXPECT().DeleteVM(oldVMCID),
+
+				// create new vm
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+
+				// attach the disk, then unmount and detach it to resize it in place
+				mockCloud.EXPECT().AttachDisk(newVMCID, diskCID).Return("/dev/xyz", nil),
+				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().AddPersistentDisk(diskCID, "/dev/xyz"),
+				mockAgentClient.EXPECT().MountDisk(diskCID),
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: cpiApiVersion}, nil),
+				mockAgentClient.EXPECT().UnmountDisk(diskCID),
+				mockAgentClient.EXPECT().RemovePersistentDisk(diskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, diskCID),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().ResizeDisk(diskCID, newDiskSize),
+				mockCloud.EXPECT().AttachDisk(newVMCID, diskCID).Return("/dev/xyz", nil),
+				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().AddPersistentDisk(diskCID, "/dev/xyz"),
+				mockAgentClient.EXPECT().MountDisk(diskCID),
+
+				// start jobs & wait for running
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().GetState(),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().RunScript("pre-start", map[string]interface{}{}),
+				mockAgentClient.EXPECT().Start(),
+				mockAgentClient.EXPECT().GetState().Return(agentRunningState, nil),
+				mockAgentClient.EXPECT().RunScript("post-start", map[string]interface{}{}),
+			)
+		}
+
 		var expectDeployWithDiskMigration = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -551,6 +612,7 @@ cloud_provider:
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().AddPersistentDisk(oldDiskCID, "/dev/xyz"),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: 1}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID).Return("/dev/abc", nil),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -603,6 +665,7 @@ cloud_provider:
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().AddPersistentDisk(oldDiskCID, "/dev/xyz"),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: 1}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID).Return("/dev/abc", nil),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -692,6 +755,7 @@ cloud_provider:
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().AddPersistentDisk(oldDiskCID, "/dev/xyz"),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: 1}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID).Return("/dev/abc", nil),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -734,6 +798,7 @@ cloud_provider:
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().AddPersistentDisk(oldDiskCID, "/dev/xyz"),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().Info().Return(bicloud.CpiInfo{ApiVersion: 1}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID).Return("/dev/abc", nil),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -783,7 +848,7 @@ cloud_provider:
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
 
-			registryServerManager = biregistry.NewServerManager(logger)
+			registryServerManager = biregistry.NewServerManager(fs, "/fake-registry.json", logger)
 
 			releaseReader = &fakerel.FakeReader{}
 			releaseManager = biinstall.NewReleaseManager(logger)
@@ -928,6 +993,21 @@ cloud_provider:
 					Expect(err).ToNot(HaveOccurred())
 				})
 
+				Context("when the cpi supports resizing disks", func() {
+					It("resizes the disk without migrating its content", func() {
+						expectDeployWithDiskResize()
+
+						err := newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
+						Expect(err).ToNot(HaveOccurred())
+
+						diskRecord, found, err := diskRepo.FindCurrent()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(found).To(BeTrue())
+						Expect(diskRecord.CID).To(Equal("fake-disk-cid-1"))
+						Expect(diskRecord.Size).To(Equal(2048))
+					})
+				})
+
 				Context("when current VM has been deleted manually (outside of bosh)", func() {
 					It("migrates the disk content, but does not shutdown the old VM", func() {
 						expectDeployWithDiskMigrationMissingVM()
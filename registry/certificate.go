@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type CertificateProvider interface {
+	Certificate(host string) (string, string, error)
+}
+
+type certificateProvider struct {
+	fs        boshsys.FileSystem
+	storePath string
+}
+
+// NewCertificateProvider reuses the certificate persisted with the settings in storePath,
+// so that VMs of an interrupted create-env keep trusting the registry, and generates one otherwise.
+// Generated certificates are persisted by the server when it is started with persistence.
+func NewCertificateProvider(fs boshsys.FileSystem, storePath string) CertificateProvider {
+	return certificateProvider{fs: fs, storePath: storePath}
+}
+
+func (p certificateProvider) Certificate(host string) (string, string, error) {
+	file, err := readRegistryFile(p.fs, p.storePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	if file.Certificate != "" && file.PrivateKey != "" {
+		return file.Certificate, file.PrivateKey, nil
+	}
+
+	return GenerateCertificate(host)
+}
+
+// GenerateCertificate creates a self-signed certificate and its private key for the registry host.
+// The certificate is its own CA.
+func GenerateCertificate(host string) (string, string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Generating private key")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Generating serial number")
+	}
+
+	now := time.Now()
+
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{"Cloud Foundry"}, CommonName: host},
+		NotBefore:             now,
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	certificateRaw, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Generating certificate")
+	}
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateRaw})
+	key := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	return string(certificate), string(key), nil
+}
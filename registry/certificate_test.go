@@ -0,0 +1,48 @@
+package registry_test
+
+import (
+	"crypto/tls"
+
+	. "github.com/cloudfoundry/bosh-cli/registry"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CertificateProvider", func() {
+	var (
+		fs       *fakesys.FakeFileSystem
+		provider CertificateProvider
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		provider = NewCertificateProvider(fs, "/path/to/registry.json")
+	})
+
+	It("generates a certificate when none has been persisted", func() {
+		certificate, privateKey, err := provider.Certificate("127.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists("/path/to/registry.json")).To(BeFalse())
+	})
+
+	It("returns the certificate persisted with the registry settings", func() {
+		fs.WriteFileString("/path/to/registry.json", `{"certificate":"fake-certificate","private_key":"fake-private-key","settings":{}}`)
+
+		certificate, privateKey, err := provider.Certificate("127.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certificate).To(Equal("fake-certificate"))
+		Expect(privateKey).To(Equal("fake-private-key"))
+	})
+
+	It("returns an error when the registry settings cannot be read", func() {
+		fs.WriteFileString("/path/to/registry.json", "invalid-json")
+
+		_, _, err := provider.Certificate("127.0.0.1")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling registry file '/path/to/registry.json'"))
+	})
+})
@@ -108,7 +108,13 @@ func (h *instanceHandler) HandlePut(instanceID string, w http.ResponseWriter, re
 
 	h.logger.Debug(h.logTag, "Saving settings to registry for instance %s: %s", instanceID, string(reqBody))
 
-	isUpdated := h.registry.Save(instanceID, reqBody)
+	isUpdated, err := h.registry.Save(instanceID, reqBody)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to save settings for instance %s: %s", instanceID, err.Error())
+		h.handleServerError(w)
+		return
+	}
+
 	if isUpdated {
 		w.WriteHeader(http.StatusOK)
 		return
@@ -124,7 +130,11 @@ func (h *instanceHandler) HandleDelete(instanceID string, w http.ResponseWriter,
 	}
 
 	h.logger.Debug(h.logTag, "Deleting settings for instance %s", instanceID)
-	h.registry.Delete(instanceID)
+	err := h.registry.Delete(instanceID)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to delete settings for instance %s: %s", instanceID, err.Error())
+		h.handleServerError(w)
+	}
 }
 
 func (h *instanceHandler) handleUnauthorized(w http.ResponseWriter) {
@@ -176,3 +186,16 @@ func (h *instanceHandler) handleBadRequest(w http.ResponseWriter) {
 		h.logger.Warn(h.logTag, "Failed to write response: %s", err.Error())
 	}
 }
+
+func (h *instanceHandler) handleServerError(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusInternalServerError)
+	settingsJSON, err := json.Marshal(SettingsResponse{Status: "error"})
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to marshal 'server error' settings response %s", err.Error())
+		return
+	}
+	_, err = w.Write(settingsJSON)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to write response: %s", err.Error())
+	}
+}
@@ -68,15 +68,27 @@ func (m *MockServerManager) EXPECT() *MockServerManagerMockRecorder {
 	return m.recorder
 }
 
+// Cleanup mocks base method
+func (m *MockServerManager) Cleanup() error {
+	ret := m.ctrl.Call(m, "Cleanup")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cleanup indicates an expected call of Cleanup
+func (mr *MockServerManagerMockRecorder) Cleanup() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cleanup", reflect.TypeOf((*MockServerManager)(nil).Cleanup))
+}
+
 // Start mocks base method
-func (m *MockServerManager) Start(arg0, arg1, arg2 string, arg3 int) (registry.Server, error) {
-	ret := m.ctrl.Call(m, "Start", arg0, arg1, arg2, arg3)
+func (m *MockServerManager) Start(arg0 registry.ServerConfig) (registry.Server, error) {
+	ret := m.ctrl.Call(m, "Start", arg0)
 	ret0, _ := ret[0].(registry.Server)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Start indicates an expected call of Start
-func (mr *MockServerManagerMockRecorder) Start(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerManager)(nil).Start), arg0, arg1, arg2, arg3)
+func (mr *MockServerManagerMockRecorder) Start(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerManager)(nil).Start), arg0)
 }
@@ -1,22 +1,31 @@
 package registry
 
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
 type registry map[string][]byte
 
 type Registry interface {
-	Save(string, []byte) bool
+	Save(string, []byte) (bool, error)
 	Get(string) ([]byte, bool)
-	Delete(string)
+	Delete(string) error
 }
 
 func NewRegistry() Registry {
 	return make(registry)
 }
 
-func (r registry) Save(key string, value []byte) bool {
+func (r registry) Save(key string, value []byte) (bool, error) {
 	_, exists := r[key]
 	r[key] = value
 
-	return exists
+	return exists, nil
 }
 
 func (r registry) Get(key string) ([]byte, bool) {
@@ -25,6 +34,133 @@ func (r registry) Get(key string) ([]byte, bool) {
 	return value, exists
 }
 
-func (r registry) Delete(key string) {
+func (r registry) Delete(key string) error {
 	delete(r, key)
+	return nil
+}
+
+// fileRegistry writes all settings and the certificate the registry is served with
+// to a file whenever they change and is loaded from it when the registry is started again
+type fileRegistry struct {
+	fs       boshsys.FileSystem
+	path     string
+	registry registry
+	lock     sync.Mutex
+
+	certificate string
+	privateKey  string
+}
+
+type registryFile struct {
+	Certificate string            `json:"certificate,omitempty"`
+	PrivateKey  string            `json:"private_key,omitempty"`
+	Settings    map[string]string `json:"settings"`
+}
+
+// NewFileRegistry loads the settings persisted at path and writes the given
+// certificate and private key with them right away when they are set
+func NewFileRegistry(fs boshsys.FileSystem, path string, certificate string, privateKey string) (Registry, error) {
+	r := &fileRegistry{
+		fs:       fs,
+		path:     path,
+		registry: make(registry),
+
+		certificate: certificate,
+		privateKey:  privateKey,
+	}
+
+	file, err := readRegistryFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range file.Settings {
+		r.registry[key] = []byte(value)
+	}
+
+	if certificate != "" {
+		err = r.write()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *fileRegistry) Save(key string, value []byte) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	exists, _ := r.registry.Save(key, value)
+
+	return exists, r.write()
+}
+
+func (r *fileRegistry) Get(key string) ([]byte, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.registry.Get(key)
+}
+
+func (r *fileRegistry) Delete(key string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_ = r.registry.Delete(key)
+
+	return r.write()
+}
+
+func (r *fileRegistry) write() error {
+	file := registryFile{
+		Certificate: r.certificate,
+		PrivateKey:  r.privateKey,
+		Settings:    map[string]string{},
+	}
+
+	for key, value := range r.registry {
+		file.Settings[key] = string(value)
+	}
+
+	contents, err := json.Marshal(file)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling registry settings")
+	}
+
+	// settings contain agent credentials and must only be readable by the current user
+	f, err := r.fs.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening registry file '%s'", r.path)
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing registry file '%s'", r.path)
+	}
+
+	return nil
+}
+
+func readRegistryFile(fs boshsys.FileSystem, path string) (registryFile, error) {
+	var file registryFile
+
+	if !fs.FileExists(path) {
+		return file, nil
+	}
+
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Reading registry file '%s'", path)
+	}
+
+	err = json.Unmarshal(contents, &file)
+	if err != nil {
+		return file, bosherr.WrapErrorf(err, "Unmarshalling registry file '%s'", path)
+	}
+
+	return file, nil
 }
@@ -0,0 +1,101 @@
+package registry_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/bosh-cli/registry"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileRegistry", func() {
+	var (
+		fs           boshsys.FileSystem
+		tmpDir       string
+		registryPath string
+	)
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "bosh-registry")
+		Expect(err).ToNot(HaveOccurred())
+
+		registryPath = filepath.Join(tmpDir, "registry.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("starts empty when the file does not exist", func() {
+		registry, err := NewFileRegistry(fs, registryPath, "", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found := registry.Get("fake-instance-id")
+		Expect(found).To(BeFalse())
+		Expect(fs.FileExists(registryPath)).To(BeFalse())
+	})
+
+	It("loads settings saved by a previous registry", func() {
+		registry, err := NewFileRegistry(fs, registryPath, "", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		exists, err := registry.Save("fake-instance-id", []byte("fake-settings"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		exists, err = registry.Save("fake-other-instance-id", []byte("fake-other-settings"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		err = registry.Delete("fake-other-instance-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		reloaded, err := NewFileRegistry(fs, registryPath, "", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		settings, found := reloaded.Get("fake-instance-id")
+		Expect(found).To(BeTrue())
+		Expect(settings).To(Equal([]byte("fake-settings")))
+
+		_, found = reloaded.Get("fake-other-instance-id")
+		Expect(found).To(BeFalse())
+	})
+
+	It("only allows the current user to read the settings", func() {
+		registry, err := NewFileRegistry(fs, registryPath, "", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = registry.Save("fake-instance-id", []byte("fake-settings"))
+		Expect(err).ToNot(HaveOccurred())
+
+		info, err := os.Stat(registryPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("persists the certificate with the settings", func() {
+		_, err := NewFileRegistry(fs, registryPath, "fake-certificate", "fake-private-key")
+		Expect(err).ToNot(HaveOccurred())
+
+		certificate, privateKey, err := NewCertificateProvider(fs, registryPath).Certificate("127.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certificate).To(Equal("fake-certificate"))
+		Expect(privateKey).To(Equal("fake-private-key"))
+	})
+
+	It("returns an error when the file cannot be parsed", func() {
+		err := fs.WriteFileString(registryPath, "invalid-json")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewFileRegistry(fs, registryPath, "", "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling registry file"))
+	})
+})
@@ -1,40 +1,71 @@
 package registry
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+type ServerConfig struct {
+	Username string
+	Password string
+	Host     string
+	Port     int
+
+	// Certificate and PrivateKey make the server use TLS when set
+	Certificate string
+	PrivateKey  string
+
+	// Persist keeps instance settings and the certificate in the store file of the server manager
+	Persist bool
+}
+
 type ServerManager interface {
-	Start(string, string, string, int) (Server, error)
+	Start(ServerConfig) (Server, error)
+	Cleanup() error
 }
 
 type serverManager struct {
-	logger boshlog.Logger
-	logTag string
+	fs        boshsys.FileSystem
+	storePath string
+	logger    boshlog.Logger
+	logTag    string
 }
 
-func NewServerManager(logger boshlog.Logger) ServerManager {
+func NewServerManager(fs boshsys.FileSystem, storePath string, logger boshlog.Logger) ServerManager {
 	return &serverManager{
-		logger: logger,
-		logTag: "registryServer",
+		fs:        fs,
+		storePath: storePath,
+		logger:    logger,
+		logTag:    "registryServer",
 	}
 }
 
 // Create starts a new server on a goroutine and returns it
 // The returned error is only for starting. Error while running is logged.
-func (s *serverManager) Start(username string, password string, host string, port int) (Server, error) {
+func (s *serverManager) Start(config ServerConfig) (Server, error) {
+	registry := NewRegistry()
+
+	if config.Persist {
+		var err error
+		registry, err = NewFileRegistry(s.fs, s.storePath, config.Certificate, config.PrivateKey)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Loading registry settings")
+		}
+	}
+
 	startedCh := make(chan error)
 	server := &server{
 		logger: s.logger,
 		logTag: "registryServer",
 	}
 	go func() {
-		err := server.start(username, password, host, port, startedCh)
+		err := server.start(config, registry, startedCh)
 		if err != nil {
 			s.logger.Debug(s.logTag, "Registry error occurred: %s", err.Error())
 		}
@@ -50,6 +81,16 @@ func (s *serverManager) Start(username string, password string, host string, por
 	return server, err
 }
 
+// Cleanup deletes settings persisted by previous servers, which contain agent credentials
+func (s *serverManager) Cleanup() error {
+	err := s.fs.RemoveAll(s.storePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting registry file '%s'", s.storePath)
+	}
+
+	return nil
+}
+
 type Server interface {
 	Stop() error
 }
@@ -67,23 +108,39 @@ func NewServer(logger boshlog.Logger) Server {
 	}
 }
 
-func (s *server) start(username string, password string, host string, port int, readyErrCh chan error) error {
-	s.logger.Debug(s.logTag, "Starting registry server at %s:%d", host, port)
+func (s *server) start(config ServerConfig, registry Registry, readyErrCh chan error) error {
+	s.logger.Debug(s.logTag, "Starting registry server at %s:%d", config.Host, config.Port)
+
+	var tlsConfig *tls.Config
+
+	if config.Certificate != "" {
+		certificate, err := tls.X509KeyPair([]byte(config.Certificate), []byte(config.PrivateKey))
+		if err != nil {
+			readyErrCh <- bosherr.WrapError(err, "Loading registry certificate")
+			return nil
+		}
+
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+
 	var err error
-	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", config.Host, config.Port))
 	if err != nil {
 		readyErrCh <- bosherr.WrapError(err, "Starting registry listener")
 		return nil
 	}
 
+	if tlsConfig != nil {
+		s.listener = tls.NewListener(s.listener, tlsConfig)
+	}
+
 	readyErrCh <- nil
 
 	httpServer := http.Server{}
 	mux := http.NewServeMux()
 	httpServer.Handler = mux
 
-	registry := NewRegistry()
-	instanceHandler := newInstanceHandler(username, password, registry, s.logger)
+	instanceHandler := newInstanceHandler(config.Username, config.Password, registry, s.logger)
 	mux.HandleFunc("/instances/", instanceHandler.HandleFunc)
 
 	return httpServer.Serve(s.listener)
@@ -0,0 +1,46 @@
+package ssh
+
+import (
+	"net"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+type Agent interface {
+	Signers() ([]ssh.Signer, error)
+	Close() error
+}
+
+type agent struct {
+	client sshagent.Agent
+	conn   net.Conn
+}
+
+// NewAgent connects to the ssh-agent listening on the given socket, usually SSH_AUTH_SOCK
+func NewAgent(socketPath string) (Agent, error) {
+	if socketPath == "" {
+		return nil, bosherr.Error("Connecting to ssh-agent: SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Connecting to ssh-agent at '%s'", socketPath)
+	}
+
+	return agent{client: sshagent.NewClient(conn), conn: conn}, nil
+}
+
+func (a agent) Signers() ([]ssh.Signer, error) {
+	signers, err := a.client.Signers()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing ssh-agent keys")
+	}
+
+	return signers, nil
+}
+
+func (a agent) Close() error {
+	return a.conn.Close()
+}
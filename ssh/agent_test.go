@@ -0,0 +1,94 @@
+package ssh_test
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+
+	. "github.com/cloudfoundry/bosh-cli/ssh"
+)
+
+var _ = Describe("Agent", func() {
+	var (
+		tmpDir     string
+		socketPath string
+		listener   net.Listener
+		signer     ssh.Signer
+	)
+
+	BeforeEach(func() {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		signer, err = ssh.NewSignerFromKey(privateKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		keyring := sshagent.NewKeyring()
+		err = keyring.Add(sshagent.AddedKey{PrivateKey: privateKey, Comment: "fake-comment"})
+		Expect(err).ToNot(HaveOccurred())
+
+		tmpDir, err = ioutil.TempDir("", "bosh-ssh-agent")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath = filepath.Join(tmpDir, "agent.sock")
+
+		listener, err = net.Listen("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			defer GinkgoRecover()
+
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					sshagent.ServeAgent(keyring, conn)
+				}()
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		listener.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("signs with keys held by the agent", func() {
+		agent, err := NewAgent(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		defer agent.Close()
+
+		signers, err := agent.Signers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signers).To(HaveLen(1))
+		Expect(signers[0].PublicKey().Marshal()).To(Equal(signer.PublicKey().Marshal()))
+
+		signature, err := signers[0].Sign(rand.Reader, []byte("fake-data"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signer.PublicKey().Verify([]byte("fake-data"), signature)).To(Succeed())
+	})
+
+	It("returns an error when SSH_AUTH_SOCK is not set", func() {
+		_, err := NewAgent("")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Connecting to ssh-agent: SSH_AUTH_SOCK is not set"))
+	})
+
+	It("returns an error when the agent is not listening", func() {
+		_, err := NewAgent(filepath.Join(tmpDir, "missing.sock"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Connecting to ssh-agent at"))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
+	"os"
 	"strings"
 	"time"
 
@@ -32,43 +33,44 @@ type ClientImpl struct {
 	timeService              clock.Clock
 	startDialDelay           time.Duration
 
-	client *ssh.Client
+	client      *ssh.Client
+	jumpClients []*ssh.Client
 
 	logTag string
 	logger boshlog.Logger
 }
 
 func (s *ClientImpl) Start() error {
-	authMethods := []ssh.AuthMethod{}
+	var agentSigners []ssh.Signer
 
-	if s.opts.PrivateKey != "" {
-		signer, err := ssh.ParsePrivateKey([]byte(s.opts.PrivateKey))
+	if s.opts.UseAgent {
+		agent, err := NewAgent(os.Getenv("SSH_AUTH_SOCK"))
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Parsing private key '%s'", s.opts.PrivateKey)
+			return err
 		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	}
 
-	if s.opts.Password != "" {
-		s.logger.Debug(s.logTag, "Adding password auth method to ssh tunnel config")
+		// keys are only needed while authenticating
+		defer agent.Close()
 
-		keyboardInteractiveChallenge := func(_, _ string, questions []string, _ []bool) ([]string, error) {
-			if len(questions) == 0 {
-				return []string{}, nil
-			}
-			return []string{s.opts.Password}, nil
+		agentSigners, err = agent.Signers()
+		if err != nil {
+			return err
 		}
-		authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractiveChallenge))
-		authMethods = append(authMethods, ssh.Password(s.opts.Password))
 	}
 
-	sshConfig := &ssh.ClientConfig{
-		User:            s.opts.User,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
+	hops := append([]ClientOpts{}, s.opts.JumpHosts...)
+	hops = append(hops, s.opts)
 
-	s.logger.Debug(s.logTag, "Dialing remote server at %s:%d", s.opts.Host, s.opts.Port)
+	sshConfigs := make([]*ssh.ClientConfig, len(hops))
+
+	for i, hop := range hops {
+		var err error
+
+		sshConfigs[i], err = s.clientConfig(hop, agentSigners)
+		if err != nil {
+			return err
+		}
+	}
 
 	retryStrategy := &ClientConnectRetryStrategy{
 		TimeService:              s.timeService,
@@ -76,13 +78,13 @@ func (s *ClientImpl) Start() error {
 		AuthFailureTimeout:       s.authFailureTimeout,
 	}
 
-	var err error
-
 	for i := 0; ; i++ {
 		s.logger.Debug(s.logTag, "Making attempt #%d", i)
 
-		s.client, err = s.newClient("tcp", net.JoinHostPort(s.opts.Host, fmt.Sprintf("%d", s.opts.Port)), sshConfig)
+		clients, err := s.connect(hops, sshConfigs)
 		if err == nil {
+			s.client = clients[len(clients)-1]
+			s.jumpClients = clients[:len(clients)-1]
 			break
 		}
 
@@ -107,10 +109,112 @@ func (s *ClientImpl) Listen(n, addr string) (net.Listener, error) {
 }
 
 func (s *ClientImpl) Stop() error {
+	var err error
+
 	if s.client != nil {
-		return s.client.Close()
+		err = s.client.Close()
 	}
-	return nil
+
+	for i := len(s.jumpClients) - 1; i >= 0; i-- {
+		if closeErr := s.jumpClients[i].Close(); closeErr != nil {
+			s.logger.Warn(s.logTag, "Failed to close jump host connection: %s", closeErr.Error())
+		}
+	}
+
+	return err
+}
+
+func (s *ClientImpl) clientConfig(opts ClientOpts, agentSigners []ssh.Signer) (*ssh.ClientConfig, error) {
+	authMethods := []ssh.AuthMethod{}
+
+	signers := []ssh.Signer{}
+
+	if opts.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(opts.PrivateKey))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing private key '%s'", opts.PrivateKey)
+		}
+		signers = append(signers, signer)
+	}
+
+	signers = append(signers, agentSigners...)
+
+	if len(signers) > 0 {
+		authMethods = append(authMethods, ssh.PublicKeys(signers...))
+	}
+
+	if opts.Password != "" {
+		s.logger.Debug(s.logTag, "Adding password auth method to ssh tunnel config")
+
+		password := opts.Password
+		keyboardInteractiveChallenge := func(_, _ string, questions []string, _ []bool) ([]string, error) {
+			if len(questions) == 0 {
+				return []string{}, nil
+			}
+			return []string{password}, nil
+		}
+		authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractiveChallenge))
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+
+	if opts.HostKeyFingerprint != "" {
+		hostKeyCallback = NewFingerprintCallback(opts.HostKeyFingerprint)
+	} else if s.opts.KnownHosts != "" {
+		var err error
+
+		hostKeyCallback, err = NewKnownHostsCallback(s.opts.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            opts.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+// connect dials the first hop directly and every following hop through the previous one
+func (s *ClientImpl) connect(hops []ClientOpts, sshConfigs []*ssh.ClientConfig) ([]*ssh.Client, error) {
+	var clients []*ssh.Client
+
+	closeClients := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			_ = clients[i].Close()
+		}
+	}
+
+	for i, hop := range hops {
+		addr := net.JoinHostPort(hop.Host, fmt.Sprintf("%d", hop.Port))
+
+		s.logger.Debug(s.logTag, "Dialing remote server at %s", addr)
+
+		var client *ssh.Client
+		var err error
+
+		if i == 0 {
+			client, err = s.newClient("tcp", addr, sshConfigs[i])
+		} else {
+			client, err = s.newClientThrough(clients[i-1], "tcp", addr, sshConfigs[i])
+		}
+
+		if err != nil {
+			closeClients()
+
+			if i < len(hops)-1 {
+				return nil, bosherr.WrapErrorf(err, "Connecting to jump host '%s'", addr)
+			}
+
+			return nil, err
+		}
+
+		clients = append(clients, client)
+	}
+
+	return clients, nil
 }
 
 func (s *ClientImpl) newClient(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
@@ -126,8 +230,22 @@ func (s *ClientImpl) newClient(network, addr string, config *ssh.ClientConfig) (
 		return nil, err
 	}
 
+	return newClientFromConn(conn, addr, config)
+}
+
+func (s *ClientImpl) newClientThrough(jumpClient *ssh.Client, network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := jumpClient.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClientFromConn(conn, addr, config)
+}
+
+func newClientFromConn(conn net.Conn, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
 	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
 	if err != nil {
+		_ = conn.Close()
 		return nil, err
 	}
 
@@ -157,6 +275,10 @@ func (s *ClientConnectRetryStrategy) IsRetryable(err error) bool {
 		return false
 	}
 
+	if strings.Contains(err.Error(), hostKeyVerificationFailed) {
+		return false
+	}
+
 	if strings.Contains(err.Error(), "unable to authenticate") {
 		return now.Before(s.authStartTime.Add(s.AuthFailureTimeout))
 	}
@@ -15,6 +15,19 @@ type ClientOpts struct {
 	Password   string
 	PrivateKey string
 
+	// UseAgent authenticates to the server and all jump hosts
+	// with keys of the ssh-agent listening on SSH_AUTH_SOCK
+	UseAgent bool
+
+	// KnownHosts (contents of a known_hosts file) verifies host keys of the server and all jump hosts
+	// unless a HostKeyFingerprint is pinned for a host; any host key is accepted when neither is set
+	KnownHosts         string
+	HostKeyFingerprint string
+
+	// JumpHosts are connected through in order to reach the server (like ssh -J).
+	// Only their address, credentials and HostKeyFingerprint are used.
+	JumpHosts []ClientOpts
+
 	DisableSOCKS bool
 }
 
@@ -79,6 +79,10 @@ var _ = Describe("ClientConnectRetryStrategy", func() {
 			Expect(strategy.IsRetryable(errors.New("no common algorithms"))).To(BeFalse())
 		})
 
+		It("host key verification error fails immediately", func() {
+			Expect(strategy.IsRetryable(errors.New("ssh: handshake failed: Host key verification failed for 'bastion:22'"))).To(BeFalse())
+		})
+
 		It("all other errors fail after the connection refused timeout", func() {
 			Expect(strategy.IsRetryable(errors.New("another error"))).To(BeTrue())
 
@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyVerificationFailed prefixes all host key errors
+// so that connecting is not retried after a mismatch
+const hostKeyVerificationFailed = "Host key verification failed"
+
+// NewKnownHostsCallback verifies host keys against entries in OpenSSH known_hosts format
+func NewKnownHostsCallback(contents string) (ssh.HostKeyCallback, error) {
+	// knownhosts only reads known_hosts files
+	file, err := ioutil.TempFile("", "bosh-known-hosts")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating known_hosts file")
+	}
+
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	_, err = file.WriteString(contents)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing known_hosts file")
+	}
+
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing known_hosts")
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(strings.ToLower(hostname), remote, key)
+		if err == nil {
+			return nil
+		}
+
+		switch typedErr := err.(type) {
+		case *knownhosts.RevokedError:
+			return bosherr.Errorf("%s for '%s': host key %s is revoked", hostKeyVerificationFailed, hostname, ssh.FingerprintSHA256(key))
+
+		case *knownhosts.KeyError:
+			if len(typedErr.Want) == 0 {
+				return bosherr.Errorf("%s for '%s': host is not present in known_hosts", hostKeyVerificationFailed, hostname)
+			}
+
+			var expectedKeys []string
+			for _, knownKey := range typedErr.Want {
+				expectedKeys = append(expectedKeys, ssh.FingerprintSHA256(knownKey.Key))
+			}
+
+			return bosherr.Errorf(
+				"%s for '%s': host key %s does not match known_hosts (expected %s)",
+				hostKeyVerificationFailed, hostname, ssh.FingerprintSHA256(key), strings.Join(expectedKeys, ", "))
+
+		default:
+			return bosherr.WrapErrorf(err, "%s for '%s'", hostKeyVerificationFailed, hostname)
+		}
+	}, nil
+}
+
+// NewFingerprintCallback verifies that the host key has the given
+// SHA256 fingerprint (e.g. 'SHA256:...') or MD5 fingerprint (e.g. 'MD5:aa:bb:...')
+func NewFingerprintCallback(fingerprint string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		actual := ssh.FingerprintSHA256(key)
+
+		if strings.HasPrefix(fingerprint, "MD5:") {
+			actual = "MD5:" + ssh.FingerprintLegacyMD5(key)
+		}
+
+		if actual != fingerprint {
+			return bosherr.Errorf(
+				"%s for '%s': host key fingerprint %s does not match expected fingerprint %s",
+				hostKeyVerificationFailed, hostname, actual, fingerprint)
+		}
+
+		return nil
+	}
+}
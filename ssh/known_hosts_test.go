@@ -0,0 +1,137 @@
+package ssh_test
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+
+	. "github.com/cloudfoundry/bosh-cli/ssh"
+)
+
+var _ = Describe("NewKnownHostsCallback", func() {
+	var (
+		hostKey  ssh.PublicKey
+		otherKey ssh.PublicKey
+	)
+
+	newPublicKey := func() ssh.PublicKey {
+		publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		sshPublicKey, err := ssh.NewPublicKey(publicKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		return sshPublicKey
+	}
+
+	knownHostsLine := func(hosts string, key ssh.PublicKey) string {
+		return fmt.Sprintf("%s %s", hosts, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))))
+	}
+
+	verify := func(knownHosts string, hostname string, key ssh.PublicKey) error {
+		callback, err := NewKnownHostsCallback(knownHosts)
+		Expect(err).ToNot(HaveOccurred())
+
+		return callback(hostname, &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}, key)
+	}
+
+	BeforeEach(func() {
+		hostKey = newPublicKey()
+		otherKey = newPublicKey()
+	})
+
+	It("accepts a known host key", func() {
+		knownHosts := "# comment\n" + knownHostsLine("other-host,bastion.example.com", hostKey) + "\n"
+
+		Expect(verify(knownHosts, "bastion.example.com:22", hostKey)).To(Succeed())
+		Expect(verify(knownHosts, "BASTION.example.com:22", hostKey)).To(Succeed())
+	})
+
+	It("matches hosts on non-standard ports in bracketed form", func() {
+		knownHosts := knownHostsLine("[10.0.0.5]:2222", hostKey)
+
+		Expect(verify(knownHosts, "10.0.0.5:2222", hostKey)).To(Succeed())
+		Expect(verify(knownHosts, "10.0.0.5:22", hostKey)).ToNot(Succeed())
+	})
+
+	It("matches hashed host names", func() {
+		salt := []byte("fake-salt-of-20-bytes")
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte("10.0.0.5"))
+
+		hashedHost := fmt.Sprintf("|1|%s|%s",
+			base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		Expect(verify(knownHostsLine(hashedHost, hostKey), "10.0.0.5:22", hostKey)).To(Succeed())
+		Expect(verify(knownHostsLine(hashedHost, hostKey), "10.0.0.6:22", hostKey)).ToNot(Succeed())
+	})
+
+	It("matches wildcards unless the host is negated", func() {
+		knownHosts := knownHostsLine("*.example.com,!internal.example.com", hostKey)
+
+		Expect(verify(knownHosts, "bastion.example.com:22", hostKey)).To(Succeed())
+
+		err := verify(knownHosts, "internal.example.com:22", hostKey)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Host key verification failed for 'internal.example.com:22': host is not present in known_hosts"))
+	})
+
+	It("returns an error when the host key does not match", func() {
+		err := verify(knownHostsLine("bastion.example.com", hostKey), "bastion.example.com:22", otherKey)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(
+			"Host key verification failed for 'bastion.example.com:22': host key %s does not match known_hosts (expected %s)",
+			ssh.FingerprintSHA256(otherKey), ssh.FingerprintSHA256(hostKey))))
+	})
+
+	It("returns an error when the host key is revoked", func() {
+		knownHosts := "@revoked " + knownHostsLine("*", hostKey) + "\n" + knownHostsLine("bastion.example.com", hostKey)
+
+		err := verify(knownHosts, "bastion.example.com:22", hostKey)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("is revoked"))
+	})
+
+	It("returns an error when known_hosts cannot be parsed", func() {
+		_, err := NewKnownHostsCallback("bastion.example.com ssh-ed25519 invalid-key")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing known_hosts"))
+	})
+})
+
+var _ = Describe("NewFingerprintCallback", func() {
+	var hostKey ssh.PublicKey
+
+	BeforeEach(func() {
+		publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		hostKey, err = ssh.NewPublicKey(publicKey)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("accepts a host key with the pinned SHA256 fingerprint", func() {
+		Expect(NewFingerprintCallback(ssh.FingerprintSHA256(hostKey))("bastion:22", nil, hostKey)).To(Succeed())
+	})
+
+	It("accepts a host key with the pinned MD5 fingerprint", func() {
+		Expect(NewFingerprintCallback("MD5:"+ssh.FingerprintLegacyMD5(hostKey))("bastion:22", nil, hostKey)).To(Succeed())
+	})
+
+	It("returns an error when the fingerprint does not match", func() {
+		err := NewFingerprintCallback("SHA256:fake-fingerprint")("bastion:22", nil, hostKey)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(
+			"Host key verification failed for 'bastion:22': host key fingerprint %s does not match expected fingerprint SHA256:fake-fingerprint",
+			ssh.FingerprintSHA256(hostKey))))
+	})
+})
@@ -3,6 +3,7 @@ package job
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -26,20 +27,33 @@ type DependencyCompiler interface {
 
 type dependencyCompiler struct {
 	packageCompiler bistatepkg.Compiler
+	parallel        int
 
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, logger boshlog.Logger) DependencyCompiler {
+func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, parallel int, logger boshlog.Logger) DependencyCompiler {
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	return &dependencyCompiler{
 		packageCompiler: packageCompiler,
+		parallel:        parallel,
 
 		logTag: "dependencyCompiler",
 		logger: logger,
 	}
 }
 
+type packageCompilation struct {
+	record            bistatepkg.CompiledPackageRecord
+	isAlreadyCompiled bool
+	err               error
+	done              chan struct{}
+}
+
 // Compile resolves and compiles all transitive dependencies of multiple release jobs
 func (c *dependencyCompiler) Compile(jobs []bireljob.Job, stage biui.Stage) ([]CompiledPackageRef, error) {
 	compileOrderReleasePackages, err := c.resolveJobCompilationDependencies(jobs)
@@ -104,39 +118,118 @@ func (c *dependencyCompiler) resolvePackageDependencies(releasePackage birelpkg.
 	}
 }
 
-// compilePackages compiles the specified packages, in the order specified, uploads them to the Blobstore, and returns the blob references
+// compilePackages compiles the specified packages, uploads them to the Blobstore, and returns the blob references.
+// Up to c.parallel packages are compiled at the same time once all of their dependencies are compiled,
+// stages are reported in the order specified.
 func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
+	compilations := c.startCompilations(requiredPackages)
+
 	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
 
 	for _, pkg := range requiredPackages {
 		stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		compilation := compilations.find(c.pkgKey(pkg))
 
 		err := stage.Perform(stepName, func() error {
-			compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
-			if err != nil {
-				return err
+			<-compilation.done
+
+			if compilation.err != nil {
+				return compilation.err
 			}
 
 			packageRef := CompiledPackageRef{
 				Name:        pkg.Name(),
 				Version:     pkg.Fingerprint(),
-				BlobstoreID: compiledPackageRecord.BlobID,
-				SHA1:        compiledPackageRecord.BlobSHA1,
+				BlobstoreID: compilation.record.BlobID,
+				SHA1:        compilation.record.BlobSHA1,
 			}
 			packageRefs = append(packageRefs, packageRef)
 
-			if isAlreadyCompiled {
+			if compilation.isAlreadyCompiled {
 				return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
 			}
 
 			return nil
 		})
 		if err != nil {
+			compilations.abort()
 			return nil, err
 		}
 	}
 
+	compilations.wait()
+
 	return packageRefs, nil
 }
 
+type packageCompilations struct {
+	byKey   map[string]*packageCompilation
+	aborted chan struct{}
+	once    sync.Once
+	group   sync.WaitGroup
+}
+
+func (p *packageCompilations) find(pkgKey string) *packageCompilation { return p.byKey[pkgKey] }
+
+// abort prevents compilations that have not started yet from starting and waits for the running ones
+func (p *packageCompilations) abort() {
+	p.once.Do(func() { close(p.aborted) })
+	p.wait()
+}
+
+func (p *packageCompilations) wait() { p.group.Wait() }
+
+func (c *dependencyCompiler) startCompilations(requiredPackages []birelpkg.Compilable) *packageCompilations {
+	compilations := &packageCompilations{
+		byKey:   map[string]*packageCompilation{},
+		aborted: make(chan struct{}),
+	}
+
+	for _, pkg := range requiredPackages {
+		compilations.byKey[c.pkgKey(pkg)] = &packageCompilation{done: make(chan struct{})}
+	}
+
+	slots := make(chan struct{}, c.parallel)
+
+	for _, pkg := range requiredPackages {
+		compilations.group.Add(1)
+
+		go func(pkg birelpkg.Compilable, compilation *packageCompilation) {
+			defer compilations.group.Done()
+			defer close(compilation.done)
+
+			for _, dependency := range pkg.Deps() {
+				if dependencyCompilation := compilations.find(c.pkgKey(dependency)); dependencyCompilation != nil {
+					<-dependencyCompilation.done
+					if dependencyCompilation.err != nil {
+						compilation.err = bosherr.Errorf("Dependency '%s' of package '%s' failed to compile", dependency.Name(), pkg.Name())
+						return
+					}
+				}
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-compilations.aborted:
+				compilation.err = bosherr.Errorf("Compilation of package '%s' was aborted", pkg.Name())
+				return
+			}
+			defer func() { <-slots }()
+
+			select {
+			case <-compilations.aborted:
+				compilation.err = bosherr.Errorf("Compilation of package '%s' was aborted", pkg.Name())
+				return
+			default:
+			}
+
+			c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+			compilation.record, compilation.isAlreadyCompiled, compilation.err = c.packageCompiler.Compile(pkg)
+		}(pkg, compilations.byKey[c.pkgKey(pkg)])
+	}
+
+	return compilations
+}
+
 func (c *dependencyCompiler) pkgKey(pkg birelpkg.Compilable) string { return pkg.Name() }
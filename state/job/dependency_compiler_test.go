@@ -1,6 +1,10 @@
 package job_test
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -47,7 +51,7 @@ var _ = Describe("DependencyCompiler", func() {
 		mockPackageCompiler = mock_state_package.NewMockCompiler(mockCtrl)
 
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, logger)
+		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, 1, logger)
 
 		stage = fakeui.NewFakeStage()
 
@@ -215,4 +219,80 @@ var _ = Describe("DependencyCompiler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when compiling packages in parallel", func() {
+		var (
+			leafPkgs []*boshrelpkg.Package
+			topPkg   *boshrelpkg.Package
+
+			lock       sync.Mutex
+			running    int
+			maxRunning int
+			compiled   []string
+		)
+
+		compile := func(pkg boshrelpkg.Compilable) {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			lock.Lock()
+			running--
+			compiled = append(compiled, pkg.Name())
+			lock.Unlock()
+		}
+
+		BeforeEach(func() {
+			dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, 2, logger)
+
+			running, maxRunning, compiled = 0, 0, nil
+
+			leafPkgs = []*boshrelpkg.Package{
+				newPkg("leaf1-name", "leaf1-fp", nil),
+				newPkg("leaf2-name", "leaf2-fp", nil),
+				newPkg("leaf3-name", "leaf3-fp", nil),
+			}
+			topPkg = newPkg("top-name", "top-fp", []string{"leaf1-name", "leaf2-name", "leaf3-name"})
+			topPkg.AttachDependencies(leafPkgs)
+
+			job = boshreljob.NewJob(NewResourceWithBuiltArchive("cpi", "job-fp", "path", "sha1"))
+			job.PackageNames = []string{"top-name"}
+			job.AttachPackages([]*boshrelpkg.Package{topPkg})
+			jobs = []boshreljob.Job{*job}
+		})
+
+		It("compiles independent packages concurrently up to the limit and dependencies first", func() {
+			for _, pkg := range append(leafPkgs, topPkg) {
+				mockPackageCompiler.EXPECT().Compile(pkg).Do(compile).Return(bistatepkg.CompiledPackageRecord{BlobID: pkg.Name()}, false, nil)
+			}
+
+			compiledPackageRefs, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(maxRunning).To(Equal(2))
+			Expect(compiled).To(HaveLen(4))
+			Expect(compiled[3]).To(Equal("top-name"))
+
+			Expect(compiledPackageRefs).To(HaveLen(4))
+			Expect(compiledPackageRefs[3].BlobstoreID).To(Equal("top-name"))
+			Expect(stage.PerformCalls).To(HaveLen(4))
+			Expect(stage.PerformCalls[3].Name).To(Equal("Compiling package 'top-name/top-fp'"))
+		})
+
+		It("does not compile packages depending on a package that failed to compile", func() {
+			mockPackageCompiler.EXPECT().Compile(leafPkgs[0]).Return(bistatepkg.CompiledPackageRecord{}, false, errors.New("fake-compile-error"))
+			mockPackageCompiler.EXPECT().Compile(leafPkgs[1]).Return(bistatepkg.CompiledPackageRecord{}, false, nil).MaxTimes(1)
+			mockPackageCompiler.EXPECT().Compile(leafPkgs[2]).Return(bistatepkg.CompiledPackageRecord{}, false, nil).MaxTimes(1)
+			mockPackageCompiler.EXPECT().Compile(topPkg).Times(0)
+
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+		})
+	})
 })
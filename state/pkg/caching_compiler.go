@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// cachingCompiler looks up packages missing from the compiled package repo in a
+// shared cache before compiling them, and adds newly compiled packages to the cache.
+// The cache is best-effort: failing to read or write it never fails compilation.
+type cachingCompiler struct {
+	compiler            Compiler
+	cache               CompiledPackageCache
+	platform            string
+	compiledPackageRepo CompiledPackageRepo
+	blobstore           boshblob.DigestBlobstore
+	fs                  boshsys.FileSystem
+	logger              boshlog.Logger
+	logTag              string
+}
+
+func NewCachingCompiler(
+	compiler Compiler,
+	cache CompiledPackageCache,
+	platform string,
+	compiledPackageRepo CompiledPackageRepo,
+	blobstore boshblob.DigestBlobstore,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) Compiler {
+	return &cachingCompiler{
+		compiler:            compiler,
+		cache:               cache,
+		platform:            platform,
+		compiledPackageRepo: compiledPackageRepo,
+		blobstore:           blobstore,
+		fs:                  fs,
+		logger:              logger,
+		logTag:              "cachingCompiler",
+	}
+}
+
+func (c *cachingCompiler) Compile(pkg birelpkg.Compilable) (CompiledPackageRecord, bool, error) {
+	_, found, err := c.compiledPackageRepo.Find(pkg)
+	if err != nil {
+		return CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
+	} else if found {
+		return c.compiler.Compile(pkg)
+	}
+
+	record, found, err := c.fetch(pkg)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to fetch package '%s' from the compiled package cache: %s", pkg.Name(), err.Error())
+	} else if found {
+		return record, true, nil
+	}
+
+	record, isCompiledPackage, err := c.compiler.Compile(pkg)
+	if err != nil {
+		return record, isCompiledPackage, err
+	}
+
+	err = c.store(pkg, record)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to store package '%s' in the compiled package cache: %s", pkg.Name(), err.Error())
+	}
+
+	return record, isCompiledPackage, nil
+}
+
+func (c *cachingCompiler) fetch(pkg birelpkg.Compilable) (CompiledPackageRecord, bool, error) {
+	var record CompiledPackageRecord
+
+	file, err := c.fs.TempFile("bosh-compiled-package")
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Creating temporary file")
+	}
+
+	tarballPath := file.Name()
+	file.Close()
+
+	defer func() {
+		if err := c.fs.RemoveAll(tarballPath); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove temporary file: %s", err.Error())
+		}
+	}()
+
+	found, err := c.cache.Fetch(pkg, c.platform, tarballPath)
+	if err != nil || !found {
+		return record, false, err
+	}
+
+	blobID, digest, err := c.blobstore.Create(tarballPath)
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Creating blob")
+	}
+
+	record = CompiledPackageRecord{
+		BlobID:   blobID,
+		BlobSHA1: digest.String(),
+	}
+
+	err = c.compiledPackageRepo.Save(pkg, record)
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Saving compiled package")
+	}
+
+	return record, true, nil
+}
+
+func (c *cachingCompiler) store(pkg birelpkg.Compilable, record CompiledPackageRecord) error {
+	digest, err := boshcrypto.ParseMultipleDigest(record.BlobSHA1)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing compiled package digest")
+	}
+
+	tarballPath, err := c.blobstore.Get(record.BlobID, digest)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting compiled package blob")
+	}
+
+	defer func() {
+		if err := c.blobstore.CleanUp(tarballPath); err != nil {
+			c.logger.Warn(c.logTag, "Failed to clean up blob: %s", err.Error())
+		}
+	}()
+
+	return c.cache.Store(pkg, c.platform, tarballPath)
+}
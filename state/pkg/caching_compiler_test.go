@@ -0,0 +1,154 @@
+package pkg_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+	mock_state_package "github.com/cloudfoundry/bosh-cli/state/pkg/mocks"
+)
+
+var _ = Describe("CachingCompiler", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	var (
+		tempDir             string
+		mockCompiler        *mock_state_package.MockCompiler
+		cache               CompiledPackageCache
+		compiledPackageRepo CompiledPackageRepo
+		blobstore           *fakeblobstore.FakeDigestBlobstore
+		compiler            Compiler
+
+		pkg    *boshrelpkg.Package
+		record CompiledPackageRecord
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "caching-compiler")
+		Expect(err).ToNot(HaveOccurred())
+
+		cache = NewCompiledPackageCache(NewDirCompiledPackageCacheStore(filepath.Join(tempDir, "cache"), fs), fs, logger)
+		compiledPackageRepo = NewCompiledPackageRepo(biindex.NewInMemoryIndex())
+		blobstore = &fakeblobstore.FakeDigestBlobstore{}
+		mockCompiler = mock_state_package.NewMockCompiler(mockCtrl)
+
+		compiler = NewCachingCompiler(mockCompiler, cache, "linux-amd64", compiledPackageRepo, blobstore, fs, logger)
+
+		pkg = newPkg("fake-pkg", "fake-fp", nil)
+		record = CompiledPackageRecord{BlobID: "fake-blob-id", BlobSHA1: "fakesha1"}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	storeInCache := func(contents string) {
+		tarballPath := filepath.Join(tempDir, "compiled.tgz")
+		Expect(ioutil.WriteFile(tarballPath, []byte(contents), 0644)).To(Succeed())
+		Expect(cache.Store(pkg, "linux-amd64", tarballPath)).To(Succeed())
+	}
+
+	It("delegates packages already in the compiled package repo to the compiler", func() {
+		Expect(compiledPackageRepo.Save(pkg, record)).To(Succeed())
+		mockCompiler.EXPECT().Compile(pkg).Return(record, false, nil)
+
+		compiledRecord, isAlreadyCompiled, err := compiler.Compile(pkg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isAlreadyCompiled).To(BeFalse())
+		Expect(compiledRecord).To(Equal(record))
+		Expect(blobstore.CreateCallCount()).To(Equal(0))
+	})
+
+	It("uses packages found in the cache without compiling them", func() {
+		storeInCache("cached-contents")
+
+		blobstore.CreateStub = func(path string) (string, boshcrypto.MultipleDigest, error) {
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("cached-contents"))
+			return "cached-blob-id", boshcrypto.MustParseMultipleDigest("cachedsha1"), nil
+		}
+
+		compiledRecord, isAlreadyCompiled, err := compiler.Compile(pkg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isAlreadyCompiled).To(BeTrue())
+		Expect(compiledRecord).To(Equal(CompiledPackageRecord{BlobID: "cached-blob-id", BlobSHA1: "cachedsha1"}))
+
+		savedRecord, found, err := compiledPackageRepo.Find(pkg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(savedRecord).To(Equal(compiledRecord))
+	})
+
+	It("compiles packages missing from the cache and stores them", func() {
+		compiledTarball := filepath.Join(tempDir, "compiled-blob.tgz")
+		Expect(ioutil.WriteFile(compiledTarball, []byte("compiled-contents"), 0644)).To(Succeed())
+
+		mockCompiler.EXPECT().Compile(pkg).Return(record, false, nil)
+		blobstore.GetReturns(compiledTarball, nil)
+
+		compiledRecord, isAlreadyCompiled, err := compiler.Compile(pkg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isAlreadyCompiled).To(BeFalse())
+		Expect(compiledRecord).To(Equal(record))
+
+		blobID, _ := blobstore.GetArgsForCall(0)
+		Expect(blobID).To(Equal("fake-blob-id"))
+		Expect(blobstore.CleanUpCallCount()).To(Equal(1))
+
+		fetchedPath := filepath.Join(tempDir, "fetched.tgz")
+		found, err := cache.Fetch(pkg, "linux-amd64", fetchedPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		contents, err := ioutil.ReadFile(fetchedPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("compiled-contents"))
+	})
+
+	It("compiles packages when the blobstore rejects the cached package", func() {
+		storeInCache("cached-contents")
+
+		blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-error"))
+		mockCompiler.EXPECT().Compile(pkg).Return(record, false, nil)
+		blobstore.GetReturns(filepath.Join(tempDir, "compiled.tgz"), nil)
+
+		compiledRecord, isAlreadyCompiled, err := compiler.Compile(pkg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isAlreadyCompiled).To(BeFalse())
+		Expect(compiledRecord).To(Equal(record))
+	})
+
+	It("returns compile errors without storing anything", func() {
+		mockCompiler.EXPECT().Compile(pkg).Return(CompiledPackageRecord{}, false, errors.New("fake-compile-error"))
+
+		_, _, err := compiler.Compile(pkg)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-compile-error"))
+		Expect(blobstore.GetCallCount()).To(Equal(0))
+	})
+})
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"strings"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// CompilationPlatform identifies the machine packages are compiled on in compiled
+// package cache entries. Besides OS and architecture it includes the distribution
+// and its version from os-release since compiled packages link against libraries
+// of the distribution, e.g. a package compiled against glibc does not run with musl.
+func CompilationPlatform(fs boshsys.FileSystem, goos, goarch string) string {
+	platform := goos + "-" + goarch
+
+	for _, path := range osReleasePaths {
+		contents, err := fs.ReadFileString(path)
+		if err != nil {
+			continue
+		}
+
+		fields := parseOSRelease(contents)
+
+		if id := fields["ID"]; len(id) > 0 {
+			platform += "-" + id
+
+			if versionID := fields["VERSION_ID"]; len(versionID) > 0 {
+				platform += "-" + versionID
+			}
+		}
+
+		break
+	}
+
+	return platform
+}
+
+func parseOSRelease(contents string) map[string]string {
+	fields := map[string]string{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pieces := strings.SplitN(line, "=", 2)
+		if len(pieces) != 2 {
+			continue
+		}
+
+		fields[pieces[0]] = strings.Trim(pieces[1], `"'`)
+	}
+
+	return fields
+}
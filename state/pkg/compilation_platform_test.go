@@ -0,0 +1,41 @@
+package pkg_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+)
+
+var _ = Describe("CompilationPlatform", func() {
+	var (
+		fs *fakesys.FakeFileSystem
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("includes distribution and its version", func() {
+		fs.WriteFileString("/etc/os-release", "NAME=\"Ubuntu\"\n# comment\nID=ubuntu\nVERSION_ID=\"22.04\"\n")
+
+		Expect(CompilationPlatform(fs, "linux", "amd64")).To(Equal("linux-amd64-ubuntu-22.04"))
+	})
+
+	It("tells apart distributions with a different libc", func() {
+		fs.WriteFileString("/usr/lib/os-release", "NAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.18.4\n")
+
+		Expect(CompilationPlatform(fs, "linux", "amd64")).To(Equal("linux-amd64-alpine-3.18.4"))
+	})
+
+	It("includes distribution without a version", func() {
+		fs.WriteFileString("/etc/os-release", "ID=arch\nBUILD_ID=rolling\n")
+
+		Expect(CompilationPlatform(fs, "linux", "arm64")).To(Equal("linux-arm64-arch"))
+	})
+
+	It("only includes OS and architecture when os-release is missing", func() {
+		Expect(CompilationPlatform(fs, "darwin", "amd64")).To(Equal("darwin-amd64"))
+	})
+})
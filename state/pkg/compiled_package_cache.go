@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CompiledPackageCache shares compiled packages between installations and machines.
+// Entries are addressed by the package fingerprint, the fingerprints of all
+// of its dependencies and the platform the package was compiled for.
+type CompiledPackageCache interface {
+	// Fetch writes the cached compiled package to destinationPath.
+	// Entries that fail the integrity check are reported as not found.
+	Fetch(pkg birelpkg.Compilable, platform string, destinationPath string) (bool, error)
+	Store(pkg birelpkg.Compilable, platform string, sourcePath string) error
+}
+
+// CompiledPackageCacheStore keeps the objects of a compiled package cache
+type CompiledPackageCacheStore interface {
+	Location() string
+	Get(name string) (io.ReadCloser, bool, error)
+	Put(name string, contents io.ReadSeeker) error
+}
+
+type compiledPackageCacheEntry struct {
+	Name          string `json:"name"`
+	Fingerprint   string `json:"fingerprint"`
+	DependencyKey string `json:"dependency_key"`
+	Platform      string `json:"platform"`
+	Digest        string `json:"digest,omitempty"`
+}
+
+type compiledPackageCache struct {
+	store  CompiledPackageCacheStore
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewCompiledPackageCache(store CompiledPackageCacheStore, fs boshsys.FileSystem, logger boshlog.Logger) CompiledPackageCache {
+	return &compiledPackageCache{
+		store:  store,
+		fs:     fs,
+		logger: logger,
+		logTag: "compiledPackageCache",
+	}
+}
+
+func (c *compiledPackageCache) Fetch(pkg birelpkg.Compilable, platform string, destinationPath string) (bool, error) {
+	expectedEntry := c.entry(pkg, platform)
+	objectName := expectedEntry.objectName()
+
+	metadata, found, err := c.store.Get(objectName + ".json")
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Reading cached package metadata '%s' from '%s'", objectName, c.store.Location())
+	} else if !found {
+		return false, nil
+	}
+
+	defer metadata.Close()
+
+	metadataBytes, err := ioutil.ReadAll(metadata)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Reading cached package metadata '%s' from '%s'", objectName, c.store.Location())
+	}
+
+	var entry compiledPackageCacheEntry
+
+	err = json.Unmarshal(metadataBytes, &entry)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Ignoring cached package '%s' with invalid metadata: %s", objectName, err.Error())
+		return false, nil
+	}
+
+	digest, err := boshcrypto.ParseMultipleDigest(entry.Digest)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Ignoring cached package '%s' with invalid digest: %s", objectName, err.Error())
+		return false, nil
+	}
+
+	entry.Digest = ""
+	if entry != expectedEntry {
+		c.logger.Warn(c.logTag, "Ignoring cached package '%s' recorded for '%#v'", objectName, entry)
+		return false, nil
+	}
+
+	contents, found, err := c.store.Get(objectName + ".tgz")
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Reading cached package '%s' from '%s'", objectName, c.store.Location())
+	} else if !found {
+		return false, nil
+	}
+
+	defer contents.Close()
+
+	err = c.writeFile(destinationPath, contents)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Writing cached package '%s'", objectName)
+	}
+
+	err = digest.VerifyFilePath(destinationPath, c.fs)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Ignoring cached package '%s' failing the integrity check: %s", objectName, err.Error())
+		return false, nil
+	}
+
+	c.logger.Debug(c.logTag, "Found cached package '%s' for '%s/%s'", objectName, pkg.Name(), pkg.Fingerprint())
+
+	return true, nil
+}
+
+func (c *compiledPackageCache) Store(pkg birelpkg.Compilable, platform string, sourcePath string) error {
+	entry := c.entry(pkg, platform)
+	objectName := entry.objectName()
+
+	digest, err := boshcrypto.NewMultipleDigestFromPath(sourcePath, c.fs, []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA256})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Calculating digest of compiled package '%s'", pkg.Name())
+	}
+
+	contents, err := c.fs.OpenFile(sourcePath, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening compiled package '%s'", pkg.Name())
+	}
+
+	defer contents.Close()
+
+	err = c.store.Put(objectName+".tgz", contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing cached package '%s' to '%s'", objectName, c.store.Location())
+	}
+
+	entry.Digest = digest.String()
+
+	metadataBytes, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling cached package metadata")
+	}
+
+	// metadata is written last so that readers never see partially stored packages
+	err = c.store.Put(objectName+".json", bytes.NewReader(metadataBytes))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing cached package metadata '%s' to '%s'", objectName, c.store.Location())
+	}
+
+	c.logger.Debug(c.logTag, "Stored cached package '%s' for '%s/%s'", objectName, pkg.Name(), pkg.Fingerprint())
+
+	return nil
+}
+
+func (c *compiledPackageCache) entry(pkg birelpkg.Compilable, platform string) compiledPackageCacheEntry {
+	return compiledPackageCacheEntry{
+		Name:          pkg.Name(),
+		Fingerprint:   pkg.Fingerprint(),
+		DependencyKey: convertToDependencyKey(ResolveDependencies(pkg)),
+		Platform:      platform,
+	}
+}
+
+func (c *compiledPackageCache) writeFile(path string, contents io.Reader) error {
+	file, err := c.fs.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, contents)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// objectName is derived from everything the compiled package depends on
+func (e compiledPackageCacheEntry) objectName() string {
+	key := fmt.Sprintf("%s\n%s\n%s\n%s", e.Name, e.Fingerprint, e.DependencyKey, e.Platform)
+	return fmt.Sprintf("%s-%x", e.Name, sha256.Sum256([]byte(key)))
+}
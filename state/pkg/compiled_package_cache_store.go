@@ -0,0 +1,181 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	s3CachePrefix = "s3://"
+
+	defaultS3Region = "us-east-1"
+)
+
+// NewCompiledPackageCacheStore picks a store based on the cache location.
+// s3://bucket/prefix[?region=...&endpoint=...] keeps packages in an S3 compatible bucket,
+// anything else is a directory on the local (or a shared) file system.
+func NewCompiledPackageCacheStore(location string, fs boshsys.FileSystem) (CompiledPackageCacheStore, error) {
+	if strings.HasPrefix(location, s3CachePrefix) {
+		return newS3CompiledPackageCacheStoreFromURL(location)
+	}
+
+	dir, err := fs.ExpandPath(location)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Expanding compiled package cache path '%s'", location)
+	}
+
+	return NewDirCompiledPackageCacheStore(dir, fs), nil
+}
+
+type dirCompiledPackageCacheStore struct {
+	dir string
+	fs  boshsys.FileSystem
+}
+
+func NewDirCompiledPackageCacheStore(dir string, fs boshsys.FileSystem) CompiledPackageCacheStore {
+	return dirCompiledPackageCacheStore{dir: dir, fs: fs}
+}
+
+func (s dirCompiledPackageCacheStore) Location() string { return s.dir }
+
+func (s dirCompiledPackageCacheStore) Get(name string) (io.ReadCloser, bool, error) {
+	filePath := filepath.Join(s.dir, name)
+
+	if !s.fs.FileExists(filePath) {
+		return nil, false, nil
+	}
+
+	file, err := s.fs.OpenFile(filePath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Opening '%s'", filePath)
+	}
+
+	return file, true, nil
+}
+
+// Put writes to a temporary file first and renames it,
+// so that other machines sharing the directory never read partial files
+func (s dirCompiledPackageCacheStore) Put(name string, contents io.ReadSeeker) error {
+	err := s.fs.MkdirAll(s.dir, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating compiled package cache dir '%s'", s.dir)
+	}
+
+	filePath := filepath.Join(s.dir, name)
+	tempPath := fmt.Sprintf("%s.%d.tmp", filePath, os.Getpid())
+
+	file, err := s.fs.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating '%s'", tempPath)
+	}
+
+	_, err = io.Copy(file, contents)
+	if err != nil {
+		file.Close()
+		s.fs.RemoveAll(tempPath)
+		return bosherr.WrapErrorf(err, "Writing '%s'", tempPath)
+	}
+
+	err = file.Close()
+	if err != nil {
+		s.fs.RemoveAll(tempPath)
+		return bosherr.WrapErrorf(err, "Closing '%s'", tempPath)
+	}
+
+	err = s.fs.Rename(tempPath, filePath)
+	if err != nil {
+		s.fs.RemoveAll(tempPath)
+		return bosherr.WrapErrorf(err, "Renaming '%s' to '%s'", tempPath, filePath)
+	}
+
+	return nil
+}
+
+type s3CompiledPackageCacheStore struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+}
+
+func NewS3CompiledPackageCacheStore(client s3iface.S3API, bucket string, prefix string) CompiledPackageCacheStore {
+	return s3CompiledPackageCacheStore{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}
+}
+
+func (s s3CompiledPackageCacheStore) Location() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+func (s s3CompiledPackageCacheStore) Get(name string) (io.ReadCloser, bool, error) {
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, false, nil
+		}
+		return nil, false, bosherr.WrapErrorf(err, "Reading '%s'", s.key(name))
+	}
+
+	return output.Body, true, nil
+}
+
+func (s s3CompiledPackageCacheStore) Put(name string, contents io.ReadSeeker) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+		Body:   contents,
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s'", s.key(name))
+	}
+
+	return nil
+}
+
+func (s s3CompiledPackageCacheStore) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func newS3CompiledPackageCacheStoreFromURL(location string) (CompiledPackageCacheStore, error) {
+	cacheURL, err := url.Parse(location)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing compiled package cache location '%s'", location)
+	}
+
+	if cacheURL.Host == "" {
+		return nil, bosherr.Errorf("Expected compiled package cache location '%s' to include a bucket", location)
+	}
+
+	query := cacheURL.Query()
+
+	awsConfig := aws.NewConfig().WithRegion(defaultS3Region)
+	if region := query.Get("region"); region != "" {
+		awsConfig = awsConfig.WithRegion(region)
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	// Credentials are taken from the environment or the shared AWS configuration
+	client := s3.New(session.New(awsConfig))
+
+	return NewS3CompiledPackageCacheStore(client, cacheURL.Host, cacheURL.Path), nil
+}
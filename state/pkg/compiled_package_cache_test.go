@@ -0,0 +1,167 @@
+package pkg_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("CompiledPackageCache", func() {
+	var (
+		fs       boshsys.FileSystem
+		tempDir  string
+		cacheDir string
+		cache    CompiledPackageCache
+
+		dependency *boshrelpkg.Package
+		pkg        *boshrelpkg.Package
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "compiled-package-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		cacheDir = filepath.Join(tempDir, "cache")
+
+		store, err := NewCompiledPackageCacheStore(cacheDir, fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		cache = NewCompiledPackageCache(store, fs, logger)
+
+		dependency = newPkg("dep-pkg", "dep-fp", nil)
+		pkg = newPkg("fake-pkg", "fake-fp", []string{"dep-pkg"})
+		Expect(pkg.AttachDependencies([]*boshrelpkg.Package{dependency})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	writeTarball := func(contents string) string {
+		path := filepath.Join(tempDir, "compiled.tgz")
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+
+	cachedFiles := func() []string {
+		infos, err := ioutil.ReadDir(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	It("fetches stored packages", func() {
+		Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+
+		destination := filepath.Join(tempDir, "fetched.tgz")
+		found, err := cache.Fetch(pkg, "linux-amd64", destination)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		contents, err := ioutil.ReadFile(destination)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("compiled-contents"))
+	})
+
+	It("does not find packages compiled for another platform", func() {
+		Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+
+		found, err := cache.Fetch(pkg, "darwin-amd64", filepath.Join(tempDir, "fetched.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not find packages compiled against other dependencies", func() {
+		Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+
+		otherPkg := newPkg("fake-pkg", "fake-fp", []string{"dep-pkg"})
+		Expect(otherPkg.AttachDependencies([]*boshrelpkg.Package{newPkg("dep-pkg", "other-dep-fp", nil)})).To(Succeed())
+
+		found, err := cache.Fetch(otherPkg, "linux-amd64", filepath.Join(tempDir, "fetched.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("ignores packages that fail the integrity check", func() {
+		Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+
+		for _, name := range cachedFiles() {
+			if strings.HasSuffix(name, ".tgz") {
+				Expect(ioutil.WriteFile(filepath.Join(cacheDir, name), []byte("tampered"), 0644)).To(Succeed())
+			}
+		}
+
+		found, err := cache.Fetch(pkg, "linux-amd64", filepath.Join(tempDir, "fetched.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not leave temporary files in the cache dir", func() {
+		Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+
+		names := cachedFiles()
+		Expect(names).To(HaveLen(2))
+		for _, name := range names {
+			Expect(name).To(HavePrefix("fake-pkg-"))
+			Expect(name).ToNot(HaveSuffix(".tmp"))
+		}
+	})
+
+	Context("when the cache is an s3 bucket", func() {
+		var server *fakebiconfig.FakeS3Server
+
+		BeforeEach(func() {
+			os.Setenv("AWS_ACCESS_KEY_ID", "fake-access-key-id")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "fake-secret-access-key")
+
+			server = fakebiconfig.NewFakeS3Server()
+
+			store, err := NewCompiledPackageCacheStore("s3://fake-bucket/packages?endpoint="+server.URL, fs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Location()).To(Equal("s3://fake-bucket/packages"))
+
+			cache = NewCompiledPackageCache(store, fs, boshlog.NewLogger(boshlog.LevelNone))
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Unsetenv("AWS_ACCESS_KEY_ID")
+			os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+		})
+
+		It("stores and fetches packages in the bucket", func() {
+			found, err := cache.Fetch(pkg, "linux-amd64", filepath.Join(tempDir, "fetched.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			Expect(cache.Store(pkg, "linux-amd64", writeTarball("compiled-contents"))).To(Succeed())
+			Expect(server.PutCount).To(Equal(2))
+
+			found, err = cache.Fetch(pkg, "linux-amd64", filepath.Join(tempDir, "fetched.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, "fetched.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("compiled-contents"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -20,8 +21,10 @@ type CompiledPackageRepo interface {
 	Find(birelpkg.Compilable) (CompiledPackageRecord, bool, error)
 }
 
+// compiledPackageRepo may be used by packages compiled in parallel
 type compiledPackageRepo struct {
 	index biindex.Index
+	lock  sync.Mutex
 }
 
 func NewCompiledPackageRepo(index biindex.Index) CompiledPackageRepo {
@@ -29,6 +32,9 @@ func NewCompiledPackageRepo(index biindex.Index) CompiledPackageRepo {
 }
 
 func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPackageRecord) error {
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+
 	err := cpr.index.Save(cpr.pkgKey(pkg), record)
 
 	if err != nil {
@@ -41,6 +47,9 @@ func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPac
 func (cpr *compiledPackageRepo) Find(pkg birelpkg.Compilable) (CompiledPackageRecord, bool, error) {
 	var record CompiledPackageRecord
 
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+
 	err := cpr.index.Find(cpr.pkgKey(pkg), &record)
 	if err != nil {
 		if err == biindex.ErrNotFound {
@@ -61,15 +70,15 @@ type packageToCompiledPackageKey struct {
 	DependencyKey      string
 }
 
-func (cpr compiledPackageRepo) pkgKey(pkg birelpkg.Compilable) packageToCompiledPackageKey {
+func (cpr *compiledPackageRepo) pkgKey(pkg birelpkg.Compilable) packageToCompiledPackageKey {
 	return packageToCompiledPackageKey{
 		PackageName:        pkg.Name(),
 		PackageFingerprint: pkg.Fingerprint(),
-		DependencyKey:      cpr.convertToDependencyKey(ResolveDependencies(pkg)),
+		DependencyKey:      convertToDependencyKey(ResolveDependencies(pkg)),
 	}
 }
 
-func (cpr compiledPackageRepo) convertToDependencyKey(packages []birelpkg.Compilable) string {
+func convertToDependencyKey(packages []birelpkg.Compilable) string {
 	dependencyKeys := []string{}
 
 	for _, pkg := range packages {
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	StageEventStarted  = "started"
+	StageEventFinished = "finished"
+	StageEventSkipped  = "skipped"
+	StageEventFailed   = "failed"
+)
+
+// StageEvent is written as a single JSON line for every change of a stage
+type StageEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Stage   string    `json:"stage"`
+	Parents []string  `json:"parents,omitempty"`
+
+	// Duration is in seconds and is set once the stage is done
+	Duration *float64 `json:"duration,omitempty"`
+
+	SkipMessage string `json:"skip_message,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type jsonEventsStage struct {
+	writer      io.Writer
+	writerLock  *sync.Mutex
+	timeService clock.Clock
+	parents     []string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewJSONEventsStage(writer io.Writer, timeService clock.Clock, logger boshlog.Logger) Stage {
+	return &jsonEventsStage{
+		writer:      writer,
+		writerLock:  &sync.Mutex{},
+		timeService: timeService,
+
+		logTag: "jsonEventsStage",
+		logger: logger,
+	}
+}
+
+func (s *jsonEventsStage) Perform(name string, closure func() error) error {
+	startTime := s.start(name)
+
+	err := closure()
+	if err != nil {
+		if skipErr, ok := err.(SkipStageError); ok {
+			s.finish(name, startTime, StageEvent{Type: StageEventSkipped, SkipMessage: skipErr.SkipMessage()})
+			s.logger.Info(s.logTag, "Skipped stage '%s': %s", name, skipErr.Error())
+			return nil
+		}
+		s.finish(name, startTime, StageEvent{Type: StageEventFailed, Error: err.Error()})
+		return err
+	}
+
+	s.finish(name, startTime, StageEvent{Type: StageEventFinished})
+	return nil
+}
+
+func (s *jsonEventsStage) PerformComplex(name string, closure func(Stage) error) error {
+	startTime := s.start(name)
+
+	err := closure(s.newSubStage(name))
+	if err != nil {
+		s.finish(name, startTime, StageEvent{Type: StageEventFailed, Error: err.Error()})
+		return err
+	}
+
+	s.finish(name, startTime, StageEvent{Type: StageEventFinished})
+	return nil
+}
+
+func (s *jsonEventsStage) start(name string) time.Time {
+	startTime := s.timeService.Now()
+	s.write(StageEvent{Time: startTime, Type: StageEventStarted, Stage: name, Parents: s.parents})
+	return startTime
+}
+
+func (s *jsonEventsStage) finish(name string, startTime time.Time, event StageEvent) {
+	stopTime := s.timeService.Now()
+	duration := stopTime.Sub(startTime).Seconds()
+
+	event.Time = stopTime
+	event.Stage = name
+	event.Parents = s.parents
+	event.Duration = &duration
+
+	s.write(event)
+}
+
+func (s *jsonEventsStage) write(event StageEvent) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error(s.logTag, "Failed to marshal stage event: %s", err.Error())
+		return
+	}
+
+	s.writerLock.Lock()
+	defer s.writerLock.Unlock()
+
+	_, err = s.writer.Write(append(bytes, '\n'))
+	if err != nil {
+		s.logger.Error(s.logTag, "Failed to write stage event: %s", err.Error())
+	}
+}
+
+func (s *jsonEventsStage) newSubStage(name string) Stage {
+	parents := make([]string, len(s.parents), len(s.parents)+1)
+	copy(parents, s.parents)
+
+	return &jsonEventsStage{
+		writer:      s.writer,
+		writerLock:  s.writerLock,
+		timeService: s.timeService,
+		parents:     append(parents, name),
+
+		logTag: s.logTag,
+		logger: s.logger,
+	}
+}
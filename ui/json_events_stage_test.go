@@ -0,0 +1,118 @@
+package ui_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("JSONEventsStage", func() {
+	var (
+		out             *bytes.Buffer
+		stage           Stage
+		fakeTimeService *fakeclock.FakeClock
+		startTime       time.Time
+	)
+
+	BeforeEach(func() {
+		out = bytes.NewBufferString("")
+		startTime = time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+		fakeTimeService = fakeclock.NewFakeClock(startTime)
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		stage = NewJSONEventsStage(out, fakeTimeService, logger)
+	})
+
+	events := func() []StageEvent {
+		var events []StageEvent
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var event StageEvent
+			Expect(json.Unmarshal([]byte(line), &event)).To(Succeed())
+			events = append(events, event)
+		}
+		return events
+	}
+
+	duration := func(seconds float64) *float64 { return &seconds }
+
+	It("emits started and finished events for a simple stage", func() {
+		err := stage.Perform("Simple stage 1", func() error {
+			fakeTimeService.Increment(time.Minute)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(events()).To(Equal([]StageEvent{
+			{Time: startTime, Type: "started", Stage: "Simple stage 1"},
+			{Time: startTime.Add(time.Minute), Type: "finished", Stage: "Simple stage 1", Duration: duration(60)},
+		}))
+	})
+
+	It("emits a failed event with the error", func() {
+		stageErr := bosherr.Error("fake-stage-error")
+
+		err := stage.Perform("Simple stage 1", func() error {
+			return stageErr
+		})
+		Expect(err).To(Equal(stageErr))
+
+		Expect(events()[1]).To(Equal(StageEvent{
+			Time: startTime, Type: "failed", Stage: "Simple stage 1", Duration: duration(0), Error: "fake-stage-error",
+		}))
+	})
+
+	It("emits a skipped event with the skip message", func() {
+		err := stage.Perform("Simple stage 1", func() error {
+			return NewSkipStageError(bosherr.Error("fake-cause"), "fake-skip-message")
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(events()[1]).To(Equal(StageEvent{
+			Time: startTime, Type: "skipped", Stage: "Simple stage 1", Duration: duration(0), SkipMessage: "fake-skip-message",
+		}))
+	})
+
+	It("includes the parent stages of sub-stages", func() {
+		err := stage.PerformComplex("Complex stage", func(stage Stage) error {
+			return stage.PerformComplex("Nested stage", func(stage Stage) error {
+				return stage.Perform("Simple stage", func() error { return nil })
+			})
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var lines []string
+		for _, event := range events() {
+			lines = append(lines, event.Type+" "+strings.Join(append(event.Parents, event.Stage), " > "))
+		}
+
+		Expect(lines).To(Equal([]string{
+			"started Complex stage",
+			"started Complex stage > Nested stage",
+			"started Complex stage > Nested stage > Simple stage",
+			"finished Complex stage > Nested stage > Simple stage",
+			"finished Complex stage > Nested stage",
+			"finished Complex stage",
+		}))
+	})
+
+	It("emits a failed event for a complex stage", func() {
+		stageErr := bosherr.Error("fake-stage-error")
+
+		err := stage.PerformComplex("Complex stage", func(stage Stage) error {
+			return stageErr
+		})
+		Expect(err).To(Equal(stageErr))
+
+		Expect(events()[1].Type).To(Equal("failed"))
+		Expect(events()[1].Error).To(Equal("fake-stage-error"))
+	})
+})
@@ -6,6 +6,13 @@ type Reporter interface {
 	TaskOutputChunk(int, []byte)
 }
 
+// TaskDescriber is implemented by reporters that can show a description
+// (e.g. deployment name) next to the task ID to tell apart events of several
+// tasks reported at the same time
+type TaskDescriber interface {
+	DescribeTask(int, string)
+}
+
 type Task interface {
 	ID() int
 	State() string
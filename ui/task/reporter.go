@@ -17,7 +17,8 @@ type ReporterImpl struct {
 	eventMarkers    []eventMarker
 	lastGlobalEvent *Event
 
-	outputRest map[int]string
+	outputRest   map[int]string
+	descriptions map[int]string
 	sync.Mutex
 }
 
@@ -39,9 +40,17 @@ func NewReporter(ui boshui.UI, isForEvents bool) *ReporterImpl {
 		events:       map[int][]*Event{},
 		eventMarkers: []eventMarker{},
 		outputRest:   map[int]string{},
+		descriptions: map[int]string{},
 	}
 }
 
+func (r *ReporterImpl) DescribeTask(id int, description string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.descriptions[id] = description
+}
+
 func (r *ReporterImpl) TaskStarted(id int) {
 	r.Lock()
 	defer r.Unlock()
@@ -54,7 +63,7 @@ func (r *ReporterImpl) TaskStarted(id int) {
 	r.events[id] = []*Event{}
 	r.lastGlobalEvent = &Event{TaskID: id}
 
-	r.ui.BeginLinef("Task %d", id)
+	r.ui.BeginLinef("%s", r.taskName(id))
 }
 
 func (r *ReporterImpl) TaskFinished(id int, state string) {
@@ -71,7 +80,7 @@ func (r *ReporterImpl) TaskFinished(id int, state string) {
 	if r.noOutputSinceTaskStarted(id) {
 		r.ui.EndLinef(". %s", strings.Title(state))
 	} else {
-		r.ui.BeginLinef("\nTask %d %s\n", id, state)
+		r.ui.BeginLinef("\n%s %s\n", r.taskName(id), state)
 	}
 
 	r.eventMarkers = append(r.eventMarkers, eventMarker{TaskID: id, Type: taskEnded})
@@ -144,7 +153,7 @@ func (r *ReporterImpl) showEvent(id int, str string) {
 			}
 		}
 
-		prefix := fmt.Sprintf("\nTask %d | %s | ", id, event.TimeAsHoursStr())
+		prefix := fmt.Sprintf("\n%s | %s | ", r.taskName(id), event.TimeAsHoursStr())
 		desc := event.Stage
 
 		if len(event.Tags) > 0 {
@@ -199,6 +208,13 @@ func (r *ReporterImpl) showChunk(bytes []byte) {
 	r.ui.PrintBlock(bytes)
 }
 
+func (r *ReporterImpl) taskName(id int) string {
+	if description, found := r.descriptions[id]; found && description != "" {
+		return fmt.Sprintf("Task %d | %s", id, description)
+	}
+	return fmt.Sprintf("Task %d", id)
+}
+
 func (r *ReporterImpl) lastEventForTask(id int) *Event {
 	eventCount := len(r.events[id])
 	if eventCount > 0 {
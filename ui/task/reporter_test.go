@@ -116,6 +116,20 @@ Task 123 state
 			Expect(fakeUI.Blocks).To(Equal([]string{"\nTask 123 | 22:38:25 | ", "Error: err-msg"}))
 		})
 
+		It("prefixes task lines with task description when provided", func() {
+			reporter.(boshuit.TaskDescriber).DescribeTask(123, "fake-deployment")
+			reporter.TaskStarted(123)
+			reporter.TaskFinished(123, "state")
+			Expect(outBuf.String()).To(Equal("Task 123 | fake-deployment. State\n"))
+
+			reporterWithFakeUI.(boshuit.TaskDescriber).DescribeTask(123, "fake-deployment")
+			reporterWithFakeUI.TaskStarted(123)
+			reporterWithFakeUI.TaskOutputChunk(123, []byte(
+				`{"time":1454193505,"error":{"code":100,"message":"err-msg"}}`+"\n"))
+			reporterWithFakeUI.TaskFinished(123, "state")
+			Expect(fakeUI.Blocks).To(Equal([]string{"\nTask 123 | fake-deployment | 22:38:25 | ", "Error: err-msg"}))
+		})
+
 		It("renders events", func() {
 			deployExample := `
 {"time":7414830567,"stage":"Preparing deployment","tags":[],"total":9,"task":"Binding releases","index":1,"state":"started","progress":0}